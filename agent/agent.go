@@ -12,6 +12,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/agent/comm"
 	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
 	"github.com/evergreen-ci/evergreen/plugin/builtin/shell"
@@ -57,6 +58,10 @@ var (
 		DisplayName: "initial task setup",
 		Type:        model.SystemCommandType,
 	}
+
+	// errHostDrained is returned when the agent asks for a task on a host
+	// that has been drained, and should exit without it being an error.
+	errHostDrained = errors.New("host has been drained")
 )
 
 // TerminateHandler is an interface which defines how the agent should respond
@@ -150,8 +155,37 @@ type Agent struct {
 	currentTaskDir      string
 	currentTaskDirMutex sync.RWMutex
 
+	// taskContainer is the container the current task's commands run in,
+	// if its distro runs tasks in containers.
+	taskContainer      *command.Container
+	taskContainerMutex sync.Mutex
+
+	// taskCgroup is the cgroup the current task's processes run in, on hosts
+	// that support it, and commandUsage is the resources used by each of the
+	// task's commands so far.
+	taskCgroup      *command.Cgroup
+	commandUsage    []apimodels.CommandResourceUsage
+	taskCgroupMutex sync.Mutex
+
+	// taskCaches are the caches the current task declared, which are kept
+	// in hostCache between tasks.
+	taskCaches      []*taskCache
+	hostCache       *hostCache
+	taskCachesMutex sync.Mutex
+
 	// agent's runtime configuration options.
 	opts Options
+
+	// failedUpdate is the last revision the agent failed to update itself
+	// to, so that it isn't attempted again.
+	failedUpdate string
+
+	// slots is set if the agent runs one of several task slots in the
+	// process, and tracks the tasks running in all of them.
+	slots *slotSet
+
+	// taskAborted is set if the last task the agent ran was aborted.
+	taskAborted bool
 }
 
 // finishAndAwaitCleanup sends the returned TaskEndResponse and error
@@ -188,6 +222,9 @@ func (agt *Agent) finishAndAwaitCleanup(status string) (*apimodels.EndTaskRespon
 		agt.logger.LogTask(slogger.INFO, "Finished running post-task commands in %v.", time.Since(start).String())
 	}
 	agt.cleanup(agt.GetCurrentTaskId())
+	agt.addResourceUsage(detail)
+	agt.taskAborted = detail.Status == evergreen.TaskUndispatched
+	agt.saveTaskCaches(detail.Status == evergreen.TaskSucceeded)
 
 	if err := agt.removeTaskDirectory(); err != nil {
 		agt.logger.LogExecution(slogger.ERROR, "Error removing task directory: %v", err)
@@ -197,6 +234,10 @@ func (agt *Agent) finishAndAwaitCleanup(status string) (*apimodels.EndTaskRespon
 	agt.APILogger.FlushAndWait()
 
 	resp, err := agt.End(detail)
+	// the task's stream, if the communicator has one, isn't needed anymore
+	if stream, ok := agt.TaskCommunicator.(*comm.StreamCommunicator); ok {
+		stream.Close()
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "problem marking task complete")
 	}
@@ -372,12 +413,20 @@ type Options struct {
 	Certificate string
 	LogPrefix   string
 	StatusPort  int
+	// TaskSlots is the number of tasks the agent runs at once
+	TaskSlots int
+	// Stream is set if the agent should send heartbeats and logs over a
+	// stream, falling back to HTTP requests when it's unavailable
+	Stream bool
 }
 
 // Setup initializes all the signal chans and loggers that are used during one run of the agent.
 func (agt *Agent) Setup() error {
-	if err := SetupLogging(agt.opts.LogPrefix, agt.GetCurrentTaskId()); err != nil {
-		return errors.Wrap(err, "problem setting up logging")
+	// agents running several task slots share the process's log
+	if agt.slots == nil {
+		if err := SetupLogging(agt.opts.LogPrefix, agt.GetCurrentTaskId()); err != nil {
+			return errors.Wrap(err, "problem setting up logging")
+		}
 	}
 
 	// set signal handler
@@ -426,15 +475,13 @@ func (agt *Agent) Setup() error {
 func New(opts Options) (*Agent, error) {
 
 	// set up communicator with API server
-	httpCommunicator, err := comm.NewHTTPCommunicator(
-		opts.APIURL, opts.HostId, opts.HostSecret,
-		opts.Certificate)
+	taskCommunicator, _, err := newCommunicator(opts)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error creating new http communicator")
+		return nil, err
 	}
 	agt := &Agent{
 		opts:             opts,
-		TaskCommunicator: httpCommunicator,
+		TaskCommunicator: taskCommunicator,
 	}
 
 	// start the agent server as early as possible because the
@@ -442,7 +489,51 @@ func New(opts Options) (*Agent, error) {
 	// only one agent running on a host.
 	agt.startStatusServer(opts.StatusPort)
 
-	agt.TaskCommunicator = httpCommunicator
+	if err := agt.Setup(); err != nil {
+		return nil, err
+	}
+
+	agt.Registry = plugin.NewSimpleRegistry()
+
+	// register plugins needed for execution
+	if err := registerPlugins(agt.Registry, plugin.CommandPlugins, agt.logger); err != nil {
+		grip.Criticalf("error registering plugins: %+v", err)
+		return nil, err
+	}
+	return agt, nil
+}
+
+// newCommunicator returns the communicator an agent with the given options
+// uses to talk to the API server, along with the HTTP communicator that makes
+// its requests.
+func newCommunicator(opts Options) (comm.TaskCommunicator, *comm.HTTPCommunicator, error) {
+	if opts.Stream {
+		streamCommunicator, err := comm.NewStreamCommunicator(
+			opts.APIURL, opts.HostId, opts.HostSecret,
+			opts.Certificate)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Error creating new stream communicator")
+		}
+		return streamCommunicator, streamCommunicator.HTTPCommunicator, nil
+	}
+
+	httpCommunicator, err := comm.NewHTTPCommunicator(
+		opts.APIURL, opts.HostId, opts.HostSecret,
+		opts.Certificate)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Error creating new http communicator")
+	}
+	return httpCommunicator, httpCommunicator, nil
+}
+
+// NewLocal creates a new agent to run the task described by the local
+// communicator, without an API server or status server.
+func NewLocal(opts Options, localComm *comm.LocalCommunicator) (*Agent, error) {
+	agt := &Agent{
+		opts:             opts,
+		TaskCommunicator: localComm,
+	}
+
 	if err := agt.Setup(); err != nil {
 		return nil, err
 	}
@@ -460,11 +551,38 @@ func New(opts Options) (*Agent, error) {
 // getNextTask attempts to retrieve a next task and adds it in the
 // agent's communicator if it exists. It returns true if there is a next task in the agent.
 func (agt *Agent) getNextTask() (bool, error) {
+	if agt.slots != nil {
+		agt.slots.nextTaskMutex.Lock()
+		defer agt.slots.nextTaskMutex.Unlock()
+	}
+
 	nextTaskResponse, err := agt.GetNextTask()
 	if err != nil {
 		grip.Criticalf("error getting next task: %+v", err)
 		return false, err
 	}
+
+	// the agent can talk to the API server, so an update that got it here worked
+	confirmUpdate()
+
+	if update := nextTaskResponse.AgentUpdate; update != nil && update.Revision != agt.failedUpdate {
+		if agt.slots != nil && agt.slots.busy() {
+			// replacing the process would kill the tasks in the other slots
+			grip.Infof("not updating agent to revision %s while other task slots are busy",
+				update.Revision)
+		} else {
+			// updateSelf only returns if the update failed, in which case the
+			// agent carries on with its current binary
+			err = agt.updateSelf(update)
+			grip.Errorf("error updating agent to revision %s: %+v", update.Revision, err)
+			agt.failedUpdate = update.Revision
+		}
+	}
+
+	if nextTaskResponse.ShouldExit && nextTaskResponse.Drained {
+		grip.Noticef("agent is exiting: %v", nextTaskResponse.Message)
+		return false, errHostDrained
+	}
 	if nextTaskResponse.ShouldExit {
 		grip.Infof("next task response indicates that agent should exit: %v", nextTaskResponse.Message)
 		return false, fmt.Errorf("next task response indicates that agent should exit %v", nextTaskResponse.Message)
@@ -483,6 +601,9 @@ func (agt *Agent) getNextTask() (bool, error) {
 	grip.Infof("assigned to run task %s", nextTaskResponse.TaskId)
 
 	agt.SetTask(nextTaskResponse.TaskId, nextTaskResponse.TaskSecret)
+	if agt.slots != nil {
+		agt.slots.add(nextTaskResponse.TaskId)
+	}
 	return true, nil
 }
 
@@ -498,6 +619,13 @@ func (agt *Agent) Run() error {
 	nextTaskLoop:
 		for {
 			hasTask, err = agt.getNextTask()
+			if err == errHostDrained {
+				// make sure nothing logged for the last task is left behind
+				if agt.APILogger != nil {
+					agt.APILogger.FlushAndWait()
+				}
+				return nil
+			}
 			if err != nil {
 				grip.Criticalf("error getting next task: %+v", err)
 				return err
@@ -513,7 +641,11 @@ func (agt *Agent) Run() error {
 			return errors.Wrap(err, "error setting up task")
 		}
 		currentTask = agt.GetCurrentTaskId()
+		agt.taskAborted = false
 		resp, err := agt.RunTask()
+		if agt.slots != nil {
+			agt.slots.remove(currentTask)
+		}
 		if err != nil {
 			agt.cleanup(currentTask)
 			return errors.Wrap(err, "error running task")
@@ -523,6 +655,14 @@ func (agt *Agent) Run() error {
 			agt.cleanup(currentTask)
 			return errors.New("received nil response from API server")
 		}
+		// the API server tells the agent to exit after an aborted task, but
+		// the other slots are still running theirs, so a slot goes back to
+		// asking for a next task instead
+		if resp.ShouldExit && agt.slots != nil && agt.taskAborted {
+			grip.Noticeln("task slot is continuing after its task was aborted:", resp.Message)
+			agt.cleanup(currentTask)
+			continue
+		}
 		// this isn't an error, so it should just exit
 		if resp.ShouldExit {
 			grip.Noticeln("task response indicates that agent should exit:", resp.Message)
@@ -576,12 +716,20 @@ func (agt *Agent) RunTask() (*apimodels.EndTaskResponse, error) {
 	}
 
 	agt.logger.LogExecution(slogger.INFO, "Fetching expansions for project %v...", taskConfig.Task.Project)
-	expVars, err := agt.FetchExpansionVars()
+	projectVars, err := agt.FetchExpansionVars()
 	if err != nil {
 		agt.logger.LogExecution(slogger.ERROR, "error fetching project expansion variables: %v", err)
 		return nil, err
 	}
-	taskConfig.Expansions.Update(*expVars)
+	taskConfig.Expansions.Update(projectVars.Vars)
+
+	// keep private variables out of the logs from here on
+	for name, private := range projectVars.PrivateVars {
+		if private {
+			taskConfig.Redactor.Add(name, projectVars.Vars[name])
+		}
+	}
+	agt.APILogger.SetRedactor(taskConfig.Redactor)
 	agt.taskConfig = taskConfig
 
 	// set up the system stats collector
@@ -610,6 +758,11 @@ func (agt *Agent) RunTask() (*apimodels.EndTaskResponse, error) {
 	}
 	taskConfig.Expansions.Put("workdir", taskConfig.WorkDir)
 
+	// keep logs that can't be sent while the API server is unreachable in
+	// the task directory, until they can be replayed
+	agt.APILogger.SetJournal(comm.NewLogJournal(
+		filepath.Join(taskConfig.WorkDir, comm.LogJournalFileName), comm.DefaultLogJournalMaxBytes))
+
 	// notify API server that the task has been started.
 	agt.logger.LogExecution(slogger.INFO, "Reporting task started.")
 	if err = agt.Start(); err != nil {
@@ -617,6 +770,18 @@ func (agt *Agent) RunTask() (*apimodels.EndTaskResponse, error) {
 		return agt.finishAndAwaitCleanup(evergreen.TaskFailed)
 	}
 
+	if taskConfig.Distro.TaskContainer != nil {
+		if err = agt.startTaskContainer(taskConfig); err != nil {
+			agt.logger.LogExecution(slogger.ERROR, "error starting task container: %v", err)
+			return agt.finishAndAwaitCleanup(evergreen.TaskFailed)
+		}
+	} else {
+		// processes in a container are already accounted for and cleaned up
+		// by its runtime
+		agt.startTaskCgroup(taskConfig)
+	}
+	agt.startTaskCaches(taskConfig)
+
 	if taskConfig.Project.Pre != nil {
 		agt.logger.LogExecution(slogger.INFO, "Running pre-task commands.")
 		err = agt.RunCommands(taskConfig.Project.Pre.List(), false, agt.callbackTimeoutSignal())
@@ -734,9 +899,12 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 				TaskCommunicator: agt.TaskCommunicator}
 
 			agt.CheckIn(parsedCommand, timeoutPeriod)
+			agt.restoreTaskCaches()
 
+			agt.startCommandCgroup()
 			start := time.Now()
 			err = cmd.Execute(commandLogger, pluginCom, agt.taskConfig, stop)
+			agt.recordCommandUsage(fullCommandName)
 
 			agt.logger.LogExecution(slogger.INFO, "Finished %v in %v", fullCommandName, time.Since(start).String())
 
@@ -819,11 +987,15 @@ func (agt *Agent) createTaskDirectory(taskConfig *model.TaskConfig) error {
 		return err
 	}
 
-	agt.logger.LogExecution(slogger.INFO, "Changing into task directory: %v", newDir)
-	err = os.Chdir(newDir)
-	if err != nil {
-		agt.logger.LogExecution(slogger.ERROR, "Error changing into task directory: %v", err)
-		return err
+	// the working directory is shared by the whole process, so agents running
+	// several task slots leave it alone and rely on the task's work dir
+	if agt.slots == nil {
+		agt.logger.LogExecution(slogger.INFO, "Changing into task directory: %v", newDir)
+		err = os.Chdir(newDir)
+		if err != nil {
+			agt.logger.LogExecution(slogger.ERROR, "Error changing into task directory: %v", err)
+			return err
+		}
 	}
 	agt.setCurrentTaskDir(newDir)
 
@@ -858,15 +1030,78 @@ func (agt *Agent) setCurrentTaskDir(d string) {
 	agt.currentTaskDir = d
 }
 
+// startTaskContainer starts a container for the task's commands to run in,
+// with the task directory mounted into it.
+func (agt *Agent) startTaskContainer(taskConfig *model.TaskConfig) error {
+	settings := taskConfig.Distro.TaskContainer
+	name := "evg-" + filepath.Base(taskConfig.WorkDir)
+
+	agt.logger.LogExecution(slogger.INFO, "Starting container %v from image %v", name, settings.Image)
+	container, err := command.StartContainer(settings.Runtime, name, settings.Image,
+		taskConfig.WorkDir, settings.Options)
+	if err != nil {
+		return err
+	}
+
+	agt.taskContainerMutex.Lock()
+	defer agt.taskContainerMutex.Unlock()
+	agt.taskContainer = container
+	taskConfig.Container = container
+	return nil
+}
+
+func (agt *Agent) getTaskContainer() *command.Container {
+	agt.taskContainerMutex.Lock()
+	defer agt.taskContainerMutex.Unlock()
+
+	return agt.taskContainer
+}
+
+// removeTaskContainer tears down the task's container, if there is one. It
+// is safe to call more than once.
+func (agt *Agent) removeTaskContainer() error {
+	agt.taskContainerMutex.Lock()
+	defer agt.taskContainerMutex.Unlock()
+
+	if agt.taskContainer == nil {
+		return nil
+	}
+
+	agt.logger.LogExecution(slogger.INFO, "Removing container %v", agt.taskContainer.Name)
+	if err := agt.taskContainer.Remove(); err != nil {
+		return err
+	}
+	agt.taskContainer = nil
+	return nil
+}
+
 // removeTaskDirectory removes the folder the agent created for the
 // task it was executing.
 func (agt *Agent) removeTaskDirectory() error {
-	agt.logger.LogExecution(slogger.INFO, "Changing directory back to distro working directory.")
-	if err := os.Chdir(agt.taskConfig.Distro.WorkDir); err != nil {
-		agt.logger.LogExecution(slogger.ERROR, "Error changing directory out of task directory: %v", err)
-		return err
+	// the container may still hold files in the directory open
+	if err := agt.removeTaskContainer(); err != nil {
+		agt.logger.LogExecution(slogger.ERROR, "Error removing task container: %v", err)
+	}
+	if err := agt.removeTaskCgroup(); err != nil {
+		agt.logger.LogExecution(slogger.ERROR, "Error removing task cgroup: %v", err)
+	}
+
+	if agt.slots == nil {
+		agt.logger.LogExecution(slogger.INFO, "Changing directory back to distro working directory.")
+		if err := os.Chdir(agt.taskConfig.Distro.WorkDir); err != nil {
+			agt.logger.LogExecution(slogger.ERROR, "Error changing directory out of task directory: %v", err)
+			return err
+		}
+	}
+
+	// the log journal is in the task directory, so send what's left in it first
+	if err := agt.APILogger.CloseJournal(); err != nil {
+		agt.logger.LogExecution(slogger.ERROR, "Error closing log journal: %v", err)
 	}
 
+	// links to the caches the task used go with the task directory
+	agt.releaseTaskCaches()
+
 	agt.logger.LogExecution(slogger.INFO, "Deleting directory for completed task.")
 
 	if err := os.RemoveAll(agt.getCurrentTaskDir()); err != nil {
@@ -882,7 +1117,22 @@ func (agt *Agent) removeTaskDirectory() error {
 // and flushes the logs.
 func (agt *Agent) cleanup(taskId string) {
 	grip.Infof("cleaning up processes for task: %s", taskId)
-	if taskId != "" {
+	if container := agt.getTaskContainer(); container != nil {
+		// everything the task started is in its container
+		if err := container.KillProcesses(); err != nil {
+			msg := fmt.Sprintf("Error cleaning up processes in task container (agent-exit): %v", err)
+			grip.Critical(msg)
+		}
+	} else if taskId != "" {
+		// the cgroup has every process the task started, however it was
+		// started, while the processes with the task's markers in their
+		// environment are only those started by shell commands
+		if cg := agt.getTaskCgroup(); cg != nil {
+			if err := cg.Kill(); err != nil {
+				msg := fmt.Sprintf("Error killing processes in task cgroup (agent-exit): %v", err)
+				grip.Critical(msg)
+			}
+		}
 		if err := shell.KillSpawnedProcs(taskId, agt.logger); err != nil {
 			msg := fmt.Sprintf("Error cleaning up spawned processes (agent-exit): %v", err)
 			grip.Critical(msg)
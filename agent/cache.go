@@ -0,0 +1,727 @@
+package agent
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// taskCacheDirName is the directory in the distro's work directory that
+	// the caches tasks declare are kept in between tasks.
+	taskCacheDirName = "evg-cache"
+
+	// defaultTaskCacheMB is how much disk space task caches can take up on
+	// hosts of distros that don't say.
+	defaultTaskCacheMB = 10 * 1024
+)
+
+// hostCache keeps the caches tasks declare in a directory on the host, with
+// an entry for each name and key. Once the entries take up more than
+// maxBytes, the least recently used ones are evicted. It is shared by all of
+// the agent's task slots.
+type hostCache struct {
+	root     string
+	maxBytes int64
+
+	// inUse counts the tasks that have each entry symlinked into their task
+	// directories, which keeps it from being evicted
+	inUse map[string]int
+	mutex sync.Mutex
+}
+
+var (
+	hostCaches      = map[string]*hostCache{}
+	hostCachesMutex sync.Mutex
+)
+
+// getHostCache returns the cache kept in root, capped at maxBytes.
+func getHostCache(root string, maxBytes int64) *hostCache {
+	hostCachesMutex.Lock()
+	defer hostCachesMutex.Unlock()
+
+	hc, ok := hostCaches[root]
+	if !ok {
+		hc = &hostCache{root: root, inUse: map[string]int{}}
+		hostCaches[root] = hc
+	}
+
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	hc.maxBytes = maxBytes
+	return hc
+}
+
+func (hc *hostCache) entryPath(name, key string) string {
+	return filepath.Join(hc.root, name, key)
+}
+
+// acquire returns the path of the entry for name and key, if there is one.
+// The entry isn't evicted until it is released.
+func (hc *hostCache) acquire(name, key string) (string, bool) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	entry := hc.entryPath(name, key)
+	if info, err := os.Stat(entry); err != nil || !info.IsDir() {
+		return "", false
+	}
+	// an entry's modification time is when it was last used
+	now := time.Now()
+	grip.Warning(os.Chtimes(entry, now, now))
+	hc.inUse[entry]++
+	return entry, true
+}
+
+// release lets the entry for name and key be evicted again.
+func (hc *hostCache) release(name, key string) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	entry := hc.entryPath(name, key)
+	if hc.inUse[entry] > 1 {
+		hc.inUse[entry]--
+	} else {
+		delete(hc.inUse, entry)
+	}
+}
+
+// save creates the entry for name and key by calling fill with the directory
+// to put the entry's files in. Nothing is saved if fill fails, or if another
+// task saved an entry for name and key in the meantime.
+func (hc *hostCache) save(name, key string, fill func(dir string) error) error {
+	if err := os.MkdirAll(hc.root, 0755); err != nil {
+		return errors.Wrapf(err, "error creating cache directory %s", hc.root)
+	}
+	// entries are filled next to where they go, so they can be moved into
+	// place at once
+	staging, err := ioutil.TempDir(hc.root, ".staging-")
+	if err != nil {
+		return errors.Wrap(err, "error creating cache staging directory")
+	}
+	defer func() { grip.Warning(os.RemoveAll(staging)) }()
+
+	dir := filepath.Join(staging, "entry")
+	if err = fill(dir); err != nil {
+		return err
+	}
+
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	entry := hc.entryPath(name, key)
+	if _, err = os.Stat(entry); err == nil {
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return errors.Wrapf(err, "error creating cache directory for %s", name)
+	}
+	if err = os.Rename(dir, entry); err != nil {
+		return errors.Wrapf(err, "error moving cache %s into place", name)
+	}
+	now := time.Now()
+	return errors.WithStack(os.Chtimes(entry, now, now))
+}
+
+// evict removes the least recently used entries that aren't in use until the
+// entries take up no more than the cache's cap. It returns the name and key of
+// each entry it removed.
+func (hc *hostCache) evict() ([]string, error) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	type cacheEntry struct {
+		path string
+		used time.Time
+		size int64
+	}
+	entries := []cacheEntry{}
+	var total int64
+
+	names, err := ioutil.ReadDir(hc.root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading cache directory %s", hc.root)
+	}
+	for _, name := range names {
+		// staging directories are still being filled
+		if !name.IsDir() || strings.HasPrefix(name.Name(), ".") {
+			continue
+		}
+		keys, err := ioutil.ReadDir(filepath.Join(hc.root, name.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading cache directory for %s", name.Name())
+		}
+		for _, key := range keys {
+			entry := filepath.Join(hc.root, name.Name(), key.Name())
+			size, err := dirSize(entry)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, cacheEntry{path: entry, used: key.ModTime(), size: size})
+			total += size
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	removed := []string{}
+	for _, entry := range entries {
+		if total <= hc.maxBytes {
+			break
+		}
+		if hc.inUse[entry.path] > 0 {
+			continue
+		}
+		if err = os.RemoveAll(entry.path); err != nil {
+			return removed, errors.Wrapf(err, "error removing cache entry %s", entry.path)
+		}
+		total -= entry.size
+		rel, _ := filepath.Rel(hc.root, entry.path)
+		removed = append(removed, filepath.ToSlash(rel))
+	}
+	return removed, nil
+}
+
+// taskCache is a cache declared by the task the agent is running.
+type taskCache struct {
+	model.CacheDir
+
+	// key is the cache's key, once its key files exist
+	key string
+	// restored is whether the cache was put in place from an entry
+	restored bool
+	// acquired is whether the cache is symlinked to the host cache's entry
+	acquired bool
+}
+
+// startTaskCaches sets up the caches declared by the task and its variant,
+// which are put in place as soon as their keys are known.
+func (agt *Agent) startTaskCaches(taskConfig *model.TaskConfig) {
+	agt.taskCachesMutex.Lock()
+	defer agt.taskCachesMutex.Unlock()
+
+	agt.taskCaches = nil
+	for _, cache := range taskConfig.Project.FindTaskCaches(taskConfig.Task.DisplayName, taskConfig.BuildVariant.Name) {
+		agt.taskCaches = append(agt.taskCaches, &taskCache{CacheDir: cache})
+	}
+	if len(agt.taskCaches) == 0 {
+		return
+	}
+
+	maxMB := taskConfig.Distro.TaskCacheMB
+	if maxMB == 0 {
+		maxMB = defaultTaskCacheMB
+	}
+	agt.hostCache = getHostCache(filepath.Join(taskConfig.Distro.WorkDir, taskCacheDirName),
+		int64(maxMB)*1024*1024)
+}
+
+// restoreTaskCaches puts the task's caches whose key files have appeared
+// since the last command in place, from the host cache or their bucket.
+func (agt *Agent) restoreTaskCaches() {
+	agt.taskCachesMutex.Lock()
+	defer agt.taskCachesMutex.Unlock()
+
+	for _, cache := range agt.taskCaches {
+		if cache.key != "" {
+			continue
+		}
+		key, ok, err := cacheKey(agt.taskConfig.WorkDir, &cache.CacheDir)
+		if err != nil {
+			agt.logger.LogExecution(slogger.WARN, "Error computing key of cache '%v': %v", cache.Name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		cache.key = key
+		agt.restoreTaskCache(cache)
+	}
+}
+
+func (agt *Agent) restoreTaskCache(cache *taskCache) {
+	dest := filepath.Join(agt.taskConfig.WorkDir, cache.Path)
+	if info, err := os.Lstat(dest); err == nil {
+		if !info.IsDir() || !isEmptyDir(dest) {
+			agt.logger.LogTask(slogger.INFO, "Not restoring cache '%v' since %v already exists", cache.Name, cache.Path)
+			return
+		}
+		grip.Warning(os.Remove(dest))
+	}
+
+	source := "host"
+	entry, ok := agt.hostCache.acquire(cache.Name, cache.key)
+	if !ok && cache.S3 != nil && agt.downloadTaskCache(cache) {
+		source = "s3"
+		entry, ok = agt.hostCache.acquire(cache.Name, cache.key)
+	}
+	if !ok {
+		agt.logger.LogTask(slogger.INFO, "Cache miss for '%v' (key %v)", cache.Name, cache.key)
+		return
+	}
+	cache.acquired = true
+
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err == nil && !cache.Copy && agt.taskConfig.Distro.TaskContainer == nil {
+		if err = os.Symlink(entry, dest); err == nil {
+			cache.restored = true
+			agt.logger.LogTask(slogger.INFO, "Cache hit for '%v' (key %v) from %v, linked at %v",
+				cache.Name, cache.key, source, cache.Path)
+			return
+		}
+		agt.logger.LogExecution(slogger.WARN, "Copying cache '%v' since it can't be linked: %v", cache.Name, err)
+	}
+
+	// a container only has the task directory mounted, so symlinks out of
+	// it wouldn't resolve
+	if err == nil {
+		err = copyDir(entry, dest)
+	}
+	agt.hostCache.release(cache.Name, cache.key)
+	cache.acquired = false
+	if err != nil {
+		agt.logger.LogExecution(slogger.ERROR, "Error restoring cache '%v': %v", cache.Name, err)
+		grip.Warning(os.RemoveAll(dest))
+		return
+	}
+	cache.restored = true
+	agt.logger.LogTask(slogger.INFO, "Cache hit for '%v' (key %v) from %v, copied to %v",
+		cache.Name, cache.key, source, cache.Path)
+}
+
+// saveTaskCaches keeps the caches that the task filled, if it succeeded, and
+// evicts old entries to make room for them.
+func (agt *Agent) saveTaskCaches(succeeded bool) {
+	agt.taskCachesMutex.Lock()
+	defer agt.taskCachesMutex.Unlock()
+
+	if len(agt.taskCaches) == 0 {
+		return
+	}
+	if !succeeded {
+		agt.logger.LogTask(slogger.INFO, "Not saving caches since the task failed")
+		return
+	}
+
+	for _, cache := range agt.taskCaches {
+		if cache.restored {
+			continue
+		}
+		if cache.key == "" {
+			key, ok, err := cacheKey(agt.taskConfig.WorkDir, &cache.CacheDir)
+			if err != nil || !ok {
+				agt.logger.LogTask(slogger.INFO, "Not saving cache '%v' since its key files don't exist", cache.Name)
+				continue
+			}
+			cache.key = key
+		}
+
+		src := filepath.Join(agt.taskConfig.WorkDir, cache.Path)
+		if info, err := os.Lstat(src); err != nil || !info.IsDir() {
+			agt.logger.LogTask(slogger.INFO, "Not saving cache '%v' since %v is not a directory", cache.Name, cache.Path)
+			continue
+		}
+		// the task directory is about to be removed, so the cache is
+		// moved out of it where possible
+		err := agt.hostCache.save(cache.Name, cache.key, func(dir string) error {
+			if os.Rename(src, dir) == nil {
+				return nil
+			}
+			return copyDir(src, dir)
+		})
+		if err != nil {
+			agt.logger.LogExecution(slogger.ERROR, "Error saving cache '%v': %v", cache.Name, err)
+			continue
+		}
+		agt.logger.LogTask(slogger.INFO, "Saved cache '%v' (key %v)", cache.Name, cache.key)
+
+		if cache.S3 != nil {
+			agt.uploadTaskCache(cache)
+		}
+	}
+
+	removed, err := agt.hostCache.evict()
+	for _, entry := range removed {
+		agt.logger.LogExecution(slogger.INFO, "Evicted cache entry %v", entry)
+	}
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Error evicting cache entries: %v", err)
+	}
+}
+
+// releaseTaskCaches lets the entries the task used be evicted. It is safe to
+// call more than once.
+func (agt *Agent) releaseTaskCaches() {
+	agt.taskCachesMutex.Lock()
+	defer agt.taskCachesMutex.Unlock()
+
+	for _, cache := range agt.taskCaches {
+		if cache.acquired {
+			agt.hostCache.release(cache.Name, cache.key)
+			cache.acquired = false
+		}
+	}
+	agt.taskCaches = nil
+}
+
+// cacheS3 returns the credentials for the cache's bucket, and the URL of its
+// archive for its key there.
+func (agt *Agent) cacheS3(cache *taskCache) (*aws.Auth, string, error) {
+	fields := []string{cache.S3.Bucket, cache.S3.Prefix, cache.S3.AwsKey, cache.S3.AwsSecret}
+	for i := range fields {
+		expanded, err := agt.taskConfig.Expansions.ExpandString(fields[i])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "error expanding s3 settings of cache '%v'", cache.Name)
+		}
+		fields[i] = expanded
+	}
+
+	auth := &aws.Auth{AccessKey: fields[2], SecretKey: fields[3]}
+	return auth, fmt.Sprintf("s3://%s/%s", fields[0], path.Join(fields[1], cache.Name, cache.key+".tgz")), nil
+}
+
+// downloadTaskCache saves the cache's archive in its bucket as the host
+// cache's entry for it, returning whether there was one.
+func (agt *Agent) downloadTaskCache(cache *taskCache) bool {
+	auth, url, err := agt.cacheS3(cache)
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Not downloading cache: %v", err)
+		return false
+	}
+
+	archive, err := thirdparty.GetS3File(auth, url)
+	if err != nil {
+		if s3Err, ok := errors.Cause(err).(*s3.Error); !ok || s3Err.StatusCode != 404 {
+			agt.logger.LogExecution(slogger.WARN, "Error downloading cache '%v' from %v: %v", cache.Name, url, err)
+		}
+		return false
+	}
+	defer archive.Close()
+
+	err = agt.hostCache.save(cache.Name, cache.key, func(dir string) error {
+		return extractCacheArchive(archive, dir)
+	})
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Error downloading cache '%v' from %v: %v", cache.Name, url, err)
+		return false
+	}
+	return true
+}
+
+// uploadTaskCache shares the host cache's entry for the cache through its
+// bucket.
+func (agt *Agent) uploadTaskCache(cache *taskCache) {
+	entry, ok := agt.hostCache.acquire(cache.Name, cache.key)
+	if !ok {
+		return
+	}
+	defer agt.hostCache.release(cache.Name, cache.key)
+
+	err := func() error {
+		auth, url, err := agt.cacheS3(cache)
+		if err != nil {
+			return err
+		}
+
+		f, err := ioutil.TempFile(agt.hostCache.root, ".upload-")
+		if err != nil {
+			return errors.Wrap(err, "error creating cache archive")
+		}
+		defer func() { grip.Warning(os.Remove(f.Name())) }()
+		err = writeCacheArchive(f, entry)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = thirdparty.PutS3File(auth, f.Name(), url, "application/x-gzip", string(s3.Private)); err != nil {
+			return err
+		}
+		agt.logger.LogTask(slogger.INFO, "Uploaded cache '%v' (key %v) to %v", cache.Name, cache.key, url)
+		return nil
+	}()
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Error uploading cache '%v': %v", cache.Name, err)
+	}
+}
+
+// cacheKey returns the key of the cache, which is a hash of its name, the
+// platform and the contents of its key files in taskDir. It returns false if
+// any of the key files don't exist yet.
+func cacheKey(taskDir string, cache *model.CacheDir) (string, bool, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s/%s\x00", cache.Name, runtime.GOOS, runtime.GOARCH)
+
+	for _, pattern := range cache.KeyFiles {
+		matches, err := filepath.Glob(filepath.Join(taskDir, pattern))
+		if err != nil {
+			return "", false, errors.Wrapf(err, "invalid key file pattern '%s'", pattern)
+		}
+		found := false
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(taskDir, match)
+			if err != nil {
+				return "", false, errors.WithStack(err)
+			}
+			contents, err := ioutil.ReadFile(match)
+			if err != nil {
+				return "", false, errors.Wrapf(err, "error reading key file %s", rel)
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(contents))
+			_, _ = h.Write(contents)
+			found = true
+		}
+		if !found {
+			return "", false, nil
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:32], true, nil
+}
+
+// writeCacheArchive writes the files in dir to w as a gzipped tarball.
+func writeCacheArchive(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error archiving %s", dir)
+	}
+
+	if err = tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(gz.Close())
+}
+
+// extractCacheArchive extracts a tarball written by writeCacheArchive into
+// dir. Files outside of dir, including through symlinks, are rejected.
+func extractCacheArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "error reading cache archive")
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading cache archive")
+		}
+
+		name := filepath.FromSlash(path.Clean(hdr.Name))
+		if name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+			return errors.Errorf("cache archive has file %s outside of it", hdr.Name)
+		}
+		target := filepath.Join(root, name)
+		// symlinks extracted earlier mustn't lead outside of it either,
+		// including one already at the file's own name
+		checked := name
+		if hdr.Typeflag == tar.TypeSymlink {
+			checked = filepath.Dir(name)
+		}
+		if !resolvesWithin(root, root, checked) {
+			return errors.Errorf("cache archive has file %s outside of it", hdr.Name)
+		}
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.FileMode(hdr.Mode).Perm())
+		case tar.TypeSymlink:
+			link := filepath.ToSlash(filepath.Dir(name)) + "/" + hdr.Linkname
+			if path.IsAbs(hdr.Linkname) || !resolvesWithin(root, root, link) {
+				return errors.Errorf("cache archive has symlink %s pointing outside of it", hdr.Name)
+			}
+			err = os.Symlink(hdr.Linkname, target)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeFile(target, tr, os.FileMode(hdr.Mode).Perm())
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error extracting %s", hdr.Name)
+		}
+	}
+}
+
+// resolvesWithin returns whether following the relative path rel from dir,
+// which must have its own symlinks followed, leads inside root. It follows
+// symlinks on the way, so that ".." after one leads to the parent of its
+// target. Paths through symlinks that lead nowhere are rejected.
+func resolvesWithin(root, dir, rel string) bool {
+	resolved := dir
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		if _, err := os.Lstat(next); os.IsNotExist(err) {
+			// nothing below here exists, so there are no more symlinks
+			resolved = next
+			continue
+		}
+		var err error
+		if resolved, err = filepath.EvalSymlinks(next); err != nil {
+			return false
+		}
+	}
+	return isWithin(root, resolved)
+}
+
+// isWithin returns whether file is root or inside it.
+func isWithin(root, file string) bool {
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyDir copies the files, directories and symlinks in src to dst.
+func copyDir(src, dst string) error {
+	err := filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return writeFile(target, f, info.Mode().Perm())
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "error copying %s", src)
+}
+
+func writeFile(file string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		grip.CatchWarning(f.Close())
+		return err
+	}
+	return f.Close()
+}
+
+// dirSize returns the total size of the files in dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, errors.Wrapf(err, "error measuring %s", dir)
+}
+
+func isEmptyDir(dir string) bool {
+	entries, err := ioutil.ReadDir(dir)
+	return err == nil && len(entries) == 0
+}
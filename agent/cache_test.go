@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCacheTestFile(t *testing.T, file, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, ioutil.WriteFile(file, []byte(contents), 0644))
+}
+
+func TestCacheKey(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cache-key")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := &model.CacheDir{Name: "gomod", KeyFiles: []string{"src/go.sum", "src/*/go.sum"}}
+
+	// caches without key files always have one
+	key, ok, err := cacheKey(dir, &model.CacheDir{Name: "gomod"})
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Len(key, 32)
+
+	_, ok, err = cacheKey(dir, cache)
+	assert.NoError(err)
+	assert.False(ok)
+
+	writeCacheTestFile(t, filepath.Join(dir, "src", "go.sum"), "a v1.0.0")
+	_, ok, err = cacheKey(dir, cache)
+	assert.NoError(err)
+	assert.False(ok)
+
+	writeCacheTestFile(t, filepath.Join(dir, "src", "tools", "go.sum"), "b v1.0.0")
+	first, ok, err := cacheKey(dir, cache)
+	assert.NoError(err)
+	assert.True(ok)
+	assert.NotEqual(key, first)
+
+	again, _, _ := cacheKey(dir, cache)
+	assert.Equal(first, again)
+
+	writeCacheTestFile(t, filepath.Join(dir, "src", "go.sum"), "a v1.0.1")
+	changed, _, _ := cacheKey(dir, cache)
+	assert.NotEqual(first, changed)
+
+	cache.Name = "other"
+	renamed, _, _ := cacheKey(dir, cache)
+	assert.NotEqual(changed, renamed)
+}
+
+func TestHostCacheSaveAndEvict(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "host-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hc := getHostCache(filepath.Join(dir, "cache"), 10)
+	assert.Equal(hc, getHostCache(filepath.Join(dir, "cache"), 10))
+
+	_, ok := hc.acquire("npm", "one")
+	assert.False(ok)
+
+	fill := func(contents string) func(string) error {
+		return func(entry string) error {
+			writeCacheTestFile(t, filepath.Join(entry, "pkg", "file"), contents)
+			return nil
+		}
+	}
+	require.NoError(t, hc.save("npm", "one", fill("123456")))
+	require.NoError(t, hc.save("npm", "two", fill("abcdef")))
+	// an entry saved by another task first is kept
+	require.NoError(t, hc.save("npm", "two", fill("ghijkl")))
+
+	entry, ok := hc.acquire("npm", "two")
+	assert.True(ok)
+	contents, err := ioutil.ReadFile(filepath.Join(entry, "pkg", "file"))
+	assert.NoError(err)
+	assert.Equal("abcdef", string(contents))
+
+	// the entry in use is kept even though it's the least recently used
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(entry, old, old))
+	removed, err := hc.evict()
+	assert.NoError(err)
+	assert.Equal([]string{"npm/one"}, removed)
+
+	hc.release("npm", "two")
+	hc.maxBytes = 6
+	removed, err = hc.evict()
+	assert.NoError(err)
+	assert.Len(removed, 0)
+
+	hc.maxBytes = 0
+	removed, err = hc.evict()
+	assert.NoError(err)
+	assert.Equal([]string{"npm/two"}, removed)
+
+	// nothing is left behind from filling entries
+	names, err := ioutil.ReadDir(hc.root)
+	assert.NoError(err)
+	for _, name := range names {
+		assert.Equal("npm", name.Name())
+	}
+}
+
+func TestCacheArchiveAndCopy(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cache-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	writeCacheTestFile(t, filepath.Join(src, "a", "file"), "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "empty"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join("a", "file"), filepath.Join(src, "link")))
+
+	check := func(dst string) {
+		contents, err := ioutil.ReadFile(filepath.Join(dst, "a", "file"))
+		assert.NoError(err)
+		assert.Equal("contents", string(contents))
+		link, err := os.Readlink(filepath.Join(dst, "link"))
+		assert.NoError(err)
+		assert.Equal(filepath.Join("a", "file"), link)
+		assert.True(isEmptyDir(filepath.Join(dst, "empty")))
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeCacheArchive(buf, src))
+	extracted := filepath.Join(dir, "extracted")
+	require.NoError(t, extractCacheArchive(buf, extracted))
+	check(extracted)
+
+	copied := filepath.Join(dir, "copied")
+	require.NoError(t, copyDir(src, copied))
+	check(copied)
+
+	size, err := dirSize(src)
+	assert.NoError(err)
+	assert.EqualValues(len("contents"), size)
+}
+
+func TestExtractCacheArchiveRejectsEscapingSymlinks(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cache-symlinks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	type entry struct {
+		name, link, contents string
+	}
+	archive := func(entries ...entry) *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+		for _, e := range entries {
+			hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.contents)), Typeflag: tar.TypeReg}
+			if e.link != "" {
+				hdr = &tar.Header{Name: e.name, Linkname: e.link, Mode: 0777, Typeflag: tar.TypeSymlink}
+			}
+			require.NoError(t, tw.WriteHeader(hdr))
+			_, err := tw.Write([]byte(e.contents))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+		return buf
+	}
+
+	for i, entries := range [][]entry{
+		{{name: "up", link: ".."}},
+		{{name: "abs", link: "/etc"}},
+		// ".." after a symlink leads to the parent of its target
+		{{name: "here", link: "."}, {name: "up", link: "here/.."}},
+		{{name: "here", link: "."}, {name: "up", link: "here/../escaped"}},
+		// a symlink that led inside when it was extracted, but doesn't once
+		// a later one is
+		{{name: "up", link: "a/../escaped"}, {name: "a", link: "."}, {name: "up", contents: "x"}},
+		{{name: "up", link: "a/.."}, {name: "a", link: "."}, {name: "up/file", contents: "x"}},
+	} {
+		extracted := filepath.Join(dir, fmt.Sprintf("extracted%d", i))
+		assert.Error(extractCacheArchive(archive(entries...), extracted), "case %d", i)
+		_, err = os.Stat(filepath.Join(dir, "escaped"))
+		assert.True(os.IsNotExist(err), "case %d", i)
+		_, err = os.Stat(filepath.Join(dir, "file"))
+		assert.True(os.IsNotExist(err), "case %d", i)
+	}
+
+	// symlinks that stay inside, even through others, are kept
+	extracted := filepath.Join(dir, "ok")
+	require.NoError(t, extractCacheArchive(archive(
+		entry{name: "sub/file", contents: "x"},
+		entry{name: "link", link: "sub"},
+		entry{name: "sub/up", link: "../link/file"},
+		entry{name: "dangling", link: "missing/file"},
+	), extracted))
+	contents, err := ioutil.ReadFile(filepath.Join(extracted, "sub", "up"))
+	assert.NoError(err)
+	assert.Equal("x", string(contents))
+}
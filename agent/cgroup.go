@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/mongodb/grip/slogger"
+)
+
+// startTaskCgroup creates a cgroup for the task's processes to run in, with
+// the resource limits of its distro and variant. Tasks are run without one
+// if cgroups can't be used on the host.
+func (agt *Agent) startTaskCgroup(taskConfig *model.TaskConfig) {
+	limits := taskConfig.Distro.ResourceLimits.Merge(taskConfig.BuildVariant.ResourceLimits)
+
+	// not being able to enforce limits is worse than not being able to
+	// account for the task's resources
+	level := slogger.WARN
+	if *limits != (distro.ResourceLimits{}) {
+		level = slogger.ERROR
+	}
+
+	if runtime.GOOS != "linux" {
+		if level == slogger.ERROR {
+			agt.logger.LogExecution(level, "Resource limits are only supported on linux")
+		}
+		return
+	}
+
+	cg, err := command.NewTaskCgroup("evg-"+filepath.Base(taskConfig.WorkDir), command.CgroupLimits{
+		CPUs:        limits.CPUs,
+		MemoryBytes: int64(limits.MemoryMB) * 1024 * 1024,
+		Pids:        limits.Pids,
+	})
+	if err != nil {
+		agt.logger.LogExecution(level, "Running task without a cgroup: %v", err)
+		return
+	}
+
+	agt.logger.LogExecution(slogger.INFO, "Running task in cgroup %v", cg.Path)
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+	agt.taskCgroup = cg
+	agt.commandUsage = nil
+}
+
+func (agt *Agent) getTaskCgroup() *command.Cgroup {
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+
+	return agt.taskCgroup
+}
+
+// startCommandCgroup creates a cgroup in the task's cgroup for the next
+// command's processes to run in, if the task has one.
+func (agt *Agent) startCommandCgroup() {
+	agt.taskConfig.Cgroup = nil
+
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+	if agt.taskCgroup == nil {
+		return
+	}
+
+	cg, err := agt.taskCgroup.NewChild(fmt.Sprintf("cmd-%d", len(agt.commandUsage)))
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Running command without a cgroup: %v", err)
+		return
+	}
+	agt.taskConfig.Cgroup = cg
+}
+
+// recordCommandUsage records the resources used by the command that just ran,
+// if it ran in a cgroup.
+func (agt *Agent) recordCommandUsage(commandName string) {
+	cg := agt.taskConfig.Cgroup
+	if cg == nil {
+		return
+	}
+	agt.taskConfig.Cgroup = nil
+
+	usage, err := cg.Usage()
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Error getting resource usage of command %v: %v", commandName, err)
+		usage = &apimodels.ResourceUsage{}
+	}
+
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+	agt.commandUsage = append(agt.commandUsage, apimodels.CommandResourceUsage{
+		Command:       commandName,
+		ResourceUsage: *usage,
+	})
+}
+
+// addResourceUsage adds the resources used by the task and by each of its
+// commands to the detail, if the task ran in a cgroup.
+func (agt *Agent) addResourceUsage(detail *apimodels.TaskEndDetail) {
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+	if agt.taskCgroup == nil {
+		return
+	}
+
+	usage, err := agt.taskCgroup.Usage()
+	if err != nil {
+		agt.logger.LogExecution(slogger.WARN, "Error getting resource usage of task: %v", err)
+		return
+	}
+	detail.ResourceUsage = usage
+	detail.CommandResourceUsage = agt.commandUsage
+}
+
+// removeTaskCgroup kills anything still running in the task's cgroup and
+// removes it, if there is one. It is safe to call more than once.
+func (agt *Agent) removeTaskCgroup() error {
+	agt.taskCgroupMutex.Lock()
+	defer agt.taskCgroupMutex.Unlock()
+	if agt.taskCgroup == nil {
+		return nil
+	}
+
+	if err := agt.taskCgroup.Kill(); err != nil {
+		return err
+	}
+	if err := agt.taskCgroup.Remove(); err != nil {
+		return err
+	}
+	agt.taskCgroup = nil
+	agt.commandUsage = nil
+	return nil
+}
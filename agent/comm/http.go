@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
@@ -46,6 +47,10 @@ type HTTPCommunicator struct {
 	httpClient    *http.Client
 	// TODO only use one Client after global locking is removed
 	heartbeatClient *http.Client
+
+	// RunningTasks, if set, returns the tasks the agent is running in its
+	// other task slots, which are reported when asking for a next task
+	RunningTasks func() []string
 }
 
 // NewHTTPCommunicator returns an initialized HTTPCommunicator.
@@ -265,9 +270,14 @@ func (h *HTTPCommunicator) GetDistro() (*distro.Distro, error) {
 // GetNextTask returns a next task response by getting the next task for a given host.
 func (h *HTTPCommunicator) GetNextTask() (*apimodels.NextTaskResponse, error) {
 	taskResponse := &apimodels.NextTaskResponse{}
+	path := "agent/next_task"
+	if h.RunningTasks != nil {
+		query := url.Values{apimodels.RunningTaskParam: h.RunningTasks()}
+		path += "?" + query.Encode()
+	}
 	retriableGet := util.RetriableFunc(
 		func() error {
-			resp, err := h.TryGet("agent/next_task")
+			resp, err := h.TryGet(path)
 			if resp == nil {
 				return util.RetriableError{fmt.Errorf("empty response")}
 			}
@@ -483,6 +493,9 @@ func (h *HTTPCommunicator) tryRequestWithClient(path string, method string, clie
 	req.Header.Add(evergreen.HostHeader, h.HostId)
 	req.Header.Add(evergreen.HostSecretHeader, h.HostSecret)
 	req.Header.Add("Content-Type", "application/json")
+	if evergreen.BuildRevision != "" {
+		req.Header.Add(evergreen.AgentRevisionHeader, evergreen.BuildRevision)
+	}
 
 	resp, err := client.Do(req)
 	return resp, errors.WithStack(err)
@@ -520,12 +533,13 @@ func (h *HTTPCommunicator) postJSON(path string, data interface{}) (
 	return resp, retryFail, err
 }
 
-// FetchExpansionVars loads expansions for a communicator's task from the API server.
-func (h *HTTPCommunicator) FetchExpansionVars() (*apimodels.ExpansionVars, error) {
-	resultVars := &apimodels.ExpansionVars{}
+// FetchExpansionVars loads expansions for a communicator's task from the
+// API server, along with which of them are private.
+func (h *HTTPCommunicator) FetchExpansionVars() (*apimodels.ProjectVars, error) {
+	resultVars := &apimodels.ProjectVars{}
 	retriableGet := util.RetriableFunc(
 		func() error {
-			resp, err := h.TryTaskGet("fetch_vars")
+			resp, err := h.TryTaskGet("fetch_vars?" + apimodels.PrivateVarsParam + "=true")
 			if resp != nil {
 				defer resp.Body.Close()
 			}
@@ -269,13 +269,18 @@ func TestCommunicatorServerUp(t *testing.T) {
 			test_vars["test_key"] = "test_value"
 			test_vars["second_fetch"] = "more_one"
 			serveMux.HandleFunc("/task/mocktaskid/fetch_vars", func(w http.ResponseWriter, req *http.Request) {
-				util.WriteJSON(&w, test_vars, http.StatusOK)
+				So(req.FormValue(apimodels.PrivateVarsParam), ShouldEqual, "true")
+				util.WriteJSON(&w, apimodels.ProjectVars{
+					Vars:        test_vars,
+					PrivateVars: map[string]bool{"test_key": true},
+				}, http.StatusOK)
 			})
 			resultingVars, err := agentCommunicator.FetchExpansionVars()
 			So(err, ShouldBeNil)
-			So(len(*resultingVars), ShouldEqual, 2)
-			So((*resultingVars)["test_key"], ShouldEqual, "test_value")
-			So((*resultingVars)["second_fetch"], ShouldEqual, "more_one")
+			So(len(resultingVars.Vars), ShouldEqual, 2)
+			So(resultingVars.Vars["test_key"], ShouldEqual, "test_value")
+			So(resultingVars.Vars["second_fetch"], ShouldEqual, "more_one")
+			So(resultingVars.PrivateVars["test_key"], ShouldBeTrue)
 
 		})
 	})
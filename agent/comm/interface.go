@@ -22,7 +22,7 @@ type TaskCommunicator interface {
 	GetVersion() (*version.Version, error)
 	Log([]apimodels.LogMessage) error
 	Heartbeat() (bool, error)
-	FetchExpansionVars() (*apimodels.ExpansionVars, error)
+	FetchExpansionVars() (*apimodels.ProjectVars, error)
 	GetNextTask() (*apimodels.NextTaskResponse, error)
 	TryTaskGet(path string) (*http.Response, error)
 	TryTaskPost(path string, data interface{}) (*http.Response, error)
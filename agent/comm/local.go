@@ -0,0 +1,312 @@
+package comm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// localKeyValIncPath is the endpoint used by the keyval.inc command
+	localKeyValIncPath = "keyval/inc"
+	// localTestLogsPath is the endpoint used to attach test logs
+	localTestLogsPath = "test_logs"
+)
+
+// LocalCommunicator implements TaskCommunicator for running a task without an
+// API server. The task, distro, version and project ref are supplied up front,
+// and everything the agent would send to the API server is written to files
+// in OutputDir instead.
+type LocalCommunicator struct {
+	OutputDir  string
+	Task       *task.Task
+	Distro     *distro.Distro
+	Version    *version.Version
+	ProjectRef *model.ProjectRef
+	Vars       apimodels.ExpansionVars
+
+	// EndDetail holds the details the task ended with, once it has finished.
+	EndDetail *apimodels.TaskEndDetail
+
+	// posts holds the last document posted to each endpoint, so that
+	// commands can read back what earlier commands attached
+	posts   map[string][]byte
+	keyVals map[string]int64
+	mutex   sync.Mutex
+}
+
+// NewLocalCommunicator returns a LocalCommunicator that writes its output
+// to the given directory, creating it if necessary.
+func NewLocalCommunicator(outputDir string, t *task.Task, d *distro.Distro, v *version.Version,
+	ref *model.ProjectRef, vars apimodels.ExpansionVars) (*LocalCommunicator, error) {
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "error creating output directory %s", outputDir)
+	}
+	if vars == nil {
+		vars = apimodels.ExpansionVars{}
+	}
+
+	return &LocalCommunicator{
+		OutputDir:  outputDir,
+		Task:       t,
+		Distro:     d,
+		Version:    v,
+		ProjectRef: ref,
+		Vars:       vars,
+		posts:      map[string][]byte{},
+		keyVals:    map[string]int64{},
+	}, nil
+}
+
+// Start records the task that is about to run.
+func (lc *LocalCommunicator) Start() error {
+	return errors.WithStack(lc.writeJSON("task.json", lc.Task))
+}
+
+// End records the details the task ended with, and tells the agent to exit
+// since there are no more tasks to run.
+func (lc *LocalCommunicator) End(detail *apimodels.TaskEndDetail) (*apimodels.EndTaskResponse, error) {
+	lc.mutex.Lock()
+	lc.EndDetail = detail
+	lc.mutex.Unlock()
+
+	if err := lc.writeJSON("end.json", detail); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &apimodels.EndTaskResponse{ShouldExit: true, Message: "local task finished"}, nil
+}
+
+func (lc *LocalCommunicator) GetTask() (*task.Task, error) {
+	return lc.Task, nil
+}
+
+func (lc *LocalCommunicator) GetProjectRef() (*model.ProjectRef, error) {
+	return lc.ProjectRef, nil
+}
+
+func (lc *LocalCommunicator) GetDistro() (*distro.Distro, error) {
+	return lc.Distro, nil
+}
+
+func (lc *LocalCommunicator) GetVersion() (*version.Version, error) {
+	return lc.Version, nil
+}
+
+// Log appends the messages to task.log, execution.log or system.log,
+// depending on their type.
+func (lc *LocalCommunicator) Log(messages []apimodels.LogMessage) error {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	buffers := map[string]*bytes.Buffer{}
+	for _, msg := range messages {
+		name := localLogFileName(msg.Type)
+		if buffers[name] == nil {
+			buffers[name] = &bytes.Buffer{}
+		}
+		fmt.Fprintf(buffers[name], "[%s] [%s] %s\n",
+			msg.Timestamp.Format("2006/01/02 15:04:05.000"), msg.Severity, msg.Message)
+	}
+
+	catcher := grip.NewCatcher()
+	for name, buf := range buffers {
+		catcher.Add(lc.appendFile(name, buf.Bytes()))
+	}
+	return catcher.Resolve()
+}
+
+func localLogFileName(logType string) string {
+	switch logType {
+	case apimodels.TaskLogPrefix:
+		return "task.log"
+	case apimodels.SystemLogPrefix:
+		return "system.log"
+	default:
+		return "execution.log"
+	}
+}
+
+// Heartbeat always succeeds, since a local task can't be aborted.
+func (lc *LocalCommunicator) Heartbeat() (bool, error) {
+	return false, nil
+}
+
+// FetchExpansionVars returns the variables read from the local vars file.
+func (lc *LocalCommunicator) FetchExpansionVars() (*apimodels.ProjectVars, error) {
+	return &apimodels.ProjectVars{Vars: lc.Vars}, nil
+}
+
+// GetNextTask tells the agent to exit, since only one task is run locally.
+func (lc *LocalCommunicator) GetNextTask() (*apimodels.NextTaskResponse, error) {
+	return &apimodels.NextTaskResponse{ShouldExit: true, Message: "no more local tasks"}, nil
+}
+
+// TryTaskGet returns the last document posted to the path, or a 404 if
+// nothing has been posted there.
+func (lc *LocalCommunicator) TryTaskGet(path string) (*http.Response, error) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	data, ok := lc.posts[path]
+	if !ok {
+		return localResponse(http.StatusNotFound,
+			[]byte(fmt.Sprintf("'%s' is not available when running locally", path))), nil
+	}
+	return localResponse(http.StatusOK, data), nil
+}
+
+// TryTaskPost writes the document to a file named after the path. Each post
+// is appended to the file as a line of JSON. Test logs and keyval
+// increments are answered the way the API server would answer them.
+func (lc *LocalCommunicator) TryTaskPost(path string, data interface{}) (*http.Response, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error marshaling data for %s", path)
+	}
+
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	switch path {
+	case localKeyValIncPath:
+		return lc.incKeyVal(data)
+	case localTestLogsPath:
+		return lc.writeTestLog(out)
+	}
+
+	lc.posts[path] = out
+	if err = lc.appendFile(localPostFileName(path), append(out, '\n')); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return localResponse(http.StatusOK, []byte("{}")), nil
+}
+
+// TryGet always returns a 404, since there is no API server to ask.
+func (lc *LocalCommunicator) TryGet(path string) (*http.Response, error) {
+	return localResponse(http.StatusNotFound,
+		[]byte(fmt.Sprintf("'%s' is not available when running locally", path))), nil
+}
+
+// TryPostJSON discards the data, since there is no API server to send it to.
+func (lc *LocalCommunicator) TryPostJSON(path string, data interface{}) (*http.Response, error) {
+	return localResponse(http.StatusOK, []byte("{}")), nil
+}
+
+func (lc *LocalCommunicator) SetTask(taskId, taskSecret string) {
+	lc.Task.Id = taskId
+	lc.Task.Secret = taskSecret
+}
+
+func (lc *LocalCommunicator) GetCurrentTaskId() string {
+	return lc.Task.Id
+}
+
+func (lc *LocalCommunicator) SetSignalChan(chan Signal) {}
+
+func (lc *LocalCommunicator) SetLogger(*slogger.Logger) {}
+
+// incKeyVal increments a counter kept for the duration of the local run.
+func (lc *LocalCommunicator) incKeyVal(data interface{}) (*http.Response, error) {
+	key, ok := data.(string)
+	if !ok {
+		return localResponse(http.StatusBadRequest, []byte("key must be a string")), nil
+	}
+	lc.keyVals[key]++
+
+	out, err := json.Marshal(model.KeyVal{Key: key, Value: lc.keyVals[key]})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = lc.writeJSON("keyval.json", lc.keyVals); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return localResponse(http.StatusOK, out), nil
+}
+
+// writeTestLog writes the test log to its own file in the test_logs
+// directory, and replies with the log's id.
+func (lc *LocalCommunicator) writeTestLog(data []byte) (*http.Response, error) {
+	testLog := model.TestLog{}
+	if err := json.Unmarshal(data, &testLog); err != nil {
+		return localResponse(http.StatusBadRequest, []byte(err.Error())), nil
+	}
+
+	// test names are often paths, which shouldn't become directories
+	baseId := strings.Replace(util.CleanName(testLog.Name), "/", "_", -1)
+	if baseId == "" {
+		baseId = "test"
+	}
+	id := baseId
+	for i := 1; ; i++ {
+		if _, ok := lc.posts[localTestLogsPath+"/"+id]; !ok {
+			break
+		}
+		id = fmt.Sprintf("%s_%d", baseId, i)
+	}
+	lc.posts[localTestLogsPath+"/"+id] = data
+
+	if err := lc.writeFile(filepath.Join(localTestLogsPath, id+".json"), data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return localResponse(http.StatusOK, []byte(fmt.Sprintf(`{"_id":%q}`, id))), nil
+}
+
+// localPostFileName maps an endpoint, e.g. "json/data/foo", to the file that
+// documents posted to it are written to, e.g. "json_data_foo.json".
+func localPostFileName(path string) string {
+	return strings.Replace(strings.Trim(path, "/"), "/", "_", -1) + ".json"
+}
+
+func (lc *LocalCommunicator) writeJSON(name string, data interface{}) error {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "error marshaling %s", name)
+	}
+	return lc.writeFile(name, out)
+}
+
+func (lc *LocalCommunicator) writeFile(name string, data []byte) error {
+	path := filepath.Join(lc.OutputDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory for %s", path)
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, data, 0644), "error writing %s", path)
+}
+
+func (lc *LocalCommunicator) appendFile(name string, data []byte) error {
+	path := filepath.Join(lc.OutputDir, name)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "error opening %s", path)
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "error writing %s", path)
+	}
+	return errors.Wrapf(f.Close(), "error closing %s", path)
+}
+
+func localResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
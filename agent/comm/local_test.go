@@ -0,0 +1,151 @@
+package comm
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestLocalCommunicator(t *testing.T) (*LocalCommunicator, func()) {
+	dir, err := ioutil.TempDir("", "local-comm")
+	require.NoError(t, err)
+
+	lc, err := NewLocalCommunicator(filepath.Join(dir, "out"), &task.Task{Id: "t1"},
+		&distro.Distro{Id: "local"}, &version.Version{Id: "v1"}, &model.ProjectRef{Identifier: "p"},
+		apimodels.ExpansionVars{"secret": "value"})
+	require.NoError(t, err)
+
+	return lc, func() { os.RemoveAll(dir) }
+}
+
+func TestLocalCommunicatorSuppliesTaskData(t *testing.T) {
+	assert := assert.New(t)
+	lc, cleanup := makeTestLocalCommunicator(t)
+	defer cleanup()
+
+	tsk, err := lc.GetTask()
+	assert.NoError(err)
+	assert.Equal("t1", tsk.Id)
+	assert.Equal("t1", lc.GetCurrentTaskId())
+
+	vars, err := lc.FetchExpansionVars()
+	assert.NoError(err)
+	assert.Equal("value", vars.Vars["secret"])
+
+	next, err := lc.GetNextTask()
+	assert.NoError(err)
+	assert.True(next.ShouldExit)
+}
+
+func TestLocalCommunicatorWritesLogsByType(t *testing.T) {
+	assert := assert.New(t)
+	lc, cleanup := makeTestLocalCommunicator(t)
+	defer cleanup()
+
+	now := time.Now()
+	assert.NoError(lc.Log([]apimodels.LogMessage{
+		{Type: apimodels.TaskLogPrefix, Severity: apimodels.LogInfoPrefix, Message: "task one", Timestamp: now},
+		{Type: apimodels.AgentLogPrefix, Severity: apimodels.LogInfoPrefix, Message: "agent one", Timestamp: now},
+		{Type: apimodels.TaskLogPrefix, Severity: apimodels.LogInfoPrefix, Message: "task two", Timestamp: now},
+	}))
+
+	taskLog, err := ioutil.ReadFile(filepath.Join(lc.OutputDir, "task.log"))
+	assert.NoError(err)
+	assert.Contains(string(taskLog), "task one")
+	assert.Contains(string(taskLog), "task two")
+	assert.NotContains(string(taskLog), "agent one")
+
+	execLog, err := ioutil.ReadFile(filepath.Join(lc.OutputDir, "execution.log"))
+	assert.NoError(err)
+	assert.Contains(string(execLog), "agent one")
+}
+
+func TestLocalCommunicatorPosts(t *testing.T) {
+	assert := assert.New(t)
+	lc, cleanup := makeTestLocalCommunicator(t)
+	defer cleanup()
+
+	// nothing has been posted yet
+	resp, err := lc.TryTaskGet("json/data/foo")
+	assert.NoError(err)
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	resp, err = lc.TryTaskPost("json/data/foo", map[string]int{"a": 1})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	resp, err = lc.TryTaskPost("json/data/foo", map[string]int{"a": 2})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	out, err := ioutil.ReadFile(filepath.Join(lc.OutputDir, "json_data_foo.json"))
+	assert.NoError(err)
+	assert.Equal("{\"a\":1}\n{\"a\":2}\n", string(out))
+
+	// the last post can be read back
+	resp, err = lc.TryTaskGet("json/data/foo")
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	data := map[string]int{}
+	assert.NoError(util.ReadJSONInto(resp.Body, &data))
+	assert.Equal(2, data["a"])
+}
+
+func TestLocalCommunicatorKeyValAndTestLogs(t *testing.T) {
+	assert := assert.New(t)
+	lc, cleanup := makeTestLocalCommunicator(t)
+	defer cleanup()
+
+	for i := int64(1); i <= 2; i++ {
+		resp, err := lc.TryTaskPost("keyval/inc", "counter")
+		assert.NoError(err)
+		kv := &model.KeyVal{}
+		assert.NoError(util.ReadJSONInto(resp.Body, kv))
+		assert.Equal(i, kv.Value)
+	}
+
+	ids := []string{}
+	for i := 0; i < 2; i++ {
+		resp, err := lc.TryTaskPost("test_logs", &model.TestLog{Name: "dir/my test", Lines: []string{"ok"}})
+		assert.NoError(err)
+		reply := struct {
+			Id string `json:"_id"`
+		}{}
+		assert.NoError(util.ReadJSONInto(resp.Body, &reply))
+		ids = append(ids, reply.Id)
+	}
+	assert.Equal([]string{"dir_my_test", "dir_my_test_1"}, ids)
+
+	for _, id := range ids {
+		_, err := os.Stat(filepath.Join(lc.OutputDir, "test_logs", id+".json"))
+		assert.NoError(err)
+	}
+}
+
+func TestLocalCommunicatorEnd(t *testing.T) {
+	assert := assert.New(t)
+	lc, cleanup := makeTestLocalCommunicator(t)
+	defer cleanup()
+
+	assert.NoError(lc.Start())
+	resp, err := lc.End(&apimodels.TaskEndDetail{Status: "failed", Description: "shell.exec"})
+	assert.NoError(err)
+	assert.True(resp.ShouldExit)
+	assert.Equal("failed", lc.EndDetail.Status)
+
+	for _, name := range []string{"task.json", "end.json"} {
+		_, err = os.Stat(filepath.Join(lc.OutputDir, name))
+		assert.NoError(err)
+	}
+}
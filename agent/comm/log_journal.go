@@ -0,0 +1,215 @@
+package comm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LogJournalFileName is the name of the journal file in the task directory.
+	LogJournalFileName = ".evergreen-log-journal"
+
+	// DefaultLogJournalMaxBytes bounds the size of the journal so that a long
+	// outage can't fill up the host's disk.
+	DefaultLogJournalMaxBytes = 64 * 1024 * 1024
+)
+
+// LogJournal is an on-disk queue of log message batches that could not be
+// sent to the API server. Batches are appended as lines of JSON and replayed
+// in the order they were written. Once the journal reaches its size limit,
+// further batches are dropped, and a marker describing the gap is written in
+// their place as soon as there is room for it.
+type LogJournal struct {
+	path     string
+	maxBytes int64
+
+	// size is the number of bytes in the file, and offset is the number of
+	// those bytes that have already been replayed
+	size   int64
+	offset int64
+
+	// the number of messages dropped since the journal filled up, and the
+	// time span they cover
+	dropped  int
+	gapStart time.Time
+	gapEnd   time.Time
+
+	mutex sync.Mutex
+}
+
+// NewLogJournal returns a journal that stores its batches in the file at
+// path, which is created when the first batch is written.
+func NewLogJournal(path string, maxBytes int64) *LogJournal {
+	return &LogJournal{
+		path:     path,
+		maxBytes: maxBytes,
+	}
+}
+
+// Path returns the path of the journal file.
+func (j *LogJournal) Path() string {
+	return j.path
+}
+
+// Pending returns true if the journal holds messages, or a gap marker, that
+// have not been replayed yet.
+func (j *LogJournal) Pending() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.size > j.offset || j.dropped > 0
+}
+
+// Write appends a batch of messages to the journal. If the journal is full,
+// the messages are dropped and counted towards the current gap.
+func (j *LogJournal) Write(messages []apimodels.LogMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	line, err := marshalJournalBatch(messages)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if j.size+int64(len(line)) > j.maxBytes {
+		if j.dropped == 0 {
+			j.gapStart = messages[0].Timestamp
+		}
+		j.dropped += len(messages)
+		j.gapEnd = messages[len(messages)-1].Timestamp
+		return nil
+	}
+
+	if j.dropped > 0 {
+		// the marker is small, so it is written even if it goes over the limit
+		marker, err := marshalJournalBatch([]apimodels.LogMessage{j.gapMarker()})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err = j.appendLine(marker); err != nil {
+			return errors.WithStack(err)
+		}
+		j.dropped = 0
+	}
+
+	return errors.WithStack(j.appendLine(line))
+}
+
+// Replay sends every batch in the journal, in order, stopping at the first
+// batch that fails to send. It returns the number of messages sent. Once the
+// whole journal has been sent the file is emptied.
+func (j *LogJournal) Replay(send func([]apimodels.LogMessage) error) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	sent := 0
+	if j.size > j.offset {
+		file, err := os.Open(j.path)
+		if err != nil {
+			return 0, errors.Wrapf(err, "error opening log journal %s", j.path)
+		}
+		defer file.Close()
+
+		if _, err = file.Seek(j.offset, io.SeekStart); err != nil {
+			return 0, errors.Wrapf(err, "error seeking in log journal %s", j.path)
+		}
+
+		reader := bufio.NewReader(file)
+		for j.offset < j.size {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return sent, errors.Wrapf(err, "error reading log journal %s", j.path)
+			}
+
+			batch := []apimodels.LogMessage{}
+			if err = json.Unmarshal(line, &batch); err != nil {
+				return sent, errors.Wrapf(err, "error parsing log journal %s", j.path)
+			}
+			if err = send(batch); err != nil {
+				return sent, errors.WithStack(err)
+			}
+
+			j.offset += int64(len(line))
+			sent += len(batch)
+		}
+
+		if err = os.Truncate(j.path, 0); err != nil {
+			return sent, errors.Wrapf(err, "error truncating log journal %s", j.path)
+		}
+		j.size = 0
+		j.offset = 0
+	}
+
+	if j.dropped > 0 {
+		if err := send([]apimodels.LogMessage{j.gapMarker()}); err != nil {
+			return sent, errors.WithStack(err)
+		}
+		j.dropped = 0
+	}
+
+	return sent, nil
+}
+
+// Remove deletes the journal file, discarding anything left in it.
+func (j *LogJournal) Remove() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.size = 0
+	j.offset = 0
+	j.dropped = 0
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing log journal %s", j.path)
+	}
+	return nil
+}
+
+// gapMarker returns a task log message describing the messages that were
+// dropped while the journal was full.
+func (j *LogJournal) gapMarker() apimodels.LogMessage {
+	return apimodels.LogMessage{
+		Type:     apimodels.TaskLogPrefix,
+		Severity: apimodels.LogWarnPrefix,
+		Message: fmt.Sprintf("[evergreen] %d log messages from %s to %s were lost "+
+			"while the API server was unreachable", j.dropped,
+			j.gapStart.Format(time.RFC3339), j.gapEnd.Format(time.RFC3339)),
+		Timestamp: j.gapEnd,
+		Version:   evergreen.LogmessageCurrentVersion,
+	}
+}
+
+func (j *LogJournal) appendLine(line []byte) error {
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error opening log journal %s", j.path)
+	}
+
+	n, err := file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "error writing log journal %s", j.path)
+	}
+	return errors.Wrapf(file.Close(), "error closing log journal %s", j.path)
+}
+
+func marshalJournalBatch(messages []apimodels.LogMessage) ([]byte, error) {
+	line, err := json.Marshal(messages)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling log messages")
+	}
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,174 @@
+package comm
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeJournalMessages(texts ...string) []apimodels.LogMessage {
+	msgs := []apimodels.LogMessage{}
+	for _, text := range texts {
+		msgs = append(msgs, apimodels.LogMessage{
+			Type:      apimodels.TaskLogPrefix,
+			Severity:  apimodels.LogInfoPrefix,
+			Message:   text,
+			Timestamp: time.Now(),
+		})
+	}
+	return msgs
+}
+
+func messageTexts(batches [][]apimodels.LogMessage) []string {
+	texts := []string{}
+	for _, batch := range batches {
+		for _, msg := range batch {
+			texts = append(texts, msg.Message)
+		}
+	}
+	return texts
+}
+
+func makeTestJournal(t *testing.T, maxBytes int64) (*LogJournal, func()) {
+	dir, err := ioutil.TempDir("", "log-journal")
+	require.NoError(t, err)
+	return NewLogJournal(filepath.Join(dir, LogJournalFileName), maxBytes), func() { os.RemoveAll(dir) }
+}
+
+func TestLogJournalReplaysInOrder(t *testing.T) {
+	assert := assert.New(t)
+	journal, cleanup := makeTestJournal(t, DefaultLogJournalMaxBytes)
+	defer cleanup()
+
+	assert.False(journal.Pending())
+	assert.NoError(journal.Write(makeJournalMessages("a", "b")))
+	assert.NoError(journal.Write(makeJournalMessages("c")))
+	assert.True(journal.Pending())
+
+	// the second batch fails, so only the first is sent
+	sent := [][]apimodels.LogMessage{}
+	attempts := 0
+	n, err := journal.Replay(func(batch []apimodels.LogMessage) error {
+		attempts++
+		if attempts == 2 {
+			return errors.New("api server is down")
+		}
+		sent = append(sent, batch)
+		return nil
+	})
+	assert.Error(err)
+	assert.Equal(2, n)
+	assert.True(journal.Pending())
+
+	// the rest is sent once the server is back
+	assert.NoError(journal.Write(makeJournalMessages("d")))
+	n, err = journal.Replay(func(batch []apimodels.LogMessage) error {
+		sent = append(sent, batch)
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(2, n)
+	assert.False(journal.Pending())
+	assert.Equal([]string{"a", "b", "c", "d"}, messageTexts(sent))
+
+	info, err := os.Stat(journal.Path())
+	assert.NoError(err)
+	assert.EqualValues(0, info.Size())
+
+	assert.NoError(journal.Remove())
+	_, err = os.Stat(journal.Path())
+	assert.True(os.IsNotExist(err))
+}
+
+func TestLogJournalMarksGapsWhenFull(t *testing.T) {
+	assert := assert.New(t)
+
+	line, err := marshalJournalBatch(makeJournalMessages("a"))
+	require.NoError(t, err)
+	journal, cleanup := makeTestJournal(t, int64(len(line)))
+	defer cleanup()
+
+	// only the first batch fits
+	assert.NoError(journal.Write(makeJournalMessages("a")))
+	assert.NoError(journal.Write(makeJournalMessages("b", "c")))
+
+	sent := [][]apimodels.LogMessage{}
+	send := func(batch []apimodels.LogMessage) error {
+		sent = append(sent, batch)
+		return nil
+	}
+	_, err = journal.Replay(send)
+	assert.NoError(err)
+	require.Len(t, sent, 2)
+	assert.Equal("a", sent[0][0].Message)
+	assert.Equal(apimodels.LogWarnPrefix, sent[1][0].Severity)
+	assert.Contains(sent[1][0].Message, "2 log messages")
+	assert.False(journal.Pending())
+}
+
+func TestLogJournalMarksGapsWhereTheyHappened(t *testing.T) {
+	assert := assert.New(t)
+
+	line, err := marshalJournalBatch(makeJournalMessages("a"))
+	require.NoError(t, err)
+	journal, cleanup := makeTestJournal(t, int64(2*len(line)))
+	defer cleanup()
+
+	// a batch too big to fit is dropped, but a later small one still fits
+	assert.NoError(journal.Write(makeJournalMessages("a")))
+	assert.NoError(journal.Write(makeJournalMessages("b", "b", "b")))
+	assert.NoError(journal.Write(makeJournalMessages("c")))
+
+	sent := [][]apimodels.LogMessage{}
+	_, err = journal.Replay(func(batch []apimodels.LogMessage) error {
+		sent = append(sent, batch)
+		return nil
+	})
+	assert.NoError(err)
+	require.Len(t, sent, 3)
+	assert.Equal("a", sent[0][0].Message)
+	assert.Contains(sent[1][0].Message, "3 log messages")
+	assert.Equal("c", sent[2][0].Message)
+}
+
+func TestAPILoggerJournalsWhileServerIsDown(t *testing.T) {
+	assert := assert.New(t)
+	journal, cleanup := makeTestJournal(t, DefaultLogJournalMaxBytes)
+	defer cleanup()
+
+	mc := &MockCommunicator{LogChan: make(chan []apimodels.LogMessage, 100)}
+	apiLgr := NewAPILogger(mc)
+	apiLgr.SendAfterLines = 1000
+	apiLgr.SetJournal(journal)
+
+	mc.shouldFailEnd = true
+	for _, text := range []string{"a", "b"} {
+		assert.Equal(0, apiLgr.sendLogs(makeJournalMessages(text), false))
+	}
+	assert.True(journal.Pending())
+	assert.Len(mc.LogChan, 0)
+
+	// once the server is back, the journal is replayed before new messages
+	mc.shouldFailEnd = false
+	apiLgr.messages = makeJournalMessages("c")
+	assert.Equal(3, apiLgr.FlushAndWait())
+	assert.False(journal.Pending())
+
+	close(mc.LogChan)
+	sent := [][]apimodels.LogMessage{}
+	for batch := range mc.LogChan {
+		sent = append(sent, batch)
+	}
+	assert.Equal([]string{"a", "b", "c"}, messageTexts(sent))
+
+	assert.NoError(apiLgr.CloseJournal())
+	_, err := os.Stat(journal.Path())
+	assert.True(os.IsNotExist(err))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 	"github.com/mongodb/grip/send"
@@ -223,6 +224,21 @@ type APILogger struct {
 	// or the SendAfterLines threshold is reached.
 	autoFlushTimer *time.Timer
 
+	// journal holds messages that could not be sent while the API server
+	// was unreachable, until they can be replayed. When nil, messages that
+	// fail to send are dropped.
+	journal *LogJournal
+
+	// How long to wait after a failed send before replaying the journal, so
+	// that every flush during an outage doesn't wait on the API server.
+	JournalRetryInterval time.Duration
+
+	// last time sending messages to the API server failed
+	lastSendFailure time.Time
+
+	// redactor, if set, hides secret values in messages before they're sent
+	redactor *util.Redactor
+
 	// The mechanism for communicating with the remote endpoint.
 	TaskCommunicator
 }
@@ -231,16 +247,35 @@ type APILogger struct {
 func NewAPILogger(tc TaskCommunicator) *APILogger {
 	sendAfterDuration := 5 * time.Second
 	return &APILogger{
-		messages:          make([]apimodels.LogMessage, 0, 100),
-		flushLock:         sync.Mutex{},
-		appendLock:        sync.Mutex{},
-		SendAfterLines:    100,
-		SendAfterDuration: sendAfterDuration,
-		autoFlushTimer:    time.NewTimer(sendAfterDuration),
-		TaskCommunicator:  tc,
+		messages:             make([]apimodels.LogMessage, 0, 100),
+		flushLock:            sync.Mutex{},
+		appendLock:           sync.Mutex{},
+		SendAfterLines:       100,
+		SendAfterDuration:    sendAfterDuration,
+		autoFlushTimer:       time.NewTimer(sendAfterDuration),
+		JournalRetryInterval: 30 * time.Second,
+		TaskCommunicator:     tc,
 	}
 }
 
+// SetJournal sets the journal that messages are written to while they can't
+// be sent to the API server.
+func (apiLgr *APILogger) SetJournal(journal *LogJournal) {
+	apiLgr.flushLock.Lock()
+	defer apiLgr.flushLock.Unlock()
+
+	apiLgr.journal = journal
+}
+
+// SetRedactor sets the Redactor that hides secret values in messages before
+// they're sent to the API server.
+func (apiLgr *APILogger) SetRedactor(redactor *util.Redactor) {
+	apiLgr.appendLock.Lock()
+	defer apiLgr.appendLock.Unlock()
+
+	apiLgr.redactor = redactor
+}
+
 // Append (to satisfy the Appender interface) adds a log message to the internal
 // buffer, and translates the log message into a format that is used by the
 // remote endpoint.
@@ -263,6 +298,9 @@ func (apiLgr *APILogger) Append(log *slogger.Log) error {
 
 	apiLgr.appendLock.Lock()
 	defer apiLgr.appendLock.Unlock()
+	if apiLgr.redactor != nil {
+		logMessage.Message = apiLgr.redactor.Redact(logMessage.Message)
+	}
 	apiLgr.messages = append(apiLgr.messages, *logMessage)
 
 	if len(apiLgr.messages) < apiLgr.SendAfterLines ||
@@ -275,35 +313,95 @@ func (apiLgr *APILogger) Append(log *slogger.Log) error {
 	return nil
 }
 
-func (apiLgr *APILogger) sendLogs(flushMsgs []apimodels.LogMessage) int {
+// sendLogs sends the messages to the API server. If a journal is set, the
+// messages are journaled when they can't be sent, and journaled messages are
+// replayed ahead of them to keep the logs in order. Replays are only
+// attempted once JournalRetryInterval has passed since the last failure,
+// unless drainJournal is set.
+func (apiLgr *APILogger) sendLogs(flushMsgs []apimodels.LogMessage, drainJournal bool) int {
 	start := time.Now()
 	apiLgr.flushLock.Lock()
 	defer apiLgr.flushLock.Unlock()
-	if len(flushMsgs) == 0 {
+
+	if apiLgr.journal == nil || !apiLgr.journal.Pending() {
+		if len(flushMsgs) == 0 {
+			return 0
+		}
+
+		err := apiLgr.TaskCommunicator.Log(flushMsgs)
+		if err == nil {
+			grip.Infof("sent %d log messages to api server, in %s",
+				len(flushMsgs), time.Since(start))
+			return len(flushMsgs)
+		}
+		if apiLgr.journal == nil {
+			grip.Error(err)
+			return 0
+		}
+
+		grip.Warningf("problem sending %d log messages, writing them to %s: %+v",
+			len(flushMsgs), apiLgr.journal.Path(), err)
+		apiLgr.lastSendFailure = time.Now()
+		grip.CatchError(apiLgr.journal.Write(flushMsgs))
+		return 0
+	}
+
+	grip.CatchError(apiLgr.journal.Write(flushMsgs))
+	if !drainJournal && time.Since(apiLgr.lastSendFailure) < apiLgr.JournalRetryInterval {
 		return 0
 	}
 
-	grip.CatchError(apiLgr.TaskCommunicator.Log(flushMsgs))
-	grip.Infof("sent %d log messages to api server, in %s",
-		len(flushMsgs), time.Since(start))
-	return len(flushMsgs)
+	sent, err := apiLgr.journal.Replay(apiLgr.TaskCommunicator.Log)
+	if err != nil {
+		apiLgr.lastSendFailure = time.Now()
+		grip.Warningf("problem replaying log journal %s after sending %d messages: %+v",
+			apiLgr.journal.Path(), sent, err)
+	} else {
+		grip.Infof("replayed %d log messages from %s, in %s",
+			sent, apiLgr.journal.Path(), time.Since(start))
+	}
+	return sent
 }
 
+// FlushAndWait sends all buffered messages, and everything in the journal,
+// blocking until the HTTP requests have completed.
 func (apiLgr *APILogger) FlushAndWait() int {
 	apiLgr.appendLock.Lock()
 	defer apiLgr.appendLock.Unlock()
 
 	apiLgr.lastFlush = time.Now()
-	if len(apiLgr.messages) == 0 {
-		return 0
-	}
-
-	numMessages := apiLgr.sendLogs(apiLgr.messages)
+	numMessages := apiLgr.sendLogs(apiLgr.messages, true)
 	apiLgr.messages = make([]apimodels.LogMessage, 0, apiLgr.SendAfterLines)
 
 	return numMessages
 }
 
+// CloseJournal sends all buffered messages and everything in the journal,
+// then stops journaling. Anything that still can't be sent is discarded along
+// with the journal file.
+func (apiLgr *APILogger) CloseJournal() error {
+	apiLgr.appendLock.Lock()
+	defer apiLgr.appendLock.Unlock()
+
+	apiLgr.lastFlush = time.Now()
+	apiLgr.sendLogs(apiLgr.messages, true)
+	apiLgr.messages = make([]apimodels.LogMessage, 0, apiLgr.SendAfterLines)
+
+	apiLgr.flushLock.Lock()
+	journal := apiLgr.journal
+	apiLgr.journal = nil
+	apiLgr.flushLock.Unlock()
+
+	if journal == nil {
+		return nil
+	}
+	if journal.Pending() {
+		grip.Warningf("discarding log messages in %s that could not be sent to the api server",
+			journal.Path())
+	}
+	return journal.Remove()
+}
+
 // flushInternal assumes that the caller already holds apiLgr.appendLock
 func (apiLgr *APILogger) flushInternal() {
 	apiLgr.lastFlush = time.Now()
@@ -312,7 +410,7 @@ func (apiLgr *APILogger) flushInternal() {
 	copy(messagesToSend, apiLgr.messages)
 	apiLgr.messages = make([]apimodels.LogMessage, 0, apiLgr.SendAfterLines)
 
-	go apiLgr.sendLogs(messagesToSend)
+	go apiLgr.sendLogs(messagesToSend, false)
 }
 
 // Flush pushes log messages (asynchronously, without waiting for messages to send.)
@@ -5,9 +5,11 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip/send"
 	"github.com/mongodb/grip/slogger"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestLogging(t *testing.T) {
@@ -70,6 +72,29 @@ func TestLogging(t *testing.T) {
 	})
 }
 
+func TestAPILoggerRedactsSecrets(t *testing.T) {
+	assert := assert.New(t)
+	taskCommunicator := &MockCommunicator{
+		LogChan: make(chan []apimodels.LogMessage, 1),
+	}
+	apiLogger := NewAPILogger(taskCommunicator)
+	redactor := util.NewRedactor()
+	redactor.Add("password", "correct-horse")
+	apiLogger.SetRedactor(redactor)
+
+	testLogger := slogger.Logger{
+		Name:      "",
+		Appenders: []send.Sender{slogger.WrapAppender(apiLogger)},
+	}
+	testLogger.Logf(slogger.INFO, "logging in with correct-horse")
+	apiLogger.FlushAndWait()
+
+	messages := <-taskCommunicator.LogChan
+	assert.Len(messages, 1)
+	assert.Contains(messages[0].Message, "logging in with <REDACTED:password>")
+	assert.NotContains(messages[0].Message, "correct-horse")
+}
+
 func TestCommandLogger(t *testing.T) {
 	Convey("With an CommandLogger", t, func() {
 
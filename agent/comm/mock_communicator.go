@@ -156,8 +156,8 @@ func (mc *MockCommunicator) Heartbeat() (bool, error) {
 	return mc.abort, nil
 }
 
-func (*MockCommunicator) FetchExpansionVars() (*apimodels.ExpansionVars, error) {
-	return &apimodels.ExpansionVars{}, nil
+func (*MockCommunicator) FetchExpansionVars() (*apimodels.ProjectVars, error) {
+	return &apimodels.ProjectVars{}, nil
 }
 
 func (m *MockCommunicator) SetSignalChan(chan Signal) {}
@@ -0,0 +1,297 @@
+package comm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// streamReplyTimeout is how long to wait for the API server to reply
+	// to a message before giving up on the stream.
+	streamReplyTimeout = 30 * time.Second
+
+	// streamRetryInterval is how long to wait after failing to open a
+	// stream before trying again.
+	streamRetryInterval = time.Minute
+)
+
+// StreamCommunicator is a TaskCommunicator that sends heartbeats and logs to
+// the API server over a websocket that stays open while a task runs, which
+// the API server uses to tell the agent as soon as the task is aborted.
+// Everything else, and anything sent while the stream is unavailable, goes
+// through the embedded HTTPCommunicator.
+type StreamCommunicator struct {
+	*HTTPCommunicator
+
+	// conn is the open stream for the task with id connTaskId, if any
+	conn       *websocket.Conn
+	connTaskId string
+	connecting bool
+	retryAfter time.Time
+
+	// closedTaskId is the task whose stream was closed by Close, which
+	// isn't reopened until the communicator is set to another task
+	closedTaskId string
+
+	// pending holds a channel for each message awaiting a reply
+	pending map[int64]chan *apimodels.StreamMessage
+	nextId  int64
+
+	mutex sync.Mutex
+}
+
+// NewStreamCommunicator returns an initialized StreamCommunicator.
+// The cert parameter may be blank if default system certificates are being used.
+func NewStreamCommunicator(serverURL, hostId, hostSecret, cert string) (*StreamCommunicator, error) {
+	httpComm, err := NewHTTPCommunicator(serverURL, hostId, hostSecret, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamCommunicator{
+		HTTPCommunicator: httpComm,
+		pending:          map[int64]chan *apimodels.StreamMessage{},
+	}, nil
+}
+
+// SetTask sets the task the communicator sends messages for, and starts
+// opening a stream for it.
+func (s *StreamCommunicator) SetTask(taskId, taskSecret string) {
+	s.HTTPCommunicator.SetTask(taskId, taskSecret)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn != nil && s.connTaskId != taskId {
+		s.closeLocked()
+	}
+	// a new task gets a fresh attempt at a stream
+	s.retryAfter = time.Time{}
+	s.closedTaskId = ""
+	s.connectLocked()
+}
+
+// Heartbeat sends a heartbeat over the stream, or over HTTP if the stream is
+// unavailable.
+func (s *StreamCommunicator) Heartbeat() (bool, error) {
+	reply, err := s.request(&apimodels.StreamMessage{Type: apimodels.StreamHeartbeat})
+	if err != nil {
+		// the HTTP heartbeat reports a wrong secret, among other things
+		s.logf(slogger.WARN, "Sending heartbeat over HTTP: %v", err)
+		return s.HTTPCommunicator.Heartbeat()
+	}
+	return reply.Abort, nil
+}
+
+// Log sends a batch of log messages for the task's logs over the stream, or
+// over HTTP if the stream is unavailable.
+func (s *StreamCommunicator) Log(messages []apimodels.LogMessage) error {
+	_, err := s.request(&apimodels.StreamMessage{
+		Type: apimodels.StreamLog,
+		Log: &apimodels.TaskLog{
+			TaskId:       s.GetCurrentTaskId(),
+			Timestamp:    time.Now(),
+			MessageCount: len(messages),
+			Messages:     messages,
+		},
+	})
+	if err != nil {
+		s.logf(slogger.WARN, "Sending logs over HTTP: %v", err)
+		return s.HTTPCommunicator.Log(messages)
+	}
+	return nil
+}
+
+// Close closes the stream, if it's open, once the task has ended. Messages
+// sent for the task after that go over HTTP.
+func (s *StreamCommunicator) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closedTaskId = s.GetCurrentTaskId()
+	s.closeLocked()
+}
+
+// request sends msg over the stream and waits for the reply. It returns an
+// error without waiting if there is no stream open for the current task.
+func (s *StreamCommunicator) request(msg *apimodels.StreamMessage) (*apimodels.StreamMessage, error) {
+	s.mutex.Lock()
+	if s.conn == nil || s.connTaskId != s.GetCurrentTaskId() {
+		s.connectLocked()
+		s.mutex.Unlock()
+		return nil, errors.New("no stream is open")
+	}
+	conn := s.conn
+	s.nextId++
+	msg.Id = s.nextId
+	replies := make(chan *apimodels.StreamMessage, 1)
+	s.pending[msg.Id] = replies
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.pending, msg.Id)
+	}()
+
+	if err := websocket.JSON.Send(conn, msg); err != nil {
+		s.closeConn(conn)
+		return nil, errors.Wrap(err, "error sending on stream")
+	}
+
+	timer := time.NewTimer(streamReplyTimeout)
+	defer timer.Stop()
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return nil, errors.New("stream closed before replying")
+		}
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		return reply, nil
+	case <-timer.C:
+		s.closeConn(conn)
+		return nil, errors.New("timed out waiting for a reply on the stream")
+	}
+}
+
+// connectLocked starts opening a stream for the current task in the
+// background, unless one is already being opened or the last attempt failed
+// recently. The mutex must be held.
+func (s *StreamCommunicator) connectLocked() {
+	taskId := s.GetCurrentTaskId()
+	if taskId == "" || taskId == s.closedTaskId || s.connecting || time.Now().Before(s.retryAfter) {
+		return
+	}
+	s.connecting = true
+
+	config, err := s.streamConfig()
+	go func() {
+		var conn *websocket.Conn
+		if err == nil {
+			conn, err = websocket.DialConfig(config)
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.connecting = false
+		if err != nil {
+			s.retryAfter = time.Now().Add(streamRetryInterval)
+			s.logf(slogger.WARN, "Could not open stream to API server, using HTTP: %v", err)
+			return
+		}
+		if taskId != s.GetCurrentTaskId() || taskId == s.closedTaskId {
+			// the agent has moved on to another task, or ended this one, since
+			grip.CatchWarning(conn.Close())
+			return
+		}
+
+		s.closeLocked()
+		s.conn = conn
+		s.connTaskId = taskId
+		go s.receive(conn)
+	}()
+}
+
+// streamConfig returns the configuration for opening a stream for the
+// current task.
+func (s *StreamCommunicator) streamConfig() (*websocket.Config, error) {
+	location, err := url.Parse(fmt.Sprintf("%s/task/%s/stream", s.ServerURLRoot, s.TaskId))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	origin := *location
+	location.Scheme = strings.Replace(location.Scheme, "http", "ws", 1)
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	config.Header.Add(evergreen.TaskSecretHeader, s.TaskSecret)
+	config.Header.Add(evergreen.HostHeader, s.HostId)
+	config.Header.Add(evergreen.HostSecretHeader, s.HostSecret)
+	if evergreen.BuildRevision != "" {
+		config.Header.Add(evergreen.AgentRevisionHeader, evergreen.BuildRevision)
+	}
+
+	if s.HttpsCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(s.HttpsCert)) {
+			return nil, errors.New("failed to append HttpsCert to new cert pool")
+		}
+		config.TlsConfig = &tls.Config{RootCAs: pool}
+	}
+	return config, nil
+}
+
+// receive handles the messages the API server sends over conn until it's
+// closed.
+func (s *StreamCommunicator) receive(conn *websocket.Conn) {
+	for {
+		msg := &apimodels.StreamMessage{}
+		if err := websocket.JSON.Receive(conn, msg); err != nil {
+			s.closeConn(conn)
+			return
+		}
+
+		switch msg.Type {
+		case apimodels.StreamAbort:
+			s.logf(slogger.INFO, "Task was aborted")
+			// don't block if the agent is already stopping the task
+			select {
+			case s.SignalChan <- AbortedByUser:
+			default:
+			}
+		case apimodels.StreamReply:
+			s.mutex.Lock()
+			if replies, ok := s.pending[msg.Id]; ok {
+				replies <- msg
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// closeConn closes conn, if it is still the open stream.
+func (s *StreamCommunicator) closeConn(conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == conn {
+		s.closeLocked()
+	}
+}
+
+// closeLocked closes the open stream, if there is one, and fails the
+// messages awaiting replies on it. The mutex must be held.
+func (s *StreamCommunicator) closeLocked() {
+	if s.conn == nil {
+		return
+	}
+
+	grip.CatchDebug(s.conn.Close())
+	s.conn = nil
+	s.connTaskId = ""
+	for id, replies := range s.pending {
+		close(replies)
+		delete(s.pending, id)
+	}
+}
+
+func (s *StreamCommunicator) logf(level slogger.Level, format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Logf(level, format, args...)
+	}
+}
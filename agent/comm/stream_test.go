@@ -0,0 +1,148 @@
+package comm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/mongodb/grip/send"
+	"github.com/mongodb/grip/slogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// streamServer is a fake API server for the stream protocol.
+type streamServer struct {
+	abort     chan struct{}
+	logs      []apimodels.LogMessage
+	httpCalls []string
+	mutex     sync.Mutex
+}
+
+func (ss *streamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/stream") {
+		if r.Header.Get(evergreen.TaskSecretHeader) != "secret" {
+			http.Error(w, "wrong secret!", http.StatusConflict)
+			return
+		}
+		websocket.Handler(ss.serveStream).ServeHTTP(w, r)
+		return
+	}
+
+	ss.mutex.Lock()
+	ss.httpCalls = append(ss.httpCalls, r.URL.Path)
+	ss.mutex.Unlock()
+	_ = json.NewEncoder(w).Encode(apimodels.HeartbeatResponse{})
+}
+
+func (ss *streamServer) serveStream(ws *websocket.Conn) {
+	go func() {
+		<-ss.abort
+		_ = websocket.JSON.Send(ws, &apimodels.StreamMessage{Type: apimodels.StreamAbort})
+	}()
+
+	for {
+		msg := &apimodels.StreamMessage{}
+		if err := websocket.JSON.Receive(ws, msg); err != nil {
+			return
+		}
+		if msg.Log != nil {
+			ss.mutex.Lock()
+			ss.logs = append(ss.logs, msg.Log.Messages...)
+			ss.mutex.Unlock()
+		}
+		_ = websocket.JSON.Send(ws, &apimodels.StreamMessage{Id: msg.Id, Type: apimodels.StreamReply})
+	}
+}
+
+func (ss *streamServer) getHTTPCalls() []string {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.httpCalls
+}
+
+func waitForStream(t *testing.T, s *StreamCommunicator, open bool) {
+	for i := 0; i < 100; i++ {
+		s.mutex.Lock()
+		done := (s.conn != nil) == open && !s.connecting
+		s.mutex.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("stream never became open=%v", open)
+}
+
+func TestStreamCommunicator(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &streamServer{abort: make(chan struct{})}
+	server := httptest.NewServer(ss)
+	defer server.Close()
+
+	s, err := NewStreamCommunicator(server.URL, "host", "hostsecret", "")
+	require.NoError(t, err)
+	defer s.Close()
+	s.SetLogger(&slogger.Logger{Name: "test", Appenders: []send.Sender{slogger.StdOutAppender()}})
+	signals := make(chan Signal, 1)
+	s.SetSignalChan(signals)
+
+	s.SetTask("t1", "secret")
+	waitForStream(t, s, true)
+
+	abort, err := s.Heartbeat()
+	assert.NoError(err)
+	assert.False(abort)
+	assert.NoError(s.Log([]apimodels.LogMessage{{Message: "hello"}}))
+	assert.Empty(ss.getHTTPCalls(), "messages should go over the stream")
+	ss.mutex.Lock()
+	assert.Len(ss.logs, 1)
+	ss.mutex.Unlock()
+
+	// aborts are pushed without waiting for a heartbeat
+	close(ss.abort)
+	select {
+	case sig := <-signals:
+		assert.Equal(AbortedByUser, sig)
+	case <-time.After(time.Second):
+		assert.Fail("abort was not received within a second")
+	}
+
+	// once the task has ended, its stream isn't reopened
+	s.Close()
+	_, err = s.Heartbeat()
+	assert.NoError(err)
+	waitForStream(t, s, false)
+	assert.Len(ss.getHTTPCalls(), 1)
+}
+
+func TestStreamCommunicatorFallsBackToHTTP(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &streamServer{abort: make(chan struct{})}
+	server := httptest.NewServer(ss)
+	defer server.Close()
+
+	s, err := NewStreamCommunicator(server.URL, "host", "hostsecret", "")
+	require.NoError(t, err)
+	defer s.Close()
+	s.SetLogger(&slogger.Logger{Name: "test", Appenders: []send.Sender{slogger.StdOutAppender()}})
+
+	// the stream is refused, so messages go over HTTP
+	s.SetTask("t1", "wrong")
+	waitForStream(t, s, false)
+
+	abort, err := s.Heartbeat()
+	assert.NoError(err)
+	assert.False(abort)
+	assert.Len(ss.getHTTPCalls(), 1)
+	assert.True(strings.HasSuffix(ss.getHTTPCalls()[0], "/task/t1/heartbeat"))
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/agent"
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
 	"github.com/mongodb/grip"
@@ -60,8 +61,16 @@ func main() {
 	httpsCertFile := flag.String("https_cert", "", "path to a self-signed private cert")
 	logPrefix := flag.String("log_prefix", "evg-agent", "prefix for the agent's log filename")
 	port := flag.Int("status_port", statsPort, "port to run the status server on")
+	slots := flag.Int("slots", 1, "number of tasks to run at once")
+	stream := flag.Bool("stream", true, "send heartbeats and logs over a stream to the API server, if it can be opened")
+	version := flag.Bool("version", false, "print the revision the agent was built from and exit")
 	flag.Parse()
 
+	if *version {
+		fmt.Println(evergreen.BuildRevision)
+		return
+	}
+
 	grip.CatchEmergencyFatal(agent.SetupLogging("agent-startup", "init"))
 	grip.SetDefaultLevel(level.Info)
 	grip.SetThreshold(level.Debug)
@@ -80,11 +89,26 @@ func main() {
 		HostSecret:  *hostSecret,
 		StatusPort:  *port,
 		LogPrefix:   *logPrefix,
+		TaskSlots:   *slots,
+		Stream:      *stream,
+	}
+
+	if *slots > 1 {
+		if err = agent.RunSlots(initialOptions); err != nil {
+			grip.Error(agent.RollbackUpdate())
+			grip.EmergencyFatal(err)
+		}
+		return
 	}
 
 	agt, err := agent.New(initialOptions)
 	if err != nil {
+		// if this binary was just installed by an update, go back to the old one
+		grip.Error(agent.RollbackUpdate())
 		grip.EmergencyFatalf("could not create new agent: %+v", err)
 	}
-	grip.CatchEmergencyFatal(agt.Run())
+	if err = agt.Run(); err != nil {
+		grip.Error(agent.RollbackUpdate())
+		grip.EmergencyFatal(err)
+	}
 }
@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// slotSet tracks the tasks running in the slots of an agent that runs more
+// than one task at a time. Each slot is run by its own Agent, with its own
+// communicator, loggers, timeout watchers and task directory.
+type slotSet struct {
+	tasks map[string]bool
+	mutex sync.RWMutex
+
+	// slots take turns asking for a next task, so that a task assigned to
+	// one slot is always among the tasks reported by the next one to ask
+	nextTaskMutex sync.Mutex
+}
+
+func newSlotSet() *slotSet {
+	return &slotSet{tasks: map[string]bool{}}
+}
+
+// running returns the tasks running in all of the slots.
+func (s *slotSet) running() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tasks := make([]string, 0, len(s.tasks))
+	for taskId := range s.tasks {
+		tasks = append(tasks, taskId)
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// busy returns true if any of the slots is running a task.
+func (s *slotSet) busy() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.tasks) > 0
+}
+
+func (s *slotSet) add(taskId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tasks[taskId] = true
+}
+
+func (s *slotSet) remove(taskId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.tasks, taskId)
+}
+
+// RunSlots runs opts.TaskSlots agents side by side in this process, each of
+// which pulls tasks from the API server and runs them in its own task
+// directory. It returns once all of the agents have exited.
+func RunSlots(opts Options) error {
+	if opts.TaskSlots < 2 {
+		return errors.Errorf("an agent with %d task slots should be run with Run", opts.TaskSlots)
+	}
+
+	// the process-wide log is shared by all of the slots, rather than being
+	// replaced for each task
+	if err := SetupLogging(opts.LogPrefix, "slots"); err != nil {
+		return errors.Wrap(err, "problem setting up logging")
+	}
+
+	slots := newSlotSet()
+	agents := make([]*Agent, 0, opts.TaskSlots)
+	for i := 0; i < opts.TaskSlots; i++ {
+		// only the first slot's agent runs the status server, which is what
+		// keeps a second agent process from starting on the host
+		agt, err := newSlotAgent(opts, slots, i == 0)
+		if err != nil {
+			return errors.Wrapf(err, "error creating agent for task slot %d", i)
+		}
+		agents = append(agents, agt)
+	}
+
+	grip.Noticef("running %d task slots", len(agents))
+
+	errs := make(chan error, len(agents))
+	for i, agt := range agents {
+		go func(slot int, agt *Agent) {
+			errs <- errors.Wrapf(agt.Run(), "task slot %d", slot)
+		}(i, agt)
+	}
+
+	catcher := grip.NewCatcher()
+	for range agents {
+		catcher.Add(<-errs)
+	}
+	return catcher.Resolve()
+}
+
+// newSlotAgent creates an agent to run one of the task slots in slots.
+func newSlotAgent(opts Options, slots *slotSet, statusServer bool) (*Agent, error) {
+	taskCommunicator, httpCommunicator, err := newCommunicator(opts)
+	if err != nil {
+		return nil, err
+	}
+	httpCommunicator.RunningTasks = slots.running
+
+	agt := &Agent{
+		opts:             opts,
+		TaskCommunicator: taskCommunicator,
+		slots:            slots,
+	}
+
+	if statusServer {
+		agt.startStatusServer(opts.StatusPort)
+	}
+
+	if err := agt.Setup(); err != nil {
+		return nil, err
+	}
+
+	agt.Registry = plugin.NewSimpleRegistry()
+
+	// register plugins needed for execution
+	if err := registerPlugins(agt.Registry, plugin.CommandPlugins, agt.logger); err != nil {
+		grip.Criticalf("error registering plugins: %+v", err)
+		return nil, err
+	}
+	return agt, nil
+}
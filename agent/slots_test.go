@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotSetTracksRunningTasks(t *testing.T) {
+	assert := assert.New(t)
+	slots := newSlotSet()
+
+	assert.False(slots.busy())
+	assert.Empty(slots.running())
+
+	slots.add("b")
+	slots.add("a")
+	assert.True(slots.busy())
+	assert.Equal([]string{"a", "b"}, slots.running())
+
+	slots.remove("a")
+	slots.remove("missing")
+	assert.Equal([]string{"b"}, slots.running())
+
+	slots.remove("b")
+	assert.False(slots.busy())
+}
+
+func TestRunSlotsNeedsSeveralSlots(t *testing.T) {
+	assert.Error(t, RunSlots(Options{TaskSlots: 1}))
+}
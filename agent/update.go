@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/kardianos/osext"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RollbackEnvVar is set when the agent re-executes itself after an
+	// update, to the path of the binary it replaced. If the new binary fails
+	// to start, it restores and re-executes that binary.
+	RollbackEnvVar = "EVERGREEN_AGENT_ROLLBACK"
+
+	// how long the new binary has to report its version before the update
+	// is abandoned
+	updateVersionCheckTimeout = time.Minute
+)
+
+// updateSelf replaces the agent's binary with the one described by the
+// update, and re-executes it. It only returns if the update failed, in which
+// case the old binary is left in place.
+func (agt *Agent) updateSelf(update *apimodels.AgentUpdate) error {
+	current, err := osext.Executable()
+	if err != nil {
+		return errors.Wrap(err, "error finding agent binary")
+	}
+
+	grip.Noticef("updating agent from revision %s to %s", evergreen.BuildRevision, update.Revision)
+
+	newPath := current + ".new"
+	if err = agt.downloadAgent(update, newPath); err != nil {
+		grip.Warning(os.Remove(newPath))
+		return errors.WithStack(err)
+	}
+	if err = checkAgentVersion(newPath, update.Revision); err != nil {
+		grip.Warning(os.Remove(newPath))
+		return errors.WithStack(err)
+	}
+
+	backupPath := current + ".old"
+	if err = os.Rename(current, backupPath); err != nil {
+		grip.Warning(os.Remove(newPath))
+		return errors.Wrap(err, "error moving old agent binary aside")
+	}
+	if err = os.Rename(newPath, current); err != nil {
+		grip.Warning(os.Rename(backupPath, current))
+		grip.Warning(os.Remove(newPath))
+		return errors.Wrap(err, "error installing new agent binary")
+	}
+
+	env := append(withoutEnv(os.Environ(), RollbackEnvVar), RollbackEnvVar+"="+backupPath)
+	err = reexec(current, os.Args, env)
+
+	// the agent is only still running if it couldn't execute the new binary
+	grip.Warning(os.Rename(backupPath, current))
+	return errors.Wrapf(err, "error executing agent revision %s", update.Revision)
+}
+
+// downloadAgent writes the agent binary to the given path, verifying its
+// checksum.
+func (agt *Agent) downloadAgent(update *apimodels.AgentUpdate, path string) error {
+	var resp *http.Response
+	retriableGet := util.RetriableFunc(
+		func() error {
+			var err error
+			resp, err = agt.TryGet(update.URL)
+			if err != nil {
+				return util.RetriableError{Failure: errors.WithStack(err)}
+			}
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return util.RetriableError{Failure: errors.Errorf("unexpected status code %d", resp.StatusCode)}
+			}
+			return nil
+		},
+	)
+	if _, err := util.Retry(retriableGet, 5, 5*time.Second); err != nil {
+		return errors.Wrapf(err, "error downloading agent revision %s", update.Revision)
+	}
+	defer resp.Body.Close()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", path)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error writing agent binary to %s", path)
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != update.SHA256 {
+		return errors.Errorf("checksum of downloaded agent binary is %s, expected %s",
+			sum, update.SHA256)
+	}
+	return nil
+}
+
+// checkAgentVersion runs the binary at path to make sure that it starts, and
+// that it was built from the expected revision.
+func checkAgentVersion(path, revision string) error {
+	out := &bytes.Buffer{}
+	cmd := exec.Command(path, "-version")
+	cmd.Stdout = out
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "error starting new agent binary")
+	}
+
+	timer := time.AfterFunc(updateVersionCheckTimeout, func() {
+		grip.Warning(cmd.Process.Kill())
+	})
+	err := cmd.Wait()
+	timer.Stop()
+	if err != nil {
+		return errors.Wrap(err, "error checking version of new agent binary")
+	}
+
+	if version := strings.TrimSpace(out.String()); version != revision {
+		return errors.Errorf("new agent binary has revision '%s', expected '%s'", version, revision)
+	}
+	return nil
+}
+
+// confirmUpdate removes the binary replaced by the last update, once the new
+// agent has shown that it works by talking to the API server.
+func confirmUpdate() {
+	backupPath := os.Getenv(RollbackEnvVar)
+	if backupPath == "" {
+		return
+	}
+
+	grip.Infof("agent update confirmed, removing old binary %s", backupPath)
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		grip.Warning(errors.Wrapf(err, "error removing old agent binary %s", backupPath))
+	}
+	grip.Warning(os.Unsetenv(RollbackEnvVar))
+}
+
+// RollbackUpdate restores and re-executes the binary replaced by the last
+// update, if the update has not been confirmed yet. It returns nil without
+// doing anything if there is nothing to roll back to; otherwise it only
+// returns if the rollback failed.
+func RollbackUpdate() error {
+	backupPath := os.Getenv(RollbackEnvVar)
+	if backupPath == "" {
+		return nil
+	}
+
+	current, err := osext.Executable()
+	if err != nil {
+		return errors.Wrap(err, "error finding agent binary")
+	}
+
+	grip.Warningf("rolling back agent update, restoring %s", backupPath)
+	if err = os.Rename(backupPath, current); err != nil {
+		return errors.Wrapf(err, "error restoring old agent binary %s", backupPath)
+	}
+
+	return errors.Wrap(reexec(current, os.Args, withoutEnv(os.Environ(), RollbackEnvVar)),
+		"error executing old agent binary")
+}
+
+// withoutEnv returns the environment without the given variable.
+func withoutEnv(env []string, key string) []string {
+	out := make([]string, 0, len(env))
+	for _, e := range env {
+		if !strings.HasPrefix(e, key+"=") {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/agent/comm"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadAgentVerifiesChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	binary := []byte("new agent binary")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(binary)
+	}))
+	defer server.Close()
+
+	httpComm, err := comm.NewHTTPCommunicator(server.URL, "host", "secret", "")
+	require.NoError(t, err)
+	agt := &Agent{TaskCommunicator: httpComm}
+
+	dir, err := ioutil.TempDir("", "agent-update")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "main.new")
+
+	sum := sha256.Sum256(binary)
+	update := &apimodels.AgentUpdate{
+		Revision: "abc",
+		URL:      "agent/binary",
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+	assert.NoError(agt.downloadAgent(update, path))
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(binary, contents)
+
+	update.SHA256 = "0123"
+	assert.Error(agt.downloadAgent(update, path))
+}
+
+func TestCheckAgentVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("agents do not update themselves on windows")
+	}
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "agent-update")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "main")
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\necho abc\n"), 0755))
+	assert.NoError(checkAgentVersion(path, "abc"))
+	assert.Error(checkAgentVersion(path, "def"))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	assert.Error(checkAgentVersion(path, "abc"))
+
+	assert.Error(checkAgentVersion(filepath.Join(dir, "missing"), "abc"))
+}
+
+func TestWithoutEnv(t *testing.T) {
+	env := []string{"A=1", RollbackEnvVar + "=/tmp/main.old", RollbackEnvVar + "_OTHER=2"}
+	assert.Equal(t, []string{"A=1", RollbackEnvVar + "_OTHER=2"}, withoutEnv(env, RollbackEnvVar))
+}
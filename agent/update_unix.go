@@ -0,0 +1,15 @@
+// +build !windows
+
+package agent
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// reexec replaces the running agent with the binary at path. It only returns
+// if the binary could not be executed.
+func reexec(path string, args, env []string) error {
+	return errors.WithStack(syscall.Exec(path, args, env))
+}
@@ -0,0 +1,9 @@
+package agent
+
+import "github.com/pkg/errors"
+
+// reexec is not supported on windows, where a running binary can't replace
+// itself; windows agents are restarted by the taskrunner instead.
+func reexec(path string, args, env []string) error {
+	return errors.New("agents can not update themselves on windows")
+}
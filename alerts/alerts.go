@@ -7,9 +7,11 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/model/version"
@@ -50,7 +52,10 @@ type AlertContext struct {
 	Patch        *patch.Patch
 	Host         *host.Host
 	FailedTests  []task.TestResult
-	Settings     *evergreen.Settings
+	// PerfRegressions are the regressions in the task's performance results,
+	// for performance regression alerts.
+	PerfRegressions []perf.Regression
+	Settings        *evergreen.Settings
 }
 
 func (qp *QueueProcessor) Name() string {
@@ -101,6 +106,15 @@ func (qp *QueueProcessor) loadAlertContext(a *alert.AlertRequest) (*AlertContext
 					aCtx.FailedTests = append(aCtx.FailedTests, test)
 				}
 			}
+			if a.Trigger == alertrecord.PerfRegressionId {
+				tp, err := perf.FindOne(perf.ByTaskIdAndExecution(aCtx.Task.Id, aCtx.Task.Execution))
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				if tp != nil {
+					aCtx.PerfRegressions = tp.Regressions
+				}
+			}
 		}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"gopkg.in/mgo.v2/bson"
@@ -77,6 +78,39 @@ func RunTaskFailureTriggers(taskId string) error {
 	return nil
 }
 
+// RunPerfRegressionTriggers queues alerts for the regressions found in the performance results
+// the task attached.
+func RunPerfRegressionTriggers(t *task.Task, tp *perf.TaskPerf) error {
+	ctx := triggerContext{task: t, perf: tp}
+	for _, trigger := range AvailablePerfTriggers {
+		shouldExec, err := trigger.ShouldExecute(ctx)
+		if err != nil {
+			return err
+		}
+		if !shouldExec {
+			continue
+		}
+		err = alert.EnqueueAlertRequest(&alert.AlertRequest{
+			Id:        bson.NewObjectId(),
+			Trigger:   trigger.Id(),
+			TaskId:    t.Id,
+			HostId:    t.HostId,
+			Execution: t.Execution,
+			BuildId:   t.BuildId,
+			VersionId: t.Version,
+			ProjectId: t.Project,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if err = storeTriggerBookkeeping(ctx, []Trigger{trigger}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func RunHostProvisionFailTriggers(h *host.Host) error {
 	ctx := triggerContext{host: h}
 	trigger := &ProvisionFailed{}
@@ -101,6 +135,40 @@ func RunHostProvisionFailTriggers(h *host.Host) error {
 	return storeTriggerBookkeeping(ctx, []Trigger{trigger})
 }
 
+// RunHostQuarantinedTriggers alerts admins that a host was quarantined by its
+// health checks.
+func RunHostQuarantinedTriggers(h *host.Host) error {
+	return runHostTrigger(h, HostQuarantined{})
+}
+
+// RunHostReturnedToServiceTriggers alerts admins that a host quarantined by its
+// health checks has recovered.
+func RunHostReturnedToServiceTriggers(h *host.Host) error {
+	return runHostTrigger(h, HostReturnedToService{})
+}
+
+func runHostTrigger(h *host.Host, trigger Trigger) error {
+	ctx := triggerContext{host: h}
+	shouldExec, err := trigger.ShouldExecute(ctx)
+	if err != nil {
+		return err
+	}
+	if !shouldExec {
+		return nil
+	}
+
+	err = alert.EnqueueAlertRequest(&alert.AlertRequest{
+		Id:        bson.NewObjectId(),
+		Trigger:   trigger.Id(),
+		HostId:    h.Id,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return storeTriggerBookkeeping(ctx, []Trigger{trigger})
+}
+
 func RunSpawnWarningTriggers(host *host.Host) error {
 	ctx := triggerContext{host: host}
 	for _, trigger := range SpawnWarningTriggers {
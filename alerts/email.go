@@ -135,6 +135,12 @@ func getTemplate(alertCtx AlertContext) string {
 		fallthrough
 	case alertrecord.SpawnHostTwelveHourWarning:
 		return "email/host_spawn.html"
+	case alertrecord.HostQuarantined:
+		fallthrough
+	case alertrecord.HostReturnedToService:
+		return "email/host_health.html"
+	case alertrecord.PerfRegressionId:
+		return "email/perf_regression.html"
 	default:
 		return "email/task_fail.html"
 	}
@@ -196,6 +202,29 @@ func taskFailureSubject(ctx AlertContext) string {
 	return subj.String()
 }
 
+// perfRegressionSummary describes a performance regression in the style of
+//  Task_name on Variant (test1 metric, test2 metric)
+// for the subjects of alerts about it.
+func perfRegressionSummary(ctx AlertContext) string {
+	subj := &bytes.Buffer{}
+	fmt.Fprintf(subj, "%s on %s", ctx.Task.DisplayName, ctx.Build.DisplayName)
+
+	regressed := []string{}
+	for _, r := range ctx.PerfRegressions {
+		regressed = append(regressed, fmt.Sprintf("%s %s", cleanTestName(r.Test), r.Metric))
+	}
+	if len(regressed) > 0 {
+		subj.WriteString(" (")
+		if len(regressed) <= 4 {
+			subj.WriteString(strings.Join(regressed, ", "))
+		} else {
+			fmt.Fprintf(subj, "%s, %s, +%v more", regressed[0], regressed[1], len(regressed)-2)
+		}
+		subj.WriteString(")")
+	}
+	return subj.String()
+}
+
 // getSubject generates a subject line for an e-mail for the given alert.
 func getSubject(alertCtx AlertContext) string {
 	switch alertCtx.AlertRequest.Trigger {
@@ -217,6 +246,17 @@ func getSubject(alertCtx AlertContext) string {
 	case alertrecord.SpawnHostTwelveHourWarning:
 		return fmt.Sprintf("Your %s host (%s) will expire in twelve hours.",
 			alertCtx.Host.Distro, alertCtx.Host.Id)
+	case alertrecord.HostQuarantined:
+		return fmt.Sprintf("Host %s (%s) quarantined after failing health checks",
+			alertCtx.Host.Id, alertCtx.Host.Distro.Id)
+	case alertrecord.HostReturnedToService:
+		return fmt.Sprintf("Host %s (%s) returned to service after passing health checks",
+			alertCtx.Host.Id, alertCtx.Host.Distro.Id)
+	case alertrecord.PerfRegressionId:
+		return fmt.Sprintf("Performance Regression: %s // %s @ %s",
+			perfRegressionSummary(alertCtx),
+			alertCtx.ProjectRef.DisplayName,
+			alertCtx.Version.Revision[0:8])
 		// TODO(EVG-224) alertrecord.SpawnHostExpired:
 	}
 	return taskFailureSubject(alertCtx)
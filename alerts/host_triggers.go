@@ -121,3 +121,37 @@ func (pf *ProvisionFailed) ShouldExecute(ctx triggerContext) (bool, error) {
 	}
 	return true, nil
 }
+
+type HostQuarantined struct{}
+
+func (hq HostQuarantined) Id() string { return alertrecord.HostQuarantined }
+
+func (hq HostQuarantined) Display() string {
+	return "Host was quarantined after failing health checks"
+}
+
+func (hq HostQuarantined) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	// No bookkeeping done for this trigger - it fires once per transition.
+	return nil
+}
+
+func (hq HostQuarantined) ShouldExecute(ctx triggerContext) (bool, error) {
+	return ctx.host.Status == evergreen.HostQuarantined, nil
+}
+
+type HostReturnedToService struct{}
+
+func (hr HostReturnedToService) Id() string { return alertrecord.HostReturnedToService }
+
+func (hr HostReturnedToService) Display() string {
+	return "Host was returned to service after passing health checks"
+}
+
+func (hr HostReturnedToService) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	// No bookkeeping done for this trigger - it fires once per transition.
+	return nil
+}
+
+func (hr HostReturnedToService) ShouldExecute(ctx triggerContext) (bool, error) {
+	return ctx.host.Status == evergreen.HostRunning, nil
+}
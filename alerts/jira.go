@@ -8,8 +8,10 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"github.com/evergreen-ci/evergreen/thirdparty"
@@ -20,11 +22,12 @@ import (
 
 // DescriptionTemplateString defines the content of the alert ticket.
 const DescriptionTemplateString = `
-h2. [{{.Task.DisplayName}} failed on {{.Build.DisplayName}}|{{.UIRoot}}/task/{{.Task.Id}}/{{.Task.Execution}}]
+h2. [{{.Task.DisplayName}} {{if .Regressions}}regressed{{else}}failed{{end}} on {{.Build.DisplayName}}|{{.UIRoot}}/task/{{.Task.Id}}/{{.Task.Execution}}]
 Host: [{{.Host.Host}}|{{.UIRoot}}/host/{{.Host.Id}}]
 Project: [{{.Project.DisplayName}}|{{.UIRoot}}/waterfall/{{.Project.Identifier}}]
 Commit: [diff|https://github.com/{{.Project.Owner}}/{{.Project.Repo}}/commit/{{.Version.Revision}}]: {{.Version.Message}}
 {{range .Tests}}*{{.Name}}* - [Logs|{{.URL}}] | [History|{{.HistoryURL}}]
+{{end}}{{range .Regressions}}*{{.Test}}* - {{.Metric}}{{if .ThreadLevel}} ({{.ThreadLevel}} threads){{end}}: {{printf "%g" .Value}} {{.Unit}}, {{printf "%.1f" .PercentChange}}% worse than the mean of the last {{.Samples}} runs
 {{end}}
 `
 const (
@@ -104,6 +107,11 @@ func (jd *jiraDeliverer) Deliver(ctx AlertContext, alertConf model.AlertConfig)
 //  Failures: Task_name on Variant (test1, test2) [ProjectName @ githash]
 // based on the given AlertContext.
 func getSummary(ctx AlertContext) string {
+	if ctx.AlertRequest != nil && ctx.AlertRequest.Trigger == alertrecord.PerfRegressionId {
+		return fmt.Sprintf("Performance Regression: %s [%s @ %s]",
+			perfRegressionSummary(ctx), ctx.ProjectRef.DisplayName, ctx.Version.Revision[0:8])
+	}
+
 	subj := &bytes.Buffer{}
 	failed := []string{}
 	for _, test := range ctx.Task.TestResults {
@@ -174,14 +182,15 @@ func getDescription(ctx AlertContext, uiRoot string) (string, error) {
 	}
 
 	args := struct {
-		Task    *task.Task
-		Build   *build.Build
-		Host    *host.Host
-		Project *model.ProjectRef
-		Version *version.Version
-		Tests   []jiraTestFailure
-		UIRoot  string
-	}{ctx.Task, ctx.Build, ctx.Host, ctx.ProjectRef, ctx.Version, tests, uiRoot}
+		Task        *task.Task
+		Build       *build.Build
+		Host        *host.Host
+		Project     *model.ProjectRef
+		Version     *version.Version
+		Tests       []jiraTestFailure
+		Regressions []perf.Regression
+		UIRoot      string
+	}{ctx.Task, ctx.Build, ctx.Host, ctx.ProjectRef, ctx.Version, tests, ctx.PerfRegressions, uiRoot}
 	buf := &bytes.Buffer{}
 	if err := DescriptionTemplate.Execute(buf, args); err != nil {
 		return "", err
@@ -0,0 +1,37 @@
+package alerts
+
+import (
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+)
+
+// PerfRegression is a trigger that queues an alert the first time a mainline task attaches
+// performance results that are significantly worse than those of its previous runs.
+type PerfRegression struct{}
+
+func (pr PerfRegression) Id() string { return alertrecord.PerfRegressionId }
+func (pr PerfRegression) Display() string {
+	return "a task's performance regresses"
+}
+func (pr PerfRegression) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	rec := newAlertRecord(ctx, alertrecord.PerfRegressionId)
+	rec.TaskId = ctx.task.Id
+	rec.TaskExecution = ctx.task.Execution
+	return rec
+}
+
+func (pr PerfRegression) ShouldExecute(ctx triggerContext) (bool, error) {
+	if ctx.task == nil || ctx.perf == nil || len(ctx.perf.Regressions) == 0 {
+		return false, nil
+	}
+	if ctx.task.Requester != evergreen.RepotrackerVersionRequester {
+		return false, nil
+	}
+	// results can be attached more than once per task, but only alert once
+	// for each execution
+	rec, err := alertrecord.FindOne(alertrecord.ByPerfRegressionInTask(ctx.task.Id, ctx.task.Execution))
+	if err != nil {
+		return false, err
+	}
+	return rec == nil, nil
+}
@@ -0,0 +1,52 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+	"github.com/evergreen-ci/evergreen/model/build"
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerfRegressionSkipsTasksWithoutMainlineRegressions(t *testing.T) {
+	assert := assert.New(t)
+	trigger := PerfRegression{}
+	tsk := &task.Task{Id: "t1", Requester: evergreen.PatchVersionRequester}
+
+	shouldExec, err := trigger.ShouldExecute(triggerContext{task: tsk})
+	assert.NoError(err)
+	assert.False(shouldExec)
+
+	shouldExec, err = trigger.ShouldExecute(triggerContext{task: tsk, perf: &perf.TaskPerf{
+		Regressions: []perf.Regression{{Test: "insert", Metric: "latency"}},
+	}})
+	assert.NoError(err)
+	assert.False(shouldExec)
+}
+
+func TestPerfRegressionSubjects(t *testing.T) {
+	assert := assert.New(t)
+	ctx := AlertContext{
+		AlertRequest: &alert.AlertRequest{Trigger: alertrecord.PerfRegressionId},
+		Task:         &task.Task{DisplayName: "bench"},
+		Build:        &build.Build{DisplayName: "Linux"},
+		ProjectRef:   &model.ProjectRef{DisplayName: "Evergreen"},
+		Version:      &version.Version{Revision: "0123456789abcdef"},
+		PerfRegressions: []perf.Regression{
+			{Test: "pkg/BenchmarkInsert", Metric: "ns/op"},
+			{Test: "insert", Metric: "throughput"},
+		},
+	}
+
+	assert.Equal("Performance Regression: bench on Linux (BenchmarkInsert ns/op, insert throughput) // Evergreen @ 01234567",
+		getSubject(ctx))
+	assert.Equal("Performance Regression: bench on Linux (BenchmarkInsert ns/op, insert throughput) [Evergreen @ 01234567]",
+		getSummary(ctx))
+	assert.Equal("email/perf_regression.html", getTemplate(ctx))
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"gopkg.in/mgo.v2/bson"
@@ -40,6 +41,7 @@ type triggerContext struct {
 	task              *task.Task
 	previousCompleted *task.Task
 	host              *host.Host
+	perf              *perf.TaskPerf
 }
 
 var (
@@ -52,6 +54,11 @@ var (
 		TaskFailTransition{},
 	}
 
+	// AvailablePerfTriggers are the triggers for the performance results tasks attach.
+	AvailablePerfTriggers = []Trigger{
+		PerfRegression{},
+	}
+
 	AvailableProjectTriggers = []Trigger{
 		LastRevisionNotFound{},
 	}
@@ -19,6 +19,25 @@ type TaskEndDetail struct {
 	Type        string `bson:"type,omitempty" json:"type,omitempty"`
 	Description string `bson:"desc,omitempty" json:"desc,omitempty"`
 	TimedOut    bool   `bson:"timed_out,omitempty" json:"timed_out,omitempty"`
+
+	// ResourceUsage is what the task used, if the agent could account for it.
+	ResourceUsage        *ResourceUsage         `bson:"resource_usage,omitempty" json:"resource_usage,omitempty"`
+	CommandResourceUsage []CommandResourceUsage `bson:"command_resource_usage,omitempty" json:"command_resource_usage,omitempty"`
+}
+
+// ResourceUsage is the resources used by all of the processes a task, or one
+// of its commands, ran.
+type ResourceUsage struct {
+	PeakMemoryBytes int64   `bson:"peak_memory_bytes" json:"peak_memory_bytes"`
+	CPUSecs         float64 `bson:"cpu_secs" json:"cpu_secs"`
+	IOReadBytes     int64   `bson:"io_read_bytes" json:"io_read_bytes"`
+	IOWriteBytes    int64   `bson:"io_write_bytes" json:"io_write_bytes"`
+}
+
+// CommandResourceUsage is the resources used by one of a task's commands.
+type CommandResourceUsage struct {
+	Command       string `bson:"command" json:"command"`
+	ResourceUsage `bson:",inline"`
 }
 
 type TaskEndDetails struct {
@@ -39,12 +58,44 @@ type TaskEndResponse struct {
 // ExpansionVars is a map of expansion variables for a project.
 type ExpansionVars map[string]string
 
+// PrivateVarsParam is the query parameter with which agents ask for the
+// names of a project's private variables along with their values.
+const PrivateVarsParam = "private_vars"
+
+// ProjectVars are the variables of a task's project, along with which of them
+// are private, so that their values can be kept out of the task's logs.
+type ProjectVars struct {
+	Vars        ExpansionVars   `json:"vars"`
+	PrivateVars map[string]bool `json:"private_vars"`
+}
+
+// RunningTaskParam is the query parameter with which agents running more than
+// one task at a time report the tasks they are running when they ask for a
+// next task, so that the API server can tell which of the host's tasks have
+// been lost.
+const RunningTaskParam = "running_task"
+
 // NextTaskResponse represents the response sent back when an agent asks for a next task
 type NextTaskResponse struct {
 	TaskId     string `json:"task_id,omitempty"`
 	TaskSecret string `json:"task_secret,omitempty"`
 	ShouldExit bool   `json:"should_exit,omitempty"`
 	Message    string `json:"message,omitempty"`
+
+	// Drained is set along with ShouldExit when the host has been drained,
+	// rather than the agent having to exit because something is wrong
+	Drained bool `json:"drained,omitempty"`
+
+	// AgentUpdate is set when the agent is out of date and can update itself
+	AgentUpdate *AgentUpdate `json:"agent_update,omitempty"`
+}
+
+// AgentUpdate describes the agent binary an agent should replace itself with.
+type AgentUpdate struct {
+	Revision string `json:"revision"`
+	// URL is the path of the binary relative to the API root
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
 }
 
 // EndTaskResponse is what is returned when the task ends
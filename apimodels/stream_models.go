@@ -0,0 +1,31 @@
+package apimodels
+
+// Types of the messages sent over the stream between an agent and the API
+// server while it runs a task.
+const (
+	// StreamHeartbeat is sent by the agent in place of a heartbeat request.
+	StreamHeartbeat = "heartbeat"
+	// StreamLog is sent by the agent with a batch of task logs.
+	StreamLog = "log"
+	// StreamReply is sent by the API server in response to the agent's
+	// messages, with the same id.
+	StreamReply = "reply"
+	// StreamAbort is sent by the API server as soon as the task is aborted.
+	StreamAbort = "abort"
+)
+
+// StreamMessage is a message sent in either direction over the stream
+// between an agent and the API server.
+type StreamMessage struct {
+	// Id matches up the API server's replies with the agent's messages.
+	Id   int64  `json:"id,omitempty"`
+	Type string `json:"type"`
+
+	// Log is set on log messages.
+	Log *TaskLog `json:"log,omitempty"`
+
+	// Abort is set on replies to heartbeats if the task has been aborted.
+	Abort bool `json:"abort,omitempty"`
+	// Error is set on replies if the message couldn't be handled.
+	Error string `json:"error,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/evergreen-ci/evergreen/cli"
+	_ "github.com/evergreen-ci/evergreen/plugin/config"
 	"github.com/jessevdk/go-flags"
 )
 
@@ -27,6 +28,7 @@ func main() {
 	parser.AddCommand("evaluate", "display a project file's evaluated and expanded form", "", &cli.EvaluateCommand{})
 	parser.AddCommand("fetch", "fetch data associated with a task", "", &cli.FetchCommand{GlobalOpts: &opts})
 	parser.AddCommand("export", "export statistics as csv or json for given options", "", &cli.ExportCommand{GlobalOpts: &opts})
+	parser.AddCommand("run-local", "run a task on the local machine without an API server", "", &cli.RunLocalCommand{})
 	parser.AddCommand("test-history", "retrieve test history for a given project", "", &cli.TestHistoryCommand{GlobalOpts: &opts})
 
 	_, err := parser.Parse()
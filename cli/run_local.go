@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/agent"
+	"github.com/evergreen-ci/evergreen/agent/comm"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	localDistroId  = "local"
+	localVersionId = "local"
+)
+
+// RunLocalCommand runs a task from a project config on the local machine,
+// using the agent's plugins but no API server. Everything the task would
+// send to the API server is written to the output directory instead.
+type RunLocalCommand struct {
+	ProjectFile string `short:"f" long:"file" description:"path to the project config file" required:"true"`
+	Project     string `short:"p" long:"project" description:"project identifier (defaults to the name of the config file)"`
+	Task        string `short:"t" long:"task" description:"name of the task to run" required:"true"`
+	Variant     string `short:"v" long:"variant" description:"name of the variant to run the task on" required:"true"`
+	DistroFile  string `short:"d" long:"distro" description:"path to a yaml file describing the distro to emulate"`
+	VarsFile    string `long:"vars" description:"path to a yaml file of project variables, e.g. secrets"`
+	WorkDir     string `long:"workdir" description:"directory to run the task in (defaults to the distro's work_dir, or the current directory)"`
+	OutputDir   string `short:"o" long:"output" description:"directory to write logs, results and files to" default:"evergreen-local"`
+	Revision    string `long:"revision" description:"revision to use for the ${revision} expansion and git.get_project"`
+	Owner       string `long:"owner" description:"owner of the project's repository, used by git.get_project"`
+	Repo        string `long:"repo" description:"name of the project's repository, used by git.get_project"`
+	Branch      string `long:"branch" description:"branch of the project's repository" default:"master"`
+}
+
+func (rlc *RunLocalCommand) Execute(args []string) error {
+	localComm, err := rlc.makeCommunicator()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	agt, err := agent.NewLocal(agent.Options{}, localComm)
+	if err != nil {
+		return errors.Wrap(err, "error creating local agent")
+	}
+
+	if _, err = agt.RunTask(); err != nil {
+		return errors.Wrapf(err, "error running task '%s'", rlc.Task)
+	}
+
+	detail := localComm.EndDetail
+	if detail == nil {
+		return errors.Errorf("task '%s' did not finish", rlc.Task)
+	}
+
+	fmt.Printf("Task '%s' on '%s' finished with status '%s'; output written to %s\n",
+		rlc.Task, rlc.Variant, detail.Status, rlc.OutputDir)
+	if detail.Status != evergreen.TaskSucceeded {
+		return errors.Errorf("task failed: %s", detail.Description)
+	}
+	return nil
+}
+
+// makeCommunicator reads the local files and builds the task, version, distro
+// and project ref that the API server would otherwise provide.
+func (rlc *RunLocalCommand) makeCommunicator() (*comm.LocalCommunicator, error) {
+	configBytes, err := ioutil.ReadFile(rlc.ProjectFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading project config")
+	}
+
+	identifier := rlc.Project
+	if identifier == "" {
+		base := filepath.Base(rlc.ProjectFile)
+		identifier = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	p := &model.Project{}
+	if err = model.LoadProjectInto(configBytes, identifier, p); err != nil {
+		return nil, errors.Wrap(err, "error loading project")
+	}
+	if err = checkLocalTask(p, rlc.Variant, rlc.Task); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	d, err := loadLocalDistro(rlc.DistroFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if rlc.WorkDir != "" {
+		d.WorkDir = rlc.WorkDir
+	}
+	if d.WorkDir == "" {
+		if d.WorkDir, err = os.Getwd(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if d.WorkDir, err = filepath.Abs(d.WorkDir); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vars, err := loadLocalVars(rlc.VarsFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	outputDir, err := filepath.Abs(rlc.OutputDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	author := ""
+	if u, err := user.Current(); err == nil {
+		author = u.Username
+	}
+
+	now := time.Now()
+	v := &version.Version{
+		Id:         localVersionId,
+		CreateTime: now,
+		Revision:   rlc.Revision,
+		Author:     author,
+		Config:     string(configBytes),
+		Owner:      rlc.Owner,
+		Repo:       rlc.Repo,
+		Branch:     rlc.Branch,
+		RepoKind:   model.GithubRepoType,
+		Identifier: identifier,
+		Requester:  evergreen.RepotrackerVersionRequester,
+	}
+	t := &task.Task{
+		Id: util.CleanName(fmt.Sprintf("%s_%s_%s_%s",
+			identifier, rlc.Variant, rlc.Task, now.Format(distro.NameTimeFormat))),
+		CreateTime:   now,
+		Version:      v.Id,
+		Project:      identifier,
+		Revision:     rlc.Revision,
+		DisplayName:  rlc.Task,
+		BuildId:      localVersionId,
+		BuildVariant: rlc.Variant,
+		DistroId:     d.Id,
+		Requester:    v.Requester,
+	}
+	ref := &model.ProjectRef{
+		Identifier: identifier,
+		Owner:      rlc.Owner,
+		Repo:       rlc.Repo,
+		Branch:     rlc.Branch,
+		RepoKind:   model.GithubRepoType,
+		Enabled:    true,
+	}
+
+	return comm.NewLocalCommunicator(outputDir, t, d, v, ref, vars)
+}
+
+// checkLocalTask makes sure that the task exists and runs on the variant.
+func checkLocalTask(p *model.Project, variant, taskName string) error {
+	if p.FindProjectTask(taskName) == nil {
+		return errors.Errorf("task '%s' is not defined in the project", taskName)
+	}
+
+	bv := p.FindBuildVariant(variant)
+	if bv == nil {
+		return errors.Errorf("variant '%s' is not defined in the project", variant)
+	}
+	for _, t := range bv.Tasks {
+		if t.Name == taskName {
+			return nil
+		}
+	}
+	return errors.Errorf("task '%s' does not run on variant '%s'", taskName, variant)
+}
+
+// loadLocalDistro reads a distro from a yaml file with the same fields as the
+// distro's json representation. Without a file, a distro with no settings is
+// used.
+func loadLocalDistro(path string) (*distro.Distro, error) {
+	d := &distro.Distro{}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading distro file")
+		}
+		raw := map[string]interface{}{}
+		if err = yaml.Unmarshal(data, &raw); err != nil {
+			return nil, errors.Wrap(err, "error parsing distro file")
+		}
+		if err = mapstructure.Decode(raw, d); err != nil {
+			return nil, errors.Wrap(err, "error decoding distro file")
+		}
+	}
+
+	if d.Id == "" {
+		d.Id = localDistroId
+	}
+	return d, nil
+}
+
+// loadLocalVars reads project variables from a yaml file of keys and values.
+func loadLocalVars(path string) (apimodels.ExpansionVars, error) {
+	vars := apimodels.ExpansionVars{}
+	if path == "" {
+		return vars, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading vars file")
+	}
+	if err = yaml.Unmarshal(data, &vars); err != nil {
+		return nil, errors.Wrap(err, "error parsing vars file")
+	}
+	return vars, nil
+}
@@ -1,6 +1,7 @@
 package cloud
 
 import (
+	"io"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -114,6 +115,19 @@ type CloudCostCalculator interface {
 	CostForDuration(host *host.Host, start time.Time, end time.Time) (float64, error)
 }
 
+// CommandRunner is an interface for cloud managers that can run commands on
+// their hosts through the provider's API instead of over SSH.
+type CommandRunner interface {
+	// UseCommandRunner returns true if commands for the host should be run
+	// with RunCommand rather than over SSH.
+	UseCommandRunner(*host.Host) bool
+
+	// RunCommand runs args on the host, streaming stdin (if non-nil) to the
+	// command and writing its combined output to output (if non-nil). If
+	// background is true, RunCommand returns as soon as the command starts.
+	RunCommand(h *host.Host, args []string, stdin io.Reader, output io.Writer, background bool) error
+}
+
 // HostOptions is a struct of options that are commonly passed around when creating a
 // new cloud host.
 type HostOptions struct {
@@ -122,6 +136,10 @@ type HostOptions struct {
 	UserName           string
 	UserData           string
 	UserHost           bool
+
+	// Tags are applied to the host in the provider, along with tags for its
+	// distro and the user who started it. See SettingsTags.
+	Tags map[string]string
 }
 
 // NewIntent creates an IntentHost using the given host settings. An IntentHost is a host that
@@ -147,6 +165,7 @@ func NewIntent(d distro.Distro, instanceName, provider string, options HostOptio
 		Provider:         provider,
 		StartedBy:        options.UserName,
 		UserHost:         options.UserHost,
+		InstanceTags:     makeInstanceTags(d, options),
 	}
 
 	if options.ExpirationDuration != nil {
@@ -0,0 +1,278 @@
+package cloud
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/pkg/errors"
+)
+
+const (
+	// minThrottleBackoff is how long spawns wait after a provider first
+	// throttles a request. The wait doubles for each further throttled
+	// request, up to maxThrottleBackoff.
+	minThrottleBackoff = 5 * time.Second
+	maxThrottleBackoff = 5 * time.Minute
+)
+
+// ErrQuotaExceeded is returned by Limiter.Reserve when spawning another host
+// would exceed the provider's instance quota.
+var ErrQuotaExceeded = errors.New("instance quota exceeded")
+
+// ProviderLimits describes the API rate limit and instance quota that a cloud
+// provider enforces on an account in a region.
+type ProviderLimits struct {
+	// RequestsPerSecond is the sustained rate of spawn requests allowed.
+	// Zero means there is no limit.
+	RequestsPerSecond float64
+	// Burst is how many requests may be made at once before the rate
+	// applies. If zero, it is derived from RequestsPerSecond.
+	Burst int
+	// InstanceQuota is the most instances that may exist at once. Zero
+	// means there is no quota.
+	InstanceQuota int
+}
+
+// WithOverrides returns the limits with any non-zero values from the admin
+// settings applied.
+func (l ProviderLimits) WithOverrides(conf evergreen.ProviderLimitsConfig) ProviderLimits {
+	if conf.RequestsPerSecond > 0 {
+		l.RequestsPerSecond = conf.RequestsPerSecond
+	}
+	if conf.Burst > 0 {
+		l.Burst = conf.Burst
+	}
+	if conf.InstanceQuota > 0 {
+		l.InstanceQuota = conf.InstanceQuota
+	}
+	return l
+}
+
+func (l ProviderLimits) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.RequestsPerSecond))
+}
+
+// RateLimitedManager is an interface for cloud managers whose APIs throttle
+// requests or cap the number of instances that may run at once.
+type RateLimitedManager interface {
+	// GetAccount returns the name of the account the manager's requests and
+	// instances count against. Managers that share an account share its
+	// limits.
+	GetAccount() string
+
+	// GetRegion returns the region that hosts of the distro are spawned in.
+	GetRegion(*distro.Distro) string
+
+	// GetLimits returns the limits that apply in the region.
+	GetLimits(region string) ProviderLimits
+
+	// IsThrottlingError returns true if the error means the provider
+	// rejected a request because of a rate limit or quota.
+	IsThrottlingError(error) bool
+}
+
+// Limiter spaces out spawn requests to a provider account in a region so they stay
+// within its rate limit and instance quota, and backs off when the provider
+// throttles requests anyway. Limiters are shared by everything in the process
+// that spawns hosts; use GetLimiter to get one.
+type Limiter struct {
+	account string
+	region  string
+
+	mu           sync.Mutex
+	limits       ProviderLimits
+	tokens       float64
+	lastRefill   time.Time
+	instances    int
+	backoff      time.Duration
+	backoffUntil time.Time
+	throttled    int
+}
+
+// LimiterStatus is a snapshot of a Limiter's state.
+type LimiterStatus struct {
+	// Provider is the provider account the limiter is for.
+	Provider          string
+	Region            string
+	Instances         int
+	InstanceQuota     int
+	RequestsPerSecond float64
+	ThrottledRequests int
+	BackoffUntil      time.Time
+}
+
+var limiterRegistry = struct {
+	sync.Mutex
+	limiters map[string]*Limiter
+}{limiters: map[string]*Limiter{}}
+
+// GetLimiter returns the limiter for the provider account and region, creating
+// it if necessary, and updates it with the given limits.
+func GetLimiter(account, region string, limits ProviderLimits) *Limiter {
+	limiterRegistry.Lock()
+	defer limiterRegistry.Unlock()
+
+	key := LimiterKey(account, region)
+	limiter, ok := limiterRegistry.limiters[key]
+	if !ok {
+		limiter = &Limiter{
+			account:    account,
+			region:     region,
+			tokens:     limits.burst(),
+			lastRefill: time.Now(),
+		}
+		limiterRegistry.limiters[key] = limiter
+	}
+
+	limiter.mu.Lock()
+	limiter.limits = limits
+	limiter.mu.Unlock()
+
+	return limiter
+}
+
+// LimiterKey identifies the limiter for the provider account and region.
+func LimiterKey(account, region string) string {
+	return account + "/" + region
+}
+
+// GetLimiterStatuses returns the status of every limiter in the process,
+// sorted by provider and region.
+func GetLimiterStatuses() []LimiterStatus {
+	limiterRegistry.Lock()
+	defer limiterRegistry.Unlock()
+
+	statuses := make([]LimiterStatus, 0, len(limiterRegistry.limiters))
+	for _, limiter := range limiterRegistry.limiters {
+		statuses = append(statuses, limiter.Status())
+	}
+	sort.Sort(limiterStatusSorter(statuses))
+
+	return statuses
+}
+
+type limiterStatusSorter []LimiterStatus
+
+func (s limiterStatusSorter) Len() int      { return len(s) }
+func (s limiterStatusSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s limiterStatusSorter) Less(i, j int) bool {
+	if s[i].Provider != s[j].Provider {
+		return s[i].Provider < s[j].Provider
+	}
+	return s[i].Region < s[j].Region
+}
+
+// SetInstances records how many instances currently count against the quota.
+func (l *Limiter) SetInstances(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.instances = n
+}
+
+// Reserve claims a request and an instance from the quota. It returns how
+// long the caller must wait before making the request, or ErrQuotaExceeded if
+// the quota is used up. Callers that don't go on to make the request, or
+// whose request fails, must call Release or Throttled.
+func (l *Limiter) Reserve() (time.Duration, error) {
+	return l.reserve(time.Now())
+}
+
+func (l *Limiter) reserve(now time.Time) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.InstanceQuota > 0 && l.instances >= l.limits.InstanceQuota {
+		return 0, errors.Wrapf(ErrQuotaExceeded, "%d of %d instances in use for %s in region '%s'",
+			l.instances, l.limits.InstanceQuota, l.account, l.region)
+	}
+	l.instances++
+
+	var wait time.Duration
+	if l.limits.RequestsPerSecond > 0 {
+		// refill the bucket for the time since the last request, then
+		// take a token, waiting for one to accumulate if it's empty
+		elapsed := now.Sub(l.lastRefill).Seconds()
+		l.tokens = math.Min(l.limits.burst(), l.tokens+elapsed*l.limits.RequestsPerSecond)
+		l.lastRefill = now
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.limits.RequestsPerSecond * float64(time.Second))
+		}
+	}
+
+	if l.backoffUntil.After(now) && l.backoffUntil.Sub(now) > wait {
+		wait = l.backoffUntil.Sub(now)
+	}
+
+	return wait, nil
+}
+
+// Release returns an instance reserved for a request that was not made or
+// that failed for reasons other than throttling.
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.instances > 0 {
+		l.instances--
+	}
+}
+
+// Succeeded records that a request went through, ending any backoff.
+func (l *Limiter) Succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.backoff = 0
+}
+
+// Throttled records that the provider throttled a request. The reserved
+// instance is returned and further requests are held back for an
+// exponentially increasing period.
+func (l *Limiter) Throttled() {
+	l.throttle(time.Now())
+}
+
+func (l *Limiter) throttle(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.instances > 0 {
+		l.instances--
+	}
+	l.throttled++
+
+	if l.backoff == 0 {
+		l.backoff = minThrottleBackoff
+	} else if l.backoff < maxThrottleBackoff {
+		l.backoff *= 2
+		if l.backoff > maxThrottleBackoff {
+			l.backoff = maxThrottleBackoff
+		}
+	}
+	l.backoffUntil = now.Add(l.backoff)
+}
+
+// Status returns a snapshot of the limiter's state.
+func (l *Limiter) Status() LimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return LimiterStatus{
+		Provider:          l.account,
+		Region:            l.region,
+		Instances:         l.instances,
+		InstanceQuota:     l.limits.InstanceQuota,
+		RequestsPerSecond: l.limits.RequestsPerSecond,
+		ThrottledRequests: l.throttled,
+		BackoffUntil:      l.backoffUntil,
+	}
+}
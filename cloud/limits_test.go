@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderLimitsOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	defaults := ProviderLimits{RequestsPerSecond: 2, Burst: 5}
+	limits := defaults.WithOverrides(evergreen.ProviderLimitsConfig{InstanceQuota: 20})
+	assert.Equal(ProviderLimits{RequestsPerSecond: 2, Burst: 5, InstanceQuota: 20}, limits)
+
+	limits = defaults.WithOverrides(evergreen.ProviderLimitsConfig{RequestsPerSecond: 10})
+	assert.Equal(10.0, limits.RequestsPerSecond)
+	assert.Equal(5, limits.Burst)
+}
+
+func TestLimiterRateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	limiter := GetLimiter("rate-test", "region", ProviderLimits{RequestsPerSecond: 2, Burst: 2})
+	now := limiter.lastRefill
+
+	// the burst goes through immediately, then requests are spaced out
+	for i := 0; i < 2; i++ {
+		wait, err := limiter.reserve(now)
+		assert.NoError(err)
+		assert.Equal(time.Duration(0), wait)
+	}
+	wait, err := limiter.reserve(now)
+	assert.NoError(err)
+	assert.Equal(500*time.Millisecond, wait)
+	wait, err = limiter.reserve(now)
+	assert.NoError(err)
+	assert.Equal(time.Second, wait)
+
+	// tokens accumulate again over time
+	wait, err = limiter.reserve(now.Add(3 * time.Second))
+	assert.NoError(err)
+	assert.Equal(time.Duration(0), wait)
+}
+
+func TestLimiterQuota(t *testing.T) {
+	assert := assert.New(t)
+
+	limiter := GetLimiter("quota-test", "region", ProviderLimits{InstanceQuota: 3})
+	limiter.SetInstances(2)
+
+	_, err := limiter.Reserve()
+	assert.NoError(err)
+	_, err = limiter.Reserve()
+	assert.Equal(ErrQuotaExceeded, errors.Cause(err))
+
+	// a failed spawn gives its instance back
+	limiter.Release()
+	_, err = limiter.Reserve()
+	assert.NoError(err)
+
+	status := limiter.Status()
+	assert.Equal(3, status.Instances)
+	assert.Equal(3, status.InstanceQuota)
+}
+
+func TestLimiterThrottleBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	limiter := GetLimiter("backoff-test", "region", ProviderLimits{})
+	now := time.Now()
+
+	_, err := limiter.reserve(now)
+	assert.NoError(err)
+	limiter.throttle(now)
+	assert.Equal(0, limiter.Status().Instances)
+
+	wait, err := limiter.reserve(now)
+	assert.NoError(err)
+	assert.Equal(minThrottleBackoff, wait)
+
+	// the backoff doubles with each throttled request, up to the maximum
+	limiter.throttle(now)
+	wait, _ = limiter.reserve(now)
+	assert.Equal(2*minThrottleBackoff, wait)
+	for i := 0; i < 10; i++ {
+		limiter.throttle(now)
+	}
+	wait, _ = limiter.reserve(now)
+	assert.Equal(maxThrottleBackoff, wait)
+
+	// a successful request resets the backoff
+	limiter.Succeeded()
+	limiter.throttle(now)
+	wait, _ = limiter.reserve(now)
+	assert.Equal(minThrottleBackoff, wait)
+	assert.Equal(13, limiter.Status().ThrottledRequests)
+}
+
+func TestGetLimiterStatuses(t *testing.T) {
+	assert := assert.New(t)
+
+	GetLimiter("status-test-b", "us-west-2", ProviderLimits{})
+	GetLimiter("status-test-a", "us-east-1", ProviderLimits{InstanceQuota: 5})
+	same := GetLimiter("status-test-a", "us-east-1", ProviderLimits{InstanceQuota: 10})
+	assert.Equal(10, same.Status().InstanceQuota)
+
+	statuses := GetLimiterStatuses()
+	for i := 1; i < len(statuses); i++ {
+		assert.True(statuses[i-1].Provider <= statuses[i].Provider)
+	}
+}
@@ -3,6 +3,8 @@ package docker
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"time"
 
@@ -16,7 +18,6 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
-	"gopkg.in/mgo.v2/bson"
 )
 
 const (
@@ -28,6 +29,9 @@ const (
 
 	ProviderName   = "docker"
 	TimeoutSeconds = 5
+
+	// bytesPerMB converts the memory limit in the settings to bytes
+	bytesPerMB = 1024 * 1024
 )
 
 type DockerManager struct {
@@ -51,6 +55,28 @@ type Settings struct {
 	ClientPort int        `mapstructure:"client_port" json:"client_port" bson:"client_port"`
 	PortRange  *portRange `mapstructure:"port_range" json:"port_range" bson:"port_range"`
 	Auth       *auth      `mapstructure:"auth" json:"auth" bson:"auth"`
+
+	// HostIps is a pool of Docker machines to spread containers across. If
+	// empty, all containers are started on HostIp.
+	HostIps []string `mapstructure:"host_ips" json:"host_ips" bson:"host_ips"`
+	// MaxContainers is the most containers that may run on each Docker
+	// machine at once. Zero means there is no limit.
+	MaxContainers int `mapstructure:"max_containers" json:"max_containers" bson:"max_containers"`
+
+	// Dockerfile, if set, is built into the image used for the distro's
+	// containers instead of pulling ImageId.
+	Dockerfile string `mapstructure:"dockerfile" json:"dockerfile" bson:"dockerfile"`
+
+	// resource limits and runtime configuration for each container
+	CPUShares  int64    `mapstructure:"cpu_shares" json:"cpu_shares" bson:"cpu_shares"`
+	MemoryMB   int64    `mapstructure:"memory_mb" json:"memory_mb" bson:"memory_mb"`
+	Volumes    []string `mapstructure:"volumes" json:"volumes" bson:"volumes"`
+	Env        []string `mapstructure:"env" json:"env" bson:"env"`
+	Privileged bool     `mapstructure:"privileged" json:"privileged" bson:"privileged"`
+
+	// UseExec starts the agent and setup scripts with docker exec instead
+	// of over SSH, so the image does not need to run sshd.
+	UseExec bool `mapstructure:"use_exec" json:"use_exec" bson:"use_exec"`
 }
 
 var (
@@ -61,6 +87,9 @@ var (
 	ClientPort = bsonutil.MustHaveTag(Settings{}, "ClientPort")
 	PortRange  = bsonutil.MustHaveTag(Settings{}, "PortRange")
 	Auth       = bsonutil.MustHaveTag(Settings{}, "Auth")
+	HostIps    = bsonutil.MustHaveTag(Settings{}, "HostIps")
+	Dockerfile = bsonutil.MustHaveTag(Settings{}, "Dockerfile")
+	UseExec    = bsonutil.MustHaveTag(Settings{}, "UseExec")
 
 	// bson fields for the portRange struct
 	MinPort = bsonutil.MustHaveTag(portRange{}, "MinPort")
@@ -73,46 +102,81 @@ var (
 
 	// exposed port (set to 22/tcp, default ssh port)
 	SSHDPort docker.Port = "22/tcp"
+
+	// sshdCmd runs sshd in the foreground so the container stays up and
+	// accepts SSH connections
+	sshdCmd = []string{"/usr/sbin/sshd", "-D"}
+	// keepAliveCmd keeps containers that are driven with docker exec running
+	keepAliveCmd = []string{"tail", "-f", "/dev/null"}
 )
 
 //*********************************************************************************
 // Helper Functions
 //*********************************************************************************
 
-func generateClient(d *distro.Distro) (*docker.Client, *Settings, error) {
-	// Populate and validate settings
-	settings := &Settings{} // Instantiate global settings
+func getSettings(d *distro.Distro) (*Settings, error) {
+	settings := &Settings{}
 	if err := mapstructure.Decode(d.ProviderSettings, settings); err != nil {
-		return nil, settings, errors.Wrapf(err, "Error decoding params for distro %v", d.Id)
+		return nil, errors.Wrapf(err, "Error decoding params for distro %v", d.Id)
 	}
 
 	if err := settings.Validate(); err != nil {
-		return nil, settings, errors.Wrapf(err, "Invalid Docker settings in distro %v: %v", d.Id)
+		return nil, errors.Wrapf(err, "Invalid Docker settings in distro %v", d.Id)
 	}
 
+	return settings, nil
+}
+
+func generateClient(settings *Settings, hostIp string) (*docker.Client, error) {
 	// Convert authentication strings to byte arrays
 	cert := bytes.NewBufferString(settings.Auth.Cert).Bytes()
 	key := bytes.NewBufferString(settings.Auth.Key).Bytes()
 	ca := bytes.NewBufferString(settings.Auth.Ca).Bytes()
 
 	// Create client
-	endpoint := fmt.Sprintf("tcp://%s:%v", settings.HostIp, settings.ClientPort)
+	endpoint := fmt.Sprintf("tcp://%s:%v", hostIp, settings.ClientPort)
 	client, err := docker.NewTLSClientFromBytes(endpoint, cert, key, ca)
 
 	err = errors.Wrapf(err, "Docker initialize client API call failed for host '%s'", endpoint)
 	grip.Error(err)
 
-	return client, settings, err
+	return client, err
 }
 
-func populateHostConfig(hostConfig *docker.HostConfig, d *distro.Distro) error {
-	// Retrieve client for API call and settings
-	client, settings, err := generateClient(d)
+// generateHostClient returns a client for the Docker machine that is running
+// the given host's container.
+func generateHostClient(h *host.Host) (*docker.Client, *Settings, error) {
+	settings, err := getSettings(&h.Distro)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
+	}
+
+	// hosts spawned before container pools were tracked run on HostIp
+	parent := h.ParentHost
+	if parent == "" {
+		parent = settings.HostIp
+	}
+
+	client, err := generateClient(settings, parent)
+	return client, settings, err
+}
+
+func populateHostConfig(hostConfig *docker.HostConfig, client *docker.Client, settings *Settings) error {
+	hostConfig.Binds = settings.Volumes
+	hostConfig.Privileged = settings.Privileged
+	hostConfig.CPUShares = settings.CPUShares
+	hostConfig.Memory = settings.MemoryMB * bytesPerMB
+
+	// containers driven with docker exec don't need an ssh port
+	if settings.UseExec {
+		return nil
+	}
+
+	var minPort, maxPort int64
+	if settings.PortRange != nil {
+		minPort = settings.PortRange.MinPort
+		maxPort = settings.PortRange.MaxPort
 	}
-	minPort := settings.PortRange.MinPort
-	maxPort := settings.PortRange.MaxPort
 
 	// Get all the things!
 	containers, err := client.ListContainers(docker.ListContainersOptions{})
@@ -177,10 +241,24 @@ func retrieveOpenPortBinding(containerPtr *docker.Container) (string, error) {
 
 //Validate checks that the settings from the config file are sane.
 func (settings *Settings) Validate() error {
-	if settings.HostIp == "" {
+	if settings.HostIp == "" && len(settings.HostIps) == 0 {
 		return errors.New("HostIp must not be blank")
 	}
 
+	for _, ip := range settings.HostIps {
+		if ip == "" {
+			return errors.New("HostIps must not contain blank entries")
+		}
+	}
+
+	if settings.MaxContainers < 0 {
+		return errors.New("MaxContainers must not be negative")
+	}
+
+	if settings.CPUShares < 0 || settings.MemoryMB < 0 {
+		return errors.New("Container resource limits must not be negative")
+	}
+
 	if settings.ImageId == "" {
 		return errors.New("ImageName must not be blank")
 	}
@@ -215,7 +293,8 @@ func (_ *DockerManager) GetSettings() cloud.ProviderSettings {
 	return &Settings{}
 }
 
-// SpawnInstance creates and starts a new Docker container
+// SpawnInstance creates and starts a new Docker container on the least loaded
+// machine in the distro's pool.
 func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
 	var err error
 
@@ -223,38 +302,71 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 		return nil, errors.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
 	}
 
+	settings, err := getSettings(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Pick the Docker machine with the most spare capacity
+	parent, err := selectParentHost(settings)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to find a Docker machine for distro '%s'", d.Id)
+		grip.Error(err)
+		return nil, err
+	}
+
 	// Initialize client
-	dockerClient, settings, err := generateClient(d)
+	dockerClient, err := generateClient(settings, parent)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	imageName, err := ensureImage(dockerClient, d, settings)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to prepare image for distro '%s' on host '%s'", d.Id, parent)
+		grip.Error(err)
+		return nil, err
+	}
+
 	// Create HostConfig structure
 	hostConfig := &docker.HostConfig{}
-	err = populateHostConfig(hostConfig, d)
+	err = populateHostConfig(hostConfig, dockerClient, settings)
 	if err != nil {
-		err = errors.Wrapf(err, "Unable to populate docker host config for host '%s'", settings.HostIp)
+		err = errors.Wrapf(err, "Unable to populate docker host config for host '%s'", parent)
 		grip.Error(err)
 		return nil, err
 	}
 
-	// Build container
-	containerName := "docker-" + bson.NewObjectId().Hex()
+	containerConfig := &docker.Config{
+		Cmd: sshdCmd,
+		ExposedPorts: map[docker.Port]struct{}{
+			SSHDPort: {},
+		},
+		Env:   settings.Env,
+		Image: imageName,
+	}
+	if settings.UseExec {
+		containerConfig.Cmd = keepAliveCmd
+		containerConfig.ExposedPorts = nil
+	}
+
+	// Build container; the container name doubles as the host id so it can
+	// be looked up again from the host document
+	instanceName := "container-" +
+		fmt.Sprintf("%d", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
+	intentHost := cloud.NewIntent(*d, instanceName, ProviderName, hostOpts)
+	intentHost.ParentHost = parent
+	containerConfig.Labels = intentHost.InstanceTags
+
 	newContainer, err := dockerClient.CreateContainer(
 		docker.CreateContainerOptions{
-			Name: containerName,
-			Config: &docker.Config{
-				Cmd: []string{"/usr/sbin/sshd", "-D"},
-				ExposedPorts: map[docker.Port]struct{}{
-					SSHDPort: {},
-				},
-				Image: settings.ImageId,
-			},
+			Name:       instanceName,
+			Config:     containerConfig,
 			HostConfig: hostConfig,
 		},
 	)
 	if err != nil {
-		err = errors.Wrapf(err, "Docker create container API call failed for host '%s'", settings.HostIp)
+		err = errors.Wrapf(err, "Docker create container API call failed for host '%s'", parent)
 		grip.Error(err)
 		return nil, err
 	}
@@ -262,7 +374,7 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 	// Start container
 	err = dockerClient.StartContainer(newContainer.ID, nil)
 	if err != nil {
-		err = errors.Wrapf(err, "Docker start container API call failed for host '%s'", settings.HostIp)
+		err = errors.Wrapf(err, "Docker start container API call failed for host '%s'", parent)
 		// Clean up
 		err2 := dockerClient.RemoveContainer(
 			docker.RemoveContainerOptions{
@@ -280,32 +392,32 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 	// Retrieve container details
 	newContainer, err = dockerClient.InspectContainer(newContainer.ID)
 	if err != nil {
-		err = errors.Wrapf(err, "Docker inspect container API call failed for host '%s'", settings.HostIp)
+		err = errors.Wrapf(err, "Docker inspect container API call failed for host '%s'", parent)
 		grip.Error(err)
 		return nil, err
 	}
 
-	hostPort, err := retrieveOpenPortBinding(newContainer)
-	if err != nil {
-		grip.Errorf("Error with docker container '%v': %v", newContainer.ID, err)
-		return nil, err
+	// Add host info to db. Containers driven with docker exec have no ssh
+	// address, so they are identified by the container itself and reached
+	// through ParentHost.
+	if settings.UseExec {
+		intentHost.Host = newContainer.ID
+	} else {
+		hostPort, err := retrieveOpenPortBinding(newContainer)
+		if err != nil {
+			grip.Errorf("Error with docker container '%v': %v", newContainer.ID, err)
+			return nil, err
+		}
+		intentHost.Host = fmt.Sprintf("%s:%s", sshAddress(settings, parent), hostPort)
 	}
 
-	hostStr := fmt.Sprintf("%s:%s", settings.BindIp, hostPort)
-	// Add host info to db
-	instanceName := "container-" +
-		fmt.Sprintf("%d", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
-
-	intentHost := cloud.NewIntent(*d, instanceName, ProviderName, hostOpts)
-	intentHost.Host = hostStr
-
 	err = errors.Wrapf(intentHost.Insert(), "failed to insert new host '%s'", intentHost.Id)
 	if err != nil {
 		grip.Error(err)
 		return nil, err
 	}
 
-	grip.Debugf("Successfully inserted new host '%s' for distro '%s'", intentHost.Id, d.Id)
+	grip.Debugf("Successfully inserted new host '%s' on '%s' for distro '%s'", intentHost.Id, parent, d.Id)
 	return intentHost, nil
 }
 
@@ -328,7 +440,7 @@ func getStatus(s *docker.State) int {
 // GetInstanceStatus returns a universal status code representing the state
 // of a container.
 func (dockerMgr *DockerManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
-	dockerClient, _, err := generateClient(&host.Distro)
+	dockerClient, _, err := generateHostClient(host)
 	if err != nil {
 		return cloud.StatusUnknown, err
 	}
@@ -366,21 +478,21 @@ func (dockerMgr *DockerManager) CanSpawn() (bool, error) {
 
 //TerminateInstance destroys a container.
 func (dockerMgr *DockerManager) TerminateInstance(host *host.Host) error {
-	dockerClient, _, err := generateClient(&host.Distro)
+	dockerClient, _, err := generateHostClient(host)
 	if err != nil {
 		return err
 	}
 
+	err = dockerClient.StopContainer(host.Id, TimeoutSeconds)
 	if err != nil {
-		err = errors.Wrapf(dockerClient.StopContainer(host.Id, TimeoutSeconds),
-			"failed to stop container '%s'", host.Id)
-		grip.Error(err)
-		return err
+		// a container that can't be stopped is removed forcefully below
+		grip.Warning(errors.Wrapf(err, "failed to stop container '%s'", host.Id))
 	}
 
 	err = dockerClient.RemoveContainer(
 		docker.RemoveContainerOptions{
-			ID: host.Id,
+			ID:    host.Id,
+			Force: true,
 		})
 
 	if err != nil {
@@ -399,8 +511,13 @@ func (dockerMgr *DockerManager) Configure(settings *evergreen.Settings) error {
 }
 
 //IsSSHReachable checks if a container appears to be reachable via SSH by
-//attempting to contact the host directly.
+//attempting to contact the host directly. Containers driven with docker exec
+//are reachable whenever they are up.
 func (dockerMgr *DockerManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
+	if dockerMgr.UseCommandRunner(host) {
+		return dockerMgr.IsUp(host)
+	}
+
 	sshOpts, err := dockerMgr.GetSSHOptions(host, keyPath)
 	if err != nil {
 		return false, err
@@ -444,3 +561,63 @@ func (dockerMgr *DockerManager) GetSSHOptions(host *host.Host, keyPath string) (
 func (dockerMgr *DockerManager) TimeTilNextPayment(host *host.Host) time.Duration {
 	return time.Duration(0)
 }
+
+// UseCommandRunner returns true if the host's distro is configured to start
+// the agent with docker exec.
+func (dockerMgr *DockerManager) UseCommandRunner(host *host.Host) bool {
+	settings, err := getSettings(&host.Distro)
+	if err != nil {
+		grip.Error(err)
+		return false
+	}
+	return settings.UseExec
+}
+
+// RunCommand runs a command inside the host's container with docker exec.
+func (dockerMgr *DockerManager) RunCommand(host *host.Host, args []string, stdin io.Reader,
+	output io.Writer, background bool) error {
+
+	dockerClient, _, err := generateHostClient(host)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	exec, err := dockerClient.CreateExec(docker.CreateExecOptions{
+		Container:    host.Id,
+		Cmd:          args,
+		AttachStdin:  stdin != nil,
+		AttachStdout: !background,
+		AttachStderr: !background,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Docker create exec API call failed for container '%s'", host.Id)
+	}
+
+	if background {
+		return errors.Wrapf(dockerClient.StartExec(exec.ID, docker.StartExecOptions{Detach: true}),
+			"Docker start exec API call failed for container '%s'", host.Id)
+	}
+
+	if output == nil {
+		output = ioutil.Discard
+	}
+	err = dockerClient.StartExec(exec.ID, docker.StartExecOptions{
+		InputStream:  stdin,
+		OutputStream: output,
+		ErrorStream:  output,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Docker start exec API call failed for container '%s'", host.Id)
+	}
+
+	info, err := dockerClient.InspectExec(exec.ID)
+	if err != nil {
+		return errors.Wrapf(err, "Docker inspect exec API call failed for container '%s'", host.Id)
+	}
+	if info.ExitCode != 0 {
+		return errors.Errorf("command %v in container '%s' exited with code %d",
+			args, host.Id, info.ExitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validSettings() *Settings {
+	return &Settings{
+		HostIp:     "10.0.0.1",
+		ImageId:    "evergreen/ubuntu",
+		ClientPort: 2376,
+		Auth: &auth{
+			Cert: "cert",
+			Key:  "key",
+			Ca:   "ca",
+		},
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := validSettings()
+	assert.NoError(settings.Validate())
+
+	settings.HostIp = ""
+	assert.Error(settings.Validate())
+
+	settings.HostIps = []string{"10.0.0.1", "10.0.0.2"}
+	assert.NoError(settings.Validate())
+
+	settings.HostIps = append(settings.HostIps, "")
+	assert.Error(settings.Validate())
+
+	settings = validSettings()
+	settings.MaxContainers = -1
+	assert.Error(settings.Validate())
+
+	settings = validSettings()
+	settings.MemoryMB = -512
+	assert.Error(settings.Validate())
+}
+
+func TestHostPool(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := validSettings()
+	assert.Equal([]string{"10.0.0.1"}, settings.hostPool())
+
+	settings.BindIp = "203.0.113.1"
+	assert.Equal("203.0.113.1", sshAddress(settings, "10.0.0.1"))
+
+	settings.HostIps = []string{"10.0.0.2", "10.0.0.3"}
+	assert.Equal([]string{"10.0.0.2", "10.0.0.3"}, settings.hostPool())
+	assert.Equal("10.0.0.3", sshAddress(settings, "10.0.0.3"))
+}
+
+func TestLeastLoadedHost(t *testing.T) {
+	assert := assert.New(t)
+	pool := []string{"a", "b", "c"}
+
+	// ties go to the first machine in the pool
+	selected, err := leastLoadedHost(pool, map[string]int{}, 0)
+	assert.NoError(err)
+	assert.Equal("a", selected)
+
+	selected, err = leastLoadedHost(pool, map[string]int{"a": 3, "b": 1, "c": 2}, 0)
+	assert.NoError(err)
+	assert.Equal("b", selected)
+
+	// full machines are skipped
+	selected, err = leastLoadedHost(pool, map[string]int{"a": 2, "b": 4, "c": 4}, 4)
+	assert.NoError(err)
+	assert.Equal("a", selected)
+
+	_, err = leastLoadedHost(pool, map[string]int{"a": 4, "b": 4, "c": 4}, 4)
+	assert.Error(err)
+}
+
+func TestImageName(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := validSettings()
+	assert.Equal("evergreen/ubuntu", imageName(settings))
+
+	settings.ImageId = "Evergreen/Ubuntu:latest"
+	settings.Dockerfile = "FROM ubuntu:16.04\nRUN apt-get update\n"
+	name := imageName(settings)
+	assert.True(strings.HasPrefix(name, "evergreen/ubuntu:"))
+	assert.Len(strings.TrimPrefix(name, "evergreen/ubuntu:"), imageTagLength)
+
+	// changing the Dockerfile changes the tag
+	settings.Dockerfile += "RUN apt-get install -y git\n"
+	assert.NotEqual(name, imageName(settings))
+}
+
+func TestBuildContext(t *testing.T) {
+	assert := assert.New(t)
+
+	dockerfile := "FROM ubuntu:16.04\n"
+	context, err := buildContext(dockerfile)
+	assert.NoError(err)
+
+	reader := tar.NewReader(context)
+	header, err := reader.Next()
+	assert.NoError(err)
+	assert.Equal(dockerfileName, header.Name)
+
+	contents, err := ioutil.ReadAll(reader)
+	assert.NoError(err)
+	assert.Equal(dockerfile, string(contents))
+}
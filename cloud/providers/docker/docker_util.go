@@ -0,0 +1,170 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// dockerfileName is the name the distro's Dockerfile is given in the
+	// build context sent to the Docker machine.
+	dockerfileName = "Dockerfile"
+
+	// imageTagLength is how many characters of the Dockerfile's hash are
+	// used to tag images built from it.
+	imageTagLength = 12
+)
+
+// hostPool returns the addresses of the Docker machines containers may be
+// started on.
+func (settings *Settings) hostPool() []string {
+	if len(settings.HostIps) > 0 {
+		return settings.HostIps
+	}
+	return []string{settings.HostIp}
+}
+
+// sshAddress returns the address that a container's SSH port can be reached
+// at. The bind address only identifies a machine when the pool has just one.
+func sshAddress(settings *Settings, parent string) string {
+	if settings.BindIp != "" && len(settings.hostPool()) == 1 {
+		return settings.BindIp
+	}
+	return parent
+}
+
+// selectParentHost picks the Docker machine in the pool that is running the
+// fewest containers.
+func selectParentHost(settings *Settings) (string, error) {
+	pool := settings.hostPool()
+	containers, err := host.Find(host.ByLiveParentHosts(pool))
+	if err != nil {
+		return "", errors.Wrap(err, "error finding running containers")
+	}
+
+	counts := make(map[string]int, len(pool))
+	for _, c := range containers {
+		counts[c.ParentHost]++
+	}
+
+	return leastLoadedHost(pool, counts, settings.MaxContainers)
+}
+
+// leastLoadedHost returns the machine in the pool with the fewest containers
+// that has room for another one. Ties go to the machine listed first.
+func leastLoadedHost(pool []string, counts map[string]int, maxContainers int) (string, error) {
+	selected := ""
+	for _, candidate := range pool {
+		if maxContainers > 0 && counts[candidate] >= maxContainers {
+			continue
+		}
+		if selected == "" || counts[candidate] < counts[selected] {
+			selected = candidate
+		}
+	}
+
+	if selected == "" {
+		return "", errors.Errorf("all %d Docker machines are running the maximum of %d containers",
+			len(pool), maxContainers)
+	}
+	return selected, nil
+}
+
+// imageName returns the name of the image that containers for the distro are
+// started from. Images built from a Dockerfile are tagged with its hash, so
+// editing the Dockerfile causes a new image to be built.
+func imageName(settings *Settings) string {
+	if settings.Dockerfile == "" {
+		return settings.ImageId
+	}
+
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(settings.Dockerfile)))
+	repository := strings.ToLower(settings.ImageId)
+	if idx := strings.LastIndex(repository, ":"); idx > strings.LastIndex(repository, "/") {
+		repository = repository[:idx]
+	}
+	return fmt.Sprintf("%s:%s", repository, hash[:imageTagLength])
+}
+
+// ensureImage makes sure the image for the distro is present on the Docker
+// machine, building it from the distro's Dockerfile or pulling it as needed,
+// and returns its name.
+func ensureImage(client *docker.Client, d *distro.Distro, settings *Settings) (string, error) {
+	name := imageName(settings)
+
+	_, err := client.InspectImage(name)
+	if err == nil {
+		return name, nil
+	}
+	if err != docker.ErrNoSuchImage {
+		return "", errors.Wrapf(err, "Docker inspect image API call failed for '%s'", name)
+	}
+
+	output := &bytes.Buffer{}
+	start := time.Now()
+	if settings.Dockerfile != "" {
+		var context *bytes.Buffer
+		context, err = buildContext(settings.Dockerfile)
+		if err != nil {
+			return "", errors.Wrapf(err, "error creating build context for distro '%s'", d.Id)
+		}
+
+		err = client.BuildImage(docker.BuildImageOptions{
+			Name:           name,
+			Dockerfile:     dockerfileName,
+			InputStream:    context,
+			OutputStream:   output,
+			RmTmpContainer: true,
+		})
+		err = errors.Wrapf(err, "Docker build image API call failed for '%s': %s", name, output.String())
+	} else {
+		repository, tag := docker.ParseRepositoryTag(name)
+		err = client.PullImage(docker.PullImageOptions{
+			Repository:   repository,
+			Tag:          tag,
+			OutputStream: output,
+		}, docker.AuthConfiguration{})
+		err = errors.Wrapf(err, "Docker pull image API call failed for '%s': %s", name, output.String())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	grip.Infof("Prepared image '%s' for distro '%s' in %s", name, d.Id, time.Since(start))
+	return name, nil
+}
+
+// buildContext returns a tar archive containing only the given Dockerfile,
+// for use as the context of an image build.
+func buildContext(dockerfile string) (*bytes.Buffer, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	header := &tar.Header{
+		Name:    dockerfileName,
+		Mode:    0644,
+		Size:    int64(len(dockerfile)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := tw.Write([]byte(dockerfile)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return buf, nil
+}
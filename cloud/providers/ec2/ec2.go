@@ -20,6 +20,7 @@ import (
 // EC2Manager implements the CloudManager interface for Amazon EC2
 type EC2Manager struct {
 	awsCredentials *aws.Auth
+	limits         evergreen.ProviderLimitsConfig
 }
 
 //Valid values for EC2 instance states:
@@ -80,9 +81,34 @@ func (cloudManager *EC2Manager) Configure(settings *evergreen.Settings) error {
 		AccessKey: settings.Providers.AWS.Id,
 		SecretKey: settings.Providers.AWS.Secret,
 	}
+	cloudManager.limits = settings.Providers.AWS.Limits
 	return nil
 }
 
+// GetAccount returns the AWS account, which on-demand and spot instances
+// share.
+func (cloudManager *EC2Manager) GetAccount() string {
+	return awsAccount
+}
+
+// GetRegion returns the region that hosts are spawned in, which is always
+// us-east-1.
+func (cloudManager *EC2Manager) GetRegion(*distro.Distro) string {
+	return defaultEC2Region
+}
+
+// GetLimits returns EC2's default API rate limit, with any overrides from the
+// admin settings.
+func (cloudManager *EC2Manager) GetLimits(string) cloud.ProviderLimits {
+	return defaultEC2Limits.WithOverrides(cloudManager.limits)
+}
+
+// IsThrottlingError returns true if EC2 rejected a request because of its
+// request rate or instance limits.
+func (cloudManager *EC2Manager) IsThrottlingError(err error) bool {
+	return isEC2ThrottlingError(err)
+}
+
 func (cloudManager *EC2Manager) GetSSHOptions(h *host.Host, keyPath string) ([]string, error) {
 	return getEC2KeyOptions(h, keyPath)
 }
@@ -191,7 +217,7 @@ func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 
 	// attach the tags to this instance
 	err = errors.Wrapf(attachTags(ec2Handle, tags, instance.InstanceId),
-		"unable to attach tags for $s", instance.InstanceId)
+		"unable to attach tags for %s", instance.InstanceId)
 
 	grip.Error(err)
 	grip.DebugWhenf(err == nil, "attached tag name '%s' for '%s'",
@@ -217,6 +243,12 @@ func (cloudManager *EC2Manager) OnUp(host *host.Host) error {
 	return nil
 }
 
+// UpdateTags attaches the given tags to the host's instance.
+func (cloudManager *EC2Manager) UpdateTags(host *host.Host, tags map[string]string) error {
+	return errors.Wrapf(attachTags(getUSEast(*cloudManager.awsCredentials), tags, host.Id),
+		"unable to attach tags for %s", host.Id)
+}
+
 func (cloudManager *EC2Manager) GetDNSName(host *host.Host) (string, error) {
 	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 	instanceInfo, err := getInstanceInfo(ec2Handle, host.Id)
@@ -31,8 +31,44 @@ const (
 	SpotProviderName     = "ec2-spot"
 	SpawnHostExpireDays  = 90
 	MciHostExpireDays    = 30
+
+	// defaultEC2Region is the region all EC2 hosts are spawned in
+	defaultEC2Region = "us-east-1"
+
+	// awsAccount names the AWS account that EC2 requests count against
+	awsAccount = "aws"
+)
+
+var (
+	// defaultEC2Limits approximates the rate at which EC2 accepts
+	// RunInstances and RequestSpotInstances calls. The instance quota
+	// depends on the account, so it must come from the admin settings.
+	defaultEC2Limits = cloud.ProviderLimits{
+		RequestsPerSecond: 2,
+		Burst:             5,
+	}
+
+	// ec2ThrottlingErrorCodes are the EC2 error codes that mean a request
+	// was rejected because of a rate limit or quota, and may succeed later
+	ec2ThrottlingErrorCodes = map[string]bool{
+		"RequestLimitExceeded":         true,
+		"InstanceLimitExceeded":        true,
+		"MaxSpotInstanceCountExceeded": true,
+		"InsufficientInstanceCapacity": true,
+		"VcpuLimitExceeded":            true,
+	}
 )
 
+// isEC2ThrottlingError returns true if err is an EC2 API error caused by a
+// rate limit or quota.
+func isEC2ThrottlingError(err error) bool {
+	ec2Err, ok := errors.Cause(err).(*ec2.Error)
+	if !ok {
+		return false
+	}
+	return ec2ThrottlingErrorCodes[ec2Err.Code]
+}
+
 type MountPoint struct {
 	VirtualName string `mapstructure:"virtual_name" json:"virtual_name,omitempty" bson:"virtual_name,omitempty"`
 	DeviceName  string `mapstructure:"device_name" json:"device_name,omitempty" bson:"device_name,omitempty"`
@@ -254,6 +290,11 @@ func makeTags(intentHost *host.Host) map[string]string {
 	if intentHost.UserHost {
 		tags["mode"] = "testing"
 	}
+
+	// tags used for cost attribution take precedence over the defaults
+	for key, value := range intentHost.InstanceTags {
+		tags[key] = value
+	}
 	return tags
 }
 
@@ -36,6 +36,7 @@ const (
 // EC2SpotManager implements the CloudManager interface for Amazon EC2 Spot
 type EC2SpotManager struct {
 	awsCredentials *aws.Auth
+	limits         evergreen.ProviderLimitsConfig
 }
 
 type EC2SpotSettings struct {
@@ -88,9 +89,34 @@ func (cloudManager *EC2SpotManager) Configure(settings *evergreen.Settings) erro
 		AccessKey: settings.Providers.AWS.Id,
 		SecretKey: settings.Providers.AWS.Secret,
 	}
+	cloudManager.limits = settings.Providers.AWS.Limits
 	return nil
 }
 
+// GetAccount returns the AWS account, which on-demand and spot instances
+// share.
+func (cloudManager *EC2SpotManager) GetAccount() string {
+	return awsAccount
+}
+
+// GetRegion returns the region that spot requests are made in, which is
+// always us-east-1.
+func (cloudManager *EC2SpotManager) GetRegion(*distro.Distro) string {
+	return defaultEC2Region
+}
+
+// GetLimits returns EC2's default API rate limit, with any overrides from the
+// admin settings.
+func (cloudManager *EC2SpotManager) GetLimits(string) cloud.ProviderLimits {
+	return defaultEC2Limits.WithOverrides(cloudManager.limits)
+}
+
+// IsThrottlingError returns true if EC2 rejected a spot request because of
+// its request rate or instance limits.
+func (cloudManager *EC2SpotManager) IsThrottlingError(err error) bool {
+	return isEC2ThrottlingError(err)
+}
+
 func (*EC2SpotManager) GetSettings() cloud.ProviderSettings {
 	return &EC2SpotSettings{}
 }
@@ -136,6 +162,26 @@ func (cloudManager *EC2SpotManager) OnUp(host *host.Host) error {
 	return attachTags(getUSEast(*cloudManager.awsCredentials), tags, spotReq.InstanceId)
 }
 
+// UpdateTags attaches the given tags to the host's spot request and, once the
+// request has been fulfilled, to its instance.
+func (cloudManager *EC2SpotManager) UpdateTags(host *host.Host, tags map[string]string) error {
+	ec2Handle := getUSEast(*cloudManager.awsCredentials)
+	if err := attachTags(ec2Handle, tags, host.Id); err != nil {
+		return errors.Wrapf(err, "unable to attach tags for spot request %s", host.Id)
+	}
+
+	spotReq, err := cloudManager.describeSpotRequest(host.Id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if spotReq.InstanceId == "" {
+		return nil
+	}
+
+	return errors.Wrapf(attachTags(ec2Handle, tags, spotReq.InstanceId),
+		"unable to attach tags for instance %s", spotReq.InstanceId)
+}
+
 func (cloudManager *EC2SpotManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
 	sshOpts, err := cloudManager.GetSSHOptions(host, keyPath)
 	if err != nil {
@@ -0,0 +1,327 @@
+package libvirt
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ProviderName is used to distinguish between different cloud providers.
+	ProviderName = "libvirt"
+
+	// defaults for the optional provider settings
+	defaultStoragePool = "default"
+	defaultNetwork     = "default"
+	defaultDomainType  = "kvm"
+	defaultDiskFormat  = "qcow2"
+)
+
+// Manager implements the CloudManager interface for VMs on a libvirt host.
+type Manager struct {
+	// newClient connects to libvirt at the given URI. It is replaced in tests.
+	newClient func(uri string) (client, error)
+}
+
+// ProviderSettings specifies the settings used to configure a VM.
+type ProviderSettings struct {
+	// URI is the libvirt connection URI, e.g. qemu+ssh://lab-host/system
+	URI string `mapstructure:"uri" json:"uri" bson:"uri"`
+
+	// BaseImage is the name of the volume in the storage pool that is
+	// cloned to make each VM's disk
+	BaseImage   string `mapstructure:"base_image" json:"base_image" bson:"base_image"`
+	StoragePool string `mapstructure:"storage_pool" json:"storage_pool" bson:"storage_pool"`
+	DiskFormat  string `mapstructure:"disk_format" json:"disk_format" bson:"disk_format"`
+
+	CPUs     int    `mapstructure:"cpus" json:"cpus" bson:"cpus"`
+	MemoryMB int    `mapstructure:"memory_mb" json:"memory_mb" bson:"memory_mb"`
+	Network  string `mapstructure:"network" json:"network" bson:"network"`
+
+	// DomainType is the hypervisor used to run the VM, e.g. kvm or qemu
+	DomainType string `mapstructure:"domain_type" json:"domain_type" bson:"domain_type"`
+}
+
+// Validate checks that the settings from the config file are sane.
+func (s *ProviderSettings) Validate() error {
+	if s.URI == "" {
+		return errors.New("URI must not be blank")
+	}
+
+	if s.BaseImage == "" {
+		return errors.New("Base image must not be blank")
+	}
+
+	if s.CPUs <= 0 {
+		return errors.New("Number of CPUs must be positive")
+	}
+
+	if s.MemoryMB <= 0 {
+		return errors.New("Memory must be positive")
+	}
+
+	return nil
+}
+
+func (s *ProviderSettings) getStoragePool() string {
+	if s.StoragePool == "" {
+		return defaultStoragePool
+	}
+	return s.StoragePool
+}
+
+func (s *ProviderSettings) getNetwork() string {
+	if s.Network == "" {
+		return defaultNetwork
+	}
+	return s.Network
+}
+
+func (s *ProviderSettings) getDomainType() string {
+	if s.DomainType == "" {
+		return defaultDomainType
+	}
+	return s.DomainType
+}
+
+func (s *ProviderSettings) getDiskFormat() string {
+	if s.DiskFormat == "" {
+		return defaultDiskFormat
+	}
+	return s.DiskFormat
+}
+
+// getSettings decodes the provider settings of a distro.
+func getSettings(d *distro.Distro) (*ProviderSettings, error) {
+	settings := &ProviderSettings{}
+	if err := mapstructure.Decode(d.ProviderSettings, settings); err != nil {
+		return nil, errors.Wrapf(err, "Error decoding params for distro %s", d.Id)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid settings in distro %s", d.Id)
+	}
+
+	return settings, nil
+}
+
+// connect opens a connection to the libvirt host that the distro's VMs run on.
+func (m *Manager) connect(d *distro.Distro) (client, *ProviderSettings, error) {
+	settings, err := getSettings(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := m.newClient(settings.URI)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to connect to libvirt at '%s'", settings.URI)
+	}
+
+	return c, settings, nil
+}
+
+// GetSettings returns an empty ProviderSettings struct since settings are configured on
+// instance creation.
+func (m *Manager) GetSettings() cloud.ProviderSettings {
+	return &ProviderSettings{}
+}
+
+// Configure sets up the connection factory. The libvirt hosts themselves are
+// configured in each distro.
+func (m *Manager) Configure(s *evergreen.Settings) error {
+	if m.newClient == nil {
+		m.newClient = newVirshClient
+	}
+	return nil
+}
+
+// SpawnInstance creates a new VM by cloning the distro's base image and
+// booting a domain from the clone. Information about the intended host is
+// recorded in a DB document before the VM is created.
+func (m *Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
+	if d.Provider != ProviderName {
+		return nil, errors.Errorf("Can't spawn instance of %s for distro %s: provider is %s",
+			ProviderName, d.Id, d.Provider)
+	}
+
+	c, settings, err := m.connect(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { grip.Warning(c.Close()) }()
+
+	// the host id doubles as the domain and volume name, so that they can be
+	// found again from the host document
+	name := d.GenerateName()
+	intentHost := cloud.NewIntent(*d, name, ProviderName, hostOpts)
+	if err = intentHost.Insert(); err != nil {
+		err = errors.Wrapf(err, "Could not insert intent host '%s'", intentHost.Id)
+		grip.Error(err)
+		return nil, err
+	}
+	grip.Debugf("Inserted intent host '%s' for distro '%s' to signal instance spawn intent", name, d.Id)
+
+	if err = createDomain(c, name, settings); err != nil {
+		if rmErr := intentHost.Remove(); rmErr != nil {
+			grip.Errorf("Could not remove intent host '%s': %+v", intentHost.Id, rmErr)
+		}
+		err = errors.Wrapf(err, "Could not start new instance for distro '%s'", d.Id)
+		grip.Error(err)
+		return nil, err
+	}
+
+	grip.Debugf("Started domain '%s' for distro '%s' on '%s'", name, d.Id, settings.URI)
+	return intentHost, nil
+}
+
+// createDomain clones the base image, then defines and starts a domain that
+// boots from the clone. Anything created before a failure is cleaned up.
+func createDomain(c client, name string, settings *ProviderSettings) error {
+	pool := settings.getStoragePool()
+	diskPath, err := c.CloneVolume(pool, settings.BaseImage, name)
+	if err != nil {
+		return errors.Wrapf(err, "error cloning base image '%s'", settings.BaseImage)
+	}
+
+	domain, err := makeDomainXML(name, diskPath, settings)
+	if err == nil {
+		err = errors.Wrap(c.DefineDomain(domain), "error defining domain")
+	}
+	if err != nil {
+		grip.Warning(c.DeleteVolume(pool, name))
+		return err
+	}
+
+	if err = c.StartDomain(name); err != nil {
+		grip.Warning(c.UndefineDomain(name))
+		grip.Warning(c.DeleteVolume(pool, name))
+		return errors.Wrap(err, "error starting domain")
+	}
+
+	return nil
+}
+
+// CanSpawn always returns true, since there is no way to know whether the
+// libvirt host has room for another VM.
+func (m *Manager) CanSpawn() (bool, error) {
+	return true, nil
+}
+
+// GetInstanceStatus gets the current operational status of the VM.
+func (m *Manager) GetInstanceStatus(h *host.Host) (cloud.CloudStatus, error) {
+	c, _, err := m.connect(&h.Distro)
+	if err != nil {
+		return cloud.StatusUnknown, errors.WithStack(err)
+	}
+	defer func() { grip.Warning(c.Close()) }()
+
+	state, err := c.DomainState(h.Id)
+	if err == errDomainNotFound {
+		return cloud.StatusTerminated, nil
+	}
+	if err != nil {
+		return cloud.StatusUnknown, errors.Wrapf(err, "error getting state of domain '%s'", h.Id)
+	}
+
+	return domainStateToEvgStatus(state), nil
+}
+
+// TerminateInstance destroys the VM, then undefines the domain and deletes
+// its disk.
+func (m *Manager) TerminateInstance(h *host.Host) error {
+	if h.Status == evergreen.HostTerminated {
+		err := errors.Errorf("Can not terminate %s - already marked as terminated!", h.Id)
+		grip.Error(err)
+		return err
+	}
+
+	c, settings, err := m.connect(&h.Distro)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { grip.Warning(c.Close()) }()
+
+	// destroying a domain that isn't running fails, which is fine
+	grip.Debug(errors.Wrapf(c.DestroyDomain(h.Id), "error destroying domain '%s'", h.Id))
+
+	if err = c.UndefineDomain(h.Id); err != nil && err != errDomainNotFound {
+		return errors.Wrapf(err, "error undefining domain '%s'", h.Id)
+	}
+
+	if err = c.DeleteVolume(settings.getStoragePool(), h.Id); err != nil {
+		return errors.Wrapf(err, "error deleting disk of domain '%s'", h.Id)
+	}
+
+	return errors.WithStack(h.Terminate())
+}
+
+// IsUp checks whether the VM is running.
+func (m *Manager) IsUp(h *host.Host) (bool, error) {
+	status, err := m.GetInstanceStatus(h)
+	if err != nil {
+		return false, err
+	}
+
+	return status == cloud.StatusRunning, nil
+}
+
+// OnUp does nothing since there is nothing left to set up once the VM is running.
+func (m *Manager) OnUp(h *host.Host) error {
+	return nil
+}
+
+// IsSSHReachable returns true if the host can successfully accept and run an SSH command.
+func (m *Manager) IsSSHReachable(h *host.Host, keyPath string) (bool, error) {
+	opts, err := m.GetSSHOptions(h, keyPath)
+	if err != nil {
+		return false, err
+	}
+
+	return hostutil.CheckSSHResponse(h, opts)
+}
+
+// GetDNSName returns the IPv4 address that the VM leased from libvirt's DHCP server.
+func (m *Manager) GetDNSName(h *host.Host) (string, error) {
+	c, _, err := m.connect(&h.Distro)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() { grip.Warning(c.Close()) }()
+
+	address, err := c.DomainAddress(h.Id)
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting address of domain '%s'", h.Id)
+	}
+	if address == "" {
+		return "", errors.Errorf("domain '%s' does not have a DHCP lease yet", h.Id)
+	}
+
+	return address, nil
+}
+
+// GetSSHOptions generates the command line args to be passed to SSH to allow connection
+// to the machine.
+func (m *Manager) GetSSHOptions(h *host.Host, keyPath string) ([]string, error) {
+	if keyPath == "" {
+		return []string{}, errors.New("No key specified for host")
+	}
+
+	opts := []string{"-i", keyPath}
+	for _, opt := range h.Distro.SSHOptions {
+		opts = append(opts, "-o", opt)
+	}
+
+	return opts, nil
+}
+
+// TimeTilNextPayment always returns 0, since local VMs aren't billed.
+func (m *Manager) TimeTilNextPayment(h *host.Host) time.Duration {
+	return time.Duration(0)
+}
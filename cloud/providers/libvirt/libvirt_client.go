@@ -0,0 +1,231 @@
+package libvirt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// virshMarker is echoed after every command so that the client knows where
+	// the command's output ends.
+	virshMarker = "__evergreen_virsh_done__"
+)
+
+// virshPrompts are the prompts an interactive virsh session may print before
+// reading each command.
+var virshPrompts = []string{"virsh # ", "virsh > "}
+
+// errDomainNotFound is returned by the client when the domain doesn't exist,
+// which is how a terminated host looks to libvirt.
+var errDomainNotFound = errors.New("domain not found")
+
+// The client interface wraps the libvirt interaction. A client holds one
+// connection to libvirt, and must be closed once it is no longer needed.
+type client interface {
+	// CloneVolume copies a volume in the storage pool to a new volume, returning
+	// the path of the new volume.
+	CloneVolume(pool, source, name string) (string, error)
+	DeleteVolume(pool, name string) error
+	DefineDomain(xml string) error
+	StartDomain(name string) error
+	// DomainState returns the state of the domain as reported by `virsh domstate`.
+	DomainState(name string) (string, error)
+	// DomainAddress returns the IPv4 address of the domain from its DHCP lease.
+	DomainAddress(name string) (string, error)
+	DestroyDomain(name string) error
+	UndefineDomain(name string) error
+	Close() error
+}
+
+// virshClient implements client by driving an interactive virsh session, so
+// that every command runs on the same libvirt connection. This matters for
+// drivers that keep their state in the connection, such as the test driver.
+type virshClient struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	output *bufio.Reader
+}
+
+// newVirshClient starts a virsh session connected to the given URI.
+func newVirshClient(uri string) (client, error) {
+	cmd := exec.Command("virsh", "--quiet", "--connect", uri)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// stdout and stderr share a pipe so that error messages stay in order with
+	// the markers that separate commands
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err = cmd.Start(); err != nil {
+		_ = reader.Close()
+		_ = writer.Close()
+		return nil, errors.Wrapf(err, "error starting virsh for '%s'", uri)
+	}
+	_ = writer.Close()
+
+	return &virshClient{
+		cmd:    cmd,
+		stdin:  stdin,
+		output: bufio.NewReader(reader),
+	}, nil
+}
+
+// run executes a virsh command in the session, returning its output. Lines
+// that virsh prints as errors are returned as an error.
+func (c *virshClient) run(args ...string) (string, error) {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, quoteVirshArg(arg))
+	}
+	command := strings.Join(quoted, " ")
+	echo := "echo " + virshMarker
+
+	if _, err := fmt.Fprintf(c.stdin, "%s\n%s\n", command, echo); err != nil {
+		return "", errors.Wrap(err, "error writing to virsh")
+	}
+
+	var output, errs []string
+	for {
+		line, err := c.output.ReadString('\n')
+		if err != nil {
+			return "", errors.Wrapf(err, "error reading output of virsh %s", args[0])
+		}
+		line = stripVirshPrompt(strings.TrimRight(line, "\r\n"))
+
+		switch {
+		case line == virshMarker:
+			if len(errs) > 0 {
+				return strings.Join(output, "\n"), errors.Errorf("virsh %s failed: %s",
+					args[0], strings.Join(errs, "; "))
+			}
+			return strings.Join(output, "\n"), nil
+		case line == command || line == echo:
+			// interactive sessions echo the commands they read
+		case strings.HasPrefix(line, "error:"):
+			errs = append(errs, strings.TrimSpace(strings.TrimPrefix(line, "error:")))
+		default:
+			output = append(output, line)
+		}
+	}
+}
+
+func (c *virshClient) CloneVolume(pool, source, name string) (string, error) {
+	if _, err := c.run("vol-clone", "--pool", pool, source, name); err != nil {
+		return "", errors.WithStack(err)
+	}
+	path, err := c.run("vol-path", "--pool", pool, name)
+	return strings.TrimSpace(path), errors.WithStack(err)
+}
+
+func (c *virshClient) DeleteVolume(pool, name string) error {
+	_, err := c.run("vol-delete", "--pool", pool, name)
+	return errors.WithStack(err)
+}
+
+func (c *virshClient) DefineDomain(xml string) error {
+	file, err := ioutil.TempFile("", "evergreen-domain")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.WriteString(xml); err != nil {
+		_ = file.Close()
+		return errors.WithStack(err)
+	}
+	if err = file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = c.run("define", file.Name())
+	return errors.WithStack(err)
+}
+
+func (c *virshClient) StartDomain(name string) error {
+	_, err := c.run("start", name)
+	return errors.WithStack(err)
+}
+
+func (c *virshClient) DomainState(name string) (string, error) {
+	state, err := c.run("domstate", name)
+	if err != nil {
+		return "", checkDomainNotFound(err)
+	}
+	return strings.TrimSpace(state), nil
+}
+
+func (c *virshClient) DomainAddress(name string) (string, error) {
+	output, err := c.run("domifaddr", name, "--source", "lease")
+	if err != nil {
+		return "", checkDomainNotFound(err)
+	}
+	return parseDomainAddress(output), nil
+}
+
+func (c *virshClient) DestroyDomain(name string) error {
+	_, err := c.run("destroy", name)
+	return checkDomainNotFound(err)
+}
+
+func (c *virshClient) UndefineDomain(name string) error {
+	_, err := c.run("undefine", name)
+	return checkDomainNotFound(err)
+}
+
+// Close ends the virsh session.
+func (c *virshClient) Close() error {
+	if err := c.stdin.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Wrap(c.cmd.Wait(), "error waiting for virsh to exit")
+}
+
+// checkDomainNotFound replaces errors from virsh about missing domains with
+// errDomainNotFound.
+func checkDomainNotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "domain not found") || strings.Contains(msg, "failed to get domain") {
+		return errDomainNotFound
+	}
+	return errors.WithStack(err)
+}
+
+// quoteVirshArg quotes an argument for virsh's command parser.
+func quoteVirshArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;#") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// stripVirshPrompt removes the prompts that an interactive session prints
+// before reading each command from the start of a line.
+func stripVirshPrompt(line string) string {
+	for {
+		stripped := line
+		for _, prompt := range virshPrompts {
+			stripped = strings.TrimPrefix(stripped, prompt)
+		}
+		if stripped == line {
+			return line
+		}
+		line = stripped
+	}
+}
@@ -0,0 +1,108 @@
+package libvirt
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVirsh behaves like an interactive virsh session that prints a prompt
+// and echoes each command, knowing about a single running domain.
+const fakeVirsh = `#!/bin/sh
+while printf 'virsh # ' && read -r cmd; do
+	echo "$cmd"
+	case "$cmd" in
+	"echo "*) echo "${cmd#echo }" ;;
+	"domstate vm") echo "running"; echo ;;
+	"domstate "*) echo "error: failed to get domain '${cmd#domstate }'" >&2 ;;
+	"define "*) echo "Domain vm defined from ${cmd#define }" ;;
+	*) echo "error: unknown command: '$cmd'" >&2 ;;
+	esac
+done
+`
+
+func withFakeVirsh(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "fake-virsh")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "virsh"), []byte(fakeVirsh), 0755))
+
+	path := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", dir+string(os.PathListSeparator)+path))
+	return func() {
+		_ = os.Setenv("PATH", path)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestVirshClientSession(t *testing.T) {
+	assert := assert.New(t)
+	defer withFakeVirsh(t)()
+
+	c, err := newVirshClient("test:///default")
+	require.NoError(t, err)
+
+	state, err := c.DomainState("vm")
+	assert.NoError(err)
+	assert.Equal(DomainStateRunning, state)
+
+	_, err = c.DomainState("missing")
+	assert.Equal(errDomainNotFound, err)
+
+	assert.NoError(c.DefineDomain("<domain/>"))
+	assert.Error(c.StartDomain("vm"))
+
+	// the session is still usable after an error
+	state, err = c.DomainState("vm")
+	assert.NoError(err)
+	assert.Equal(DomainStateRunning, state)
+
+	assert.NoError(c.Close())
+}
+
+func TestQuoteVirshArg(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("vm", quoteVirshArg("vm"))
+	assert.Equal(`""`, quoteVirshArg(""))
+	assert.Equal(`"my vm"`, quoteVirshArg("my vm"))
+	assert.Equal(`"a\"b\\c"`, quoteVirshArg(`a"b\c`))
+}
+
+// TestVirshClientTestDriver runs the client against libvirt's test driver,
+// which keeps its state in the connection, so it only passes if every command
+// runs in the same session.
+func TestVirshClientTestDriver(t *testing.T) {
+	if _, err := exec.LookPath("virsh"); err != nil {
+		t.Skip("virsh is not installed")
+	}
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c, err := newVirshClient("test:///default")
+	require.NoError(err)
+	defer func() { assert.NoError(c.Close()) }()
+
+	vc := c.(*virshClient)
+	_, err = vc.run("vol-create-as", "default-pool", "base", "1M")
+	require.NoError(err)
+
+	settings := &ProviderSettings{BaseImage: "base", StoragePool: "default-pool",
+		DomainType: "test", CPUs: 1, MemoryMB: 128}
+	require.NoError(createDomain(c, "evg-vm", settings))
+
+	state, err := c.DomainState("evg-vm")
+	assert.NoError(err)
+	assert.Equal(DomainStateRunning, state)
+
+	assert.NoError(c.DestroyDomain("evg-vm"))
+	assert.NoError(c.UndefineDomain("evg-vm"))
+	assert.NoError(c.DeleteVolume("default-pool", "evg-vm"))
+
+	_, err = c.DomainState("evg-vm")
+	assert.Equal(errDomainNotFound, err)
+}
@@ -0,0 +1,112 @@
+package libvirt
+
+import (
+	"encoding/xml"
+
+	"github.com/pkg/errors"
+)
+
+// clientMock keeps domains and volumes in memory.
+type clientMock struct {
+	// API call options
+	failClone  bool
+	failDefine bool
+	failStart  bool
+
+	// Other options
+	address string
+
+	domains map[string]string
+	volumes map[string]bool
+	closed  bool
+}
+
+func newClientMock() *clientMock {
+	return &clientMock{
+		domains: map[string]string{},
+		volumes: map[string]bool{},
+	}
+}
+
+func (c *clientMock) CloneVolume(pool, source, name string) (string, error) {
+	if c.failClone {
+		return "", errors.New("failed to clone volume")
+	}
+
+	c.volumes[name] = true
+	return "/var/lib/libvirt/images/" + name, nil
+}
+
+func (c *clientMock) DeleteVolume(pool, name string) error {
+	if !c.volumes[name] {
+		return errors.New("volume not found")
+	}
+
+	delete(c.volumes, name)
+	return nil
+}
+
+func (c *clientMock) DefineDomain(definition string) error {
+	if c.failDefine {
+		return errors.New("failed to define domain")
+	}
+
+	domain := domainXML{}
+	if err := xml.Unmarshal([]byte(definition), &domain); err != nil {
+		return errors.Wrap(err, "invalid domain xml")
+	}
+	c.domains[domain.Name] = DomainStateShutOff
+	return nil
+}
+
+func (c *clientMock) StartDomain(name string) error {
+	if c.failStart {
+		return errors.New("failed to start domain")
+	}
+	if _, ok := c.domains[name]; !ok {
+		return errDomainNotFound
+	}
+
+	c.domains[name] = DomainStateRunning
+	return nil
+}
+
+func (c *clientMock) DomainState(name string) (string, error) {
+	state, ok := c.domains[name]
+	if !ok {
+		return "", errDomainNotFound
+	}
+
+	return state, nil
+}
+
+func (c *clientMock) DomainAddress(name string) (string, error) {
+	if _, ok := c.domains[name]; !ok {
+		return "", errDomainNotFound
+	}
+
+	return c.address, nil
+}
+
+func (c *clientMock) DestroyDomain(name string) error {
+	if _, ok := c.domains[name]; !ok {
+		return errDomainNotFound
+	}
+
+	c.domains[name] = DomainStateShutOff
+	return nil
+}
+
+func (c *clientMock) UndefineDomain(name string) error {
+	if _, ok := c.domains[name]; !ok {
+		return errDomainNotFound
+	}
+
+	delete(c.domains, name)
+	return nil
+}
+
+func (c *clientMock) Close() error {
+	c.closed = true
+	return nil
+}
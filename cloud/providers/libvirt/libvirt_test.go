@@ -0,0 +1,172 @@
+package libvirt
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/stretchr/testify/suite"
+)
+
+type LibvirtSuite struct {
+	client   *clientMock
+	distro   distro.Distro
+	settings *ProviderSettings
+	manager  *Manager
+	suite.Suite
+}
+
+func TestLibvirtSuite(t *testing.T) {
+	suite.Run(t, new(LibvirtSuite))
+}
+
+func (s *LibvirtSuite) SetupTest() {
+	s.client = newClientMock()
+	s.manager = &Manager{
+		newClient: func(string) (client, error) { return s.client, nil },
+	}
+	s.settings = &ProviderSettings{
+		URI:       "test:///default",
+		BaseImage: "ubuntu1604",
+		CPUs:      2,
+		MemoryMB:  2048,
+	}
+	s.distro = distro.Distro{
+		Id:       "libvirt-ubuntu",
+		Provider: ProviderName,
+		ProviderSettings: &map[string]interface{}{
+			"uri":        s.settings.URI,
+			"base_image": s.settings.BaseImage,
+			"cpus":       s.settings.CPUs,
+			"memory_mb":  s.settings.MemoryMB,
+		},
+	}
+}
+
+func (s *LibvirtSuite) TestValidateSettings() {
+	s.NoError(s.settings.Validate())
+
+	noURI := *s.settings
+	noURI.URI = ""
+	s.Error(noURI.Validate())
+
+	noImage := *s.settings
+	noImage.BaseImage = ""
+	s.Error(noImage.Validate())
+
+	noCPUs := *s.settings
+	noCPUs.CPUs = 0
+	s.Error(noCPUs.Validate())
+
+	noMemory := *s.settings
+	noMemory.MemoryMB = 0
+	s.Error(noMemory.Validate())
+}
+
+func (s *LibvirtSuite) TestConfigureDefaultsToVirsh() {
+	m := &Manager{}
+	s.NoError(m.Configure(&evergreen.Settings{}))
+	s.NotNil(m.newClient)
+}
+
+func (s *LibvirtSuite) TestCreateDomain() {
+	s.NoError(createDomain(s.client, "vm", s.settings))
+	s.Equal(DomainStateRunning, s.client.domains["vm"])
+	s.True(s.client.volumes["vm"])
+}
+
+func (s *LibvirtSuite) TestCreateDomainCleansUp() {
+	s.client.failDefine = true
+	s.Error(createDomain(s.client, "vm", s.settings))
+	s.Empty(s.client.volumes)
+
+	s.client.failDefine = false
+	s.client.failStart = true
+	s.Error(createDomain(s.client, "vm", s.settings))
+	s.Empty(s.client.volumes)
+	s.Empty(s.client.domains)
+
+	s.client.failClone = true
+	s.Error(createDomain(s.client, "vm", s.settings))
+}
+
+func (s *LibvirtSuite) TestGetInstanceStatus() {
+	h := &host.Host{Id: "vm", Distro: s.distro}
+	s.NoError(createDomain(s.client, "vm", s.settings))
+
+	status, err := s.manager.GetInstanceStatus(h)
+	s.NoError(err)
+	s.Equal(cloud.StatusRunning, status)
+	s.True(s.client.closed)
+
+	s.client.domains["vm"] = DomainStateShutOff
+	status, err = s.manager.GetInstanceStatus(h)
+	s.NoError(err)
+	s.Equal(cloud.StatusStopped, status)
+
+	delete(s.client.domains, "vm")
+	status, err = s.manager.GetInstanceStatus(h)
+	s.NoError(err)
+	s.Equal(cloud.StatusTerminated, status)
+}
+
+func (s *LibvirtSuite) TestGetInstanceStatusInvalidSettings() {
+	h := &host.Host{Id: "vm", Distro: distro.Distro{Id: "bad", Provider: ProviderName}}
+	_, err := s.manager.GetInstanceStatus(h)
+	s.Error(err)
+}
+
+func (s *LibvirtSuite) TestGetDNSName() {
+	h := &host.Host{Id: "vm", Distro: s.distro}
+	s.NoError(createDomain(s.client, "vm", s.settings))
+
+	_, err := s.manager.GetDNSName(h)
+	s.Error(err, "a domain without a lease has no address")
+
+	s.client.address = "192.168.122.45"
+	dns, err := s.manager.GetDNSName(h)
+	s.NoError(err)
+	s.Equal("192.168.122.45", dns)
+}
+
+func (s *LibvirtSuite) TestGetSSHOptions() {
+	h := &host.Host{Distro: distro.Distro{SSHOptions: []string{"StrictHostKeyChecking=no"}}}
+
+	_, err := s.manager.GetSSHOptions(h, "")
+	s.Error(err)
+
+	opts, err := s.manager.GetSSHOptions(h, "key")
+	s.NoError(err)
+	s.Equal([]string{"-i", "key", "-o", "StrictHostKeyChecking=no"}, opts)
+}
+
+func (s *LibvirtSuite) TestMakeDomainXML() {
+	domain, err := makeDomainXML("vm", "/var/lib/libvirt/images/vm", s.settings)
+	s.NoError(err)
+	s.Contains(domain, `<domain type="kvm">`)
+	s.Contains(domain, `<name>vm</name>`)
+	s.Contains(domain, `<memory unit="MiB">2048</memory>`)
+	s.Contains(domain, `<vcpu>2</vcpu>`)
+	s.Contains(domain, `<driver name="qemu" type="qcow2"></driver>`)
+	s.Contains(domain, `<source file="/var/lib/libvirt/images/vm"></source>`)
+	s.Contains(domain, `<source network="default"></source>`)
+}
+
+func (s *LibvirtSuite) TestParseDomainAddress() {
+	output := ` Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ vnet0      52:54:00:4b:3f:8a    ipv6         fe80::5054:ff:fe4b:3f8a/64
+ -          -                    ipv4         192.168.122.45/24
+`
+	s.Equal("192.168.122.45", parseDomainAddress(output))
+	s.Equal("", parseDomainAddress(""))
+}
+
+func (s *LibvirtSuite) TestDomainStateToEvgStatus() {
+	s.Equal(cloud.StatusRunning, domainStateToEvgStatus(DomainStateRunning))
+	s.Equal(cloud.StatusStopped, domainStateToEvgStatus(DomainStateShutOff))
+	s.Equal(cloud.StatusFailed, domainStateToEvgStatus(DomainStateCrashed))
+	s.Equal(cloud.StatusUnknown, domainStateToEvgStatus("nostate"))
+}
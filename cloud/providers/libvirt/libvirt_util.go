@@ -0,0 +1,152 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Domain states, as reported by `virsh domstate`.
+	DomainStateRunning  = "running"
+	DomainStateBlocked  = "idle"
+	DomainStatePaused   = "paused"
+	DomainStateShutdown = "in shutdown"
+	DomainStateShutOff  = "shut off"
+	DomainStateCrashed  = "crashed"
+	DomainStateSuspend  = "pmsuspended"
+)
+
+func domainStateToEvgStatus(state string) cloud.CloudStatus {
+	switch state {
+	case DomainStateRunning, DomainStateBlocked:
+		return cloud.StatusRunning
+	case DomainStatePaused, DomainStateShutdown, DomainStateShutOff, DomainStateSuspend:
+		return cloud.StatusStopped
+	case DomainStateCrashed:
+		return cloud.StatusFailed
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
+// The types below describe the subset of libvirt's domain XML format that is
+// needed to define a VM. See https://libvirt.org/formatdomain.html.
+
+type domainXML struct {
+	XMLName xml.Name        `xml:"domain"`
+	Type    string          `xml:"type,attr"`
+	Name    string          `xml:"name"`
+	Memory  domainMemoryXML `xml:"memory"`
+	VCPU    int             `xml:"vcpu"`
+	OS      domainOSXML     `xml:"os"`
+	Devices domainDevices   `xml:"devices"`
+}
+
+type domainMemoryXML struct {
+	Unit  string `xml:"unit,attr"`
+	Value int    `xml:",chardata"`
+}
+
+type domainOSXML struct {
+	Type string `xml:"type"`
+}
+
+type domainDevices struct {
+	Disks      []domainDiskXML      `xml:"disk"`
+	Interfaces []domainInterfaceXML `xml:"interface"`
+}
+
+type domainDiskXML struct {
+	Type   string           `xml:"type,attr"`
+	Device string           `xml:"device,attr"`
+	Driver domainDiskDriver `xml:"driver"`
+	Source domainDiskSource `xml:"source"`
+	Target domainDiskTarget `xml:"target"`
+}
+
+type domainDiskDriver struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type domainDiskSource struct {
+	File string `xml:"file,attr"`
+}
+
+type domainDiskTarget struct {
+	Dev string `xml:"dev,attr"`
+	Bus string `xml:"bus,attr"`
+}
+
+type domainInterfaceXML struct {
+	Type   string                `xml:"type,attr"`
+	Source domainInterfaceSource `xml:"source"`
+	Model  domainInterfaceModel  `xml:"model"`
+}
+
+type domainInterfaceSource struct {
+	Network string `xml:"network,attr"`
+}
+
+type domainInterfaceModel struct {
+	Type string `xml:"type,attr"`
+}
+
+// makeDomainXML returns the XML definition of a VM with the given name that
+// boots from the disk at diskPath.
+func makeDomainXML(name, diskPath string, settings *ProviderSettings) (string, error) {
+	domain := domainXML{
+		Type:   settings.getDomainType(),
+		Name:   name,
+		Memory: domainMemoryXML{Unit: "MiB", Value: settings.MemoryMB},
+		VCPU:   settings.CPUs,
+		OS:     domainOSXML{Type: "hvm"},
+		Devices: domainDevices{
+			Disks: []domainDiskXML{{
+				Type:   "file",
+				Device: "disk",
+				Driver: domainDiskDriver{Name: "qemu", Type: settings.getDiskFormat()},
+				Source: domainDiskSource{File: diskPath},
+				Target: domainDiskTarget{Dev: "vda", Bus: "virtio"},
+			}},
+			Interfaces: []domainInterfaceXML{{
+				Type:   "network",
+				Source: domainInterfaceSource{Network: settings.getNetwork()},
+				Model:  domainInterfaceModel{Type: "virtio"},
+			}},
+		},
+	}
+
+	out, err := xml.MarshalIndent(domain, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "error generating domain xml")
+	}
+	return string(out), nil
+}
+
+// parseDomainAddress returns the first IPv4 address in the output of
+// `virsh domifaddr`, without its prefix length, or an empty string if the
+// domain has no DHCP lease yet.
+//
+// The output looks like:
+//
+//	 Name       MAC address          Protocol     Address
+//	-------------------------------------------------------------------------------
+//	 vnet0      52:54:00:4b:3f:8a    ipv4         192.168.122.45/24
+func parseDomainAddress(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[len(fields)-2] != "ipv4" {
+			continue
+		}
+		address := fields[len(fields)-1]
+		if idx := strings.Index(address, "/"); idx != -1 {
+			address = address[:idx]
+		}
+		return address
+	}
+	return ""
+}
@@ -1,6 +1,8 @@
 package openstack
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -26,6 +28,7 @@ type Manager struct {
 	authOptions  *gophercloud.AuthOptions
 	endpointOpts *gophercloud.EndpointOpts
 	client       client
+	limits       evergreen.ProviderLimitsConfig
 }
 
 // ProviderSettings specifies the settings used to configure a host instance.
@@ -75,6 +78,7 @@ func (m *Manager) Configure(s *evergreen.Settings) error {
 	m.endpointOpts = &gophercloud.EndpointOpts{
 		Region: config.Region,
 	}
+	m.limits = config.Limits
 
 	if m.client == nil {
 		m.client = &clientImpl{}
@@ -87,6 +91,37 @@ func (m *Manager) Configure(s *evergreen.Settings) error {
 	return nil
 }
 
+// GetAccount returns the name of the OpenStack account, which is the one
+// configured in the admin settings.
+func (m *Manager) GetAccount() string {
+	return ProviderName
+}
+
+// GetRegion returns the region that hosts are spawned in, as configured in the admin settings.
+func (m *Manager) GetRegion(*distro.Distro) string {
+	return m.endpointOpts.Region
+}
+
+// GetLimits returns the API rate limit and instance quota from the admin settings. OpenStack
+// deployments set their own limits, so there are no defaults.
+func (m *Manager) GetLimits(string) cloud.ProviderLimits {
+	return cloud.ProviderLimits{}.WithOverrides(m.limits)
+}
+
+// IsThrottlingError returns true if the OpenStack API rejected a request because of a rate
+// limit or because the tenant's quota is used up.
+func (m *Manager) IsThrottlingError(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case gophercloud.ErrDefault429:
+		return true
+	case gophercloud.ErrUnexpectedResponseCode:
+		return (e.Actual == http.StatusForbidden || e.Actual == http.StatusRequestEntityTooLarge) &&
+			strings.Contains(strings.ToLower(string(e.Body)), "quota exceeded")
+	default:
+		return false
+	}
+}
+
 // SpawnInstance attempts to create a new host by requesting one from the OpenStack API.
 // Information about the intended (and eventually created) host is recorded in a DB document.
 //
@@ -178,6 +213,11 @@ func (m *Manager) IsUp(host *host.Host) (bool, error) {
 	return status == cloud.StatusRunning, nil
 }
 
+// UpdateTags sets the given tags as metadata on the server.
+func (m *Manager) UpdateTags(host *host.Host, tags map[string]string) error {
+	return m.client.UpdateMetadata(host.Id, tags)
+}
+
 // OnUp does nothing since tags are attached in SpawnInstance.
 func (m *Manager) OnUp(host *host.Host) error {
 	return nil
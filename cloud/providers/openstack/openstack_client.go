@@ -14,6 +14,7 @@ type client interface {
 	CreateInstance(servers.CreateOpts, string) (*servers.Server, error)
 	GetInstance(string) (*servers.Server, error)	
 	DeleteInstance(string) error
+	UpdateMetadata(string, map[string]string) error
 }
 
 type clientImpl struct {
@@ -57,3 +58,10 @@ func (c *clientImpl) DeleteInstance(id string) error {
 	err := servers.Delete(c.ServiceClient, id).ExtractErr()
 	return errors.Wrap(err, "OpenStack Delete API call failed")
 }
+
+// UpdateMetadata sets the given metadata on the server, leaving any other existing
+// metadata unchanged.
+func (c *clientImpl) UpdateMetadata(id string, metadata map[string]string) error {
+	_, err := servers.UpdateMetadata(c.ServiceClient, id, servers.MetadataOpts(metadata)).Extract()
+	return errors.Wrap(err, "OpenStack UpdateMetadata API call failed")
+}
@@ -16,6 +16,7 @@ type clientMock struct {
 	failCreate bool
 	failGet    bool
 	failDelete bool
+	failUpdate bool
 
 	// Other options
 	isServerActive bool
@@ -62,3 +63,11 @@ func (c *clientMock) DeleteInstance(id string) error {
 
 	return nil
 }
+
+func (c *clientMock) UpdateMetadata(id string, metadata map[string]string) error {
+	if c.failUpdate {
+		return errors.New("failed to update metadata")
+	}
+
+	return nil
+}
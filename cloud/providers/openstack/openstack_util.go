@@ -79,5 +79,10 @@ func makeTags(intent *host.Host) map[string]string {
 	if intent.UserHost {
 		tags["mode"] = "testing"
 	}
+
+	// tags used for cost attribution take precedence over the defaults
+	for key, value := range intent.InstanceTags {
+		tags[key] = value
+	}
 	return tags
 }
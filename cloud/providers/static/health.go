@@ -0,0 +1,185 @@
+package static
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaults for the health check settings
+	DefaultFailureThreshold  = 3
+	DefaultRecoveryThreshold = 3
+	DefaultMaxClockSkew      = 5 * time.Minute
+
+	// how long each command run by a health check may take
+	healthCheckTimeout = 2 * time.Minute
+)
+
+// HealthCheckSettings configures the periodic health checks of a distro's
+// static hosts. Hosts are always checked for SSH reachability and clock skew;
+// the disk and script checks only run if they are configured.
+type HealthCheckSettings struct {
+	Disabled bool `mapstructure:"disabled" json:"disabled" bson:"disabled"`
+
+	// the minimum free space, in megabytes, required on the volume holding
+	// DiskPath, which defaults to the distro's working directory
+	MinDiskFreeMB int    `mapstructure:"min_disk_free_mb" json:"min_disk_free_mb" bson:"min_disk_free_mb"`
+	DiskPath      string `mapstructure:"disk_path" json:"disk_path" bson:"disk_path"`
+
+	// the largest difference allowed between the host's clock and ours
+	MaxClockSkewSecs int `mapstructure:"max_clock_skew_secs" json:"max_clock_skew_secs" bson:"max_clock_skew_secs"`
+
+	// a command run on the host; it fails the check if it exits non-zero
+	Script string `mapstructure:"script" json:"script" bson:"script"`
+
+	// the number of consecutive failed checks after which a host is
+	// quarantined, and the number of consecutive passes after which a
+	// quarantined host is returned to service
+	FailureThreshold  int `mapstructure:"failure_threshold" json:"failure_threshold" bson:"failure_threshold"`
+	RecoveryThreshold int `mapstructure:"recovery_threshold" json:"recovery_threshold" bson:"recovery_threshold"`
+}
+
+// Validate checks that the health check settings are sane.
+func (s *HealthCheckSettings) Validate() error {
+	if s.MinDiskFreeMB < 0 || s.MaxClockSkewSecs < 0 {
+		return errors.New("health check limits can not be negative")
+	}
+	if s.FailureThreshold < 0 || s.RecoveryThreshold < 0 {
+		return errors.New("health check thresholds can not be negative")
+	}
+	return nil
+}
+
+// GetFailureThreshold returns the number of failed checks in a row after
+// which a host is quarantined.
+func (s *HealthCheckSettings) GetFailureThreshold() int {
+	if s.FailureThreshold == 0 {
+		return DefaultFailureThreshold
+	}
+	return s.FailureThreshold
+}
+
+// GetRecoveryThreshold returns the number of passed checks in a row after
+// which a quarantined host is returned to service.
+func (s *HealthCheckSettings) GetRecoveryThreshold() int {
+	if s.RecoveryThreshold == 0 {
+		return DefaultRecoveryThreshold
+	}
+	return s.RecoveryThreshold
+}
+
+func (s *HealthCheckSettings) getMaxClockSkew() time.Duration {
+	if s.MaxClockSkewSecs == 0 {
+		return DefaultMaxClockSkew
+	}
+	return time.Duration(s.MaxClockSkewSecs) * time.Second
+}
+
+// GetHealthCheckSettings returns the health check settings of a static distro.
+func GetHealthCheckSettings(d *distro.Distro) (*HealthCheckSettings, error) {
+	settings := &Settings{}
+	if err := mapstructure.Decode(d.ProviderSettings, settings); err != nil {
+		return nil, errors.Wrapf(err, "invalid static settings for '%s'", d.Id)
+	}
+	return &settings.HealthCheck, nil
+}
+
+// CheckHealth runs the configured health checks against the host, returning
+// an error describing the first check that failed.
+func (staticMgr *StaticManager) CheckHealth(h *host.Host, keyPath string, settings *HealthCheckSettings) error {
+	reachable, err := staticMgr.IsSSHReachable(h, keyPath)
+	if err != nil {
+		return errors.Wrap(err, "error checking ssh reachability")
+	}
+	if !reachable {
+		return errors.New("host is not reachable over ssh")
+	}
+
+	sshOpts, err := staticMgr.GetSSHOptions(h, keyPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	diskPath := settings.DiskPath
+	if diskPath == "" {
+		diskPath = h.Distro.WorkDir
+	}
+	if diskPath == "" {
+		diskPath = "/"
+	}
+
+	start := time.Now()
+	output, err := hostutil.RunSSHCommand(h, hostStatsCommand(diskPath), sshOpts, healthCheckTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "error reading host stats: %s", output)
+	}
+	// assume the remote clock was read halfway through the command
+	localTime := start.Add(time.Since(start) / 2)
+
+	freeMB, remoteTime, err := parseHostStats(output)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if settings.MinDiskFreeMB > 0 && freeMB < int64(settings.MinDiskFreeMB) {
+		return errors.Errorf("%d MB free in %s, need at least %d MB",
+			freeMB, diskPath, settings.MinDiskFreeMB)
+	}
+	skew := remoteTime.Sub(localTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > settings.getMaxClockSkew() {
+		return errors.Errorf("clock is off by %s, more than the allowed %s",
+			skew, settings.getMaxClockSkew())
+	}
+
+	if settings.Script != "" {
+		output, err = hostutil.RunSSHCommand(h, settings.Script, sshOpts, healthCheckTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "health check script failed: %s", output)
+		}
+	}
+
+	return nil
+}
+
+// hostStatsCommand returns a command that prints the line of `df` output for
+// the volume holding the path, followed by the host's clock in seconds since
+// the epoch.
+func hostStatsCommand(path string) string {
+	return fmt.Sprintf("df -Pk '%s' | tail -n 1 && date +%%s", strings.Replace(path, "'", `'\''`, -1))
+}
+
+// parseHostStats reads the free disk space in megabytes and the host's clock
+// from the output of hostStatsCommand. Anything printed before those lines,
+// such as a login banner, is ignored.
+func parseHostStats(output string) (int64, time.Time, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, time.Time{}, errors.Errorf("unexpected host stats output: %s", output)
+	}
+
+	dfFields := strings.Fields(lines[len(lines)-2])
+	if len(dfFields) < 4 {
+		return 0, time.Time{}, errors.Errorf("unexpected df output: %s", lines[len(lines)-2])
+	}
+	freeKB, err := strconv.ParseInt(dfFields[3], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, errors.Wrapf(err, "unexpected df output: %s", lines[len(lines)-2])
+	}
+
+	epoch, err := strconv.ParseInt(strings.TrimSpace(lines[len(lines)-1]), 10, 64)
+	if err != nil {
+		return 0, time.Time{}, errors.Wrapf(err, "unexpected date output: %s", lines[len(lines)-1])
+	}
+
+	return freeKB / 1024, time.Unix(epoch, 0), nil
+}
@@ -0,0 +1,39 @@
+package static
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHostStats(t *testing.T) {
+	assert := assert.New(t)
+
+	output := "Welcome to the build farm\n" +
+		"/dev/sda1  41152736 24106944  15032024      62% /\n" +
+		"1500000000\n"
+	freeMB, remoteTime, err := parseHostStats(output)
+	assert.NoError(err)
+	assert.Equal(int64(15032024/1024), freeMB)
+	assert.Equal(time.Unix(1500000000, 0), remoteTime)
+
+	_, _, err = parseHostStats("1500000000")
+	assert.Error(err)
+
+	_, _, err = parseHostStats("/dev/sda1 41152736 24106944 lots 62% /\n1500000000")
+	assert.Error(err)
+}
+
+func TestHealthCheckSettingsDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := &HealthCheckSettings{}
+	assert.NoError(settings.Validate())
+	assert.Equal(DefaultFailureThreshold, settings.GetFailureThreshold())
+	assert.Equal(DefaultRecoveryThreshold, settings.GetRecoveryThreshold())
+	assert.Equal(DefaultMaxClockSkew, settings.getMaxClockSkew())
+
+	settings.FailureThreshold = -1
+	assert.Error(settings.Validate())
+}
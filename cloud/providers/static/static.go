@@ -18,7 +18,8 @@ const ProviderName = "static"
 type StaticManager struct{}
 
 type Settings struct {
-	Hosts []Host `mapstructure:"hosts" json:"hosts" bson:"hosts"`
+	Hosts       []Host              `mapstructure:"hosts" json:"hosts" bson:"hosts"`
+	HealthCheck HealthCheckSettings `mapstructure:"health_check" json:"health_check" bson:"health_check"`
 }
 
 type Host struct {
@@ -40,7 +41,7 @@ func (s *Settings) Validate() error {
 			return errors.New("host 'name' field can not be blank")
 		}
 	}
-	return nil
+	return errors.WithStack(s.HealthCheck.Validate())
 }
 
 func (staticMgr *StaticManager) SpawnInstance(distro *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
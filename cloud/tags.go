@@ -0,0 +1,86 @@
+package cloud
+
+import (
+	"os"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+)
+
+// Keys of the tags Evergreen applies to hosts so that cloud costs can be
+// attributed to the distros, projects and users that incur them.
+const (
+	DistroTagKey    = "distro"
+	InstanceTagKey  = "evergreen-instance"
+	StartedByTagKey = "started-by"
+	OwnerTagKey     = "owner"
+	ProjectTagKey   = "project"
+)
+
+// CloudTagger is an interface for cloud managers that can change the tags of
+// a host after it has been spawned.
+type CloudTagger interface {
+	// UpdateTags replaces the host's tags in the provider with the given
+	// set.
+	UpdateTags(h *host.Host, tags map[string]string) error
+}
+
+// SettingsTags returns the tags that every host spawned by this Evergreen
+// instance gets: the admin-defined tags, and the instance's name.
+func SettingsTags(settings *evergreen.Settings) map[string]string {
+	tags := map[string]string{}
+	for key, value := range settings.Providers.Tags {
+		tags[key] = value
+	}
+
+	name := settings.Providers.InstanceName
+	if name == "" {
+		var err error
+		name, err = os.Hostname()
+		if err != nil {
+			name = "unknown"
+		}
+	}
+	tags[InstanceTagKey] = name
+
+	return tags
+}
+
+// makeInstanceTags returns the tags for a new host of the distro, adding tags
+// that identify the distro and the user who started the host to those in
+// the options.
+func makeInstanceTags(d distro.Distro, options HostOptions) map[string]string {
+	tags := map[string]string{}
+	for key, value := range options.Tags {
+		tags[key] = value
+	}
+
+	tags[DistroTagKey] = d.Id
+	tags[StartedByTagKey] = options.UserName
+	if options.UserHost {
+		owner := options.UserName
+		if options.ProvisionOptions != nil && options.ProvisionOptions.OwnerId != "" {
+			owner = options.ProvisionOptions.OwnerId
+		}
+		tags[OwnerTagKey] = owner
+	}
+
+	return tags
+}
+
+// ProjectTags returns the host's tags with the project tag set to the given
+// project, and whether that differs from the host's current tags.
+func ProjectTags(h *host.Host, project string) (map[string]string, bool) {
+	if h.InstanceTags[ProjectTagKey] == project {
+		return h.InstanceTags, false
+	}
+
+	tags := map[string]string{}
+	for key, value := range h.InstanceTags {
+		tags[key] = value
+	}
+	tags[ProjectTagKey] = project
+
+	return tags, true
+}
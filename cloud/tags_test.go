@@ -0,0 +1,65 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsTags(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := &evergreen.Settings{}
+	settings.Providers.InstanceName = "evergreen-prod"
+	settings.Providers.Tags = map[string]string{"team": "build"}
+
+	tags := SettingsTags(settings)
+	assert.Equal(map[string]string{"team": "build", InstanceTagKey: "evergreen-prod"}, tags)
+
+	// the settings' tags are copied, not shared
+	tags["team"] = "other"
+	assert.Equal("build", settings.Providers.Tags["team"])
+}
+
+func TestNewIntentTags(t *testing.T) {
+	assert := assert.New(t)
+
+	d := distro.Distro{Id: "ubuntu"}
+	options := HostOptions{
+		UserName: evergreen.User,
+		Tags:     map[string]string{InstanceTagKey: "evergreen-prod"},
+	}
+	h := NewIntent(d, "host", "mock", options)
+	assert.Equal(map[string]string{
+		InstanceTagKey:  "evergreen-prod",
+		DistroTagKey:    "ubuntu",
+		StartedByTagKey: evergreen.User,
+	}, h.InstanceTags)
+
+	// spawn hosts are attributed to their owner
+	options = HostOptions{
+		UserName:         "user",
+		UserHost:         true,
+		ProvisionOptions: &host.ProvisionOptions{OwnerId: "owner"},
+	}
+	h = NewIntent(d, "host", "mock", options)
+	assert.Equal("owner", h.InstanceTags[OwnerTagKey])
+	assert.Equal("user", h.InstanceTags[StartedByTagKey])
+}
+
+func TestProjectTags(t *testing.T) {
+	assert := assert.New(t)
+
+	h := &host.Host{InstanceTags: map[string]string{DistroTagKey: "ubuntu"}}
+	tags, changed := ProjectTags(h, "mci")
+	assert.True(changed)
+	assert.Equal(map[string]string{DistroTagKey: "ubuntu", ProjectTagKey: "mci"}, tags)
+	assert.Empty(h.InstanceTags[ProjectTagKey])
+
+	h.InstanceTags = tags
+	_, changed = ProjectTags(h, "mci")
+	assert.False(changed)
+}
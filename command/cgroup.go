@@ -0,0 +1,21 @@
+package command
+
+// CgroupLimits are limits on the resources used by the processes in a cgroup.
+// A zero value means no limit.
+type CgroupLimits struct {
+	// CPUs is the number of CPUs worth of time the processes can use.
+	CPUs float64
+	// MemoryBytes is the memory the processes can use.
+	MemoryBytes int64
+	// Pids is the number of processes and threads there can be at once.
+	Pids int
+}
+
+// Cgroup is a cgroup that commands can be run in, so that the resources used
+// by every process they start can be limited, accounted for and cleaned up,
+// however those processes were started. Cgroups are only supported on Linux,
+// using the unified (v2) hierarchy.
+type Cgroup struct {
+	// Path is the directory of the cgroup in the cgroup filesystem.
+	Path string
+}
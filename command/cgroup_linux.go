@@ -0,0 +1,349 @@
+// +build linux
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/pkg/errors"
+)
+
+const (
+	cgroupMount = "/sys/fs/cgroup"
+
+	// agentCgroupName is the leaf cgroup that the agent moves itself into,
+	// out of the way of the cgroups it creates for tasks.
+	agentCgroupName = "evergreen-agent"
+
+	cpuMaxPeriod = 100000
+
+	cgroupRemoveAttempts = 20
+	cgroupRemoveInterval = 50 * time.Millisecond
+)
+
+// cgroupControllers are the controllers enabled for task cgroups, if they
+// are available.
+var cgroupControllers = []string{"cpu", "io", "memory", "pids"}
+
+var (
+	cgroupBaseOnce sync.Once
+	cgroupBasePath string
+	cgroupBaseErr  error
+)
+
+// NewTaskCgroup creates a cgroup named name for a task, with the limits
+// applied to it. Commands are run in children of the cgroup, so that the
+// resources each one uses are accounted for separately.
+func NewTaskCgroup(name string, limits CgroupLimits) (*Cgroup, error) {
+	base, err := cgroupBase()
+	if err != nil {
+		return nil, errors.Wrap(err, "error setting up cgroups for the agent")
+	}
+
+	cg := &Cgroup{Path: filepath.Join(base, name)}
+	if err = os.Mkdir(cg.Path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "error creating cgroup %s", cg.Path)
+	}
+
+	if err = cg.setLimits(limits); err == nil {
+		err = enableControllers(cg.Path)
+	}
+	if err != nil {
+		_ = cg.Remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// NewChild creates a cgroup named name in the cgroup, which shares its limits.
+func (c *Cgroup) NewChild(name string) (*Cgroup, error) {
+	child := &Cgroup{Path: filepath.Join(c.Path, name)}
+	if err := os.Mkdir(child.Path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "error creating cgroup %s", child.Path)
+	}
+	return child, nil
+}
+
+// Usage returns the resources used by the processes that have run in the
+// cgroup and its descendants.
+func (c *Cgroup) Usage() (*apimodels.ResourceUsage, error) {
+	usage := &apimodels.ResourceUsage{}
+
+	// memory.peak is missing on older kernels, which only report the
+	// current usage
+	peak, err := readCgroupFile(c.Path, "memory.peak")
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+	if peak != "" {
+		if usage.PeakMemoryBytes, err = strconv.ParseInt(peak, 10, 64); err != nil {
+			return nil, errors.Wrapf(err, "error parsing memory.peak of cgroup %s", c.Path)
+		}
+	}
+
+	cpuStat, err := readCgroupFile(c.Path, "cpu.stat")
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range parseCgroupKeys(cpuStat) {
+		if key == "usage_usec" {
+			usage.CPUSecs = float64(val) / 1e6
+		}
+	}
+
+	// io.stat has a line of keys for each device
+	ioStat, err := readCgroupFile(c.Path, "io.stat")
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+	for _, line := range strings.Split(ioStat, "\n") {
+		keys := parseCgroupKeys(line)
+		usage.IOReadBytes += keys["rbytes"]
+		usage.IOWriteBytes += keys["wbytes"]
+	}
+
+	return usage, nil
+}
+
+// Kill kills every process in the cgroup and its descendants.
+func (c *Cgroup) Kill() error {
+	err := writeCgroupFile(c.Path, "cgroup.kill", "1")
+	if err == nil || !os.IsNotExist(errors.Cause(err)) {
+		return err
+	}
+
+	// cgroup.kill is missing on older kernels, so kill the processes one
+	// at a time. Any they fork in the meantime are caught on the next pass.
+	for i := 0; i < cgroupRemoveAttempts; i++ {
+		killed, err := c.killProcesses()
+		if err != nil || killed == 0 {
+			return err
+		}
+	}
+	return errors.Errorf("processes in cgroup %s kept being started", c.Path)
+}
+
+// killProcesses kills the processes in the cgroup and its descendants,
+// returning the number that it found.
+func (c *Cgroup) killProcesses() (int, error) {
+	killed := 0
+	err := filepath.Walk(c.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		procs, err := readCgroupFile(path, "cgroup.procs")
+		if err != nil {
+			return err
+		}
+		for _, pid := range strings.Fields(procs) {
+			p, err := strconv.Atoi(pid)
+			if err != nil {
+				return errors.Wrapf(err, "error parsing cgroup.procs of cgroup %s", path)
+			}
+			if err = syscall.Kill(p, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+				return errors.Wrapf(err, "error killing process %d", p)
+			}
+			killed++
+		}
+		return nil
+	})
+	return killed, errors.WithStack(err)
+}
+
+// Remove removes the cgroup and its descendants, which must have no
+// processes left in them.
+func (c *Cgroup) Remove() error {
+	entries, err := ioutil.ReadDir(c.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error reading cgroup %s", c.Path)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err = (&Cgroup{Path: filepath.Join(c.Path, entry.Name())}).Remove(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// killed processes take a moment to leave the cgroup
+	for i := 0; i < cgroupRemoveAttempts; i++ {
+		err = syscall.Rmdir(c.Path)
+		if err != syscall.EBUSY {
+			break
+		}
+		time.Sleep(cgroupRemoveInterval)
+	}
+	if err != nil && err != syscall.ENOENT {
+		return errors.Wrapf(err, "error removing cgroup %s", c.Path)
+	}
+	return nil
+}
+
+// apply makes cmd start its process in the cgroup. The returned function must
+// be called once the process has been started.
+func (c *Cgroup) apply(cmd *exec.Cmd) (func(), error) {
+	dir, err := os.Open(c.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening cgroup %s", c.Path)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+
+	return func() { _ = dir.Close() }, nil
+}
+
+func (c *Cgroup) setLimits(limits CgroupLimits) error {
+	if limits.CPUs > 0 {
+		quota := int64(limits.CPUs * cpuMaxPeriod)
+		if err := writeCgroupFile(c.Path, "cpu.max", fmt.Sprintf("%d %d", quota, cpuMaxPeriod)); err != nil {
+			return err
+		}
+	}
+	if limits.MemoryBytes > 0 {
+		if err := writeCgroupFile(c.Path, "memory.max", strconv.FormatInt(limits.MemoryBytes, 10)); err != nil {
+			return err
+		}
+	}
+	if limits.Pids > 0 {
+		if err := writeCgroupFile(c.Path, "pids.max", strconv.Itoa(limits.Pids)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cgroupBase returns the cgroup that task cgroups are created in, which is
+// the one the agent was started in. The first time it's called, it moves the
+// agent into a leaf cgroup of its own, since the processes in a cgroup can't
+// be split between it and child cgroups with controllers enabled.
+func cgroupBase() (string, error) {
+	cgroupBaseOnce.Do(func() {
+		cgroupBasePath, cgroupBaseErr = setupCgroupBase()
+	})
+	return cgroupBasePath, cgroupBaseErr
+}
+
+func setupCgroupBase() (string, error) {
+	contents, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", errors.Wrap(err, "error reading the agent's cgroup")
+	}
+	base := parseUnifiedCgroup(contents)
+	if _, err = os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); base == "" || err != nil {
+		return "", errors.Errorf("the unified cgroup hierarchy is not mounted at %s", cgroupMount)
+	}
+	base = filepath.Join(cgroupMount, base)
+
+	// an agent that replaced itself with a new version is already in its
+	// own cgroup
+	if filepath.Base(base) == agentCgroupName {
+		return filepath.Dir(base), nil
+	}
+
+	agentCgroup := filepath.Join(base, agentCgroupName)
+	if err = os.Mkdir(agentCgroup, 0755); err != nil && !os.IsExist(err) {
+		return "", errors.Wrapf(err, "error creating cgroup %s", agentCgroup)
+	}
+	if err = writeCgroupFile(agentCgroup, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		return "", err
+	}
+	if err = enableControllers(base); err != nil {
+		return "", err
+	}
+
+	return base, nil
+}
+
+// parseUnifiedCgroup returns the path of the process's cgroup in the unified
+// hierarchy, from the contents of /proc/<pid>/cgroup.
+func parseUnifiedCgroup(contents []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		if path := strings.TrimPrefix(scanner.Text(), "0::"); path != scanner.Text() {
+			return path
+		}
+	}
+	return ""
+}
+
+// enableControllers enables the controllers for task cgroups that are
+// available in the cgroup at path for its children.
+func enableControllers(path string) error {
+	available, err := readCgroupFile(path, "cgroup.controllers")
+	if err != nil {
+		return err
+	}
+
+	enable := []string{}
+	for _, controller := range cgroupControllers {
+		for _, a := range strings.Fields(available) {
+			if a == controller {
+				enable = append(enable, "+"+controller)
+			}
+		}
+	}
+	if len(enable) == 0 {
+		return nil
+	}
+	return writeCgroupFile(path, "cgroup.subtree_control", strings.Join(enable, " "))
+}
+
+// parseCgroupKeys parses the "key value" lines of files like cpu.stat, or the
+// "key=value" fields of a line of io.stat.
+func parseCgroupKeys(contents string) map[string]int64 {
+	keys := map[string]int64{}
+	fields := strings.Fields(contents)
+	for i, field := range fields {
+		key, val := field, ""
+		if parts := strings.SplitN(field, "=", 2); len(parts) == 2 {
+			key, val = parts[0], parts[1]
+		} else if i+1 < len(fields) {
+			val = fields[i+1]
+		}
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			keys[key] = n
+		}
+	}
+	return keys
+}
+
+func readCgroupFile(path, file string) (string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(path, file))
+	if err != nil {
+		return "", errors.Wrapf(err, "error reading %s of cgroup %s", file, path)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+func writeCgroupFile(path, file, contents string) error {
+	// cgroup files must never be created
+	f, err := os.OpenFile(filepath.Join(path, file), os.O_WRONLY, 0)
+	if err != nil {
+		return errors.Wrapf(err, "error opening %s of cgroup %s", file, path)
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(contents); err != nil {
+		return errors.Wrapf(err, "error writing %s of cgroup %s", file, path)
+	}
+	return nil
+}
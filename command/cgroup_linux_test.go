@@ -0,0 +1,67 @@
+// +build linux
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnifiedCgroup(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/system.slice/evergreen.service",
+		parseUnifiedCgroup([]byte("0::/system.slice/evergreen.service\n")))
+	assert.Equal("/user.slice", parseUnifiedCgroup([]byte("4:memory:/a\n1:cpu:/\n0::/user.slice\n")))
+	assert.Equal("", parseUnifiedCgroup([]byte("4:memory:/a\n1:cpu:/\n")))
+}
+
+func TestParseCgroupKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	keys := parseCgroupKeys("usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n")
+	assert.Equal(int64(2500000), keys["usage_usec"])
+	assert.Equal(int64(500000), keys["system_usec"])
+
+	keys = parseCgroupKeys("8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0")
+	assert.Equal(int64(4096), keys["rbytes"])
+	assert.Equal(int64(8192), keys["wbytes"])
+}
+
+func TestTaskCgroup(t *testing.T) {
+	assert := assert.New(t)
+
+	cg, err := NewTaskCgroup(fmt.Sprintf("test-%d", os.Getpid()), CgroupLimits{Pids: 100})
+	if err != nil {
+		t.Skipf("cgroups can't be used here: %v", err)
+	}
+	defer func() { assert.NoError(cg.Remove()) }()
+
+	child, err := cg.NewChild("cmd-0")
+	require.NoError(t, err)
+
+	lc := &LocalCommand{
+		CmdString: "sleep 60 & head -c 1000000 /dev/zero > /dev/null",
+		Shell:     "sh",
+		Cgroup:    child,
+	}
+	require.NoError(t, lc.Start())
+	assert.NoError(lc.Cmd.Wait())
+
+	procs, err := readCgroupFile(child.Path, "cgroup.procs")
+	assert.NoError(err)
+	assert.NotEmpty(procs, "the backgrounded sleep should still be in the cgroup")
+
+	usage, err := cg.Usage()
+	require.NoError(t, err)
+	assert.True(usage.CPUSecs > 0)
+
+	assert.NoError(cg.Kill())
+	assert.NoError(cg.Remove())
+	_, err = os.Stat(cg.Path)
+	assert.True(os.IsNotExist(err))
+}
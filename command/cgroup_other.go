@@ -0,0 +1,27 @@
+// +build !linux
+
+package command
+
+import (
+	"os/exec"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/pkg/errors"
+)
+
+var errCgroupsUnsupported = errors.New("cgroups are only supported on linux")
+
+// NewTaskCgroup returns an error, because cgroups are only supported on Linux.
+func NewTaskCgroup(name string, limits CgroupLimits) (*Cgroup, error) {
+	return nil, errCgroupsUnsupported
+}
+
+func (c *Cgroup) NewChild(name string) (*Cgroup, error) { return nil, errCgroupsUnsupported }
+
+func (c *Cgroup) Usage() (*apimodels.ResourceUsage, error) { return nil, errCgroupsUnsupported }
+
+func (c *Cgroup) Kill() error { return errCgroupsUnsupported }
+
+func (c *Cgroup) Remove() error { return errCgroupsUnsupported }
+
+func (c *Cgroup) apply(cmd *exec.Cmd) (func(), error) { return nil, errCgroupsUnsupported }
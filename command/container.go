@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultContainerRuntime is the command used to manage containers if none is
+// configured.
+const DefaultContainerRuntime = "docker"
+
+const (
+	// processGroupScript runs its arguments after the first in a new
+	// session, and so in their own process group, and writes the group's id
+	// to the file named by the first. A background job's stdin would be
+	// /dev/null, so the script's own is passed on as fd 3.
+	processGroupScript = `pidfile=$1; shift
+exec 3<&0
+setsid "$@" <&3 3<&- &
+echo $! > "$pidfile"
+wait $!
+status=$?
+rm -f "$pidfile"
+exit $status`
+
+	// killProcessGroupScript kills the process group whose id is in the
+	// file named by its argument.
+	killProcessGroupScript = `kill -9 -"$(cat "$1")"`
+)
+
+// containerCommands counts the commands run in containers, to name their pid
+// files.
+var containerCommands int64
+
+// Container is a long-running container that commands can be executed in.
+// It is created with a directory on the host bind-mounted at the same path,
+// so that paths in that directory mean the same thing inside and outside of
+// the container.
+type Container struct {
+	// Runtime is the command used to manage the container, e.g. docker or
+	// podman, which must support the docker command line.
+	Runtime string
+	// Name is the name the container was started with.
+	Name string
+}
+
+// StartContainer starts a container from the image, named name, with dir
+// mounted into it. The options are passed to the runtime's run command before
+// the image name, so they can override the defaults.
+func StartContainer(containerRuntime, name, image, dir string, options []string) (*Container, error) {
+	if containerRuntime == "" {
+		containerRuntime = DefaultContainerRuntime
+	}
+
+	args := []string{"run", "--detach", "--name", name,
+		"--volume", fmt.Sprintf("%s:%s", dir, dir), "--workdir", dir}
+
+	// run as the agent's user, so that it can clean up the files the
+	// task leaves behind
+	if runtime.GOOS != "windows" {
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+	args = append(args, options...)
+
+	// the container only has to stay up until it is removed
+	args = append(args, image, "tail", "-f", "/dev/null")
+
+	if err := runContainerCommand(containerRuntime, args...); err != nil {
+		return nil, errors.Wrapf(err, "error starting container %s from image %s", name, image)
+	}
+
+	return &Container{Runtime: containerRuntime, Name: name}, nil
+}
+
+// Command returns a command that runs args in the container, in the directory
+// dir and with the environment env. Stdin is passed through to the process in
+// the container. The process is started in its own process group, whose id is
+// written to pidFile in the container, so that KillProcessGroup can stop it
+// and its children.
+func (c *Container) Command(dir string, env []string, pidFile string, args ...string) *exec.Cmd {
+	execArgs := []string{"exec", "--interactive"}
+	if dir != "" {
+		execArgs = append(execArgs, "--workdir", dir)
+	}
+	for _, e := range env {
+		execArgs = append(execArgs, "--env", e)
+	}
+	execArgs = append(execArgs, c.Name, "sh", "-c", processGroupScript, "sh", pidFile)
+	execArgs = append(execArgs, args...)
+
+	return exec.Command(c.Runtime, execArgs...)
+}
+
+// newPidFile returns a path in the container to record the process group of a
+// command in.
+func (c *Container) newPidFile() string {
+	return fmt.Sprintf("/tmp/evergreen-%d-%d.pid", os.Getpid(), atomic.AddInt64(&containerCommands, 1))
+}
+
+// KillProcessGroup kills the process group of a command started with
+// Command, along with anything the command started. The rest of the
+// container is left running.
+func (c *Container) KillProcessGroup(pidFile string) error {
+	return errors.Wrapf(runContainerCommand(c.Runtime, "exec", c.Name, "sh", "-c", killProcessGroupScript, "sh", pidFile),
+		"error killing processes in container %s", c.Name)
+}
+
+// KillProcesses kills every process in the container other than the one that
+// keeps it running. It's for cleaning up after a whole task; use
+// KillProcessGroup to stop a single command.
+func (c *Container) KillProcesses() error {
+	return errors.Wrapf(runContainerCommand(c.Runtime, "exec", c.Name, "kill", "-9", "-1"),
+		"error killing processes in container %s", c.Name)
+}
+
+// Remove stops and deletes the container, along with any processes still
+// running in it.
+func (c *Container) Remove() error {
+	return errors.Wrapf(runContainerCommand(c.Runtime, "rm", "--force", c.Name),
+		"error removing container %s", c.Name)
+}
+
+// runContainerCommand runs the container runtime with args, and includes its
+// output in the error if it fails.
+func runContainerCommand(containerRuntime string, args ...string) error {
+	out := &bytes.Buffer{}
+	cmd := exec.Command(containerRuntime, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "%s %s: %s", containerRuntime, args[0], strings.TrimSpace(out.String()))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Container{Runtime: "docker", Name: "evg-abc"}
+	cmd := c.Command("/data/abc", []string{"A=1", "B=2"}, "/tmp/1.pid", "sh", "-c", "true")
+	assert.Equal([]string{"docker", "exec", "--interactive", "--workdir", "/data/abc",
+		"--env", "A=1", "--env", "B=2", "evg-abc", "sh", "-c", processGroupScript, "sh", "/tmp/1.pid",
+		"sh", "-c", "true"}, cmd.Args)
+
+	cmd = c.Command("", nil, "/tmp/2.pid", "ls")
+	assert.Equal([]string{"docker", "exec", "--interactive", "evg-abc",
+		"sh", "-c", processGroupScript, "sh", "/tmp/2.pid", "ls"}, cmd.Args)
+}
+
+func TestContainerProcessGroup(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not available")
+	}
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "process-group")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "cmd.pid")
+
+	// stdin reaches the command, and the pid file is removed once it exits
+	out := &bytes.Buffer{}
+	cmd := exec.Command("sh", "-c", processGroupScript, "sh", pidFile, "cat")
+	cmd.Stdin = strings.NewReader("hi")
+	cmd.Stdout = out
+	require.NoError(t, cmd.Run())
+	assert.Equal("hi", out.String())
+	_, err = os.Stat(pidFile)
+	assert.True(os.IsNotExist(err))
+
+	// killing the group stops the command and what it started
+	cmd = exec.Command("sh", "-c", processGroupScript, "sh", pidFile, "sh", "-c", "sleep 30 & sleep 30")
+	require.NoError(t, cmd.Start())
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(pidFile); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	start := time.Now()
+	require.NoError(t, exec.Command("sh", "-c", killProcessGroupScript, "sh", pidFile).Run())
+	assert.Error(cmd.Wait())
+	assert.True(time.Since(start) < 10*time.Second)
+}
+
+func TestLocalCommandInContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := &LocalCommand{
+		CmdString:        "echo hi",
+		Shell:            "bash",
+		ScriptMode:       true,
+		WorkingDirectory: os.TempDir(),
+		Environment:      []string{"EVR_TASK_ID=t1"},
+		Container:        &Container{Runtime: "true", Name: "evg-abc"},
+	}
+	assert.NoError(lc.Start())
+	assert.NoError(lc.Cmd.Wait())
+
+	// the environment goes to the process in the container, and the script
+	// is passed on stdin
+	assert.Equal([]string{"true", "exec", "--interactive", "--workdir", os.TempDir(),
+		"--env", "EVR_TASK_ID=t1", "evg-abc", "sh", "-c", processGroupScript, "sh", lc.pidFile,
+		"bash"}, lc.Cmd.Args)
+	assert.NotContains(lc.Cmd.Env, "EVR_TASK_ID=t1")
+	assert.NotNil(lc.Cmd.Stdin)
+
+	lc.Container = nil
+	lc.ScriptMode = false
+	assert.NoError(lc.Start())
+	assert.NoError(lc.Cmd.Wait())
+	assert.Equal([]string{"bash", "-c", "echo hi"}, lc.Cmd.Args)
+	assert.Equal([]string{"EVR_TASK_ID=t1"}, lc.Cmd.Env)
+}
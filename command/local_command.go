@@ -6,13 +6,18 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
 type LocalCommand struct {
-	CmdString        string
+	CmdString string
+	// Binary, if set, is run directly with Args, instead of running
+	// CmdString with the shell.
+	Binary           string
+	Args             []string
 	WorkingDirectory string
 	Shell            string
 	Environment      []string
@@ -20,7 +25,17 @@ type LocalCommand struct {
 	Stdout           io.Writer
 	Stderr           io.Writer
 	Cmd              *exec.Cmd
-	mutex            sync.RWMutex
+	// Container, if set, is the container the command is run in. The
+	// environment is passed to the process in the container, rather than to
+	// the container runtime.
+	Container *Container
+	// Cgroup, if set, is the cgroup the command's process is started in.
+	// It's ignored for commands run in a container.
+	Cgroup *Cgroup
+	// pidFile is where the process group of a command run in a container is
+	// recorded.
+	pidFile string
+	mutex   sync.RWMutex
 }
 
 func (lc *LocalCommand) Run() error {
@@ -39,7 +54,7 @@ func (lc *LocalCommand) GetPid() int {
 	lc.mutex.RLock()
 	defer lc.mutex.RUnlock()
 
-	if lc.Cmd == nil {
+	if lc.Cmd == nil || lc.Cmd.Process == nil {
 		return -1
 	}
 
@@ -50,29 +65,50 @@ func (lc *LocalCommand) Start() error {
 	lc.mutex.Lock()
 	defer lc.mutex.Unlock()
 
-	if lc.Shell == "" {
-		lc.Shell = "sh"
+	var args []string
+	if lc.Binary != "" {
+		args = append([]string{lc.Binary}, lc.Args...)
+	} else {
+		if lc.Shell == "" {
+			lc.Shell = "sh"
+		}
+
+		args = []string{lc.Shell}
+		if !lc.ScriptMode {
+			args = append(args, "-c", lc.CmdString)
+		}
 	}
 
 	var cmd *exec.Cmd
-	if lc.ScriptMode {
-		cmd = exec.Command(lc.Shell)
-		cmd.Stdin = strings.NewReader(lc.CmdString)
+	if lc.Container != nil {
+		lc.pidFile = lc.Container.newPidFile()
+		cmd = lc.Container.Command(lc.WorkingDirectory, lc.Environment, lc.pidFile, args...)
 	} else {
-		cmd = exec.Command(lc.Shell, "-c", lc.CmdString)
+		cmd = exec.Command(args[0], args[1:]...)
+		cmd.Env = lc.Environment
+	}
+	if lc.ScriptMode && lc.Binary == "" {
+		cmd.Stdin = strings.NewReader(lc.CmdString)
 	}
 
 	// create the command, set the options
 	if lc.WorkingDirectory != "" {
 		cmd.Dir = lc.WorkingDirectory
 	}
-	cmd.Env = lc.Environment
 	if cmd.Env == nil {
 		cmd.Env = os.Environ()
 	}
 	cmd.Stdout = lc.Stdout
 	cmd.Stderr = lc.Stderr
 
+	if lc.Cgroup != nil && lc.Container == nil {
+		started, err := lc.Cgroup.apply(cmd)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer started()
+	}
+
 	// cache the command running
 	lc.Cmd = cmd
 
@@ -85,6 +121,11 @@ func (lc *LocalCommand) Stop() error {
 	defer lc.mutex.Unlock()
 
 	if lc.Cmd != nil && lc.Cmd.Process != nil {
+		if lc.Container != nil {
+			// killing the runtime's client leaves the process in the
+			// container running
+			grip.CatchWarning(lc.Container.KillProcessGroup(lc.pidFile))
+		}
 		return lc.Cmd.Process.Kill()
 	}
 	grip.Warning("Trying to stop command but Cmd / Process was nil")
@@ -102,10 +143,39 @@ func (lc *LocalCommand) PrepToRun(expansions *Expansions) error {
 		return errors.WithStack(err)
 	}
 
+	lc.Binary, err = expansions.ExpandString(lc.Binary)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i, arg := range lc.Args {
+		lc.Args[i], err = expansions.ExpandString(arg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	lc.WorkingDirectory, err = expansions.ExpandString(lc.WorkingDirectory)
 	return errors.WithStack(err)
 }
 
+// ExitCode returns the exit code of a process from the error returned by
+// waiting for it, and false if the error isn't from the process exiting.
+func ExitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	exitErr, ok := errors.Cause(err).(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return 0, false
+	}
+	return status.ExitStatus(), true
+}
+
 type LocalCommandGroup struct {
 	Commands   []*LocalCommand
 	Expansions *Expansions
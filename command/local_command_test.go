@@ -9,6 +9,8 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLocalCommands(t *testing.T) {
@@ -281,3 +283,40 @@ func TestLocalScript(t *testing.T) {
 
 	})
 }
+
+func TestLocalBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell command test doesn't make sense on windows")
+	}
+	assert := assert.New(t)
+
+	stdout := &CacheLastWritten{}
+	command := &LocalCommand{
+		Binary: "echo",
+		Args:   []string{"${greeting}", "it's $HOME `pwd`"},
+		Stdout: stdout,
+		Stderr: ioutil.Discard,
+	}
+	require.NoError(t, command.PrepToRun(NewExpansions(map[string]string{"greeting": "hi"})))
+	require.NoError(t, command.Run())
+	// the arguments are passed as they are, without a shell
+	assert.Equal("hi it's $HOME `pwd`\n", string(stdout.LastWritten))
+	assert.Equal("", command.Shell)
+
+	code, ok := ExitCode(nil)
+	assert.True(ok)
+	assert.Equal(0, code)
+
+	command = &LocalCommand{Binary: "sh", Args: []string{"-c", "exit 3"}}
+	err := command.Run()
+	require.Error(t, err)
+	code, ok = ExitCode(err)
+	assert.True(ok)
+	assert.Equal(3, code)
+
+	command = &LocalCommand{Binary: "evergreen-no-such-binary"}
+	err = command.Run()
+	require.Error(t, err)
+	_, ok = ExitCode(err)
+	assert.False(ok)
+}
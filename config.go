@@ -163,12 +163,30 @@ type CloudProviders struct {
 	AWS          AWSConfig          `yaml:"aws"`
 	DigitalOcean DigitalOceanConfig `yaml:"digitalocean"`
 	OpenStack    OpenStackConfig    `yaml:"openstack"`
+
+	// InstanceName identifies this Evergreen instance in the tags of the
+	// hosts it spawns. It defaults to the name of the machine.
+	InstanceName string `yaml:"instance_name"`
+	// Tags are applied to every host Evergreen spawns, for attributing
+	// cloud costs.
+	Tags map[string]string `yaml:"tags"`
 }
 
 // AWSConfig stores auth info for Amazon Web Services.
 type AWSConfig struct {
 	Secret string `yaml:"aws_secret"`
 	Id     string `yaml:"aws_id"`
+
+	Limits ProviderLimitsConfig `yaml:"limits"`
+}
+
+// ProviderLimitsConfig overrides the API rate limit and instance quota that the
+// scheduler observes when spawning hosts with a cloud provider. Zero values
+// leave the provider's defaults in place.
+type ProviderLimitsConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+	InstanceQuota     int     `yaml:"instance_quota"`
 }
 
 // DigitalOceanConfig stores auth info for Digital Ocean.
@@ -191,6 +209,8 @@ type OpenStackConfig struct {
 	ProjectID   string `yaml:"project_id"`
 
 	Region string `yaml:"region"`
+
+	Limits ProviderLimitsConfig `yaml:"limits"`
 }
 
 // JiraConfig stores auth info for interacting with Atlassian Jira.
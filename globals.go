@@ -101,6 +101,10 @@ const (
 	ContentTypeValue  = "application/json"
 	APIUserHeader     = "Api-Key"
 	APIKeyHeader      = "Api-User"
+
+	// AgentRevisionHeader is sent by agents that are able to update
+	// themselves, with the revision they were built from
+	AgentRevisionHeader = "Agent-Revision"
 )
 
 // HTTP constants. Added after Go1.4. Here for compatibility with GCCGO
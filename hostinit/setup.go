@@ -219,6 +219,12 @@ func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 		// if this fails it is probably due to an API hiccup, so we keep going.
 		grip.Warningf("OnUp callback failed for host '%v': '%+v'", targetHost.Id, err)
 	}
+
+	// some providers can run the scripts without going through ssh
+	if runner, ok := cloudMgr.(cloud.CommandRunner); ok && runner.UseCommandRunner(targetHost) {
+		return init.setupHostWithRunner(targetHost, runner)
+	}
+
 	cloudHost, err := providers.GetCloudHost(targetHost, init.Settings)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to get cloud host for %s", targetHost.Id)
@@ -251,6 +257,46 @@ func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 	return "", nil
 }
 
+// setupHostWithRunner writes the distro's teardown script to the host and runs
+// its setup script using the provider's API rather than ssh.
+func (init *HostInit) setupHostWithRunner(targetHost *host.Host, runner cloud.CommandRunner) (string, error) {
+	if targetHost.Distro.Teardown != "" {
+		teardown, err := init.expandScript(targetHost.Distro.Teardown)
+		if err != nil {
+			return "", errors.Wrapf(err, "error expanding script for host %s", targetHost.Id)
+		}
+		err = util.RunFunctionWithTimeout(func() error {
+			return runner.RunCommand(targetHost, []string{"sh", "-c", "cat > " + teardownScriptName},
+				strings.NewReader(teardown), nil, false)
+		}, SCPTimeout)
+		if err != nil {
+			return "", errors.Wrapf(err, "error copying script %v to host %v",
+				teardownScriptName, targetHost.Id)
+		}
+	}
+
+	if targetHost.Distro.Setup == "" {
+		return "", nil
+	}
+
+	setup, err := init.expandScript(targetHost.Distro.Setup)
+	if err != nil {
+		return "", errors.Wrapf(err, "error expanding script for host %s", targetHost.Id)
+	}
+
+	output := &util.CappedWriter{
+		Buffer:   &bytes.Buffer{},
+		MaxBytes: 1024 * 1024, // 1MB
+	}
+	err = util.RunFunctionWithTimeout(func() error {
+		return runner.RunCommand(targetHost, []string{"sh", "-s"}, strings.NewReader(setup), output, false)
+	}, time.Duration(SSHTimeoutSeconds)*time.Second)
+	if err != nil {
+		return output.String(), errors.Wrapf(err, "error running setup script on host %s", targetHost.Id)
+	}
+	return output.String(), nil
+}
+
 // copyScript writes a given script as file "name" to the target host. This works
 // by creating a local copy of the script on the runner's machine, scping it over
 // then removing the local copy.
@@ -7,6 +7,7 @@ import (
 	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
 )
 
 const SSHTimeout = time.Minute * 10
@@ -56,3 +57,36 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 	)
 	return sshCmdStd.String(), err
 }
+
+// RunSSHCommand executes a command on the remote host, returning its output and any
+// errors that occur. The command is stopped if it runs longer than the timeout.
+func RunSSHCommand(h *host.Host, cmdString string, sshOptions []string, timeout time.Duration) (string, error) {
+	hostInfo, err := util.ParseSSHInfo(h.Host)
+	if err != nil {
+		return "", err
+	}
+	user := h.Distro.User
+	if hostInfo.User != "" {
+		user = hostInfo.User
+	}
+
+	output := &util.CappedWriter{
+		Buffer:   &bytes.Buffer{},
+		MaxBytes: 1024 * 1024, // 1MB
+	}
+	cmd := &command.RemoteCommand{
+		CmdString:      cmdString,
+		Stdout:         output,
+		Stderr:         output,
+		RemoteHostName: hostInfo.Hostname,
+		User:           user,
+		Options:        append([]string{"-p", hostInfo.Port}, sshOptions...),
+		Background:     false,
+	}
+
+	err = util.RunFunctionWithTimeout(cmd.Run, timeout)
+	if err == util.ErrTimedOut {
+		grip.Warning(cmd.Stop())
+	}
+	return output.String(), err
+}
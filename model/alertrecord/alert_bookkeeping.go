@@ -19,6 +19,7 @@ var (
 	FirstTaskTypeFailureId = "first_tasktype_failure"
 	TaskFailTransitionId   = "task_transition_failure"
 	LastRevisionNotFound   = "last_revision_not_found"
+	PerfRegressionId       = "perf_regression"
 )
 
 // Host triggers
@@ -28,6 +29,8 @@ var (
 	SpawnHostTwelveHourWarning = "spawn_twelvehour"
 	SlowProvisionWarning       = "slow_provision"
 	ProvisionFailed            = "provision_failed"
+	HostQuarantined            = "host_quarantined"
+	HostReturnedToService      = "host_returned_to_service"
 )
 
 type AlertRecord struct {
@@ -35,6 +38,7 @@ type AlertRecord struct {
 	Type                string        `bson:"type"`
 	HostId              string        `bson:"host_id,omitempty"`
 	TaskId              string        `bson:"task_id,omitempty"`
+	TaskExecution       int           `bson:"execution"`
 	ProjectId           string        `bson:"project_id,omitempty"`
 	VersionId           string        `bson:"version_id,omitempty"`
 	TaskName            string        `bson:"task_name,omitempty"`
@@ -46,6 +50,7 @@ var (
 	IdKey                  = bsonutil.MustHaveTag(AlertRecord{}, "Id")
 	TypeKey                = bsonutil.MustHaveTag(AlertRecord{}, "Type")
 	TaskIdKey              = bsonutil.MustHaveTag(AlertRecord{}, "TaskId")
+	TaskExecutionKey       = bsonutil.MustHaveTag(AlertRecord{}, "TaskExecution")
 	HostIdKey              = bsonutil.MustHaveTag(AlertRecord{}, "HostId")
 	TaskNameKey            = bsonutil.MustHaveTag(AlertRecord{}, "TaskName")
 	VariantKey             = bsonutil.MustHaveTag(AlertRecord{}, "Variant")
@@ -106,6 +111,14 @@ func ByHostAlertRecordType(hostId, triggerId string) db.Q {
 	}).Limit(1)
 }
 
+func ByPerfRegressionInTask(taskId string, execution int) db.Q {
+	return db.Query(bson.M{
+		TypeKey:          PerfRegressionId,
+		TaskIdKey:        taskId,
+		TaskExecutionKey: execution,
+	}).Limit(1)
+}
+
 func ByLastRevNotFound(projectId, versionId string) db.Q {
 	return db.Query(bson.M{
 		TypeKey:      LastRevisionNotFound,
@@ -24,6 +24,12 @@ func ByTaskId(id string) db.Q {
 	return db.Query(bson.D{{TaskIdKey, id}})
 }
 
+// ByTaskIds returns a query for entries of any of the given tasks, sorted by
+// Task name
+func ByTaskIds(ids []string) db.Q {
+	return db.Query(bson.M{TaskIdKey: bson.M{"$in": ids}}).Sort([]string{TaskNameKey})
+}
+
 // ByBuildId returns all entries with the given Build Id, sorted by Task name
 func ByBuildId(id string) db.Q {
 	return db.Query(bson.D{{BuildIdKey, id}}).Sort([]string{TaskNameKey})
@@ -2,11 +2,13 @@ package model
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/pkg/errors"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -80,8 +82,8 @@ func AuditHostTaskConsistency() ([]HostTaskInconsistency, error) {
 // running task fields, all running (or dispatched) tasks, and the hosts in those tasks'
 // host id field. Returns a mapping of host Ids to task Ids and task Ids to host Ids,
 // representing both directions of the relationship.
-func loadHostTaskMapping() (map[string]string, map[string]string, error) {
-	hostToTask := map[string]string{}
+func loadHostTaskMapping() (map[string][]string, map[string]string, error) {
+	hostToTask := map[string][]string{}
 	hostTaskIds := []string{}
 	taskToHost := map[string]string{}
 	taskHostIds := []string{}
@@ -94,7 +96,7 @@ func loadHostTaskMapping() (map[string]string, map[string]string, error) {
 	}
 
 	for _, h := range runningHosts {
-		hostTaskIds = append(hostTaskIds, h.RunningTask)
+		hostTaskIds = append(hostTaskIds, h.RunningTasks()...)
 	}
 	hostsTasks, err := task.Find(task.ByIds(hostTaskIds))
 	if err != nil {
@@ -118,9 +120,9 @@ func loadHostTaskMapping() (map[string]string, map[string]string, error) {
 
 	// we only want to have running hosts that are not empty.
 	for _, h := range append(runningHosts, tasksHosts...) {
-		// if the running task is empty don't add it to the map
-		if h.RunningTask != "" {
-			hostToTask[h.Id] = h.RunningTask
+		// if there are no running tasks don't add it to the map
+		if tasks := h.RunningTasks(); len(tasks) > 0 {
+			hostToTask[h.Id] = tasks
 		}
 	}
 
@@ -128,32 +130,35 @@ func loadHostTaskMapping() (map[string]string, map[string]string, error) {
 }
 
 // auditHostMapping takes a mapping of hosts->tasks and tasks->hosts and
-// returns descriptions of any inconsistencies.
-func auditHostTaskMapping(hostToTask, taskToHost map[string]string) []HostTaskInconsistency {
+// returns descriptions of any inconsistencies. Hosts with more than one task
+// slot map to all of the tasks running in their slots.
+func auditHostTaskMapping(hostToTask map[string][]string, taskToHost map[string]string) []HostTaskInconsistency {
 	found := []HostTaskInconsistency{}
 	// cases where a host thinks its running a task that it isn't
-	for h, t := range hostToTask {
-		cachedTask, ok := taskToHost[t]
-		if !ok {
-			// host thinks it is running a task that does not exist
-			found = append(found, HostTaskInconsistency{
-				Host:          h,
-				HostTaskCache: t,
-			})
-		} else {
-			if cachedTask != h {
+	for h, tasks := range hostToTask {
+		for _, t := range tasks {
+			cachedTask, ok := taskToHost[t]
+			if !ok {
+				// host thinks it is running a task that does not exist
 				found = append(found, HostTaskInconsistency{
 					Host:          h,
 					HostTaskCache: t,
-					Task:          t,
-					TaskHostCache: cachedTask,
 				})
+			} else {
+				if cachedTask != h {
+					found = append(found, HostTaskInconsistency{
+						Host:          h,
+						HostTaskCache: t,
+						Task:          t,
+						TaskHostCache: cachedTask,
+					})
+				}
 			}
 		}
 	}
 	// cases where a task thinks it is running on a host that isnt running it
 	for t, h := range taskToHost {
-		cachedHost, ok := hostToTask[h]
+		cachedTasks, ok := hostToTask[h]
 		if !ok {
 			// task thinks it is running on a host that does not exist
 			found = append(found, HostTaskInconsistency{
@@ -161,12 +166,12 @@ func auditHostTaskMapping(hostToTask, taskToHost map[string]string) []HostTaskIn
 				TaskHostCache: h,
 			})
 		} else {
-			if cachedHost != t {
+			if !util.SliceContains(cachedTasks, t) {
 				found = append(found, HostTaskInconsistency{
 					Task:          t,
 					TaskHostCache: h,
 					Host:          h,
-					HostTaskCache: cachedHost,
+					HostTaskCache: strings.Join(cachedTasks, ","),
 				})
 			}
 		}
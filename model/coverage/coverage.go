@@ -0,0 +1,208 @@
+// Package coverage stores the line coverage tasks attach, merges it into
+// coverage of whole versions, and works out how much of a patch's changes
+// its tests covered.
+package coverage
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+const (
+	TaskCollection        = "task_coverage"
+	VersionCollection     = "version_coverage"
+	VersionFileCollection = "version_file_coverage"
+)
+
+// FileCoverage is the line coverage of one source file. Lines that weren't
+// instrumented, like comments and declarations, are in neither list.
+type FileCoverage struct {
+	File      string `json:"file" bson:"file"`
+	Covered   []int  `json:"covered" bson:"covered"`
+	Uncovered []int  `json:"uncovered" bson:"uncovered"`
+}
+
+// Summary counts the covered and instrumented lines of some set of files.
+type Summary struct {
+	Covered int `json:"covered" bson:"covered"`
+	Total   int `json:"total" bson:"total"`
+}
+
+// Percent returns the percentage of the lines that are covered, or zero if
+// there aren't any.
+func (s Summary) Percent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return 100 * float64(s.Covered) / float64(s.Total)
+}
+
+// PackageSummary is the coverage of the files in one directory.
+type PackageSummary struct {
+	Package string  `json:"package" bson:"package"`
+	Summary Summary `json:"summary" bson:"summary"`
+}
+
+// TaskCoverage is the line coverage attached by a task. Only the coverage of
+// the task's latest execution is kept.
+type TaskCoverage struct {
+	Id         string         `json:"id" bson:"_id"`
+	Execution  int            `json:"execution" bson:"execution"`
+	VersionId  string         `json:"version_id" bson:"version_id"`
+	ProjectId  string         `json:"project_id" bson:"project_id"`
+	Variant    string         `json:"variant" bson:"variant"`
+	TaskName   string         `json:"task_name" bson:"task_name"`
+	UpdateTime time.Time      `json:"update_time" bson:"update_time"`
+	Files      []FileCoverage `json:"files" bson:"files"`
+}
+
+// VersionCoverage is the coverage of all of a version's tasks merged
+// together, a line being covered if any task covered it.
+type VersionCoverage struct {
+	Id                  string `json:"id" bson:"_id"`
+	ProjectId           string `json:"project_id" bson:"project_id"`
+	Revision            string `json:"revision" bson:"revision"`
+	RevisionOrderNumber int    `json:"order" bson:"order"`
+	Mainline            bool   `json:"mainline" bson:"mainline"`
+	// BaseOrder is the order of the mainline version the version's coverage
+	// is compared to: the version itself, or the one a patch was made against.
+	BaseOrder  int       `json:"base_order" bson:"base_order"`
+	UpdateTime time.Time `json:"update_time" bson:"update_time"`
+	// Files are stored apart from the version's totals, as
+	// VersionFileCoverage, and are only loaded when asked for.
+	Files    []FileCoverage   `json:"files,omitempty" bson:"-"`
+	Total    Summary          `json:"total" bson:"total"`
+	Packages []PackageSummary `json:"packages" bson:"packages"`
+	// Patch is the coverage of the lines a patch changed, for patch versions.
+	Patch *DiffCoverage `json:"patch,omitempty" bson:"patch,omitempty"`
+
+	// Attached counts the times tasks have attached coverage to the
+	// version, and RolledUp is the count the coverage was merged at, so a
+	// merge never overwrites one that saw more of the tasks' coverage.
+	Attached int `json:"-" bson:"attached"`
+	RolledUp int `json:"-" bson:"rolled_up"`
+}
+
+// VersionFileCoverage is the merged coverage of one of a version's files.
+type VersionFileCoverage struct {
+	Id           string `json:"id" bson:"_id"`
+	VersionId    string `json:"version_id" bson:"version_id"`
+	FileCoverage `bson:",inline"`
+	// RolledUp is the count of attachments the version's coverage was
+	// merged at, as for VersionCoverage.
+	RolledUp int `json:"-" bson:"rolled_up"`
+}
+
+// NewVersionFileCoverage returns the stored coverage of the version's file.
+func NewVersionFileCoverage(versionId string, file FileCoverage, rolledUp int) *VersionFileCoverage {
+	return &VersionFileCoverage{
+		Id:           versionId + "/" + file.File,
+		VersionId:    versionId,
+		FileCoverage: file,
+		RolledUp:     rolledUp,
+	}
+}
+
+// NewTaskCoverage returns empty coverage for the task's current execution.
+func NewTaskCoverage(t *task.Task) *TaskCoverage {
+	return &TaskCoverage{
+		Id:        t.Id,
+		Execution: t.Execution,
+		VersionId: t.Version,
+		ProjectId: t.Project,
+		Variant:   t.BuildVariant,
+		TaskName:  t.DisplayName,
+	}
+}
+
+// IsMainline returns whether a version with the requester is a mainline
+// commit rather than a patch.
+func IsMainline(requester string) bool {
+	return requester == evergreen.RepotrackerVersionRequester
+}
+
+// MergeFiles combines coverage of the same files, so that a line is covered
+// if it was covered in any of them. The files are returned sorted by name.
+func MergeFiles(files ...[]FileCoverage) []FileCoverage {
+	lines := map[string]map[int]bool{}
+	for _, list := range files {
+		for _, file := range list {
+			fileLines, ok := lines[file.File]
+			if !ok {
+				fileLines = map[int]bool{}
+				lines[file.File] = fileLines
+			}
+			for _, line := range file.Uncovered {
+				if _, ok := fileLines[line]; !ok {
+					fileLines[line] = false
+				}
+			}
+			for _, line := range file.Covered {
+				fileLines[line] = true
+			}
+		}
+	}
+
+	merged := make([]FileCoverage, 0, len(lines))
+	for name, fileLines := range lines {
+		merged = append(merged, NewFileCoverage(name, fileLines))
+	}
+	sort.Sort(filesByName(merged))
+	return merged
+}
+
+// NewFileCoverage returns the coverage of a file from whether each of its
+// instrumented lines was covered.
+func NewFileCoverage(name string, lines map[int]bool) FileCoverage {
+	file := FileCoverage{File: name, Covered: []int{}, Uncovered: []int{}}
+	for line, covered := range lines {
+		if covered {
+			file.Covered = append(file.Covered, line)
+		} else {
+			file.Uncovered = append(file.Uncovered, line)
+		}
+	}
+	sort.Ints(file.Covered)
+	sort.Ints(file.Uncovered)
+	return file
+}
+
+// Summarize returns the total coverage of the files, and the coverage of
+// each directory they're in, sorted by directory.
+func Summarize(files []FileCoverage) (Summary, []PackageSummary) {
+	total := Summary{}
+	byPackage := map[string]Summary{}
+	for _, file := range files {
+		pkg := path.Dir(file.File)
+		summary := byPackage[pkg]
+		summary.Covered += len(file.Covered)
+		summary.Total += len(file.Covered) + len(file.Uncovered)
+		byPackage[pkg] = summary
+
+		total.Covered += len(file.Covered)
+		total.Total += len(file.Covered) + len(file.Uncovered)
+	}
+
+	packages := make([]PackageSummary, 0, len(byPackage))
+	for pkg, summary := range byPackage {
+		packages = append(packages, PackageSummary{Package: pkg, Summary: summary})
+	}
+	sort.Sort(packagesByName(packages))
+	return total, packages
+}
+
+type filesByName []FileCoverage
+
+func (fs filesByName) Len() int           { return len(fs) }
+func (fs filesByName) Swap(i, j int)      { fs[i], fs[j] = fs[j], fs[i] }
+func (fs filesByName) Less(i, j int) bool { return fs[i].File < fs[j].File }
+
+type packagesByName []PackageSummary
+
+func (ps packagesByName) Len() int           { return len(ps) }
+func (ps packagesByName) Swap(i, j int)      { ps[i], ps[j] = ps[j], ps[i] }
+func (ps packagesByName) Less(i, j int) bool { return ps[i].Package < ps[j].Package }
@@ -0,0 +1,124 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	merged := MergeFiles(
+		[]FileCoverage{
+			{File: "pkg/b.go", Covered: []int{1, 2}, Uncovered: []int{3, 4}},
+			{File: "pkg/a.go", Covered: []int{}, Uncovered: []int{10}},
+		},
+		[]FileCoverage{
+			{File: "pkg/b.go", Covered: []int{4}, Uncovered: []int{1, 5}},
+		},
+	)
+
+	require.Len(t, merged, 2)
+	assert.Equal("pkg/a.go", merged[0].File)
+	assert.Equal([]int{}, merged[0].Covered)
+	assert.Equal([]int{10}, merged[0].Uncovered)
+
+	// a line covered by either is covered
+	assert.Equal("pkg/b.go", merged[1].File)
+	assert.Equal([]int{1, 2, 4}, merged[1].Covered)
+	assert.Equal([]int{3, 5}, merged[1].Uncovered)
+}
+
+func TestSummarize(t *testing.T) {
+	assert := assert.New(t)
+
+	total, packages := Summarize([]FileCoverage{
+		{File: "a/x.go", Covered: []int{1, 2, 3}, Uncovered: []int{4}},
+		{File: "a/y.go", Covered: []int{1}, Uncovered: []int{2, 3, 4}},
+		{File: "b/z.go", Covered: []int{}, Uncovered: []int{7, 8}},
+	})
+
+	assert.Equal(Summary{Covered: 4, Total: 10}, total)
+	assert.Equal(40.0, total.Percent())
+	assert.Equal([]PackageSummary{
+		{Package: "a", Summary: Summary{Covered: 4, Total: 8}},
+		{Package: "b", Summary: Summary{Covered: 0, Total: 2}},
+	}, packages)
+
+	assert.Equal(0.0, Summary{}.Percent())
+}
+
+const testDiff = `diff --git a/model/foo.go b/model/foo.go
+index 1234567..89abcde 100644
+--- a/model/foo.go
++++ b/model/foo.go
+@@ -1,4 +1,6 @@
+ package model
+-func a() {}
++func a() {
++	b()
++}
+
+ func c() {}
+@@ -20,3 +21,3 @@ func d() {
+ 	e()
+-	f()
++	g()
+ }
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-var x = 1
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+\ No newline at end of file
+`
+
+func TestAddedLines(t *testing.T) {
+	assert.Equal(t, map[string][]int{
+		"model/foo.go": {2, 3, 4, 22},
+		"README.md":    {1},
+	}, AddedLines(testDiff))
+
+	// lines in a hunk that look like file headers are still added or
+	// removed lines
+	assert.Equal(t, map[string][]int{
+		"notes.md": {2, 3, 11},
+	}, AddedLines(`diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1,3 +1,4 @@
+ # Notes
+--- old rule
++++ new rule
++@@ new section @@
+ end
+@@ -10 +11 @@
+-diff old
++diff new
+`))
+}
+
+func TestComputeDiffCoverage(t *testing.T) {
+	assert := assert.New(t)
+
+	diffCoverage := ComputeDiffCoverage(testDiff, []FileCoverage{
+		// paths in reports often have a prefix the diff doesn't
+		{File: "github.com/evergreen-ci/evergreen/model/foo.go", Covered: []int{3, 7}, Uncovered: []int{22}},
+		{File: "github.com/evergreen-ci/evergreen/other/bar.go", Covered: []int{2}},
+	})
+
+	// uninstrumented lines like 2 and 4 aren't counted
+	assert.Equal(Summary{Covered: 1, Total: 2}, diffCoverage.Total)
+	require.Len(t, diffCoverage.Files, 1)
+	assert.Equal(FileCoverage{File: "model/foo.go", Covered: []int{3}, Uncovered: []int{22}}, diffCoverage.Files[0])
+}
@@ -0,0 +1,343 @@
+package coverage
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DefaultTrendLength is how many mainline versions coverage trends go back.
+const DefaultTrendLength = 20
+
+var (
+	// BSON fields for the task coverage struct
+	TaskIdKey         = bsonutil.MustHaveTag(TaskCoverage{}, "Id")
+	TaskExecutionKey  = bsonutil.MustHaveTag(TaskCoverage{}, "Execution")
+	TaskVersionIdKey  = bsonutil.MustHaveTag(TaskCoverage{}, "VersionId")
+	TaskProjectIdKey  = bsonutil.MustHaveTag(TaskCoverage{}, "ProjectId")
+	TaskVariantKey    = bsonutil.MustHaveTag(TaskCoverage{}, "Variant")
+	TaskTaskNameKey   = bsonutil.MustHaveTag(TaskCoverage{}, "TaskName")
+	TaskUpdateTimeKey = bsonutil.MustHaveTag(TaskCoverage{}, "UpdateTime")
+	TaskFilesKey      = bsonutil.MustHaveTag(TaskCoverage{}, "Files")
+
+	// BSON fields for the version coverage struct
+	VersionIdKey                  = bsonutil.MustHaveTag(VersionCoverage{}, "Id")
+	VersionProjectIdKey           = bsonutil.MustHaveTag(VersionCoverage{}, "ProjectId")
+	VersionRevisionKey            = bsonutil.MustHaveTag(VersionCoverage{}, "Revision")
+	VersionRevisionOrderNumberKey = bsonutil.MustHaveTag(VersionCoverage{}, "RevisionOrderNumber")
+	VersionMainlineKey            = bsonutil.MustHaveTag(VersionCoverage{}, "Mainline")
+	VersionBaseOrderKey           = bsonutil.MustHaveTag(VersionCoverage{}, "BaseOrder")
+	VersionUpdateTimeKey          = bsonutil.MustHaveTag(VersionCoverage{}, "UpdateTime")
+	VersionTotalKey               = bsonutil.MustHaveTag(VersionCoverage{}, "Total")
+	VersionPackagesKey            = bsonutil.MustHaveTag(VersionCoverage{}, "Packages")
+	VersionPatchKey               = bsonutil.MustHaveTag(VersionCoverage{}, "Patch")
+	VersionAttachedKey            = bsonutil.MustHaveTag(VersionCoverage{}, "Attached")
+	VersionRolledUpKey            = bsonutil.MustHaveTag(VersionCoverage{}, "RolledUp")
+
+	// BSON fields for the version file coverage struct
+	VersionFileIdKey        = bsonutil.MustHaveTag(VersionFileCoverage{}, "Id")
+	VersionFileVersionIdKey = bsonutil.MustHaveTag(VersionFileCoverage{}, "VersionId")
+	VersionFileFileKey      = bsonutil.MustHaveTag(FileCoverage{}, "File")
+	VersionFileRolledUpKey  = bsonutil.MustHaveTag(VersionFileCoverage{}, "RolledUp")
+)
+
+// === Queries ===
+
+// ByTaskId returns a query for the coverage a task attached.
+func ByTaskId(taskId string) db.Q {
+	return db.Query(bson.M{TaskIdKey: taskId})
+}
+
+// ByVersionId returns a query for the coverage attached by a version's tasks.
+func ByVersionId(versionId string) db.Q {
+	return db.Query(bson.M{TaskVersionIdKey: versionId})
+}
+
+// VersionById returns a query for the merged coverage of a version, once
+// it's been merged.
+func VersionById(versionId string) db.Q {
+	return db.Query(bson.M{
+		VersionIdKey:       versionId,
+		VersionRolledUpKey: bson.M{"$gt": 0},
+	})
+}
+
+// ByMainlineTrend returns a query for the merged coverage of up to limit
+// mainline versions of the project, up to and including the given order,
+// most recent first.
+func ByMainlineTrend(projectId string, order, limit int) db.Q {
+	return db.Query(bson.M{
+		VersionProjectIdKey:           projectId,
+		VersionMainlineKey:            true,
+		VersionRevisionOrderNumberKey: bson.M{"$lte": order},
+	}).Sort([]string{"-" + VersionRevisionOrderNumberKey}).
+		Limit(limit)
+}
+
+// FilesByVersionId returns a query for the merged coverage of each of a
+// version's files, sorted by file.
+func FilesByVersionId(versionId string) db.Q {
+	return db.Query(bson.M{VersionFileVersionIdKey: versionId}).
+		Sort([]string{VersionFileFileKey})
+}
+
+// === DB Logic ===
+
+// FindOneTask gets one TaskCoverage for the given query.
+func FindOneTask(query db.Q) (*TaskCoverage, error) {
+	tc := &TaskCoverage{}
+	err := db.FindOneQ(TaskCollection, query, tc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return tc, err
+}
+
+// FindTasks gets every TaskCoverage for the given query.
+func FindTasks(query db.Q) ([]TaskCoverage, error) {
+	tcs := []TaskCoverage{}
+	err := db.FindAllQ(TaskCollection, query, &tcs)
+	return tcs, err
+}
+
+// FindOneVersion gets one VersionCoverage for the given query.
+func FindOneVersion(query db.Q) (*VersionCoverage, error) {
+	vc := &VersionCoverage{}
+	err := db.FindOneQ(VersionCollection, query, vc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return vc, err
+}
+
+// FindVersions gets every VersionCoverage for the given query.
+func FindVersions(query db.Q) ([]VersionCoverage, error) {
+	vcs := []VersionCoverage{}
+	err := db.FindAllQ(VersionCollection, query, &vcs)
+	return vcs, err
+}
+
+// FindVersionFiles gets the merged coverage of each of a version's files,
+// sorted by file.
+func FindVersionFiles(versionId string) ([]FileCoverage, error) {
+	vfcs := []VersionFileCoverage{}
+	if err := db.FindAllQ(VersionFileCollection, FilesByVersionId(versionId), &vfcs); err != nil {
+		return nil, err
+	}
+	files := make([]FileCoverage, 0, len(vfcs))
+	for _, vfc := range vfcs {
+		files = append(files, vfc.FileCoverage)
+	}
+	return files, nil
+}
+
+// FindTrend returns the coverage of the mainline versions leading up to the
+// version, or to the version a patch was made against, most recent first.
+func FindTrend(vc *VersionCoverage, length int) ([]VersionCoverage, error) {
+	if vc.BaseOrder == 0 {
+		return []VersionCoverage{}, nil
+	}
+	return FindVersions(ByMainlineTrend(vc.ProjectId, vc.BaseOrder, length))
+}
+
+// AttachCoverage merges files into the coverage the task has attached, and
+// updates the coverage of the task's version. Coverage from earlier
+// executions of the task is replaced.
+func AttachCoverage(t *task.Task, files []FileCoverage) (*VersionCoverage, error) {
+	tc, err := FindOneTask(ByTaskId(t.Id))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding coverage for task %s", t.Id)
+	}
+	if tc == nil || tc.Execution != t.Execution {
+		tc = NewTaskCoverage(t)
+	}
+
+	tc.Files = MergeFiles(tc.Files, files)
+	tc.UpdateTime = time.Now()
+	if err = tc.Upsert(); err != nil {
+		return nil, errors.Wrapf(err, "problem saving coverage for task %s", t.Id)
+	}
+
+	return UpdateVersionCoverage(t.Version)
+}
+
+// UpdateVersionCoverage merges the coverage attached by all of a version's
+// tasks, and, for patches, works out the coverage of the lines they changed.
+// If tasks attach coverage while it runs, the merge that saw the most of it
+// is the one that's kept, and returned.
+func UpdateVersionCoverage(versionId string) (*VersionCoverage, error) {
+	attached, err := markAttached(versionId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem marking coverage attached to version %s", versionId)
+	}
+
+	v, err := version.FindOne(version.ById(versionId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding version %s", versionId)
+	}
+	if v == nil {
+		return nil, errors.Errorf("version %s not found", versionId)
+	}
+
+	tcs, err := FindTasks(ByVersionId(versionId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding task coverage for version %s", versionId)
+	}
+	files := make([][]FileCoverage, 0, len(tcs))
+	for _, tc := range tcs {
+		files = append(files, tc.Files)
+	}
+
+	vc := &VersionCoverage{
+		Id:                  v.Id,
+		ProjectId:           v.Identifier,
+		Revision:            v.Revision,
+		RevisionOrderNumber: v.RevisionOrderNumber,
+		Mainline:            IsMainline(v.Requester),
+		UpdateTime:          time.Now(),
+		Files:               MergeFiles(files...),
+		Attached:            attached,
+		RolledUp:            attached,
+	}
+	vc.Total, vc.Packages = Summarize(vc.Files)
+
+	if vc.Mainline {
+		vc.BaseOrder = vc.RevisionOrderNumber
+	} else if err = vc.addPatchCoverage(); err != nil {
+		return nil, err
+	}
+
+	saved, err := vc.saveRollUp()
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem saving coverage for version %s", versionId)
+	}
+	if !saved {
+		// a merge that started after this one has already been saved;
+		// callers of this only need its totals
+		return FindOneVersion(VersionById(versionId))
+	}
+	return vc, nil
+}
+
+// markAttached counts another attachment of coverage to the version, and
+// returns the new count.
+func markAttached(versionId string) (int, error) {
+	change := mgo.Change{
+		Update:    bson.M{"$inc": bson.M{VersionAttachedKey: 1}},
+		ReturnNew: true,
+		Upsert:    true,
+	}
+	vc := &VersionCoverage{}
+	_, err := db.FindAndModify(VersionCollection, bson.M{VersionIdKey: versionId}, nil, change, vc)
+	if mgo.IsDup(err) {
+		// another attachment created the version's coverage first
+		_, err = db.FindAndModify(VersionCollection, bson.M{VersionIdKey: versionId}, nil, change, vc)
+	}
+	return vc.Attached, err
+}
+
+// addPatchCoverage works out the coverage of the lines changed by the patch
+// that created the version, and finds the version it was made against.
+func (vc *VersionCoverage) addPatchCoverage() error {
+	p, err := patch.FindOne(patch.ByVersion(vc.Id))
+	if err != nil {
+		return errors.Wrapf(err, "problem finding patch for version %s", vc.Id)
+	}
+	if p == nil {
+		return nil
+	}
+	if err = p.FetchPatchFiles(); err != nil {
+		return errors.Wrapf(err, "problem fetching diffs for patch %s", p.Id.Hex())
+	}
+
+	// coverage is only attached for the project's own code, not its modules
+	for _, modulePatch := range p.Patches {
+		if modulePatch.ModuleName == "" {
+			vc.Patch = ComputeDiffCoverage(modulePatch.PatchSet.Patch, vc.Files)
+			break
+		}
+	}
+
+	base, err := version.FindOne(version.ByProjectIdAndRevision(p.Project, p.Githash))
+	if err != nil {
+		return errors.Wrapf(err, "problem finding base version for patch %s", p.Id.Hex())
+	}
+	if base != nil {
+		vc.BaseOrder = base.RevisionOrderNumber
+	}
+	return nil
+}
+
+// Upsert saves the task's coverage, replacing what was saved before.
+func (tc *TaskCoverage) Upsert() error {
+	_, err := db.Upsert(
+		TaskCollection,
+		bson.M{
+			TaskIdKey: tc.Id,
+		},
+		tc,
+	)
+	return err
+}
+
+// saveRollUp saves the version's merged coverage, unless coverage merged
+// after more attachments has already been saved. Its files are saved first,
+// each only over coverage merged after fewer attachments, and files it no
+// longer has are removed. It returns whether the version's totals were saved.
+func (vc *VersionCoverage) saveRollUp() (bool, error) {
+	for _, file := range vc.Files {
+		vfc := NewVersionFileCoverage(vc.Id, file, vc.RolledUp)
+		_, err := db.Upsert(
+			VersionFileCollection,
+			bson.M{
+				VersionFileIdKey:       vfc.Id,
+				VersionFileRolledUpKey: bson.M{"$lt": vfc.RolledUp},
+			},
+			vfc,
+		)
+		// the upsert fails on its duplicate id if the file is newer
+		if err != nil && !mgo.IsDup(err) {
+			return false, errors.Wrapf(err, "problem saving coverage for file %s", file.File)
+		}
+	}
+	err := db.RemoveAll(VersionFileCollection, bson.M{
+		VersionFileVersionIdKey: vc.Id,
+		VersionFileRolledUpKey:  bson.M{"$lt": vc.RolledUp},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "problem removing coverage for old files")
+	}
+
+	err = db.Update(
+		VersionCollection,
+		bson.M{
+			VersionIdKey:       vc.Id,
+			VersionRolledUpKey: bson.M{"$lt": vc.RolledUp},
+		},
+		bson.M{
+			"$set": bson.M{
+				VersionProjectIdKey:           vc.ProjectId,
+				VersionRevisionKey:            vc.Revision,
+				VersionRevisionOrderNumberKey: vc.RevisionOrderNumber,
+				VersionMainlineKey:            vc.Mainline,
+				VersionBaseOrderKey:           vc.BaseOrder,
+				VersionUpdateTimeKey:          vc.UpdateTime,
+				VersionTotalKey:               vc.Total,
+				VersionPackagesKey:            vc.Packages,
+				VersionPatchKey:               vc.Patch,
+				VersionRolledUpKey:            vc.RolledUp,
+			},
+		},
+	)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,149 @@
+package coverage
+
+import (
+	"bufio"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the header of a hunk in a unified diff, saving the
+// number of old lines it has, the line its new side starts at, and the
+// number of new lines it has. Omitted counts are 1.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// DiffCoverage is the coverage of the lines a patch added or changed. Changed
+// lines that weren't instrumented aren't counted.
+type DiffCoverage struct {
+	Total Summary        `json:"total" bson:"total"`
+	Files []FileCoverage `json:"files" bson:"files"`
+}
+
+// AddedLines returns the line numbers, in the new version of each file, of
+// the lines a unified diff adds or changes. Deleted files aren't included.
+// The counts in each hunk's header say where it ends, so added and removed
+// lines that look like file headers are read as part of the hunk.
+func AddedLines(diff string) map[string][]int {
+	added := map[string][]int{}
+	file := ""
+	line := 0
+	oldLeft, newLeft := 0, 0
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if file != "" {
+					added[file] = append(added[file], line)
+				}
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, "\\"):
+			default:
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "diff "):
+			file = ""
+		case strings.HasPrefix(text, "+++ "):
+			file = diffPath(strings.TrimPrefix(text, "+++ "))
+		case strings.HasPrefix(text, "@@ "):
+			m := hunkHeader.FindStringSubmatch(text)
+			if m == nil {
+				continue
+			}
+			oldLeft = hunkCount(m[1])
+			line, _ = strconv.Atoi(m[2])
+			newLeft = hunkCount(m[3])
+		}
+	}
+	return added
+}
+
+// hunkCount returns the number of lines in a hunk header's count, which is 1
+// if it was omitted.
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// diffPath returns the path of a file in the header of a diff, without the
+// b/ prefix git adds, or "" for /dev/null.
+func diffPath(header string) string {
+	// some tools add a timestamp after a tab
+	if i := strings.Index(header, "\t"); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// ComputeDiffCoverage returns the coverage of the lines a diff added. The
+// paths in coverage reports are often absolute or import paths, so a file
+// in the diff matches the coverage of any file whose path ends with its own.
+func ComputeDiffCoverage(diff string, files []FileCoverage) *DiffCoverage {
+	result := &DiffCoverage{Files: []FileCoverage{}}
+	for name, lines := range AddedLines(diff) {
+		covered := findFile(files, name)
+		if covered == nil {
+			continue
+		}
+
+		instrumented := map[int]bool{}
+		for _, line := range covered.Uncovered {
+			instrumented[line] = false
+		}
+		for _, line := range covered.Covered {
+			instrumented[line] = true
+		}
+
+		changed := map[int]bool{}
+		for _, line := range lines {
+			if isCovered, ok := instrumented[line]; ok {
+				changed[line] = isCovered
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		fileCoverage := NewFileCoverage(name, changed)
+		result.Total.Covered += len(fileCoverage.Covered)
+		result.Total.Total += len(changed)
+		result.Files = append(result.Files, fileCoverage)
+	}
+	sort.Sort(filesByName(result.Files))
+	return result
+}
+
+// findFile returns the coverage of the file at the path, preferring an exact
+// match to one that only ends with it.
+func findFile(files []FileCoverage, name string) *FileCoverage {
+	var match *FileCoverage
+	for i := range files {
+		if files[i].File == name {
+			return &files[i]
+		}
+		if match == nil && strings.HasSuffix(files[i].File, "/"+name) {
+			match = &files[i]
+		}
+	}
+	return match
+}
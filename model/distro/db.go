@@ -11,6 +11,10 @@ var (
 	IdKey               = bsonutil.MustHaveTag(Distro{}, "Id")
 	ArchKey             = bsonutil.MustHaveTag(Distro{}, "Arch")
 	PoolSizeKey         = bsonutil.MustHaveTag(Distro{}, "PoolSize")
+	TaskSlotsKey        = bsonutil.MustHaveTag(Distro{}, "TaskSlots")
+	TaskContainerKey    = bsonutil.MustHaveTag(Distro{}, "TaskContainer")
+	ResourceLimitsKey   = bsonutil.MustHaveTag(Distro{}, "ResourceLimits")
+	TaskCacheMBKey      = bsonutil.MustHaveTag(Distro{}, "TaskCacheMB")
 	ProviderKey         = bsonutil.MustHaveTag(Distro{}, "Provider")
 	ProviderSettingsKey = bsonutil.MustHaveTag(Distro{}, "ProviderSettings")
 	SetupAsSudoKey      = bsonutil.MustHaveTag(Distro{}, "SetupAsSudo")
@@ -33,6 +33,77 @@ type Distro struct {
 
 	SpawnAllowed bool        `bson:"spawn_allowed" json:"spawn_allowed,omitempty" mapstructure:"spawn_allowed,omitempty"`
 	Expansions   []Expansion `bson:"expansions,omitempty" json:"expansions,omitempty" mapstructure:"expansions,omitempty"`
+
+	// TaskSlots is the number of tasks each host of the distro runs at
+	// once. Zero means one.
+	TaskSlots int `bson:"task_slots,omitempty" json:"task_slots,omitempty" mapstructure:"task_slots,omitempty"`
+
+	// TaskContainer, if set, makes the agent run each task's commands in a
+	// fresh container.
+	TaskContainer *TaskContainer `bson:"task_container,omitempty" json:"task_container,omitempty" mapstructure:"task_container,omitempty"`
+
+	// ResourceLimits, if set, limits the resources each task uses on hosts
+	// of the distro. Build variants can override them.
+	ResourceLimits *ResourceLimits `bson:"resource_limits,omitempty" json:"resource_limits,omitempty" mapstructure:"resource_limits,omitempty"`
+
+	// TaskCacheMB is the most disk space the caches that tasks keep on hosts
+	// of the distro can take up, in megabytes. Zero means the default.
+	TaskCacheMB int `bson:"task_cache_mb,omitempty" json:"task_cache_mb,omitempty" mapstructure:"task_cache_mb,omitempty"`
+}
+
+// TaskContainer describes the container that each task runs in on hosts of
+// a distro.
+type TaskContainer struct {
+	// Image is the image the container is created from.
+	Image string `bson:"image" json:"image" mapstructure:"image"`
+	// Runtime is the command that manages containers, which must support
+	// the docker command line. Defaults to docker.
+	Runtime string `bson:"runtime,omitempty" json:"runtime,omitempty" mapstructure:"runtime,omitempty"`
+	// Options are extra options passed to the runtime when creating the
+	// container, e.g. to limit its resources.
+	Options []string `bson:"options,omitempty" json:"options,omitempty" mapstructure:"options,omitempty"`
+}
+
+// ResourceLimits are limits on the resources a task can use, which the agent
+// enforces on Linux. A zero value means no limit.
+type ResourceLimits struct {
+	// CPUs is the number of CPUs worth of time the task can use.
+	CPUs float64 `bson:"cpus,omitempty" json:"cpus,omitempty" mapstructure:"cpus,omitempty" yaml:"cpus,omitempty"`
+	// MemoryMB is the memory the task can use, in megabytes.
+	MemoryMB int `bson:"memory_mb,omitempty" json:"memory_mb,omitempty" mapstructure:"memory_mb,omitempty" yaml:"memory_mb,omitempty"`
+	// Pids is the number of processes and threads the task can have at once.
+	Pids int `bson:"pids,omitempty" json:"pids,omitempty" mapstructure:"pids,omitempty" yaml:"pids,omitempty"`
+}
+
+// Validate returns an error if any of the limits are negative.
+func (l *ResourceLimits) Validate() error {
+	if l.CPUs < 0 || l.MemoryMB < 0 || l.Pids < 0 {
+		return fmt.Errorf("resource limits cannot be negative")
+	}
+	return nil
+}
+
+// Merge returns the limits in l, overridden by any that are set in other.
+// Either may be nil.
+func (l *ResourceLimits) Merge(other *ResourceLimits) *ResourceLimits {
+	merged := ResourceLimits{}
+	if l != nil {
+		merged = *l
+	}
+	if other == nil {
+		return &merged
+	}
+
+	if other.CPUs != 0 {
+		merged.CPUs = other.CPUs
+	}
+	if other.MemoryMB != 0 {
+		merged.MemoryMB = other.MemoryMB
+	}
+	if other.Pids != 0 {
+		merged.Pids = other.Pids
+	}
+	return &merged
 }
 
 type ValidateFormat string
@@ -52,3 +123,11 @@ func (d *Distro) GenerateName() string {
 	return "evg_" + d.Id + "_" + time.Now().Format(NameTimeFormat) +
 		fmt.Sprintf("_%v", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
 }
+
+// Slots returns the number of tasks each host of the distro runs at once.
+func (d *Distro) Slots() int {
+	if d.TaskSlots < 1 {
+		return 1
+	}
+	return d.TaskSlots
+}
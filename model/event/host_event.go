@@ -23,6 +23,8 @@ const (
 	EventTaskFinished             = "HOST_TASK_FINISHED"
 	EventHostTeardown             = "HOST_TEARDOWN"
 	EventHostTerminatedExternally = "HOST_TERMINATED_EXTERNALLY"
+	EventHostQuarantined          = "HOST_QUARANTINED"
+	EventHostReturnedToService    = "HOST_RETURNED_TO_SERVICE"
 )
 
 // implements EventData
@@ -112,3 +114,11 @@ func LogHostTeardown(hostId, teardownLogs string, success bool, duration time.Du
 func LogMonitorOperation(hostId string, op string) {
 	LogHostEvent(hostId, EventHostMonitorFlag, HostEventData{MonitorOp: op})
 }
+
+func LogHostQuarantined(hostId string, reason string) {
+	LogHostEvent(hostId, EventHostQuarantined, HostEventData{Logs: reason})
+}
+
+func LogHostReturnedToService(hostId string) {
+	LogHostEvent(hostId, EventHostReturnedToService, HostEventData{})
+}
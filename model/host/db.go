@@ -28,6 +28,7 @@ var (
 	ProviderKey              = bsonutil.MustHaveTag(Host{}, "Provider")
 	ProvisionedKey           = bsonutil.MustHaveTag(Host{}, "Provisioned")
 	RunningTaskKey           = bsonutil.MustHaveTag(Host{}, "RunningTask")
+	SlotTasksKey             = bsonutil.MustHaveTag(Host{}, "SlotTasks")
 	PidKey                   = bsonutil.MustHaveTag(Host{}, "Pid")
 	TaskDispatchTimeKey      = bsonutil.MustHaveTag(Host{}, "TaskDispatchTime")
 	CreateTimeKey            = bsonutil.MustHaveTag(Host{}, "CreationTime")
@@ -38,12 +39,25 @@ var (
 	StatusKey                = bsonutil.MustHaveTag(Host{}, "Status")
 	AgentRevisionKey         = bsonutil.MustHaveTag(Host{}, "AgentRevision")
 	StartedByKey             = bsonutil.MustHaveTag(Host{}, "StartedBy")
+	ParentHostKey            = bsonutil.MustHaveTag(Host{}, "ParentHost")
 	InstanceTypeKey          = bsonutil.MustHaveTag(Host{}, "InstanceType")
+	InstanceTagsKey          = bsonutil.MustHaveTag(Host{}, "InstanceTags")
 	NotificationsKey         = bsonutil.MustHaveTag(Host{}, "Notifications")
 	UserDataKey              = bsonutil.MustHaveTag(Host{}, "UserData")
 	LastReachabilityCheckKey = bsonutil.MustHaveTag(Host{}, "LastReachabilityCheck")
 	LastCommunicationTimeKey = bsonutil.MustHaveTag(Host{}, "LastCommunicationTime")
 	UnreachableSinceKey      = bsonutil.MustHaveTag(Host{}, "UnreachableSince")
+	HealthCheckKey           = bsonutil.MustHaveTag(Host{}, "HealthCheck")
+	DrainActionKey           = bsonutil.MustHaveTag(Host{}, "DrainAction")
+)
+
+var (
+	// firstSlotTaskKey only exists on hosts with a task in a slot other than
+	// their first, so it can be used to query for hosts without any tasks
+	firstSlotTaskKey = SlotTasksKey + ".0"
+
+	// bson fields for the HealthCheckStatus struct
+	HealthCheckLastCheckKey = bsonutil.MustHaveTag(HealthCheckStatus{}, "LastCheck")
 )
 
 // === Queries ===
@@ -65,6 +79,15 @@ func ByUserWithRunningStatus(user string) db.Q {
 // (i.e. status != terminated).
 var IsRunning = db.Query(bson.M{StatusKey: bson.M{"$ne": evergreen.HostTerminated}})
 
+// ByUnterminatedProvider produces a query that returns all hosts from the given
+// cloud provider that have not been terminated, including spawn hosts.
+func ByUnterminatedProvider(provider string) db.Q {
+	return db.Query(bson.M{
+		ProviderKey: provider,
+		StatusKey:   bson.M{"$ne": evergreen.HostTerminated},
+	})
+}
+
 // IsLive is a query that returns all working hosts started by Evergreen
 var IsLive = db.Query(
 	bson.M{
@@ -88,9 +111,10 @@ func ByUserWithUnterminatedStatus(user string) db.Q {
 // Evergreen hosts without an assigned task.
 var IsAvailableAndFree = db.Query(
 	bson.M{
-		RunningTaskKey: bson.M{"$exists": false},
-		StatusKey:      evergreen.HostRunning,
-		StartedByKey:   evergreen.User,
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		StatusKey:        evergreen.HostRunning,
+		StartedByKey:     evergreen.User,
 	},
 ).Sort([]string{"-" + LTCTimeKey})
 
@@ -99,10 +123,11 @@ var IsAvailableAndFree = db.Query(
 func ByAvailableForDistro(d string) db.Q {
 	distroIdKey := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
 	return db.Query(bson.M{
-		distroIdKey:    d,
-		RunningTaskKey: bson.M{"$exists": false},
-		StatusKey:      evergreen.HostRunning,
-		StartedByKey:   evergreen.User,
+		distroIdKey:      d,
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		StatusKey:        evergreen.HostRunning,
+		StartedByKey:     evergreen.User,
 	}).Sort([]string{"-" + LTCTimeKey})
 }
 
@@ -110,9 +135,10 @@ func ByAvailableForDistro(d string) db.Q {
 // Evergreen hosts without an assigned task.
 var IsFree = db.Query(
 	bson.M{
-		RunningTaskKey: bson.M{"$exists": false},
-		StartedByKey:   evergreen.User,
-		StatusKey:      evergreen.HostRunning,
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		StartedByKey:     evergreen.User,
+		StatusKey:        evergreen.HostRunning,
 	},
 )
 
@@ -137,11 +163,12 @@ var IsUninitialized = db.Query(
 // are not doing work and were created before the given time.
 func ByUnproductiveSince(threshold time.Time) db.Q {
 	return db.Query(bson.M{
-		RunningTaskKey: bson.M{"$exists": false},
-		LTCKey:         "",
-		CreateTimeKey:  bson.M{"$lte": threshold},
-		StatusKey:      bson.M{"$ne": evergreen.HostTerminated},
-		StartedByKey:   evergreen.User,
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		LTCKey:           "",
+		CreateTimeKey:    bson.M{"$lte": threshold},
+		StatusKey:        bson.M{"$ne": evergreen.HostTerminated},
+		StartedByKey:     evergreen.User,
 	})
 }
 
@@ -157,7 +184,10 @@ var IsRunningAndSpawned = db.Query(
 // IsRunningTask is a query that returns all running hosts with a running task
 var IsRunningTask = db.Query(
 	bson.M{
-		RunningTaskKey: bson.M{"$exists": true},
+		"$or": []bson.M{
+			{RunningTaskKey: bson.M{"$exists": true}},
+			{firstSlotTaskKey: bson.M{"$exists": true}},
+		},
 	},
 )
 
@@ -165,18 +195,50 @@ var IsRunningTask = db.Query(
 // running task that are marked for decommissioning.
 var IsDecommissioned = db.Query(
 	bson.M{
-		RunningTaskKey: bson.M{"$exists": false},
-		StatusKey:      evergreen.HostDecommissioned},
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		StatusKey:        evergreen.HostDecommissioned},
 )
 
 // ByDistroId produces a query that returns all working hosts (not terminated and
 // not quarantined) of the given distro.
 func ByDistroId(distroId string) db.Q {
+	return db.Query(byDistroIdFilter(distroId))
+}
+
+// byDistroIdFilter is the filter of ByDistroId, for updates, which can't be
+// given a db.Q.
+func byDistroIdFilter(distroId string) bson.M {
 	dId := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
-	return db.Query(bson.M{
+	return bson.M{
 		dId:          distroId,
 		StartedByKey: evergreen.User,
 		StatusKey:    bson.M{"$in": evergreen.UphostStatus},
+	}
+}
+
+// byDistroIdRunningTasksFilter filters the hosts of ByDistroId down to those
+// running tasks if busy is true, or to those that aren't otherwise.
+func byDistroIdRunningTasksFilter(distroId string, busy bool) bson.M {
+	filter := byDistroIdFilter(distroId)
+	if busy {
+		filter["$or"] = []bson.M{
+			{RunningTaskKey: bson.M{"$exists": true}},
+			{firstSlotTaskKey: bson.M{"$exists": true}},
+		}
+	} else {
+		filter[RunningTaskKey] = bson.M{"$exists": false}
+		filter[firstSlotTaskKey] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+// ByLiveParentHosts produces a query that returns all unterminated hosts
+// running as containers on any of the given parent machines.
+func ByLiveParentHosts(parents []string) db.Q {
+	return db.Query(bson.M{
+		ParentHostKey: bson.M{"$in": parents},
+		StatusKey:     bson.M{"$ne": evergreen.HostTerminated},
 	})
 }
 
@@ -192,9 +254,15 @@ func ByIds(ids []string) db.Q {
 	})
 }
 
-// ByRunningTaskId returns a host running the task with the given id.
+// ByRunningTaskId returns a host running the task with the given id in any
+// of its slots.
 func ByRunningTaskId(taskId string) db.Q {
-	return db.Query(bson.D{{RunningTaskKey, taskId}})
+	return db.Query(bson.M{
+		"$or": []bson.M{
+			{RunningTaskKey: taskId},
+			{SlotTasksKey: taskId},
+		},
+	})
 }
 
 // ByDynamicWithinTime is a query that returns all dynamic hosts running between a certain time and another time.
@@ -225,9 +293,10 @@ var AllStatic = db.Query(
 // IsIdle is a query that returns all running Evergreen hosts with no task.
 var IsIdle = db.Query(
 	bson.M{
-		RunningTaskKey: bson.M{"$exists": false},
-		StatusKey:      evergreen.HostRunning,
-		StartedByKey:   evergreen.User,
+		RunningTaskKey:   bson.M{"$exists": false},
+		firstSlotTaskKey: bson.M{"$exists": false},
+		StatusKey:        evergreen.HostRunning,
+		StartedByKey:     evergreen.User,
 	},
 )
 
@@ -251,6 +320,7 @@ func ByNotMonitoredSince(threshold time.Time) db.Q {
 	return db.Query(bson.M{
 		"$and": []bson.M{
 			{RunningTaskKey: bson.M{"$exists": false}},
+			{firstSlotTaskKey: bson.M{"$exists": false}},
 			{StatusKey: bson.M{
 				"$in": []string{evergreen.HostRunning, evergreen.HostUnreachable},
 			}},
@@ -263,6 +333,23 @@ func ByNotMonitoredSince(threshold time.Time) db.Q {
 	})
 }
 
+// ByHealthCheckDue produces a query that returns the running, unreachable
+// and quarantined hosts of the given provider whose health has not been
+// checked since the threshold.
+func ByHealthCheckDue(provider string, threshold time.Time) db.Q {
+	lastCheckKey := HealthCheckKey + "." + HealthCheckLastCheckKey
+	return db.Query(bson.M{
+		ProviderKey: provider,
+		StatusKey: bson.M{
+			"$in": []string{evergreen.HostRunning, evergreen.HostUnreachable, evergreen.HostQuarantined},
+		},
+		"$or": []bson.M{
+			{lastCheckKey: bson.M{"$lte": threshold}},
+			{lastCheckKey: bson.M{"$exists": false}},
+		},
+	})
+}
+
 // ByExpiringBetween produces a query that returns  any user-spawned hosts
 // that will expire between the specified times.
 func ByExpiringBetween(lowerBound time.Time, upperBound time.Time) db.Q {
@@ -303,11 +390,13 @@ var IsProvisioningFailure = db.Query(bson.D{{StatusKey, evergreen.HostProvisionF
 
 // ByRunningWithTimedOutLCT returns hosts that are running and either have no Last Commmunication Time
 // or have one that exists that is greater than the MaxLTCInterval duration away from the current time.
+// Hosts whose agent exits once they're drained are left out.
 func ByRunningWithTimedOutLCT(currentTime time.Time) db.Q {
 	cutoffTime := currentTime.Add(-MaxLCTInterval)
 	return db.Query(bson.M{
-		StatusKey:    evergreen.HostRunning,
-		StartedByKey: evergreen.User,
+		StatusKey:      evergreen.HostRunning,
+		StartedByKey:   evergreen.User,
+		DrainActionKey: bson.M{"$ne": DrainExit},
 		"$or": []bson.M{
 			{LastCommunicationTimeKey: util.ZeroTime},
 			{LastCommunicationTimeKey: bson.M{"$lte": cutoffTime}},
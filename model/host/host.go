@@ -32,6 +32,10 @@ type Host struct {
 	// the task that is currently running on the host
 	RunningTask string `bson:"running_task,omitempty" json:"running_task,omitempty"`
 
+	// for hosts of distros with more than one task slot, the tasks running
+	// in the slots other than the first
+	SlotTasks []string `bson:"slot_tasks,omitempty" json:"slot_tasks,omitempty"`
+
 	// the pid of the task that is currently running on the host
 	Pid string `bson:"pid" json:"pid"`
 
@@ -50,8 +54,12 @@ type Host struct {
 	// True if this host was created manually by a user (i.e. with spawnhost)
 	UserHost      bool   `bson:"user_host" json:"user_host"`
 	AgentRevision string `bson:"agent_revision" json:"agent_revision"`
+	// for hosts that are containers, the address of the machine running them
+	ParentHost string `bson:"parent_host,omitempty" json:"parent_host,omitempty"`
 	// for ec2 dynamic hosts, the instance type requested
 	InstanceType string `bson:"instance_type" json:"instance_type,omitempty"`
+	// the tags applied to the host in its cloud provider, for cost attribution
+	InstanceTags map[string]string `bson:"instance_tags,omitempty" json:"instance_tags,omitempty"`
 	// stores information on expiration notifications for spawn hosts
 	Notifications map[string]bool `bson:"notifications,omitempty" json:"notifications,omitempty"`
 
@@ -63,6 +71,30 @@ type Host struct {
 
 	// if set, the time at which the host first became unreachable
 	UnreachableSince time.Time `bson:"unreachable_since,omitempty" json:"unreachable_since"`
+
+	// the results of the most recent health checks, for hosts that are health checked
+	HealthCheck HealthCheckStatus `bson:"health_check,omitempty" json:"health_check"`
+
+	// if set, the host is being drained: it is given no new tasks, and once
+	// the tasks it is running have finished the drain action is taken
+	DrainAction string `bson:"drain_action,omitempty" json:"drain_action,omitempty"`
+}
+
+// HealthCheckStatus tracks the outcome of a host's recent health checks.
+type HealthCheckStatus struct {
+	// the last time that the host's health was checked
+	LastCheck time.Time `bson:"last_check" json:"last_check"`
+
+	// the number of checks in a row that have failed or passed
+	ConsecutiveFailures int `bson:"failures" json:"failures"`
+	ConsecutivePasses   int `bson:"passes" json:"passes"`
+
+	// the reason the most recent check failed, if it did
+	LastError string `bson:"last_error,omitempty" json:"last_error,omitempty"`
+
+	// true if the host was quarantined because its health checks failed, as
+	// opposed to by an admin
+	Quarantined bool `bson:"quarantined" json:"quarantined"`
 }
 
 // ProvisionOptions is struct containing options about how a new host should be set up.
@@ -81,13 +113,21 @@ type ProvisionOptions struct {
 
 const (
 	MaxLCTInterval = time.Minute * 10
+
+	// DrainExit makes the agent of a drained host exit, leaving the host up.
+	DrainExit = "exit"
+	// DrainTerminate decommissions a drained host, so that it's terminated.
+	DrainTerminate = "terminate"
 )
 
+// DrainActions are the actions that can be taken once a host is drained.
+var DrainActions = []string{DrainExit, DrainTerminate}
+
 // IdleTime returns how long has this host been idle
 func (h *Host) IdleTime() time.Duration {
 
 	// if the host is currently running a task, it is not idle
-	if h.RunningTask != "" {
+	if len(h.RunningTasks()) > 0 {
 		return time.Duration(0)
 	}
 
@@ -102,6 +142,31 @@ func (h *Host) IdleTime() time.Duration {
 	return time.Since(h.CreationTime)
 }
 
+// RunningTasks returns all of the tasks running on the host, starting with
+// the one in its first slot.
+func (h *Host) RunningTasks() []string {
+	tasks := []string{}
+	if h.RunningTask != "" {
+		tasks = append(tasks, h.RunningTask)
+	}
+	return append(tasks, h.SlotTasks...)
+}
+
+// IsRunningTask returns true if the task is running in any of the host's slots.
+func (h *Host) IsRunningTask(taskId string) bool {
+	return taskId != "" && util.SliceContains(h.RunningTasks(), taskId)
+}
+
+// FreeSlots returns the number of tasks the host can be assigned before all
+// of its slots are full.
+func (h *Host) FreeSlots() int {
+	free := h.Distro.Slots() - len(h.RunningTasks())
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
 func (h *Host) SetStatus(status string) error {
 	if h.Status == evergreen.HostTerminated {
 		msg := fmt.Sprintf("Refusing to mark host %v as"+
@@ -278,27 +343,42 @@ func (h *Host) MarkAsProvisioned() error {
 	)
 }
 
-// ClearRunningTask unsets the running task key on the host and updates the last task
-// completed fields.
+// ClearRunningTask removes the task from the slot it is running in on the
+// host and updates the last task completed fields.
 func (host *Host) ClearRunningTask(prevTaskId string, finishTime time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			LTCKey:     prevTaskId,
+			LTCTimeKey: finishTime,
+		},
+	}
+	if util.SliceContains(host.SlotTasks, prevTaskId) {
+		update["$pull"] = bson.M{SlotTasksKey: prevTaskId}
+		host.SlotTasks = removeString(host.SlotTasks, prevTaskId)
+	} else {
+		update["$unset"] = bson.M{RunningTaskKey: 1}
+		host.RunningTask = ""
+	}
+
 	host.LastTaskCompleted = prevTaskId
 	host.LastTaskCompletedTime = finishTime
-	host.RunningTask = ""
 	event.LogHostRunningTaskCleared(host.Id, prevTaskId)
 	return UpdateOne(
 		bson.M{
 			IdKey: host.Id,
 		},
-		bson.M{
-			"$set": bson.M{
-				LTCKey:     prevTaskId,
-				LTCTimeKey: finishTime,
-			},
-			"$unset": bson.M{
-				RunningTaskKey: 1,
-			},
-		})
+		update)
+
+}
 
+// ClearAllRunningTasks empties all of the host's slots.
+func (host *Host) ClearAllRunningTasks(finishTime time.Time) error {
+	for _, taskId := range host.RunningTasks() {
+		if err := host.ClearRunningTask(taskId, finishTime); err != nil {
+			return errors.Wrapf(err, "error clearing task %s from host %s", taskId, host.Id)
+		}
+	}
+	return nil
 }
 
 // UpdateRunningTask takes two id strings - an old task and a new one - finds
@@ -339,6 +419,78 @@ func (host *Host) UpdateRunningTask(prevTaskId, newTaskId string,
 	return true, nil
 }
 
+// AssignTaskSlot assigns the task to the first free slot on a host with more
+// than one task slot, updating the last task completed fields if it goes in
+// the first slot. It returns false if all of the host's slots are full.
+func (host *Host) AssignTaskSlot(prevTaskId, newTaskId string, finishTime time.Time) (bool, error) {
+	if newTaskId == "" {
+		return false, errors.New("cannot assign an empty task id to a host's slot")
+	}
+
+	// the first slot is only taken if it is still empty
+	err := UpdateOne(
+		bson.M{
+			IdKey:          host.Id,
+			RunningTaskKey: bson.M{"$exists": false},
+		},
+		bson.M{
+			"$set": bson.M{
+				RunningTaskKey: newTaskId,
+				LTCKey:         prevTaskId,
+				LTCTimeKey:     finishTime,
+				PidKey:         "",
+			},
+		})
+	if err == nil {
+		host.RunningTask = newTaskId
+		event.LogHostRunningTaskSet(host.Id, newTaskId)
+		return true, nil
+	}
+	if mgo.IsDup(err) {
+		// the task is already running on another host
+		return false, nil
+	}
+	if err != mgo.ErrNotFound {
+		return false, errors.WithStack(err)
+	}
+
+	extraSlots := host.Distro.Slots() - 1
+	if extraSlots < 1 {
+		return false, nil
+	}
+
+	// the other slots are only taken if the last of them is still empty
+	err = UpdateOne(
+		bson.M{
+			IdKey: host.Id,
+			fmt.Sprintf("%s.%d", SlotTasksKey, extraSlots-1): bson.M{"$exists": false},
+		},
+		bson.M{
+			"$push": bson.M{SlotTasksKey: newTaskId},
+		})
+	if err != nil {
+		if err == mgo.ErrNotFound || mgo.IsDup(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	host.SlotTasks = append(host.SlotTasks, newTaskId)
+	event.LogHostRunningTaskSet(host.Id, newTaskId)
+
+	return true, nil
+}
+
+// removeString returns the slice without any occurrences of the string.
+func removeString(slice []string, s string) []string {
+	out := []string{}
+	for _, e := range slice {
+		if e != s {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 // SetAgentRevision sets the updated agent revision for the host
 func (h *Host) SetAgentRevision(agentRevision string) error {
 	err := UpdateOne(bson.M{IdKey: h.Id},
@@ -386,6 +538,46 @@ func (h *Host) SetUserData(userData string) error {
 	)
 }
 
+// SetHealthCheck records the outcome of the host's health checks.
+func (h *Host) SetHealthCheck(status HealthCheckStatus) error {
+	err := UpdateOne(
+		bson.M{
+			IdKey: h.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				HealthCheckKey: status,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	h.HealthCheck = status
+	return nil
+}
+
+// SetInstanceTags records the tags applied to the host in its cloud provider.
+func (h *Host) SetInstanceTags(tags map[string]string) error {
+	err := UpdateOne(
+		bson.M{
+			IdKey: h.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				InstanceTagsKey: tags,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	h.InstanceTags = tags
+	return nil
+}
+
 // SetExpirationNotification updates the notification time for a spawn host
 func (h *Host) SetExpirationNotification(thresholdKey string) error {
 	// update the in-memory host, then the database
@@ -493,6 +685,73 @@ func (h *Host) Remove() error {
 	)
 }
 
+// SetDrainAction starts draining the host, so that action is taken once the
+// tasks it's running have finished. An empty action stops draining the host.
+func (h *Host) SetDrainAction(action string) error {
+	update, err := drainUpdate(action)
+	if err != nil {
+		return err
+	}
+	if err = UpdateOne(bson.M{IdKey: h.Id}, update); err != nil {
+		return err
+	}
+	h.DrainAction = action
+	return nil
+}
+
+// FinishDrain takes the host's drain action, now that it has no tasks left.
+// Hosts that are drained without being terminated stay drained, so that
+// their agent isn't started again until the drain is stopped.
+func (h *Host) FinishDrain() error {
+	// the agent is about to exit
+	if err := h.ResetLastCommunicated(); err != nil {
+		return err
+	}
+	if h.DrainAction == DrainTerminate && h.Status == evergreen.HostRunning {
+		return h.SetDecommissioned()
+	}
+	return nil
+}
+
+// DrainHostsWithDistroId starts draining all the working hosts of the distro,
+// or stops draining them if action is empty.
+func DrainHostsWithDistroId(distroId, action string) error {
+	update, err := drainUpdate(action)
+	if err != nil {
+		return err
+	}
+	return UpdateAll(byDistroIdFilter(distroId), update)
+}
+
+// DecommissionOrDrainHostsWithDistroId decommissions the working hosts of the
+// distro that aren't running tasks right away, whether or not they have an
+// agent, and drains the others so that they're decommissioned once their
+// tasks have finished.
+func DecommissionOrDrainHostsWithDistroId(distroId string) error {
+	err := UpdateAll(
+		byDistroIdRunningTasksFilter(distroId, true),
+		bson.M{"$set": bson.M{DrainActionKey: DrainTerminate}},
+	)
+	if err != nil {
+		return err
+	}
+	// hosts that finished their tasks since being drained are caught here
+	return UpdateAll(
+		byDistroIdRunningTasksFilter(distroId, false),
+		bson.M{"$set": bson.M{StatusKey: evergreen.HostDecommissioned}},
+	)
+}
+
+func drainUpdate(action string) (bson.M, error) {
+	if action == "" {
+		return bson.M{"$unset": bson.M{DrainActionKey: 1}}, nil
+	}
+	if !util.SliceContains(DrainActions, action) {
+		return nil, errors.Errorf("'%s' is not a valid drain action", action)
+	}
+	return bson.M{"$set": bson.M{DrainActionKey: action}}, nil
+}
+
 func DecommissionHostsWithDistroId(distroId string) error {
 	err := UpdateAll(
 		ByDistroId(distroId),
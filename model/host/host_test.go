@@ -10,6 +10,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -799,3 +800,124 @@ func TestFindByLCT(t *testing.T) {
 
 	})
 }
+
+func TestHostTaskSlots(t *testing.T) {
+	assert := assert.New(t)
+
+	h := Host{Id: "h"}
+	assert.Empty(h.RunningTasks())
+	assert.Equal(1, h.FreeSlots())
+
+	h.RunningTask = "t1"
+	assert.Equal([]string{"t1"}, h.RunningTasks())
+	assert.Equal(0, h.FreeSlots())
+
+	h.Distro.TaskSlots = 3
+	h.SlotTasks = []string{"t2"}
+	assert.Equal([]string{"t1", "t2"}, h.RunningTasks())
+	assert.Equal(1, h.FreeSlots())
+	assert.True(h.IsRunningTask("t2"))
+	assert.False(h.IsRunningTask("t3"))
+	assert.False(h.IsRunningTask(""))
+
+	// a task can finish in the first slot while the others are still busy
+	h.RunningTask = ""
+	assert.Equal([]string{"t2"}, h.RunningTasks())
+	assert.Equal(2, h.FreeSlots())
+	assert.Equal(time.Duration(0), h.IdleTime())
+}
+
+func TestDrainHosts(t *testing.T) {
+	assert := assert.New(t)
+	testutil.HandleTestingErr(db.Clear(Collection), t, "Error clearing '%v' collection", Collection)
+
+	for _, id := range []string{"a1", "a2", "b1"} {
+		h := &Host{
+			Id:        id,
+			Distro:    distro.Distro{Id: id[:1]},
+			Status:    evergreen.HostRunning,
+			StartedBy: evergreen.User,
+		}
+		assert.NoError(h.Insert())
+	}
+
+	assert.Error(DrainHostsWithDistroId("a", "reboot"))
+	assert.NoError(DrainHostsWithDistroId("a", DrainExit))
+	hosts, err := Find(ByDistroId("a"))
+	assert.NoError(err)
+	assert.Len(hosts, 2)
+	for _, h := range hosts {
+		assert.Equal(DrainExit, h.DrainAction)
+	}
+
+	// drained hosts whose agent exits shouldn't have it started again
+	hosts, err = Find(ByRunningWithTimedOutLCT(time.Now()))
+	assert.NoError(err)
+	assert.Len(hosts, 1)
+	assert.Equal("b1", hosts[0].Id)
+
+	h, err := FindOne(ById("b1"))
+	assert.NoError(err)
+	assert.NoError(h.SetDrainAction(DrainTerminate))
+	assert.NoError(h.FinishDrain())
+	h, err = FindOne(ById("b1"))
+	assert.NoError(err)
+	assert.Equal(evergreen.HostDecommissioned, h.Status)
+
+	h, err = FindOne(ById("a1"))
+	assert.NoError(err)
+	assert.NoError(h.FinishDrain())
+	assert.Equal(evergreen.HostRunning, h.Status)
+	assert.NoError(h.SetDrainAction(""))
+	h, err = FindOne(ById("a1"))
+	assert.NoError(err)
+	assert.Empty(h.DrainAction)
+}
+
+func TestDecommissionOrDrainHostsWithDistroId(t *testing.T) {
+	assert := assert.New(t)
+	testutil.HandleTestingErr(db.Clear(Collection), t, "Error clearing '%v' collection", Collection)
+
+	hosts := []Host{
+		// an idle host whose agent never asks for another task
+		{Id: "idle", Status: evergreen.HostRunning},
+		{Id: "initializing", Status: evergreen.HostInitializing},
+		{Id: "provision-failed", Status: evergreen.HostProvisionFailed},
+		{Id: "busy", Status: evergreen.HostRunning, RunningTask: "t1"},
+		{Id: "busy-slot", Status: evergreen.HostRunning, SlotTasks: []string{"t2"}},
+	}
+	for _, h := range hosts {
+		h.Distro = distro.Distro{Id: "a"}
+		h.StartedBy = evergreen.User
+		assert.NoError(h.Insert())
+	}
+	other := &Host{Id: "other", Distro: distro.Distro{Id: "b"}, Status: evergreen.HostRunning, StartedBy: evergreen.User}
+	assert.NoError(other.Insert())
+
+	assert.NoError(DecommissionOrDrainHostsWithDistroId("a"))
+
+	for _, id := range []string{"idle", "initializing", "provision-failed"} {
+		h, err := FindOne(ById(id))
+		assert.NoError(err)
+		assert.Equal(evergreen.HostDecommissioned, h.Status, id)
+		assert.Empty(h.DrainAction, id)
+	}
+	for _, id := range []string{"busy", "busy-slot"} {
+		h, err := FindOne(ById(id))
+		assert.NoError(err)
+		assert.Equal(evergreen.HostRunning, h.Status, id)
+		assert.Equal(DrainTerminate, h.DrainAction, id)
+	}
+	h, err := FindOne(ById("other"))
+	assert.NoError(err)
+	assert.Equal(evergreen.HostRunning, h.Status)
+	assert.Empty(h.DrainAction)
+
+	// a drained host that finished its task without asking for another is
+	// decommissioned when the distro is decommissioned again
+	assert.NoError(UpdateOne(bson.M{IdKey: "busy"}, bson.M{"$unset": bson.M{RunningTaskKey: 1}}))
+	assert.NoError(DecommissionOrDrainHostsWithDistroId("a"))
+	h, err = FindOne(ById("busy"))
+	assert.NoError(err)
+	assert.Equal(evergreen.HostDecommissioned, h.Status)
+}
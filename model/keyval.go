@@ -1,7 +1,12 @@
 package model
 
 import (
+	"time"
+
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -32,3 +37,184 @@ func (kv *KeyVal) Inc() error {
 
 	return nil
 }
+
+const (
+	KeyValStoreCollection = "keyval_store"
+
+	// Keys in the global namespace are shared by every task, keys in the
+	// project namespace by the tasks of a project, and keys in the version
+	// namespace by the tasks of a version.
+	KeyValNamespaceGlobal  = "global"
+	KeyValNamespaceProject = "project"
+	KeyValNamespaceVersion = "version"
+
+	// KeyValMaxValueSize is the largest value that can be stored.
+	KeyValMaxValueSize = 16 * 1024
+)
+
+// KeyValEntryId identifies a key in the store. Scope is the project or
+// version id of keys in those namespaces, and empty for global keys.
+type KeyValEntryId struct {
+	Namespace string `bson:"namespace" json:"namespace"`
+	Scope     string `bson:"scope" json:"scope"`
+	Key       string `bson:"key" json:"key"`
+}
+
+// KeyValEntry is a string value in the store, which expires at ExpireAt if
+// it's set.
+type KeyValEntry struct {
+	Id        KeyValEntryId `bson:"_id" json:"id"`
+	Value     string        `bson:"value" json:"value"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+	ExpireAt  time.Time     `bson:"expire_at,omitempty" json:"expire_at"`
+}
+
+var (
+	KeyValEntryIdKey        = bsonutil.MustHaveTag(KeyValEntry{}, "Id")
+	KeyValEntryValueKey     = bsonutil.MustHaveTag(KeyValEntry{}, "Value")
+	KeyValEntryUpdatedAtKey = bsonutil.MustHaveTag(KeyValEntry{}, "UpdatedAt")
+	KeyValEntryExpireAtKey  = bsonutil.MustHaveTag(KeyValEntry{}, "ExpireAt")
+)
+
+// KeyValRequest is what the keyval commands send to the API server. Expected
+// is only used by compare and swap, and TTLSecs by set and compare and swap.
+type KeyValRequest struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Expected  string `json:"expected"`
+	TTLSecs   int    `json:"ttl_secs"`
+}
+
+// KeyValSwapResult is the result of a compare and swap. Value is the key's
+// value afterwards, if it has one.
+type KeyValSwapResult struct {
+	Swapped bool   `json:"swapped"`
+	Value   string `json:"value"`
+}
+
+// NewKeyValEntryId returns the id of the key in the namespace, as seen by
+// the task.
+func NewKeyValEntryId(namespace, key string, t *task.Task) (KeyValEntryId, error) {
+	id := KeyValEntryId{Namespace: namespace, Key: key}
+	if key == "" {
+		return id, errors.New("key may not be blank")
+	}
+	switch namespace {
+	case KeyValNamespaceGlobal:
+	case KeyValNamespaceProject:
+		id.Scope = t.Project
+	case KeyValNamespaceVersion:
+		id.Scope = t.Version
+	default:
+		return id, errors.Errorf("unknown keyval namespace '%s'", namespace)
+	}
+	return id, nil
+}
+
+// NewKeyValEntry returns an entry for the value, which expires after ttl if
+// it's positive.
+func NewKeyValEntry(id KeyValEntryId, value string, ttl time.Duration) (*KeyValEntry, error) {
+	if len(value) > KeyValMaxValueSize {
+		return nil, errors.Errorf("value for key %s is %d bytes, larger than the limit of %d",
+			id.Key, len(value), KeyValMaxValueSize)
+	}
+	entry := &KeyValEntry{
+		Id:        id,
+		Value:     value,
+		UpdatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		entry.ExpireAt = entry.UpdatedAt.Add(ttl)
+	}
+	return entry, nil
+}
+
+// unexpired matches the entries that haven't expired.
+func unexpired(now time.Time) bson.M {
+	return bson.M{"$or": []bson.M{
+		{KeyValEntryExpireAtKey: bson.M{"$exists": false}},
+		{KeyValEntryExpireAtKey: bson.M{"$gt": now}},
+	}}
+}
+
+// FindKeyValEntry returns the entry for the key, or nil if it isn't set or
+// has expired.
+func FindKeyValEntry(id KeyValEntryId) (*KeyValEntry, error) {
+	query := unexpired(time.Now())
+	query[KeyValEntryIdKey] = id
+	entry := &KeyValEntry{}
+	err := db.FindOne(KeyValStoreCollection, query, db.NoProjection, db.NoSort, entry)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding key %s", id.Key)
+	}
+	return entry, nil
+}
+
+// update returns the update that sets the entry's value and expiration.
+func (e *KeyValEntry) update() bson.M {
+	update := bson.M{
+		"$set": bson.M{
+			KeyValEntryValueKey:     e.Value,
+			KeyValEntryUpdatedAtKey: e.UpdatedAt,
+		},
+	}
+	if e.ExpireAt.IsZero() {
+		update["$unset"] = bson.M{KeyValEntryExpireAtKey: 1}
+	} else {
+		update["$set"].(bson.M)[KeyValEntryExpireAtKey] = e.ExpireAt
+	}
+	return update
+}
+
+// removeExpiredKeyValEntries deletes the entries that have expired, which
+// reads otherwise only skip over.
+func removeExpiredKeyValEntries(now time.Time) {
+	err := db.RemoveAll(KeyValStoreCollection, bson.M{KeyValEntryExpireAtKey: bson.M{"$lte": now}})
+	grip.Warning(errors.Wrap(err, "problem removing expired keys"))
+}
+
+// Set stores the entry, replacing the key's value if it has one.
+func (e *KeyValEntry) Set() error {
+	removeExpiredKeyValEntries(time.Now())
+	_, err := db.Upsert(KeyValStoreCollection, bson.M{KeyValEntryIdKey: e.Id}, e.update())
+	return errors.Wrapf(err, "problem setting key %s", e.Id.Key)
+}
+
+// CompareAndSwap stores the entry only if the key's current value is
+// expected, or if expected is empty and the key isn't set. It returns
+// whether the entry was stored.
+func (e *KeyValEntry) CompareAndSwap(expected string) (bool, error) {
+	now := time.Now()
+	removeExpiredKeyValEntries(now)
+	var query bson.M
+	if expected == "" {
+		// only match an expired entry; if the key is set and hasn't
+		// expired, the upsert fails on its duplicate id
+		query = bson.M{
+			KeyValEntryIdKey:       e.Id,
+			KeyValEntryExpireAtKey: bson.M{"$lte": now},
+		}
+	} else {
+		query = unexpired(now)
+		query[KeyValEntryIdKey] = e.Id
+		query[KeyValEntryValueKey] = expected
+	}
+
+	change := mgo.Change{
+		Update:    e.update(),
+		ReturnNew: true,
+		Upsert:    expected == "",
+	}
+	_, err := db.FindAndModify(KeyValStoreCollection, query, nil, change, &KeyValEntry{})
+	if err == mgo.ErrNotFound || mgo.IsDup(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "problem swapping key %s", e.Id.Key)
+	}
+	return true, nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyValEntryId(t *testing.T) {
+	assert := assert.New(t)
+	tsk := &task.Task{Project: "proj", Version: "v1"}
+
+	id, err := NewKeyValEntryId(KeyValNamespaceGlobal, "k", tsk)
+	assert.NoError(err)
+	assert.Equal(KeyValEntryId{Namespace: KeyValNamespaceGlobal, Key: "k"}, id)
+
+	id, err = NewKeyValEntryId(KeyValNamespaceProject, "k", tsk)
+	assert.NoError(err)
+	assert.Equal("proj", id.Scope)
+
+	id, err = NewKeyValEntryId(KeyValNamespaceVersion, "k", tsk)
+	assert.NoError(err)
+	assert.Equal("v1", id.Scope)
+
+	_, err = NewKeyValEntryId("build", "k", tsk)
+	assert.Error(err)
+	_, err = NewKeyValEntryId(KeyValNamespaceGlobal, "", tsk)
+	assert.Error(err)
+}
+
+func TestNewKeyValEntry(t *testing.T) {
+	assert := assert.New(t)
+	id := KeyValEntryId{Namespace: KeyValNamespaceGlobal, Key: "k"}
+
+	entry, err := NewKeyValEntry(id, "value", 0)
+	require.NoError(t, err)
+	assert.True(entry.ExpireAt.IsZero())
+	assert.NotContains(entry.update()["$set"], KeyValEntryExpireAtKey)
+	assert.Contains(entry.update(), "$unset")
+
+	entry, err = NewKeyValEntry(id, "value", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(time.Hour, entry.ExpireAt.Sub(entry.UpdatedAt))
+	assert.Contains(entry.update()["$set"], KeyValEntryExpireAtKey)
+	assert.NotContains(entry.update(), "$unset")
+
+	_, err = NewKeyValEntry(id, strings.Repeat("x", KeyValMaxValueSize+1), 0)
+	assert.Error(err)
+}
@@ -0,0 +1,121 @@
+package perf
+
+import (
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	// BSON fields for the task perf struct
+	IdKey                  = bsonutil.MustHaveTag(TaskPerf{}, "Id")
+	TaskIdKey              = bsonutil.MustHaveTag(TaskPerf{}, "TaskId")
+	ExecutionKey           = bsonutil.MustHaveTag(TaskPerf{}, "Execution")
+	ProjectIdKey           = bsonutil.MustHaveTag(TaskPerf{}, "ProjectId")
+	VariantKey             = bsonutil.MustHaveTag(TaskPerf{}, "Variant")
+	TaskNameKey            = bsonutil.MustHaveTag(TaskPerf{}, "TaskName")
+	RevisionKey            = bsonutil.MustHaveTag(TaskPerf{}, "Revision")
+	RevisionOrderNumberKey = bsonutil.MustHaveTag(TaskPerf{}, "RevisionOrderNumber")
+	MainlineKey            = bsonutil.MustHaveTag(TaskPerf{}, "Mainline")
+	CreateTimeKey          = bsonutil.MustHaveTag(TaskPerf{}, "CreateTime")
+	ResultsKey             = bsonutil.MustHaveTag(TaskPerf{}, "Results")
+	RegressionsKey         = bsonutil.MustHaveTag(TaskPerf{}, "Regressions")
+)
+
+// === Queries ===
+
+// ByTaskIdAndExecution returns a query for the results of an execution of a
+// task.
+func ByTaskIdAndExecution(taskId string, execution int) db.Q {
+	return db.Query(bson.M{IdKey: TaskPerfId(taskId, execution)})
+}
+
+// ByMainlineHistory returns a query for the results of the mainline runs of
+// a task before the given revision, most recent first.
+func ByMainlineHistory(projectId, variant, taskName string, beforeOrder, limit int) db.Q {
+	return db.Query(bson.M{
+		ProjectIdKey:           projectId,
+		VariantKey:             variant,
+		TaskNameKey:            taskName,
+		MainlineKey:            true,
+		RevisionOrderNumberKey: bson.M{"$lt": beforeOrder},
+	}).Sort([]string{"-" + RevisionOrderNumberKey, "-" + ExecutionKey}).Limit(limit)
+}
+
+// === DB Logic ===
+
+// FindOne gets one TaskPerf for the given query.
+func FindOne(query db.Q) (*TaskPerf, error) {
+	tp := &TaskPerf{}
+	err := db.FindOneQ(Collection, query, tp)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return tp, err
+}
+
+// Find gets every TaskPerf for the given query.
+func Find(query db.Q) ([]TaskPerf, error) {
+	tps := []TaskPerf{}
+	err := db.FindAllQ(Collection, query, &tps)
+	return tps, err
+}
+
+// FindByTask returns the results of the task's current execution, or nil if
+// it hasn't attached any.
+func FindByTask(t *task.Task) (*TaskPerf, error) {
+	return FindOne(ByTaskIdAndExecution(t.Id, t.Execution))
+}
+
+// FindMainlineHistory returns the results of up to window mainline runs of
+// the task before this one, with only the latest execution of each.
+func FindMainlineHistory(tp *TaskPerf, window int) ([]TaskPerf, error) {
+	// restarted tasks have more than one execution at a revision
+	all, err := Find(ByMainlineHistory(tp.ProjectId, tp.Variant, tp.TaskName,
+		tp.RevisionOrderNumber, window*2))
+	if err != nil {
+		return nil, err
+	}
+
+	history := []TaskPerf{}
+	seen := map[string]bool{}
+	for _, past := range all {
+		if seen[past.TaskId] {
+			continue
+		}
+		seen[past.TaskId] = true
+		history = append(history, past)
+		if len(history) == window {
+			break
+		}
+	}
+	return history, nil
+}
+
+// AddResults appends results to the task's, compares all of them to its
+// mainline history, and saves them along with the regressions found.
+func (tp *TaskPerf) AddResults(results []Result, opts DetectionOptions) error {
+	tp.Results = append(tp.Results, results...)
+
+	history, err := FindMainlineHistory(tp, opts.Window)
+	if err != nil {
+		return err
+	}
+	tp.Regressions = DetectRegressions(tp.Results, history, opts)
+	return tp.Upsert()
+}
+
+// Upsert saves the results, replacing any saved for the same execution of
+// the task.
+func (tp *TaskPerf) Upsert() error {
+	_, err := db.Upsert(
+		Collection,
+		bson.M{
+			IdKey: tp.Id,
+		},
+		tp,
+	)
+	return err
+}
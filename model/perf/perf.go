@@ -0,0 +1,88 @@
+// Package perf stores the performance results tasks attach, and finds
+// regressions in them by comparing them to the results of earlier mainline
+// runs of the same task.
+package perf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+const Collection = "perf_results"
+
+// Result is one measurement of a test, e.g. the ns/op of a Go benchmark, or
+// the throughput of a workload at some number of threads.
+type Result struct {
+	Test        string  `json:"test" bson:"test"`
+	Metric      string  `json:"metric" bson:"metric"`
+	Value       float64 `json:"value" bson:"value"`
+	Unit        string  `json:"unit,omitempty" bson:"unit,omitempty"`
+	ThreadLevel int     `json:"thread_level,omitempty" bson:"thread_level,omitempty"`
+	// HigherIsBetter says which way the metric regresses, when its unit
+	// doesn't make that clear.
+	HigherIsBetter *bool `json:"higher_is_better,omitempty" bson:"higher_is_better,omitempty"`
+}
+
+// TaskPerf holds all the performance results attached by one execution of a
+// task, and the regressions found in them.
+type TaskPerf struct {
+	Id                  string       `json:"id" bson:"_id"`
+	TaskId              string       `json:"task_id" bson:"task_id"`
+	Execution           int          `json:"execution" bson:"execution"`
+	ProjectId           string       `json:"project_id" bson:"project_id"`
+	Variant             string       `json:"variant" bson:"variant"`
+	TaskName            string       `json:"task_name" bson:"task_name"`
+	Revision            string       `json:"revision" bson:"revision"`
+	RevisionOrderNumber int          `json:"order" bson:"order"`
+	Mainline            bool         `json:"mainline" bson:"mainline"`
+	CreateTime          time.Time    `json:"create_time" bson:"create_time"`
+	Results             []Result     `json:"results" bson:"results"`
+	Regressions         []Regression `json:"regressions,omitempty" bson:"regressions,omitempty"`
+}
+
+// NewTaskPerf returns an empty set of results for the task's current
+// execution.
+func NewTaskPerf(t *task.Task) *TaskPerf {
+	return &TaskPerf{
+		Id:                  TaskPerfId(t.Id, t.Execution),
+		TaskId:              t.Id,
+		Execution:           t.Execution,
+		ProjectId:           t.Project,
+		Variant:             t.BuildVariant,
+		TaskName:            t.DisplayName,
+		Revision:            t.Revision,
+		RevisionOrderNumber: t.RevisionOrderNumber,
+		Mainline:            t.Requester == evergreen.RepotrackerVersionRequester,
+		CreateTime:          time.Now(),
+	}
+}
+
+// TaskPerfId returns the id of the results of an execution of a task.
+func TaskPerfId(taskId string, execution int) string {
+	return fmt.Sprintf("%v_%v", taskId, execution)
+}
+
+// key identifies the series of measurements a result belongs to.
+func (r Result) key() string {
+	return fmt.Sprintf("%v\x00%v\x00%v", r.Test, r.Metric, r.ThreadLevel)
+}
+
+// higherIsBetter returns whether larger values of the result are
+// improvements. Unless the result says otherwise, rates like ops/s and MB/s
+// are, and everything else, like ns/op, B/op and latencies, is not.
+func (r Result) higherIsBetter() bool {
+	if r.HigherIsBetter != nil {
+		return *r.HigherIsBetter
+	}
+	unit := strings.ToLower(strings.TrimSpace(r.Unit))
+	for _, suffix := range []string{"/s", "/sec", "/second", "per second", "ops"} {
+		if strings.HasSuffix(unit, suffix) {
+			return true
+		}
+	}
+	return false
+}
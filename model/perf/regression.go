@@ -0,0 +1,172 @@
+package perf
+
+import (
+	"math"
+	"sort"
+)
+
+// Regression is a result that's significantly worse than the results of the
+// same test and metric in the task's mainline history.
+type Regression struct {
+	Test        string  `json:"test" bson:"test"`
+	Metric      string  `json:"metric" bson:"metric"`
+	Unit        string  `json:"unit,omitempty" bson:"unit,omitempty"`
+	ThreadLevel int     `json:"thread_level,omitempty" bson:"thread_level,omitempty"`
+	Value       float64 `json:"value" bson:"value"`
+	// BaselineMean and BaselineStdDev describe the history the value was
+	// compared to, which had Samples runs of the task in it.
+	BaselineMean   float64 `json:"baseline_mean" bson:"baseline_mean"`
+	BaselineStdDev float64 `json:"baseline_stddev" bson:"baseline_stddev"`
+	Samples        int     `json:"samples" bson:"samples"`
+	// Change is how much worse the value is than the baseline, e.g. 0.2 for
+	// 20% worse.
+	Change float64 `json:"change" bson:"change"`
+	// ZScore is how many standard deviations worse the value is. It's zero
+	// if the history didn't vary at all.
+	ZScore float64 `json:"z_score" bson:"z_score"`
+}
+
+// PercentChange returns how much worse the value is than the baseline, as a
+// percentage.
+func (r Regression) PercentChange() float64 {
+	return r.Change * 100
+}
+
+// DetectionOptions control how results are compared to their history.
+type DetectionOptions struct {
+	// Window is how many earlier mainline runs of the task to compare to.
+	Window int
+	// MinSamples is how many of those runs need to have the result for it to
+	// be compared at all.
+	MinSamples int
+	// ZThreshold is how many standard deviations from the mean a value has
+	// to be to be significant.
+	ZThreshold float64
+	// MinChange is the smallest relative change that's a regression, so
+	// that very stable results don't regress on noise.
+	MinChange float64
+}
+
+// DefaultDetectionOptions flag results more than three standard deviations
+// and 5% worse than the last 20 mainline runs.
+var DefaultDetectionOptions = DetectionOptions{
+	Window:     20,
+	MinSamples: 5,
+	ZThreshold: 3,
+	MinChange:  0.05,
+}
+
+// DetectRegressions compares the results to the same tests and metrics in
+// the history, the results of earlier runs of the task. Results measured
+// several times in a run, like benchmarks run with -count, are compared by
+// their mean.
+func DetectRegressions(results []Result, history []TaskPerf, opts DetectionOptions) []Regression {
+	current, order := meansByKey(results)
+
+	baselines := map[string][]float64{}
+	for _, past := range history {
+		means, _ := meansByKey(past.Results)
+		for key, mean := range means {
+			baselines[key] = append(baselines[key], mean.Value)
+		}
+	}
+
+	regressions := []Regression{}
+	for _, key := range order {
+		result := current[key]
+		baseline := baselines[key]
+		if len(baseline) < opts.MinSamples || len(baseline) < 2 {
+			continue
+		}
+		mean, stddev := meanAndStdDev(baseline)
+		if mean == 0 {
+			continue
+		}
+
+		// positive differences are always worse
+		diff := result.Value - mean
+		if result.higherIsBetter() {
+			diff = -diff
+		}
+		change := diff / math.Abs(mean)
+		if diff <= 0 || change < opts.MinChange {
+			continue
+		}
+
+		regression := Regression{
+			Test:           result.Test,
+			Metric:         result.Metric,
+			Unit:           result.Unit,
+			ThreadLevel:    result.ThreadLevel,
+			Value:          result.Value,
+			BaselineMean:   mean,
+			BaselineStdDev: stddev,
+			Samples:        len(baseline),
+			Change:         change,
+		}
+		if stddev > 0 {
+			regression.ZScore = diff / stddev
+			if regression.ZScore < opts.ZThreshold {
+				continue
+			}
+		}
+		regressions = append(regressions, regression)
+	}
+
+	sort.Sort(regressionSorter(regressions))
+	return regressions
+}
+
+type regressionSorter []Regression
+
+func (rs regressionSorter) Len() int      { return len(rs) }
+func (rs regressionSorter) Swap(i, j int) { rs[i], rs[j] = rs[j], rs[i] }
+func (rs regressionSorter) Less(i, j int) bool {
+	if rs[i].Test != rs[j].Test {
+		return rs[i].Test < rs[j].Test
+	}
+	if rs[i].Metric != rs[j].Metric {
+		return rs[i].Metric < rs[j].Metric
+	}
+	return rs[i].ThreadLevel < rs[j].ThreadLevel
+}
+
+// meansByKey averages the results of each test and metric, returning the
+// averaged results and their keys in the order they were first seen.
+func meansByKey(results []Result) (map[string]Result, []string) {
+	sums := map[string]Result{}
+	counts := map[string]int{}
+	order := []string{}
+	for _, r := range results {
+		key := r.key()
+		sum, ok := sums[key]
+		if !ok {
+			sum = r
+			sum.Value = 0
+			order = append(order, key)
+		}
+		sum.Value += r.Value
+		sums[key] = sum
+		counts[key]++
+	}
+	for key, sum := range sums {
+		sum.Value /= float64(counts[key])
+		sums[key] = sum
+	}
+	return sums, order
+}
+
+// meanAndStdDev returns the mean and sample standard deviation of values.
+func meanAndStdDev(values []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
@@ -0,0 +1,88 @@
+package perf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyOf(test, metric, unit string, values ...float64) []TaskPerf {
+	history := []TaskPerf{}
+	for _, v := range values {
+		history = append(history, TaskPerf{Results: []Result{
+			{Test: test, Metric: metric, Unit: unit, Value: v},
+		}})
+	}
+	return history
+}
+
+func TestDetectRegressions(t *testing.T) {
+	assert := assert.New(t)
+	history := historyOf("BenchmarkInsert", "ns/op", "ns/op", 100, 102, 98, 101, 99, 100)
+
+	// within the noise
+	regressions := DetectRegressions([]Result{
+		{Test: "BenchmarkInsert", Metric: "ns/op", Unit: "ns/op", Value: 103},
+	}, history, DefaultDetectionOptions)
+	assert.Empty(regressions)
+
+	// faster isn't a regression
+	regressions = DetectRegressions([]Result{
+		{Test: "BenchmarkInsert", Metric: "ns/op", Unit: "ns/op", Value: 50},
+	}, history, DefaultDetectionOptions)
+	assert.Empty(regressions)
+
+	// repeated measurements are averaged
+	regressions = DetectRegressions([]Result{
+		{Test: "BenchmarkInsert", Metric: "ns/op", Unit: "ns/op", Value: 115},
+		{Test: "BenchmarkInsert", Metric: "ns/op", Unit: "ns/op", Value: 125},
+		{Test: "BenchmarkOther", Metric: "ns/op", Unit: "ns/op", Value: 1000},
+	}, history, DefaultDetectionOptions)
+	require.Len(t, regressions, 1)
+	assert.Equal("BenchmarkInsert", regressions[0].Test)
+	assert.Equal(120.0, regressions[0].Value)
+	assert.Equal(100.0, regressions[0].BaselineMean)
+	assert.Equal(6, regressions[0].Samples)
+	assert.InDelta(0.2, regressions[0].Change, 0.0001)
+	assert.InDelta(20.0, regressions[0].PercentChange(), 0.0001)
+	assert.True(regressions[0].ZScore > DefaultDetectionOptions.ZThreshold)
+}
+
+func TestDetectRegressionsDirection(t *testing.T) {
+	assert := assert.New(t)
+	history := historyOf("insert", "throughput", "ops/s", 1000, 1010, 990, 1000, 1005)
+
+	regressions := DetectRegressions([]Result{
+		{Test: "insert", Metric: "throughput", Unit: "ops/s", Value: 1200},
+	}, history, DefaultDetectionOptions)
+	assert.Empty(regressions)
+
+	regressions = DetectRegressions([]Result{
+		{Test: "insert", Metric: "throughput", Unit: "ops/s", Value: 800},
+	}, history, DefaultDetectionOptions)
+	assert.Len(regressions, 1)
+
+	// results can say which way is better
+	lower := false
+	regressions = DetectRegressions([]Result{
+		{Test: "insert", Metric: "throughput", Unit: "ops/s", Value: 1200, HigherIsBetter: &lower},
+	}, history, DefaultDetectionOptions)
+	assert.Len(regressions, 1)
+}
+
+func TestDetectRegressionsNeedsHistory(t *testing.T) {
+	assert := assert.New(t)
+	current := []Result{{Test: "t", Metric: "latency", Unit: "ms", Value: 200}}
+
+	assert.Empty(DetectRegressions(current, historyOf("t", "latency", "ms", 100, 100, 100), DefaultDetectionOptions))
+
+	// results that never varied regress on the minimum change alone
+	regressions := DetectRegressions(current, historyOf("t", "latency", "ms", 100, 100, 100, 100, 100), DefaultDetectionOptions)
+	require.Len(t, regressions, 1)
+	assert.Equal(0.0, regressions[0].ZScore)
+
+	// thread levels are compared separately
+	current[0].ThreadLevel = 4
+	assert.Empty(DetectRegressions(current, historyOf("t", "latency", "ms", 100, 100, 100, 100, 100), DefaultDetectionOptions))
+}
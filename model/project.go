@@ -147,6 +147,14 @@ type BuildVariant struct {
 
 	// all of the tasks to be run on the build variant, compile through tests.
 	Tasks []BuildVariantTask `yaml:"tasks,omitempty" bson:"tasks"`
+
+	// ResourceLimits overrides the resource limits of the distros the
+	// variant's tasks run on
+	ResourceLimits *distro.ResourceLimits `yaml:"resource_limits,omitempty" bson:"resource_limits,omitempty"`
+
+	// Caches are directories kept on the host between runs of the
+	// variant's tasks. Tasks can override them by name.
+	Caches []CacheDir `yaml:"cache,omitempty" bson:"cache,omitempty"`
 }
 
 type Module struct {
@@ -282,6 +290,9 @@ type ProjectTask struct {
 	//   3. false = overriding the project setting with false
 	Patchable *bool `yaml:"patchable,omitempty" bson:"patchable,omitempty"`
 	Stepback  *bool `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
+
+	// Caches are directories kept on the host between runs of the task
+	Caches []CacheDir `yaml:"cache,omitempty" bson:"cache,omitempty"`
 }
 
 type TaskConfig struct {
@@ -293,6 +304,12 @@ type TaskConfig struct {
 	BuildVariant *BuildVariant
 	Expansions   *command.Expansions
 	WorkDir      string
+	// Container, if set, is the container the task's commands run in
+	Container *command.Container
+	// Cgroup, if set, is the cgroup the current command's processes run in
+	Cgroup *command.Cgroup
+	// Redactor hides the values of private variables in the task's logs
+	Redactor *util.Redactor
 }
 
 // TaskIdTable is a map of [variant, task display name]->[task id].
@@ -460,7 +477,7 @@ func NewTaskConfig(d *distro.Distro, v *version.Version, p *Project, t *task.Tas
 	}
 
 	e := populateExpansions(d, v, bv, t)
-	return &TaskConfig{d, v, r, p, t, bv, e, d.WorkDir}, nil
+	return &TaskConfig{d, v, r, p, t, bv, e, d.WorkDir, nil, nil, util.NewRedactor()}, nil
 }
 
 func populateExpansions(d *distro.Distro, v *version.Version, bv *BuildVariant, t *task.Task) *command.Expansions {
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// validCacheName matches the names caches can have, which name directories
+// on the hosts that keep them.
+var validCacheName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// CacheDir is a directory that tasks fill with things like downloaded
+// dependencies, which the agent keeps on the host after the task finishes so
+// that later tasks don't have to fill it again.
+type CacheDir struct {
+	// Name identifies the cache on the host. Tasks that declare caches with
+	// the same name and key share them.
+	Name string `yaml:"name,omitempty" bson:"name"`
+
+	// Path is where the cache is put, relative to the task directory.
+	Path string `yaml:"path,omitempty" bson:"path"`
+
+	// KeyFiles are patterns matching files in the task directory, like
+	// go.sum or package-lock.json, whose contents the cache is keyed by.
+	// The cache is put in place before the first command that runs once
+	// they all exist.
+	KeyFiles []string `yaml:"key_files,omitempty" bson:"key_files,omitempty"`
+
+	// Copy makes the agent copy the cache into the task directory, rather
+	// than symlinking it, so that the task can't change the cache.
+	Copy bool `yaml:"copy,omitempty" bson:"copy,omitempty"`
+
+	// S3, if set, shares the cache between hosts through a bucket.
+	S3 *CacheS3 `yaml:"s3,omitempty" bson:"s3,omitempty"`
+}
+
+// CacheS3 is where a cache is shared between hosts. Its fields are expanded
+// with the task's expansions.
+type CacheS3 struct {
+	Bucket    string `yaml:"bucket,omitempty" bson:"bucket"`
+	Prefix    string `yaml:"prefix,omitempty" bson:"prefix,omitempty"`
+	AwsKey    string `yaml:"aws_key,omitempty" bson:"aws_key"`
+	AwsSecret string `yaml:"aws_secret,omitempty" bson:"aws_secret"`
+}
+
+// Validate returns an error if the cache can't be kept on hosts.
+func (c *CacheDir) Validate() error {
+	if !validCacheName.MatchString(c.Name) {
+		return fmt.Errorf("cache name '%v' must be letters, numbers, '.', '_' and '-'", c.Name)
+	}
+	if c.Path == "" {
+		return fmt.Errorf("cache '%v' must have a path", c.Name)
+	}
+	cleaned := filepath.Clean(c.Path)
+	if filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." ||
+		strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path '%v' of cache '%v' must be inside the task directory", c.Path, c.Name)
+	}
+	for _, pattern := range c.KeyFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("key file '%v' of cache '%v' is not a valid pattern", pattern, c.Name)
+		}
+	}
+	if c.S3 != nil && (c.S3.Bucket == "" || c.S3.AwsKey == "" || c.S3.AwsSecret == "") {
+		return fmt.Errorf("s3 of cache '%v' must have a bucket, aws_key and aws_secret", c.Name)
+	}
+	return nil
+}
+
+// FindTaskCaches returns the caches of the task when it runs on the variant,
+// which are the variant's caches, overridden by name by the task's own.
+func (p *Project) FindTaskCaches(taskName, variantName string) []CacheDir {
+	caches := []CacheDir{}
+	if bv := p.FindBuildVariant(variantName); bv != nil {
+		caches = append(caches, bv.Caches...)
+	}
+	pt := p.FindProjectTask(taskName)
+	if pt == nil {
+		return caches
+	}
+
+	for _, cache := range pt.Caches {
+		overridden := false
+		for i := range caches {
+			if caches[i].Name == cache.Name {
+				caches[i] = cache
+				overridden = true
+			}
+		}
+		if !overridden {
+			caches = append(caches, cache)
+		}
+	}
+	return caches
+}
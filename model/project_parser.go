@@ -5,6 +5,7 @@ import (
 	"reflect"
 
 	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -75,6 +76,7 @@ type parserTask struct {
 	Tags            parserStringSlice   `yaml:"tags"`
 	Patchable       *bool               `yaml:"patchable"`
 	Stepback        *bool               `yaml:"stepback"`
+	Caches          []CacheDir          `yaml:"cache"`
 }
 
 // helper methods for task tag evaluations
@@ -224,6 +226,9 @@ type parserBV struct {
 	RunOn       parserStringSlice  `yaml:"run_on"`
 	Tasks       parserBVTasks      `yaml:"tasks"`
 
+	ResourceLimits *distro.ResourceLimits `yaml:"resource_limits"`
+	Caches         []CacheDir             `yaml:"cache"`
+
 	// internal matrix stuff
 	matrixId  string
 	matrixVal matrixValue
@@ -465,6 +470,7 @@ func evaluateTasks(tse *taskSelectorEvaluator, vse *variantSelectorEvaluator,
 			Tags:            pt.Tags,
 			Patchable:       pt.Patchable,
 			Stepback:        pt.Stepback,
+			Caches:          pt.Caches,
 		}
 		t.DependsOn, errs = evaluateDependsOn(tse, vse, pt.DependsOn)
 		evalErrs = append(evalErrs, errs...)
@@ -493,6 +499,9 @@ func evaluateBuildVariants(tse *taskSelectorEvaluator, vse *variantSelectorEvalu
 			Stepback:    pbv.Stepback,
 			RunOn:       pbv.RunOn,
 			Tags:        pbv.Tags,
+
+			ResourceLimits: pbv.ResourceLimits,
+			Caches:         pbv.Caches,
 		}
 		bv.Tasks, errs = evaluateBVTasks(tse, vse, pbv.Tasks)
 		// evaluate any rules passed in during matrix construction
@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFindProject(t *testing.T) {
@@ -271,3 +272,38 @@ func TestIgnoresAllFiles(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestFindTaskCaches(t *testing.T) {
+	assert := assert.New(t)
+
+	yml := `
+tasks:
+- name: compile
+  cache:
+  - name: gomod
+    path: go/pkg/mod
+    key_files: [src/go.sum]
+- name: lint
+buildvariants:
+- name: linux
+  cache:
+  - name: gomod
+    path: gopath/pkg/mod
+  - name: npm
+    path: src/node_modules
+    key_files: [src/package-lock.json]
+    copy: true
+  tasks:
+  - name: compile
+  - name: lint
+`
+	p, errs := projectFromYAML([]byte(yml))
+	assert.Len(errs, 0)
+
+	assert.Equal([]CacheDir{
+		{Name: "gomod", Path: "go/pkg/mod", KeyFiles: []string{"src/go.sum"}},
+		{Name: "npm", Path: "src/node_modules", KeyFiles: []string{"src/package-lock.json"}, Copy: true},
+	}, p.FindTaskCaches("compile", "linux"))
+	assert.Equal(p.BuildVariants[0].Caches, p.FindTaskCaches("lint", "linux"))
+	assert.Len(p.FindTaskCaches("lint", "windows"), 0)
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	ProviderQuotasCollection = "provider_quotas"
+)
+
+// ProviderQuota records how much of a cloud provider's instance quota and API
+// rate limit the scheduler is using in a region. The scheduler updates it on
+// every run so the stats pages can show it.
+type ProviderQuota struct {
+	Id                string    `bson:"_id" json:"id"`
+	Provider          string    `bson:"provider" json:"provider"`
+	Region            string    `bson:"region" json:"region"`
+	Instances         int       `bson:"instances" json:"instances"`
+	InstanceQuota     int       `bson:"instance_quota" json:"instance_quota"`
+	RequestsPerSecond float64   `bson:"requests_per_second" json:"requests_per_second"`
+	ThrottledRequests int       `bson:"throttled_requests" json:"throttled_requests"`
+	BackoffUntil      time.Time `bson:"backoff_until" json:"backoff_until"`
+	LastUpdated       time.Time `bson:"last_updated" json:"last_updated"`
+}
+
+var (
+	ProviderQuotaIdKey                = bsonutil.MustHaveTag(ProviderQuota{}, "Id")
+	ProviderQuotaProviderKey          = bsonutil.MustHaveTag(ProviderQuota{}, "Provider")
+	ProviderQuotaRegionKey            = bsonutil.MustHaveTag(ProviderQuota{}, "Region")
+	ProviderQuotaInstancesKey         = bsonutil.MustHaveTag(ProviderQuota{}, "Instances")
+	ProviderQuotaInstanceQuotaKey     = bsonutil.MustHaveTag(ProviderQuota{}, "InstanceQuota")
+	ProviderQuotaRequestsPerSecondKey = bsonutil.MustHaveTag(ProviderQuota{}, "RequestsPerSecond")
+	ProviderQuotaThrottledRequestsKey = bsonutil.MustHaveTag(ProviderQuota{}, "ThrottledRequests")
+	ProviderQuotaBackoffUntilKey      = bsonutil.MustHaveTag(ProviderQuota{}, "BackoffUntil")
+	ProviderQuotaLastUpdatedKey       = bsonutil.MustHaveTag(ProviderQuota{}, "LastUpdated")
+)
+
+// ProviderQuotaId returns the id of the quota record for a provider and region.
+func ProviderQuotaId(provider, region string) string {
+	return provider + "/" + region
+}
+
+// FindAllProviderQuotas returns the quota records for every provider and
+// region, sorted by provider and region.
+func FindAllProviderQuotas() ([]ProviderQuota, error) {
+	quotas := []ProviderQuota{}
+	err := db.FindAll(
+		ProviderQuotasCollection,
+		bson.M{},
+		db.NoProjection,
+		[]string{ProviderQuotaProviderKey, ProviderQuotaRegionKey},
+		db.NoSkip,
+		db.NoLimit,
+		&quotas,
+	)
+	return quotas, err
+}
+
+// Upsert saves the quota record, replacing any previous record for the same
+// provider and region.
+func (q *ProviderQuota) Upsert() error {
+	q.Id = ProviderQuotaId(q.Provider, q.Region)
+	_, err := db.Upsert(
+		ProviderQuotasCollection,
+		bson.M{
+			ProviderQuotaIdKey: q.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				ProviderQuotaProviderKey:          q.Provider,
+				ProviderQuotaRegionKey:            q.Region,
+				ProviderQuotaInstancesKey:         q.Instances,
+				ProviderQuotaInstanceQuotaKey:     q.InstanceQuota,
+				ProviderQuotaRequestsPerSecondKey: q.RequestsPerSecond,
+				ProviderQuotaThrottledRequestsKey: q.ThrottledRequests,
+				ProviderQuotaBackoffUntilKey:      q.BackoffUntil,
+				ProviderQuotaLastUpdatedKey:       q.LastUpdated,
+			},
+		},
+	)
+	return err
+}
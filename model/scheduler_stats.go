@@ -47,6 +47,7 @@ type FrameBounds struct {
 }
 
 // HostUtilizationBucket represents an aggregate view of the hosts and tasks Bucket for a given time frame.
+// Host time is counted per task slot.
 type HostUtilizationBucket struct {
 	StaticHost  time.Duration `json:"static_host" csv:"static_host"`
 	DynamicHost time.Duration `json:"dynamic_host" csv:"dynamic_host"`
@@ -163,10 +164,11 @@ func bucketResource(resource ResourceInfo, frameStart, frameEnd time.Time, bucke
 }
 
 // CreateHostBuckets takes in a list of hosts with their creation and termination times
-// and returns durations bucketed based on a start time, number of buckets and the size of each bucket
+// and returns durations bucketed based on a start time, number of buckets and the size of each bucket.
+// Hosts with more than one task slot count once for each slot, so that the
+// durations can be compared with the time spent running tasks.
 func CreateHostBuckets(hosts []host.Host, bounds FrameBounds) ([]Bucket, []error) {
 	hostBuckets := make([]Bucket, bounds.NumberBuckets)
-	var err error
 	errs := []error{}
 	for _, h := range hosts {
 		hostResource := ResourceInfo{
@@ -174,17 +176,27 @@ func CreateHostBuckets(hosts []host.Host, bounds FrameBounds) ([]Bucket, []error
 			Start: h.CreationTime,
 			End:   h.TerminationTime,
 		}
+		slots := time.Duration(h.Distro.Slots())
 
 		// static hosts
 		if h.Provider == evergreen.HostTypeStatic {
 			for i, b := range hostBuckets {
-				hostBuckets[i] = addBucketTime(bounds.BucketSize, hostResource, b)
+				hostBuckets[i] = addBucketTime(bounds.BucketSize*slots, hostResource, b)
 			}
 			continue
 		}
-		hostBuckets, err = bucketResource(hostResource, bounds.StartTime, bounds.EndTime, bounds.BucketSize, hostBuckets)
+
+		// bucket the host on its own so that its time can be scaled by its slots
+		buckets, err := bucketResource(hostResource, bounds.StartTime, bounds.EndTime, bounds.BucketSize,
+			make([]Bucket, bounds.NumberBuckets))
 		if err != nil {
 			errs = append(errs, errors.Wrapf(err, "error bucketing host %s", h.Id))
+			continue
+		}
+		for i, b := range buckets {
+			if len(b.Resources) > 0 {
+				hostBuckets[i] = addBucketTime(b.TotalTime*slots, hostResource, hostBuckets[i])
+			}
 		}
 	}
 	return hostBuckets, errs
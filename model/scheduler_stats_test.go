@@ -13,6 +13,7 @@ import (
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 var projectTestConfig = testutil.TestConfig()
@@ -187,6 +188,34 @@ func TestCreateHostBuckets(t *testing.T) {
 	})
 }
 
+func TestCreateHostBucketsCountsTaskSlots(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	bounds := FrameBounds{
+		StartTime:     now,
+		EndTime:       now.Add(20 * time.Second),
+		BucketSize:    10 * time.Second,
+		NumberBuckets: 2,
+	}
+
+	hosts := []host.Host{
+		// 5 -> 15, with 4 slots
+		{Id: "big", CreationTime: now.Add(5 * time.Second), TerminationTime: now.Add(15 * time.Second),
+			Provider: "ec2", Distro: distro.Distro{TaskSlots: 4}},
+		// 0 -> 20, with 1 slot
+		{Id: "small", CreationTime: now, TerminationTime: now.Add(20 * time.Second), Provider: "ec2"},
+		// always up, with 2 slots
+		{Id: "static", Provider: evergreen.HostTypeStatic, Distro: distro.Distro{TaskSlots: 2}},
+	}
+
+	buckets, errs := CreateHostBuckets(hosts, bounds)
+	assert.Empty(errs)
+	assert.Len(buckets, 2)
+	assert.Equal(50*time.Second, buckets[0].TotalTime)
+	assert.Equal(50*time.Second, buckets[1].TotalTime)
+	assert.Len(buckets[0].Resources, 3)
+}
+
 func TestCreateTaskBuckets(t *testing.T) {
 	testutil.HandleTestingErr(db.ClearCollections(task.Collection), t, "couldnt reset host")
 	Convey("With a starting time and a minute bucket size and inserting tasks with different start and finish", t, func() {
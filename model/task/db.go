@@ -168,6 +168,16 @@ func ByCommit(revision, buildVariant, displayName, project, requester string) db
 	})
 }
 
+// ByVersionBuildVariantAndDisplayName creates a query for the task with a
+// display name in a build variant of a version.
+func ByVersionBuildVariantAndDisplayName(version, buildVariant, displayName string) db.Q {
+	return db.Query(bson.M{
+		VersionKey:      version,
+		BuildVariantKey: buildVariant,
+		DisplayNameKey:  displayName,
+	})
+}
+
 // ByStatusAndActivation creates a query that returns tasks of a certain status and activation state.
 func ByStatusAndActivation(status string, active bool) db.Q {
 	return db.Query(bson.M{
@@ -165,7 +165,7 @@ func flagExcessHosts(distros []distro.Distro, s *evergreen.Settings) ([]host.Hos
 
 				// if the host is not running a task, it can be
 				// safely terminated
-				if host.RunningTask == "" {
+				if len(host.RunningTasks()) == 0 {
 					excessHosts = append(excessHosts, host)
 					counter++
 				}
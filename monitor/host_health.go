@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/alerts"
+	"github.com/evergreen-ci/evergreen/cloud/providers/static"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// how long to wait in between health checks of static hosts
+	HealthCheckInterval = 5 * time.Minute
+)
+
+// healthCheckTransition is a change in a host's status caused by the outcome
+// of its health checks
+type healthCheckTransition int
+
+const (
+	noHealthTransition healthCheckTransition = iota
+	quarantineHost
+	returnHostToService
+)
+
+// monitorStaticHostHealth is a hostMonitoringFunc responsible for running
+// health checks against static hosts, quarantining the ones that keep failing
+// and returning them to service once they recover
+func monitorStaticHostHealth(settings *evergreen.Settings) []error {
+	grip.Info("Running static host health checks...")
+
+	threshold := time.Now().Add(-HealthCheckInterval)
+	hosts, err := host.Find(host.ByHealthCheckDue(static.ProviderName, threshold))
+	if err != nil {
+		return []error{errors.Wrap(err, "error finding static hosts due for health checks")}
+	}
+
+	errChan := make(chan error)
+	for _, h := range hosts {
+		// check the host in a goroutine, passing the host in as a parameter
+		// so that the variable isn't reused for subsequent iterations
+		go func(hostToCheck host.Host) {
+			errChan <- checkStaticHostHealth(hostToCheck, settings)
+		}(h)
+	}
+
+	var errs []error
+	for range hosts {
+		if err := <-errChan; err != nil {
+			errs = append(errs, errors.Wrap(err, "error checking static host health"))
+		}
+	}
+	return errs
+}
+
+// run the health checks for a single static host, and take any necessary action
+func checkStaticHostHealth(h host.Host, settings *evergreen.Settings) error {
+	healthSettings, err := static.GetHealthCheckSettings(&h.Distro)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if healthSettings.Disabled {
+		return nil
+	}
+
+	keyPath := ""
+	if h.Distro.SSHKey != "" {
+		keyPath = settings.Keys[h.Distro.SSHKey]
+	}
+
+	grip.Infoln("Running health checks for host:", h.Id)
+	staticMgr := &static.StaticManager{}
+	checkErr := staticMgr.CheckHealth(&h, keyPath, healthSettings)
+	if checkErr != nil {
+		grip.Warningf("Host %s failed health check: %v", h.Id, checkErr)
+	}
+
+	status, transition := updateHealthCheck(&h, healthSettings, checkErr, time.Now())
+	if err = h.SetHealthCheck(status); err != nil {
+		return errors.Wrapf(err, "error saving health check status for host %s", h.Id)
+	}
+
+	switch transition {
+	case quarantineHost:
+		grip.Warningf("Quarantining host %s after %d failed health checks",
+			h.Id, status.ConsecutiveFailures)
+		if err = h.SetQuarantined(); err != nil {
+			return errors.Wrapf(err, "error quarantining host %s", h.Id)
+		}
+		event.LogHostQuarantined(h.Id, status.LastError)
+		grip.Error(errors.Wrapf(alerts.RunHostQuarantinedTriggers(&h),
+			"error queuing quarantine alert for host %s", h.Id))
+	case returnHostToService:
+		grip.Infof("Returning host %s to service after %d passed health checks",
+			h.Id, status.ConsecutivePasses)
+		if err = h.SetStatus(evergreen.HostRunning); err != nil {
+			return errors.Wrapf(err, "error returning host %s to service", h.Id)
+		}
+		event.LogHostReturnedToService(h.Id)
+		grip.Error(errors.Wrapf(alerts.RunHostReturnedToServiceTriggers(&h),
+			"error queuing recovery alert for host %s", h.Id))
+	}
+
+	return nil
+}
+
+// updateHealthCheck records the outcome of a health check in the host's
+// health check status, and decides whether the host should be quarantined or
+// returned to service. Only hosts that were quarantined by their health
+// checks are returned to service; hosts quarantined by an admin stay that way.
+func updateHealthCheck(h *host.Host, settings *static.HealthCheckSettings, checkErr error,
+	now time.Time) (host.HealthCheckStatus, healthCheckTransition) {
+
+	status := h.HealthCheck
+	status.LastCheck = now
+	if checkErr != nil {
+		status.ConsecutiveFailures++
+		status.ConsecutivePasses = 0
+		status.LastError = checkErr.Error()
+	} else {
+		status.ConsecutivePasses++
+		status.ConsecutiveFailures = 0
+		status.LastError = ""
+	}
+
+	if h.Status != evergreen.HostQuarantined {
+		// the host may have been returned to service by an admin
+		status.Quarantined = false
+		if status.ConsecutiveFailures >= settings.GetFailureThreshold() {
+			status.Quarantined = true
+			return status, quarantineHost
+		}
+		return status, noHealthTransition
+	}
+
+	if status.Quarantined && status.ConsecutivePasses >= settings.GetRecoveryThreshold() {
+		status.Quarantined = false
+		return status, returnHostToService
+	}
+	return status, noHealthTransition
+}
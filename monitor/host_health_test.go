@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud/providers/static"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateHealthCheckQuarantine(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := &static.HealthCheckSettings{FailureThreshold: 2, RecoveryThreshold: 2}
+	now := time.Now()
+	checkErr := errors.New("host is not reachable over ssh")
+	h := &host.Host{Status: evergreen.HostRunning}
+
+	// a running host is quarantined once it fails enough checks in a row
+	status, transition := updateHealthCheck(h, settings, checkErr, now)
+	assert.Equal(noHealthTransition, transition)
+	assert.Equal(1, status.ConsecutiveFailures)
+	assert.Equal(checkErr.Error(), status.LastError)
+	assert.Equal(now, status.LastCheck)
+
+	h.HealthCheck = status
+	status, transition = updateHealthCheck(h, settings, checkErr, now)
+	assert.Equal(quarantineHost, transition)
+	assert.True(status.Quarantined)
+
+	// a passed check resets the count of failures
+	h.HealthCheck = host.HealthCheckStatus{ConsecutiveFailures: 1, LastError: "failed"}
+	status, transition = updateHealthCheck(h, settings, nil, now)
+	assert.Equal(noHealthTransition, transition)
+	assert.Equal(0, status.ConsecutiveFailures)
+	assert.Equal(1, status.ConsecutivePasses)
+	assert.Empty(status.LastError)
+}
+
+func TestUpdateHealthCheckReturnToService(t *testing.T) {
+	assert := assert.New(t)
+
+	settings := &static.HealthCheckSettings{FailureThreshold: 2, RecoveryThreshold: 2}
+	now := time.Now()
+	h := &host.Host{
+		Status:      evergreen.HostQuarantined,
+		HealthCheck: host.HealthCheckStatus{ConsecutiveFailures: 2, Quarantined: true},
+	}
+
+	// a quarantined host is returned to service once it passes enough checks in a row
+	status, transition := updateHealthCheck(h, settings, nil, now)
+	assert.Equal(noHealthTransition, transition)
+
+	h.HealthCheck = status
+	status, transition = updateHealthCheck(h, settings, nil, now)
+	assert.Equal(returnHostToService, transition)
+	assert.False(status.Quarantined)
+
+	// a host quarantined by an admin stays quarantined
+	h.HealthCheck = host.HealthCheckStatus{ConsecutivePasses: 5}
+	_, transition = updateHealthCheck(h, settings, nil, now)
+	assert.Equal(noHealthTransition, transition)
+}
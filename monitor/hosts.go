@@ -121,11 +121,11 @@ func terminateHosts(hosts []host.Host, settings *evergreen.Settings, reason stri
 
 // helper to terminate a single host
 func terminateHost(h *host.Host, settings *evergreen.Settings) error {
-	// clear the running task of the host in case one has been assigned.
-	if h.RunningTask != "" {
-		grip.Warningf("Host has running task: %s. Clearing running task field for host"+
-			"before terminating.", h.RunningTask)
-		err := h.ClearRunningTask(h.RunningTask, time.Now())
+	// clear the running tasks of the host in case any have been assigned.
+	if runningTasks := h.RunningTasks(); len(runningTasks) > 0 {
+		grip.Warningf("Host has running tasks: %v. Clearing running task fields for host"+
+			"before terminating.", runningTasks)
+		err := h.ClearAllRunningTasks(time.Now())
 		if err != nil {
 			grip.Errorf("Error clearing running task for host: %s", h.Id)
 		}
@@ -35,6 +35,7 @@ var (
 	// the functions the host monitor will run through to do simpler checks
 	defaultHostMonitoringFuncs = []hostMonitoringFunc{
 		monitorReachability,
+		monitorStaticHostHealth,
 	}
 
 	// the functions the notifier will use to build notifications that need
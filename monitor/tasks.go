@@ -97,8 +97,8 @@ func cleanUpTask(wrapper doomedTaskWrapper, projects map[string]model.Project) e
 		return errors.WithStack(wrapper.task.MarkUnscheduled())
 	}
 
-	// if the host still has the task as its running task, clear it.
-	if host.RunningTask == wrapper.task.Id {
+	// if the host still has the task as one of its running tasks, clear it.
+	if host.IsRunningTask(wrapper.task.Id) {
 		// clear out the host's running task
 		if err = host.ClearRunningTask(wrapper.task.Id, time.Now()); err != nil {
 			return errors.Wrapf(err, "error clearing running task %v from host %v: %v",
@@ -9,9 +9,13 @@ func init() {
 }
 
 const (
-	TarGzPackCmdName   = "targz_pack"
-	TarGzUnpackCmdName = "targz_unpack"
-	ArchivePluginName  = "archive"
+	TarGzPackCmdName    = "targz_pack"
+	TarGzUnpackCmdName  = "targz_unpack"
+	TarZstPackCmdName   = "tarzst_pack"
+	TarZstUnpackCmdName = "tarzst_unpack"
+	ZipPackCmdName      = "zip_pack"
+	ZipUnpackCmdName    = "zip_unpack"
+	ArchivePluginName   = "archive"
 )
 
 // ArchivePlugin holds commands for creating archives and extracting
@@ -32,5 +36,17 @@ func (self *ArchivePlugin) NewCommand(cmdName string) (plugin.Command, error) {
 	if cmdName == TarGzUnpackCmdName {
 		return &TarGzUnpackCommand{}, nil
 	}
+	if cmdName == TarZstPackCmdName {
+		return &PackCommand{format: FormatTarZst}, nil
+	}
+	if cmdName == TarZstUnpackCmdName {
+		return &UnpackCommand{format: FormatTarZst}, nil
+	}
+	if cmdName == ZipPackCmdName {
+		return &PackCommand{format: FormatZip}, nil
+	}
+	if cmdName == ZipUnpackCmdName {
+		return &UnpackCommand{format: FormatZip}, nil
+	}
 	return nil, &plugin.ErrUnknownCommand{cmdName}
 }
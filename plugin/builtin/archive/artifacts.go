@@ -26,6 +26,16 @@ type TarContentsFile struct {
 // Returns the number of files that were added to the archive
 func BuildArchive(tarWriter *tar.Writer, rootPath string, includes []string,
 	excludes []string, log *slogger.Logger) (int, error) {
+	return buildArchive(&tarArchiveWriter{tarWriter: tarWriter}, rootPath, includes, excludes, log)
+}
+
+// buildArchive reads the rootPath directory into any kind of archive, taking
+// included and excluded strings into account. Symlinks to files that are
+// archived too are added as symlinks, and the files underlying any others are
+// added in their place. Returns the number of files that were added to the
+// archive.
+func buildArchive(archive archiveWriter, rootPath string, includes []string,
+	excludes []string, log *slogger.Logger) (int, error) {
 
 	pathsToAdd := make(chan TarContentsFile)
 	done := make(chan bool)
@@ -82,18 +92,29 @@ func BuildArchive(tarWriter *tar.Writer, rootPath string, includes []string,
 	}(pathsToAdd)
 
 	go func(inputChan chan TarContentsFile) {
-		processed := map[string]bool{}
-	FileChanLoop:
+		// read every file first, so that symlinks are only kept when the
+		// files they point to are archived as well
+		files := []TarContentsFile{}
 		for file := range inputChan {
+			files = append(files, file)
+		}
+		archived := archivedPaths(files, excludes)
+
+		processed := map[string]bool{}
+		for _, file := range files {
 			var intarball string
-			// Tarring symlinks doesn't work reliably right now, so if the file is
-			// a symlink, leave intarball path intact but write from the file
+			var linkTarget string
+			// Symlinks to archived files are kept as symlinks. For any
+			// others, leave intarball path intact but write from the file
 			// underlying the symlink.
 			if file.info.Mode()&os.ModeSymlink > 0 {
 				symlinkPath, err := filepath.EvalSymlinks(file.path)
 				if err != nil {
 					log.Logf(slogger.WARN, "Could not follow symlink %v, ignoring", file.path)
 					continue
+				} else if target, ok := symlinkInRoot(file.path, symlinkPath, rootPath, archived); ok {
+					linkTarget = target
+					intarball = strings.Replace(file.path, "\\", "/", -1)
 				} else {
 					log.Logf(slogger.INFO, "Following symlink in %v, got: %v", file.path, symlinkPath)
 					symlinkFileInfo, err := os.Stat(symlinkPath)
@@ -128,48 +149,23 @@ func BuildArchive(tarWriter *tar.Writer, rootPath string, includes []string,
 				continue
 			}
 
-			_, fileName := filepath.Split(file.path)
-			for _, ignore := range excludes {
-				if match, _ := filepath.Match(ignore, fileName); match {
-					continue FileChanLoop
-				}
+			if isExcluded(file.path, excludes) {
+				continue
 			}
 
-			hdr := new(tar.Header)
-			hdr.Name = strings.TrimPrefix(intarball, rootPathPrefix)
-			hdr.Mode = int64(file.info.Mode())
-			hdr.Size = file.info.Size()
-			hdr.ModTime = file.info.ModTime()
-
+			name := strings.TrimPrefix(intarball, rootPathPrefix)
 			numFilesArchived++
-			err := tarWriter.WriteHeader(hdr)
-			if err != nil {
-				errChan <- errors.Wrapf(err, "Error writing header for %v", intarball)
-				return
-			}
-
-			in, err := os.Open(file.path)
-			if err != nil {
-				errChan <- errors.Wrapf(err, "Error opening %v", file.path)
-				return
-			}
-
-			amountWrote, err := io.Copy(tarWriter, in)
-			if err != nil {
-				grip.Debug(in.Close())
-				errChan <- errors.Wrapf(err, "Error writing into tar for %v", file.path)
-				return
+			if linkTarget != "" {
+				if err := archive.addSymlink(name, file.info, linkTarget); err != nil {
+					errChan <- errors.Wrapf(err, "Error writing symlink %v", intarball)
+					return
+				}
+				continue
 			}
-
-			if amountWrote != hdr.Size {
-				grip.Debug(in.Close())
-				errChan <- errors.Errorf(`Error writing to archive for %v:
-					header size %v but wrote %v`,
-					intarball, hdr.Size, amountWrote)
+			if err := archive.addFile(name, file.info, file.path); err != nil {
+				errChan <- err
 				return
 			}
-			grip.Debug(in.Close())
-			grip.Warning(tarWriter.Flush())
 		}
 		done <- true
 	}(pathsToAdd)
@@ -182,6 +178,65 @@ func BuildArchive(tarWriter *tar.Writer, rootPath string, includes []string,
 	}
 }
 
+// isExcluded returns whether the file at path matches any of the excluded
+// patterns.
+func isExcluded(path string, excludes []string) bool {
+	_, fileName := filepath.Split(path)
+	for _, ignore := range excludes {
+		if match, _ := filepath.Match(ignore, fileName); match {
+			return true
+		}
+	}
+	return false
+}
+
+// archivedPaths returns the paths of the files that are archived, and of the
+// directories they're in.
+func archivedPaths(files []TarContentsFile, excludes []string) map[string]bool {
+	archived := map[string]bool{}
+	for _, file := range files {
+		if file.info == nil || file.info.IsDir() || isExcluded(file.path, excludes) {
+			continue
+		}
+		// mark the file and then its directories, up to one that's marked
+		for path := filepath.Clean(file.path); !archived[path]; path = filepath.Dir(path) {
+			archived[path] = true
+			if filepath.Dir(path) == path {
+				break
+			}
+		}
+	}
+	return archived
+}
+
+// symlinkInRoot returns the target of the symlink at path, if it's relative,
+// it and the file it resolves to are inside rootPath, and its target is
+// archived, so that it still works wherever the archive is extracted.
+func symlinkInRoot(path, resolved, rootPath string, archived map[string]bool) (string, bool) {
+	target, err := os.Readlink(path)
+	if err != nil || filepath.IsAbs(target) {
+		return "", false
+	}
+	root, err := filepath.EvalSymlinks(rootPath)
+	if err != nil {
+		return "", false
+	}
+	linked := filepath.Join(filepath.Dir(path), target)
+	if !isWithin(root, resolved) || !isWithin(rootPath, linked) || !archived[linked] {
+		return "", false
+	}
+	return filepath.ToSlash(target), true
+}
+
+// isWithin returns whether path is inside the root directory.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // Extract unpacks the tar.Reader into rootPath.
 func Extract(tarReader *tar.Reader, rootPath string) error {
 	for {
@@ -234,12 +289,34 @@ func Extract(tarReader *tar.Reader, rootPath string) error {
 				return errors.WithStack(err)
 			}
 			grip.CatchError(f.Close())
+		} else if hdr.Typeflag == tar.TypeSymlink {
+			localFile := fmt.Sprintf("%v/%v", rootPath, hdr.Name)
+			if err = extractSymlink(rootPath, localFile, hdr.Linkname); err != nil {
+				return errors.WithStack(err)
+			}
 		} else {
 			return errors.New("Unknown file type in archive.")
 		}
 	}
 }
 
+// extractSymlink creates a symlink to target at localFile, refusing to link
+// to anything outside of rootPath.
+func extractSymlink(rootPath, localFile, target string) error {
+	target = filepath.FromSlash(target)
+	if filepath.IsAbs(target) || !isWithin(rootPath, filepath.Join(filepath.Dir(localFile), target)) {
+		return errors.Errorf("symlink %v points outside of %v", localFile, rootPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+		return err
+	}
+	// replace anything already there, like a file does
+	if err := os.Remove(localFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, localFile)
+}
+
 // TarGzReader returns a file, gzip reader, and tar reader for the given path.
 // The tar reader wraps the gzip reader, which wraps the file.
 func TarGzReader(path string) (f, gz io.ReadCloser, tarReader *tar.Reader, err error) {
@@ -257,13 +334,18 @@ func TarGzReader(path string) (f, gz io.ReadCloser, tarReader *tar.Reader, err e
 }
 
 // TarGzWriter returns a file, gzip writer, and tarWriter for the path.
-// The tar writer wraps the gzip writer, which wraps the file.
+// The tar writer wraps the gzip writer, which wraps the file. The gzip writer
+// compresses blocks of the archive in parallel.
 func TarGzWriter(path string) (f, gz io.WriteCloser, tarWriter *tar.Writer, err error) {
 	f, err = os.Create(path)
 	if err != nil {
 		return nil, nil, nil, errors.WithStack(err)
 	}
-	gz = gzip.NewWriter(f)
+	gz, err = newParallelGzipWriter(f)
+	if err != nil {
+		defer f.Close()
+		return nil, nil, nil, errors.WithStack(err)
+	}
 	tarWriter = tar.NewWriter(gz)
 	return f, gz, tarWriter, nil
 }
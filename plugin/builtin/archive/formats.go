@@ -0,0 +1,376 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FormatTarGz is a gzipped tarball.
+	FormatTarGz = "targz"
+	// FormatTarZst is a tarball compressed with zstd. Creating and extracting
+	// them needs the zstd command line tool.
+	FormatTarZst = "tarzst"
+	// FormatZip is a zip file.
+	FormatZip = "zip"
+)
+
+// FormatOf returns the format of the archive with the given file name, from
+// its extension, or "" if it isn't an archive of a known format.
+func FormatOf(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return FormatTarZst
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	default:
+		return ""
+	}
+}
+
+// archiveWriter adds files to an archive of some format. File modes are
+// kept the same way in every format.
+type archiveWriter interface {
+	// addFile adds the file at path to the archive under name.
+	addFile(name string, info os.FileInfo, path string) error
+	// addSymlink adds a symlink to target to the archive under name.
+	addSymlink(name string, info os.FileInfo, target string) error
+	// Close finishes the archive and closes the file it's written to.
+	Close() error
+}
+
+// newArchiveWriter creates an archive of the given format at path.
+func newArchiveWriter(format, path string) (archiveWriter, error) {
+	switch format {
+	case FormatTarGz:
+		f, gz, tarWriter, err := TarGzWriter(path)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveWriter{tarWriter: tarWriter, closers: []io.Closer{gz, f}}, nil
+	case FormatTarZst:
+		return newTarZstWriter(path)
+	case FormatZip:
+		return newZipArchiveWriter(path)
+	default:
+		return nil, errors.Errorf("unknown archive format '%v'", format)
+	}
+}
+
+// extractArchive extracts the archive of the given format at path into
+// rootPath.
+func extractArchive(format, path, rootPath string) error {
+	switch format {
+	case FormatTarGz:
+		f, gz, tarReader, err := TarGzReader(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		defer gz.Close()
+		return Extract(tarReader, rootPath)
+	case FormatTarZst:
+		return extractTarZst(path, rootPath)
+	case FormatZip:
+		return ExtractZip(path, rootPath)
+	default:
+		return errors.Errorf("unknown archive format '%v'", format)
+	}
+}
+
+// tarArchiveWriter adds files to a tarball, closing the writers under it in
+// order when it's closed.
+type tarArchiveWriter struct {
+	tarWriter *tar.Writer
+	closers   []io.Closer
+}
+
+func (tw *tarArchiveWriter) addFile(name string, info os.FileInfo, path string) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}
+	if err := tw.tarWriter.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "Error writing header for %v", name)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening %v", path)
+	}
+	defer in.Close()
+
+	amountWrote, err := io.Copy(tw.tarWriter, in)
+	if err != nil {
+		return errors.Wrapf(err, "Error writing into tar for %v", path)
+	}
+	if amountWrote != hdr.Size {
+		return errors.Errorf("Error writing to archive for %v: header size %v but wrote %v",
+			name, hdr.Size, amountWrote)
+	}
+	grip.Warning(tw.tarWriter.Flush())
+	return nil
+}
+
+func (tw *tarArchiveWriter) addSymlink(name string, info os.FileInfo, target string) error {
+	return errors.WithStack(tw.tarWriter.WriteHeader(&tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeSymlink,
+		Linkname: target,
+		Mode:     int64(info.Mode().Perm()),
+		ModTime:  info.ModTime(),
+	}))
+}
+
+func (tw *tarArchiveWriter) Close() error {
+	catcher := grip.NewCatcher()
+	catcher.Add(tw.tarWriter.Close())
+	for _, closer := range tw.closers {
+		catcher.Add(closer.Close())
+	}
+	return catcher.Resolve()
+}
+
+// zipArchiveWriter adds files to a zip file, compressing each with a
+// parallelDeflateWriter.
+type zipArchiveWriter struct {
+	f         *os.File
+	zipWriter *zip.Writer
+}
+
+func newZipArchiveWriter(path string) (*zipArchiveWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	zipWriter := zip.NewWriter(f)
+	zipWriter.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return newParallelDeflateWriter(w, flate.DefaultCompression, runtime.NumCPU()), nil
+	})
+	return &zipArchiveWriter{f: f, zipWriter: zipWriter}, nil
+}
+
+func (zw *zipArchiveWriter) addFile(name string, info os.FileInfo, path string) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return errors.Wrapf(err, "Error making header for %v", name)
+	}
+	hdr.Name = name
+	hdr.Method = zip.Deflate
+	hdr.SetMode(info.Mode().Perm())
+
+	out, err := zw.zipWriter.CreateHeader(hdr)
+	if err != nil {
+		return errors.Wrapf(err, "Error writing header for %v", name)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening %v", path)
+	}
+	defer in.Close()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "Error writing into zip for %v", path)
+	}
+	return nil
+}
+
+// addSymlink adds a symlink the way Info-ZIP does, as an entry with the
+// symlink mode whose contents are the target.
+func (zw *zipArchiveWriter) addSymlink(name string, info os.FileInfo, target string) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return errors.Wrapf(err, "Error making header for %v", name)
+	}
+	hdr.Name = name
+	hdr.Method = zip.Store
+	hdr.SetMode(os.ModeSymlink | info.Mode().Perm())
+
+	out, err := zw.zipWriter.CreateHeader(hdr)
+	if err != nil {
+		return errors.Wrapf(err, "Error writing header for %v", name)
+	}
+	_, err = io.WriteString(out, target)
+	return errors.WithStack(err)
+}
+
+func (zw *zipArchiveWriter) Close() error {
+	catcher := grip.NewCatcher()
+	catcher.Add(zw.zipWriter.Close())
+	catcher.Add(zw.f.Close())
+	return catcher.Resolve()
+}
+
+// ExtractZip unpacks the zip file at path into rootPath, keeping file modes
+// and symlinks.
+func ExtractZip(path, rootPath string) error {
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		localFile := filepath.Join(rootPath, filepath.FromSlash(file.Name))
+		if !isWithin(rootPath, localFile) {
+			return errors.Errorf("file %v would be extracted outside of %v", file.Name, rootPath)
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err = os.MkdirAll(localFile, 0755); err != nil {
+				return errors.WithStack(err)
+			}
+		case mode&os.ModeSymlink != 0:
+			if err = extractZipSymlink(file, rootPath, localFile); err != nil {
+				return err
+			}
+		default:
+			if err = extractZipFile(file, localFile); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func extractZipSymlink(file *zip.File, rootPath, localFile string) error {
+	in, err := file.Open()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+
+	target, err := ioutil.ReadAll(io.LimitReader(in, 4096))
+	if err != nil {
+		return errors.Wrapf(err, "error reading symlink %v", file.Name)
+	}
+	return errors.WithStack(extractSymlink(rootPath, localFile, string(target)))
+}
+
+func extractZipFile(file *zip.File, localFile string) error {
+	if err := os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	in, err := file.Open()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+
+	f, err := os.Create(localFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = io.Copy(f, in); err != nil {
+		grip.CatchError(f.Close())
+		return errors.WithStack(err)
+	}
+
+	// File's permissions should match what was in the archive
+	if err = os.Chmod(f.Name(), file.Mode().Perm()); err != nil {
+		grip.CatchError(f.Close())
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
+
+// tarZstWriter writes a tarball through the zstd command line tool, which
+// compresses it using a thread per CPU.
+type tarZstWriter struct {
+	tarArchiveWriter
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	f     *os.File
+}
+
+func newTarZstWriter(path string) (*tarZstWriter, error) {
+	zstdPath, err := exec.LookPath("zstd")
+	if err != nil {
+		return nil, errors.Wrap(err, "zstd must be installed to create tar.zst archives")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cmd := exec.Command(zstdPath, "-q", "-T0", "-c")
+	cmd.Stdout = f
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		grip.CatchError(f.Close())
+		return nil, errors.WithStack(err)
+	}
+	if err = cmd.Start(); err != nil {
+		grip.CatchError(f.Close())
+		return nil, errors.Wrap(err, "error starting zstd")
+	}
+
+	return &tarZstWriter{
+		tarArchiveWriter: tarArchiveWriter{tarWriter: tar.NewWriter(stdin)},
+		cmd:              cmd,
+		stdin:            stdin,
+		f:                f,
+	}, nil
+}
+
+func (zw *tarZstWriter) Close() error {
+	catcher := grip.NewCatcher()
+	catcher.Add(zw.tarWriter.Close())
+	catcher.Add(zw.stdin.Close())
+	catcher.Add(errors.Wrap(zw.cmd.Wait(), "error running zstd"))
+	catcher.Add(zw.f.Close())
+	return catcher.Resolve()
+}
+
+// extractTarZst decompresses the tarball at path with the zstd command line
+// tool and extracts it into rootPath.
+func extractTarZst(path, rootPath string) error {
+	zstdPath, err := exec.LookPath("zstd")
+	if err != nil {
+		return errors.Wrap(err, "zstd must be installed to extract tar.zst archives")
+	}
+
+	cmd := exec.Command(zstdPath, "-q", "-d", "-c", path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = cmd.Start(); err != nil {
+		return errors.Wrap(err, "error starting zstd")
+	}
+
+	err = Extract(tar.NewReader(stdout), rootPath)
+	if err != nil {
+		// let zstd exit rather than block writing what wasn't read
+		grip.CatchError(cmd.Process.Kill())
+		grip.CatchError(cmd.Wait())
+		return err
+	}
+	// read whatever padding follows the end of the tarball
+	if _, err = io.Copy(ioutil.Discard, stdout); err != nil {
+		grip.CatchError(cmd.Wait())
+		return errors.WithStack(err)
+	}
+	return errors.Wrapf(cmd.Wait(), "error decompressing %v", path)
+}
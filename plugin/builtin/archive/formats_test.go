@@ -0,0 +1,207 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelGzipWriter(t *testing.T) {
+	// several blocks of hard to compress data, and a partial one
+	data := make([]byte, 3*parallelBlockSize+1234)
+	rand.New(rand.NewSource(1)).Read(data[:parallelBlockSize])
+	copy(data[2*parallelBlockSize:], bytes.Repeat([]byte("evergreen "), parallelBlockSize/10))
+
+	buf := &bytes.Buffer{}
+	gw, err := newParallelGzipWriter(buf)
+	require.NoError(t, err)
+	// write in pieces that don't line up with the blocks
+	for i := 0; i < len(data); i += 100000 {
+		end := i + 100000
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err = gw.Write(data[i:end])
+		require.NoError(t, err)
+	}
+	require.NoError(t, gw.Close())
+
+	gr, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	out, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, out))
+	assert.NoError(t, gr.Close())
+}
+
+func TestParallelGzipWriterEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gw, err := newParallelGzipWriter(buf)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	gr, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	out, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+// makeArchiveSource creates a directory with a file, an executable, a
+// symlink inside of it and a symlink to a file outside of it.
+func makeArchiveSource(t *testing.T, dir string) string {
+	source := filepath.Join(dir, "source")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "data.txt"), []byte("data"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "bin", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "debug.pdb"), []byte("symbols"), 0644))
+	require.NoError(t, os.Symlink("../data.txt", filepath.Join(source, "bin", "link.txt")))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "outside.txt"), []byte("outside"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "outside.txt"), filepath.Join(source, "outside_link.txt")))
+	return source
+}
+
+func testArchiveRoundTrip(t *testing.T, format string) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "archive-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := makeArchiveSource(t, dir)
+	target := filepath.Join(dir, "archive")
+	numFiles, err := makeArchive(format, target, source, []string{"**"}, []string{"*.pdb"}, &plugintest.MockLogger{})
+	require.NoError(t, err)
+	assert.Equal(4, numFiles)
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, Unpack(format, target, dest))
+
+	data, err := ioutil.ReadFile(filepath.Join(dest, "data.txt"))
+	require.NoError(t, err)
+	assert.Equal("data", string(data))
+	_, err = os.Stat(filepath.Join(dest, "debug.pdb"))
+	assert.True(os.IsNotExist(err))
+
+	info, err := os.Stat(filepath.Join(dest, "bin", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(os.FileMode(0755), info.Mode().Perm())
+
+	// symlinks inside of the source are kept, and others are followed
+	link, err := os.Readlink(filepath.Join(dest, "bin", "link.txt"))
+	require.NoError(t, err)
+	assert.Equal("../data.txt", link)
+
+	info, err = os.Lstat(filepath.Join(dest, "outside_link.txt"))
+	require.NoError(t, err)
+	assert.True(info.Mode().IsRegular())
+	data, err = ioutil.ReadFile(filepath.Join(dest, "outside_link.txt"))
+	require.NoError(t, err)
+	assert.Equal("outside", string(data))
+}
+
+// testArchiveSymlinkToExcluded archives a symlink whose target isn't
+// included, which must be archived as the file it points to.
+func testArchiveSymlinkToExcluded(t *testing.T, format string) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "archive-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "bin"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "lib"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "lib", "foo.so"), []byte("library"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "bin", "tool"), []byte("tool"), 0755))
+	require.NoError(t, os.Symlink("../lib/foo.so", filepath.Join(source, "bin", "foo")))
+	require.NoError(t, os.Symlink("tool", filepath.Join(source, "bin", "tool-link")))
+
+	target := filepath.Join(dir, "archive")
+	numFiles, err := makeArchive(format, target, source, []string{"bin/*"}, []string{}, &plugintest.MockLogger{})
+	require.NoError(t, err)
+	assert.Equal(3, numFiles)
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, Unpack(format, target, dest))
+
+	_, err = os.Stat(filepath.Join(dest, "lib"))
+	assert.True(os.IsNotExist(err))
+	info, err := os.Lstat(filepath.Join(dest, "bin", "foo"))
+	require.NoError(t, err)
+	assert.True(info.Mode().IsRegular())
+	data, err := ioutil.ReadFile(filepath.Join(dest, "bin", "foo"))
+	require.NoError(t, err)
+	assert.Equal("library", string(data))
+
+	// links to files that are archived are still kept
+	link, err := os.Readlink(filepath.Join(dest, "bin", "tool-link"))
+	require.NoError(t, err)
+	assert.Equal("tool", link)
+}
+
+func TestTarGzRoundTrip(t *testing.T) {
+	testArchiveRoundTrip(t, FormatTarGz)
+	testArchiveSymlinkToExcluded(t, FormatTarGz)
+}
+
+func TestZipRoundTrip(t *testing.T) {
+	testArchiveRoundTrip(t, FormatZip)
+	testArchiveSymlinkToExcluded(t, FormatZip)
+}
+
+func TestTarZstRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd is not installed")
+	}
+	testArchiveRoundTrip(t, FormatTarZst)
+	testArchiveSymlinkToExcluded(t, FormatTarZst)
+}
+
+func TestExtractRejectsEscapingSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "evil.zip")
+	zw, err := newZipArchiveWriter(target)
+	require.NoError(t, err)
+	info, err := os.Lstat(dir)
+	require.NoError(t, err)
+	require.NoError(t, zw.addSymlink("escape", info, "../../etc/passwd"))
+	require.NoError(t, zw.Close())
+
+	assert.Error(t, Unpack(FormatZip, target, filepath.Join(dir, "dest")))
+	_, err = os.Lstat(filepath.Join(dir, "dest", "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestArchiveCommandNames(t *testing.T) {
+	p := &ArchivePlugin{}
+	for _, name := range []string{TarZstPackCmdName, TarZstUnpackCmdName, ZipPackCmdName, ZipUnpackCmdName} {
+		cmd, err := p.NewCommand(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, cmd.Name())
+	}
+
+	cmd, _ := p.NewCommand(ZipPackCmdName)
+	assert.Error(t, cmd.ParseParams(map[string]interface{}{"target": "t.zip", "source_dir": "s"}))
+	assert.NoError(t, cmd.ParseParams(map[string]interface{}{"target": "t.zip", "source_dir": "s", "include": []string{"**"}}))
+}
+
+func TestFormatOf(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(FormatTarGz, FormatOf("dist.tar.gz"))
+	assert.Equal(FormatTarGz, FormatOf("dist.TGZ"))
+	assert.Equal(FormatTarZst, FormatOf("dist.tar.zst"))
+	assert.Equal(FormatZip, FormatOf("dist.zip"))
+	assert.Equal("", FormatOf("dist.gz"))
+	assert.Equal("", FormatOf("binary"))
+}
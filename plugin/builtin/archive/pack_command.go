@@ -0,0 +1,122 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+// PackCommand creates an archive of a format other than tgz, selecting the
+// files to include the same way the targz_pack command does.
+type PackCommand struct {
+	// the format of the archive, set from the name of the command
+	format string
+
+	// the archive file that will be created
+	Target string `mapstructure:"target" plugin:"expand"`
+
+	// the directory to compress
+	SourceDir string `mapstructure:"source_dir" plugin:"expand"`
+
+	// a list of filename blobs to include,
+	// e.g. "*.tgz", "file.txt", "test_*"
+	Include []string `mapstructure:"include" plugin:"expand"`
+
+	// a list of filename blobs to exclude,
+	// e.g. "*.zip", "results.out", "ignore/**"
+	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+}
+
+func (self *PackCommand) Name() string {
+	return self.format + "_pack"
+}
+
+func (self *PackCommand) Plugin() string {
+	return ArchivePluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *PackCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return errors.Wrapf(err, "error parsing '%v' params", self.Name())
+	}
+	if err := self.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating '%v' params", self.Name())
+	}
+	return nil
+}
+
+// Make sure a target and source dir are set, and files are specified to be
+// included.
+func (self *PackCommand) validateParams() error {
+	if self.Target == "" {
+		return errors.New("target cannot be blank")
+	}
+	if self.SourceDir == "" {
+		return errors.New("source_dir cannot be blank")
+	}
+	if len(self.Include) == 0 {
+		return errors.New("include cannot be empty")
+	}
+
+	return nil
+}
+
+// Execute builds the archive.
+func (self *PackCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return errors.Wrap(err, "error expanding params")
+	}
+
+	// if the source dir is a relative path, join it to the working dir
+	if !filepath.IsAbs(self.SourceDir) {
+		self.SourceDir = filepath.Join(conf.WorkDir, self.SourceDir)
+	}
+
+	// if the target is a relative path, join it to the working dir
+	if !filepath.IsAbs(self.Target) {
+		self.Target = filepath.Join(conf.WorkDir, self.Target)
+	}
+
+	errChan := make(chan error)
+	filesArchived := -1
+	go func() {
+		var err error
+		filesArchived, err = self.MakeArchive(pluginLogger)
+		errChan <- errors.WithStack(err)
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if filesArchived == 0 {
+			deleteErr := os.Remove(self.Target)
+			if deleteErr != nil {
+				pluginLogger.LogExecution(slogger.INFO, "Error deleting empty archive: %v", deleteErr)
+			}
+		}
+		return nil
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of %v command", self.Name())
+		return nil
+	}
+}
+
+// MakeArchive builds the archive.
+// Returns the number of files included in the archive (0 means empty archive).
+func (self *PackCommand) MakeArchive(pluginLogger plugin.Logger) (int, error) {
+	return makeArchive(self.format, self.Target, self.SourceDir, self.Include,
+		self.ExcludeFiles, pluginLogger)
+}
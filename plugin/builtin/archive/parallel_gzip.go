@@ -0,0 +1,190 @@
+package archive
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// parallelBlockSize is how much data each goroutine compresses at once.
+const parallelBlockSize = 1 << 20
+
+// parallelDeflateWriter is a DEFLATE compressor that splits its input into
+// blocks and compresses them concurrently, like pigz. Each block but the
+// last ends with a sync flush, so the compressed blocks can be written one
+// after another as a single stream. Blocks don't share a dictionary, which
+// costs a little compression.
+type parallelDeflateWriter struct {
+	level  int
+	block  []byte
+	closed bool
+
+	// blocks holds the results of the blocks being compressed, in order.
+	// Its capacity limits how many are compressed at once.
+	blocks chan chan deflatedBlock
+	failed chan struct{}
+	done   chan error
+}
+
+type deflatedBlock struct {
+	data []byte
+	err  error
+}
+
+// newParallelDeflateWriter returns a writer that compresses what's written
+// to it into w, using up to workers goroutines.
+func newParallelDeflateWriter(w io.Writer, level, workers int) *parallelDeflateWriter {
+	if workers < 1 {
+		workers = 1
+	}
+	dw := &parallelDeflateWriter{
+		level:  level,
+		block:  make([]byte, 0, parallelBlockSize),
+		blocks: make(chan chan deflatedBlock, workers),
+		failed: make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	go dw.writeBlocks(w)
+	return dw
+}
+
+// writeBlocks writes the compressed blocks to w as they finish, in order.
+func (dw *parallelDeflateWriter) writeBlocks(w io.Writer) {
+	var err error
+	for result := range dw.blocks {
+		block := <-result
+		if err != nil {
+			continue
+		}
+		err = block.err
+		if err == nil {
+			_, err = w.Write(block.data)
+		}
+		if err != nil {
+			close(dw.failed)
+		}
+	}
+	dw.done <- err
+}
+
+func (dw *parallelDeflateWriter) Write(p []byte) (int, error) {
+	if dw.closed {
+		return 0, errors.New("write to closed writer")
+	}
+	select {
+	case <-dw.failed:
+		return 0, errors.New("error writing compressed data")
+	default:
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		space := cap(dw.block) - len(dw.block)
+		if space > len(p) {
+			space = len(p)
+		}
+		dw.block = append(dw.block, p[:space]...)
+		p = p[space:]
+		if len(dw.block) == cap(dw.block) {
+			dw.compressBlock(false)
+		}
+	}
+	return n, nil
+}
+
+// compressBlock starts compressing the data written since the last block.
+// It waits if too many blocks are already being compressed.
+func (dw *parallelDeflateWriter) compressBlock(final bool) {
+	block := dw.block
+	dw.block = make([]byte, 0, parallelBlockSize)
+
+	result := make(chan deflatedBlock, 1)
+	dw.blocks <- result
+	go func() {
+		result <- deflateBlock(block, dw.level, final)
+	}()
+}
+
+// Close compresses the rest of the data and waits for all of it to be
+// written.
+func (dw *parallelDeflateWriter) Close() error {
+	if dw.closed {
+		return nil
+	}
+	dw.closed = true
+	dw.compressBlock(true)
+	close(dw.blocks)
+	return errors.WithStack(<-dw.done)
+}
+
+func deflateBlock(block []byte, level int, final bool) deflatedBlock {
+	buf := &bytes.Buffer{}
+	fw, err := flate.NewWriter(buf, level)
+	if err != nil {
+		return deflatedBlock{err: err}
+	}
+	if _, err = fw.Write(block); err != nil {
+		return deflatedBlock{err: err}
+	}
+	if final {
+		err = fw.Close()
+	} else {
+		err = fw.Flush()
+	}
+	return deflatedBlock{data: buf.Bytes(), err: err}
+}
+
+// parallelGzipWriter writes a gzip stream whose data is compressed by a
+// parallelDeflateWriter.
+type parallelGzipWriter struct {
+	w       io.Writer
+	deflate *parallelDeflateWriter
+	crc     uint32
+	size    uint32
+	closed  bool
+}
+
+// gzipHeader is a gzip header with no name, time or extra fields.
+var gzipHeader = []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}
+
+// newParallelGzipWriter writes a gzip header to w and returns a writer that
+// compresses into it using a goroutine per CPU.
+func newParallelGzipWriter(w io.Writer) (*parallelGzipWriter, error) {
+	if _, err := w.Write(gzipHeader); err != nil {
+		return nil, errors.Wrap(err, "error writing gzip header")
+	}
+	return &parallelGzipWriter{
+		w:       w,
+		deflate: newParallelDeflateWriter(w, flate.DefaultCompression, runtime.NumCPU()),
+	}, nil
+}
+
+func (gw *parallelGzipWriter) Write(p []byte) (int, error) {
+	n, err := gw.deflate.Write(p)
+	gw.crc = crc32.Update(gw.crc, crc32.IEEETable, p[:n])
+	gw.size += uint32(n)
+	return n, err
+}
+
+// Close finishes the compressed data and writes the gzip trailer. It doesn't
+// close the underlying writer.
+func (gw *parallelGzipWriter) Close() error {
+	if gw.closed {
+		return nil
+	}
+	gw.closed = true
+	if err := gw.deflate.Close(); err != nil {
+		return err
+	}
+
+	trailer := make([]byte, 8)
+	binary.LittleEndian.PutUint32(trailer[:4], gw.crc)
+	binary.LittleEndian.PutUint32(trailer[4:], gw.size)
+	_, err := gw.w.Write(trailer)
+	return errors.Wrap(err, "error writing gzip trailer")
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
 	"github.com/mitchellh/mapstructure"
-	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/send"
 	"github.com/mongodb/grip/slogger"
 	"github.com/pkg/errors"
@@ -127,6 +126,14 @@ func (self *agentAppender) Append(log *slogger.Log) error {
 // Build the archive.
 // Returns the number of files included in the archive (0 means empty archive).
 func (self *TarGzPackCommand) MakeArchive(pluginLogger plugin.Logger) (int, error) {
+	return makeArchive(FormatTarGz, self.Target, self.SourceDir, self.Include,
+		self.ExcludeFiles, pluginLogger)
+}
+
+// makeArchive builds an archive of the given format at target from the
+// files in sourceDir. Returns the number of files included in the archive.
+func makeArchive(format, target, sourceDir string, include, exclude []string,
+	pluginLogger plugin.Logger) (int, error) {
 	// create a logger to pass into the BuildArchive command
 	appender := &agentAppender{
 		pluginLogger: pluginLogger,
@@ -137,19 +144,16 @@ func (self *TarGzPackCommand) MakeArchive(pluginLogger plugin.Logger) (int, erro
 		Appenders: []send.Sender{slogger.WrapAppender(appender)},
 	}
 
-	// create a targz writer for the target file
-	f, gz, tarWriter, err := TarGzWriter(self.Target)
+	// create a writer for the target file
+	archive, err := newArchiveWriter(format, target)
 	if err != nil {
-		return -1, errors.Wrapf(err, "error opening target archive file %s", self.Target)
+		return -1, errors.Wrapf(err, "error opening target archive file %s", target)
 	}
-	defer func() {
-		grip.CatchError(tarWriter.Close())
-		grip.CatchError(gz.Close())
-		grip.CatchError(f.Close())
-	}()
 
 	// Build the archive
-	out, err := BuildArchive(tarWriter, self.SourceDir, self.Include,
-		self.ExcludeFiles, log)
+	out, err := buildArchive(archive, sourceDir, include, exclude, log)
+	if closeErr := archive.Close(); err == nil && closeErr != nil {
+		err = errors.Wrapf(closeErr, "error finishing archive %s", target)
+	}
 	return out, errors.WithStack(err)
 }
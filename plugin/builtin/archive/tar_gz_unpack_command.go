@@ -78,18 +78,20 @@ func (self *TarGzUnpackCommand) Execute(pluginLogger plugin.Logger,
 // UnpackArchive unpacks the archive. The target archive to unpack is
 // set for the command during parameter parsing.
 func (self *TarGzUnpackCommand) UnpackArchive() error {
+	return Unpack(FormatTarGz, self.Source, self.DestDir)
+}
 
-	// get a reader for the source file
-	f, _, tarReader, err := TarGzReader(self.Source)
-	if err != nil {
-		return errors.Wrapf(err, "error opening tar file %v for reading", self.Source)
+// Unpack extracts the archive of the given format at source into
+// destDir, creating it if needed.
+func Unpack(format, source, destDir string) error {
+	if _, err := os.Stat(source); err != nil {
+		return errors.Wrapf(err, "error opening archive %v for reading", source)
 	}
-	defer f.Close()
 
-	// extract the actual tarball into the destination directory
-	if err := os.MkdirAll(self.DestDir, 0755); err != nil {
-		return errors.Wrapf(err, "error creating destination dir %v", self.DestDir)
+	// extract the archive into the destination directory
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return errors.Wrapf(err, "error creating destination dir %v", destDir)
 	}
 
-	return errors.WithStack(Extract(tarReader, self.DestDir))
+	return errors.WithStack(extractArchive(format, source, destDir))
 }
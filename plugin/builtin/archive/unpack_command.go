@@ -0,0 +1,81 @@
+package archive
+
+import (
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+// UnpackCommand extracts an archive of a format other than tgz.
+type UnpackCommand struct {
+	// the format of the archive, set from the name of the command
+	format string
+
+	// the archive file to unpack
+	Source string `mapstructure:"source" plugin:"expand"`
+	// the directory that the unpacked contents should be put into
+	DestDir string `mapstructure:"dest_dir" plugin:"expand"`
+}
+
+func (self *UnpackCommand) Name() string {
+	return self.format + "_unpack"
+}
+
+func (self *UnpackCommand) Plugin() string {
+	return ArchivePluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *UnpackCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return errors.Wrapf(err, "error parsing '%v' params", self.Name())
+	}
+	if err := self.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating '%v' params", self.Name())
+	}
+	return nil
+}
+
+// Make sure both source and dest dir are specified.
+func (self *UnpackCommand) validateParams() error {
+	if self.Source == "" {
+		return errors.New("source cannot be blank")
+	}
+	if self.DestDir == "" {
+		return errors.New("dest_dir cannot be blank")
+	}
+
+	return nil
+}
+
+// Execute unpacks the archive.
+func (self *UnpackCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return errors.Wrap(err, "error expanding params")
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.UnpackArchive()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of %v command", self.Name())
+		return nil
+	}
+}
+
+// UnpackArchive unpacks the archive into the destination directory.
+func (self *UnpackCommand) UnpackArchive() error {
+	return Unpack(self.format, self.Source, self.DestDir)
+}
@@ -20,9 +20,14 @@ const (
 	AttachPluginName      = "attach"
 	AttachResultsCmd      = "results"
 	AttachXunitResultsCmd = "xunit_results"
+	AttachTestResultsCmd  = "test_results"
+	AttachPerfResultsCmd  = "perf_results"
+	AttachCoverageCmd     = "coverage"
 
-	AttachResultsAPIEndpoint = "results"
-	AttachLogsAPIEndpoint    = "test_logs"
+	AttachResultsAPIEndpoint  = "results"
+	AttachLogsAPIEndpoint     = "test_logs"
+	AttachPerfAPIEndpoint     = "perf"
+	AttachCoverageAPIEndpoint = "coverage"
 
 	AttachResultsPostRetries   = 5
 	AttachResultsRetrySleepSec = 10 * time.Second
@@ -63,7 +68,7 @@ func stripHiddenFiles(files []artifact.File, pluginUser *user.DBUser) []artifact
 }
 
 // GetPanelConfig returns a plugin.PanelConfig struct representing panels
-// that will be added to the Task and Build pages.
+// that will be added to the Task, Build and Version pages.
 func (self *AttachPlugin) GetPanelConfig() (*plugin.PanelConfig, error) {
 	return &plugin.PanelConfig{
 		Panels: []plugin.UIPanel{
@@ -106,6 +111,18 @@ func (self *AttachPlugin) GetPanelConfig() (*plugin.PanelConfig, error) {
 					return taskArtifactFiles, nil
 				},
 			},
+			{
+				Page:     plugin.VersionPage,
+				Position: plugin.PageRight,
+				PanelHTML: "<div ng-include=\"'/plugin/attach/static/partials/version_coverage_panel.html'\" " +
+					"ng-init='coverage=plugins.attach' ng-show='plugins.attach'></div>",
+				DataFunc: func(context plugin.UIContext) (interface{}, error) {
+					if context.Version == nil {
+						return nil, nil
+					}
+					return getVersionCoverage(context.Version.Id)
+				},
+			},
 		},
 	}, nil
 }
@@ -119,6 +136,12 @@ func (self *AttachPlugin) NewCommand(cmdName string) (plugin.Command,
 		return &AttachResultsCommand{}, nil
 	case AttachXunitResultsCmd:
 		return &AttachXUnitResultsCommand{}, nil
+	case AttachTestResultsCmd:
+		return &AttachTestResultsCommand{}, nil
+	case AttachPerfResultsCmd:
+		return &AttachPerfResultsCommand{}, nil
+	case AttachCoverageCmd:
+		return &AttachCoverageCommand{}, nil
 	default:
 		return nil, errors.Errorf("No such %v command: %v", AttachPluginName, cmdName)
 	}
@@ -0,0 +1,185 @@
+package attach
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+// coverageParsers are the functions that parse each coverage format.
+var coverageParsers = map[string]func(io.Reader) ([]coverage.FileCoverage, error){
+	CoverageFormatGo:        ParseGoCoverProfile,
+	CoverageFormatLCOV:      ParseLCOV,
+	CoverageFormatCobertura: ParseCobertura,
+}
+
+// AttachCoverageCommand reads in code coverage reports and sends their line
+// coverage to the API server, which merges it into the coverage of the
+// task's version.
+type AttachCoverageCommand struct {
+	// File describes the relative path of the file to be sent. Supports globbing.
+	// Note that this can also be described via expansions.
+	File  string   `mapstructure:"file" plugin:"expand"`
+	Files []string `mapstructure:"files" plugin:"expand"`
+
+	// Format is the format of the files: "gocover", "lcov" or "cobertura".
+	Format string `mapstructure:"format"`
+}
+
+func (c *AttachCoverageCommand) Name() string {
+	return AttachCoverageCmd
+}
+
+func (c *AttachCoverageCommand) Plugin() string {
+	return AttachPluginName
+}
+
+// ParseParams reads and validates the command parameters. This is required
+// to satisfy the 'Command' interface
+func (c *AttachCoverageCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrapf(err, "error decoding '%s' params", c.Name())
+	}
+
+	if err := c.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating '%s' params", c.Name())
+	}
+
+	return nil
+}
+
+func (c *AttachCoverageCommand) validateParams() error {
+	if c.File == "" && len(c.Files) == 0 {
+		return errors.New("must specify at least one file")
+	}
+	c.Format = strings.ToLower(c.Format)
+	if _, ok := coverageParsers[c.Format]; !ok {
+		return errors.Errorf("format must be one of %v, %v, %v",
+			CoverageFormatGo, CoverageFormatLCOV, CoverageFormatCobertura)
+	}
+	return nil
+}
+
+// Execute carries out the AttachCoverageCommand command - this is required
+// to satisfy the 'Command' interface
+func (c *AttachCoverageCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	taskConfig *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := c.expandParams(taskConfig); err != nil {
+		return err
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- c.parseAndUploadCoverage(taskConfig, pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of attach coverage command")
+		return nil
+	}
+}
+
+func (c *AttachCoverageCommand) expandParams(conf *model.TaskConfig) error {
+	if c.File != "" {
+		c.Files = append(c.Files, c.File)
+	}
+
+	var err error
+	catcher := grip.NewCatcher()
+
+	for idx, f := range c.Files {
+		c.Files[idx], err = conf.Expansions.ExpandString(f)
+		catcher.Add(err)
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "problem expanding paths")
+}
+
+func (c *AttachCoverageCommand) parseAndUploadCoverage(
+	taskConfig *model.TaskConfig, pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator) error {
+
+	reportFilePaths, err := getFilePaths(taskConfig.WorkDir, c.Files)
+	if err != nil {
+		return err
+	}
+
+	parse := coverageParsers[c.Format]
+	reports := [][]coverage.FileCoverage{}
+	for _, reportFileLoc := range reportFilePaths {
+		file, err := os.Open(reportFileLoc)
+		if err != nil {
+			return errors.Wrap(err, "couldn't open coverage report")
+		}
+
+		files, err := parse(file)
+		grip.CatchWarning(file.Close())
+		if err != nil {
+			return errors.Wrapf(err, "error parsing %s", reportFileLoc)
+		}
+
+		pluginLogger.LogTask(slogger.INFO, "Found coverage of %v files in %v", len(files), reportFileLoc)
+		reports = append(reports, files)
+	}
+
+	files := coverage.MergeFiles(reports...)
+	if len(files) == 0 {
+		pluginLogger.LogTask(slogger.WARN, "No coverage found")
+		return nil
+	}
+	total, _ := coverage.Summarize(files)
+	pluginLogger.LogTask(slogger.INFO, "Posting coverage of %v files: %v of %v lines (%.1f%%) covered",
+		len(files), total.Covered, total.Total, total.Percent())
+
+	versionCoverage := &coverage.VersionCoverage{}
+	retriablePost := util.RetriableFunc(
+		func() error {
+			resp, err := pluginCom.TaskPostJSON(AttachCoverageAPIEndpoint, files)
+			if resp != nil {
+				defer resp.Body.Close()
+			}
+			if err != nil {
+				return util.RetriableError{Failure: errors.Wrap(err, "error posting coverage")}
+			}
+			if resp.StatusCode != http.StatusOK {
+				body, _ := ioutil.ReadAll(resp.Body)
+				err = errors.Errorf("error posting coverage (%v): %s", resp.StatusCode, string(body))
+				if resp.StatusCode == http.StatusBadRequest {
+					return err
+				}
+				return util.RetriableError{Failure: err}
+			}
+			return errors.Wrap(util.ReadJSONInto(resp.Body, versionCoverage), "error reading version coverage")
+		},
+	)
+	if _, err = util.Retry(retriablePost, AttachResultsPostRetries, AttachResultsRetrySleepSec); err != nil {
+		return err
+	}
+
+	pluginLogger.LogTask(slogger.INFO, "Version coverage is now %v of %v lines (%.1f%%)",
+		versionCoverage.Total.Covered, versionCoverage.Total.Total, versionCoverage.Total.Percent())
+	if versionCoverage.Patch != nil {
+		pluginLogger.LogTask(slogger.INFO, "Patch coverage is %v of %v changed lines (%.1f%%)",
+			versionCoverage.Patch.Total.Covered, versionCoverage.Patch.Total.Total,
+			versionCoverage.Patch.Total.Percent())
+	}
+	return nil
+}
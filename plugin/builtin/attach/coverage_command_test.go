@@ -0,0 +1,85 @@
+package attach
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCoverageFile(t *testing.T, parse func(io.Reader) ([]coverage.FileCoverage, error), name string) []coverage.FileCoverage {
+	file, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer file.Close()
+
+	files, err := parse(file)
+	require.NoError(t, err)
+	return files
+}
+
+func TestParseGoCoverProfile(t *testing.T) {
+	files := parseCoverageFile(t, ParseGoCoverProfile, "cover.out")
+
+	// blocks without statements don't make a line instrumented, and a line
+	// is covered if any block on it ran
+	assert.Equal(t, []coverage.FileCoverage{
+		{
+			File:      "github.com/evergreen-ci/evergreen/util/math.go",
+			Covered:   []int{5, 6, 7, 10, 11, 12},
+			Uncovered: []int{9, 13},
+		},
+		{
+			File:      "github.com/evergreen-ci/evergreen/util/strings.go",
+			Covered:   []int{},
+			Uncovered: []int{6, 7, 8},
+		},
+	}, files)
+
+	_, err := ParseGoCoverProfile(strings.NewReader("mode: set\nmath.go:5.24,7.2 1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLCOV(t *testing.T) {
+	files := parseCoverageFile(t, ParseLCOV, "lcov.info")
+
+	// records for the same file are combined
+	assert.Equal(t, []coverage.FileCoverage{
+		{File: "/src/app/lib/cart.js", Covered: []int{1, 2, 3, 5}, Uncovered: []int{7}},
+		{File: "/src/app/lib/user.js", Covered: []int{}, Uncovered: []int{10}},
+	}, files)
+
+	_, err := ParseLCOV(strings.NewReader("DA:1,1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseCobertura(t *testing.T) {
+	files := parseCoverageFile(t, ParseCobertura, "cobertura.xml")
+
+	// lines of methods are repeated in their class, so only the class's count
+	assert.Equal(t, []coverage.FileCoverage{
+		{File: "shop/cart.py", Covered: []int{1, 2, 4}, Uncovered: []int{5}},
+		{File: "shop/user.py", Covered: []int{}, Uncovered: []int{3}},
+	}, files)
+
+	_, err := ParseCobertura(strings.NewReader("<coverage><packages>"))
+	assert.Error(t, err)
+}
+
+func TestAttachCoverageParseParams(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &AttachCoverageCommand{}
+	assert.NoError(cmd.ParseParams(map[string]interface{}{"file": "cover.out", "format": "LCOV"}))
+	assert.Equal(CoverageFormatLCOV, cmd.Format)
+
+	cmd = &AttachCoverageCommand{}
+	assert.Error(cmd.ParseParams(map[string]interface{}{"file": "cover.out", "format": "jacoco"}))
+
+	cmd = &AttachCoverageCommand{}
+	assert.Error(cmd.ParseParams(map[string]interface{}{"format": "gocover"}))
+}
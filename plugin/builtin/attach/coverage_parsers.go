@@ -0,0 +1,185 @@
+package attach
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CoverageFormatGo is a profile written by go test -coverprofile.
+	CoverageFormatGo = "gocover"
+	// CoverageFormatLCOV is an LCOV tracefile, as written by lcov, gcov,
+	// istanbul and most JavaScript tools.
+	CoverageFormatLCOV = "lcov"
+	// CoverageFormatCobertura is a Cobertura XML report, as written by
+	// coverage.py, JaCoCo converters and others.
+	CoverageFormatCobertura = "cobertura"
+)
+
+// lineCoverage collects whether each line of each file was covered, in the
+// order the files were first seen.
+type lineCoverage struct {
+	lines map[string]map[int]bool
+	order []string
+}
+
+func newLineCoverage() *lineCoverage {
+	return &lineCoverage{lines: map[string]map[int]bool{}}
+}
+
+func (lc *lineCoverage) add(file string, line int, covered bool) {
+	fileLines, ok := lc.lines[file]
+	if !ok {
+		fileLines = map[int]bool{}
+		lc.lines[file] = fileLines
+		lc.order = append(lc.order, file)
+	}
+	fileLines[line] = fileLines[line] || covered
+}
+
+func (lc *lineCoverage) files() []coverage.FileCoverage {
+	files := make([]coverage.FileCoverage, 0, len(lc.order))
+	for _, file := range lc.order {
+		files = append(files, coverage.NewFileCoverage(file, lc.lines[file]))
+	}
+	return files
+}
+
+// ParseGoCoverProfile parses a Go cover profile. Each line of a profile is a
+// block of statements and how many times it ran, and a line is covered if
+// any block on it ran.
+func ParseGoCoverProfile(r io.Reader) ([]coverage.FileCoverage, error) {
+	lc := newLineCoverage()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// name.go:line.column,line.column numberOfStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, errors.Errorf("invalid cover profile line '%s'", line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, errors.Errorf("invalid cover profile line '%s'", line)
+		}
+		positions := strings.Split(fields[0], ",")
+		if len(positions) != 2 {
+			return nil, errors.Errorf("invalid block in cover profile line '%s'", line)
+		}
+		start, err := strconv.Atoi(strings.Split(positions[0], ".")[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid start line in '%s'", line)
+		}
+		end, err := strconv.Atoi(strings.Split(positions[1], ".")[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid end line in '%s'", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid statement count in '%s'", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid count in '%s'", line)
+		}
+		if statements == 0 {
+			continue
+		}
+
+		for i := start; i <= end; i++ {
+			lc.add(line[:colon], i, count > 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading cover profile")
+	}
+	return lc.files(), nil
+}
+
+// ParseLCOV parses an LCOV tracefile, using the DA records for the lines of
+// each SF source file.
+func ParseLCOV(r io.Reader) ([]coverage.FileCoverage, error) {
+	lc := newLineCoverage()
+	file := ""
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+		case line == "end_of_record":
+			file = ""
+		case strings.HasPrefix(line, "DA:"):
+			if file == "" {
+				return nil, errors.Errorf("line record '%s' outside of a source file", line)
+			}
+			// DA:line,hits[,checksum]
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(fields) < 2 {
+				return nil, errors.Errorf("invalid line record '%s'", line)
+			}
+			number, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid line number in '%s'", line)
+			}
+			// some tools write hit counts too large for an int, or as
+			// floats, so only the sign matters
+			hits, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid hit count in '%s'", line)
+			}
+			lc.add(file, number, hits > 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading lcov tracefile")
+	}
+	return lc.files(), nil
+}
+
+type coberturaReport struct {
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int    `xml:"number,attr"`
+				Hits   string `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// ParseCobertura parses a Cobertura XML report. Several classes can be in
+// the same file, so their lines are combined.
+func ParseCobertura(r io.Reader) ([]coverage.FileCoverage, error) {
+	report := coberturaReport{}
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, errors.Wrap(err, "error parsing cobertura xml")
+	}
+
+	lc := newLineCoverage()
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			for _, line := range class.Lines {
+				hits, err := strconv.ParseFloat(strings.TrimSpace(line.Hits), 64)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid hits for line %v of %s", line.Number, class.Filename)
+				}
+				lc.add(class.Filename, line.Number, hits > 0)
+			}
+		}
+	}
+	return lc.files(), nil
+}
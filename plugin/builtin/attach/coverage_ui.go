@@ -0,0 +1,115 @@
+package attach
+
+import (
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/pkg/errors"
+)
+
+// uiCoverage is the coverage of a version as shown on the version page.
+type uiCoverage struct {
+	Covered  int                 `json:"covered"`
+	Total    int                 `json:"total"`
+	Percent  float64             `json:"percent"`
+	Packages []uiPackageCoverage `json:"packages"`
+	Trend    []uiCoveragePoint   `json:"trend"`
+	Patch    *uiPatchCoverage    `json:"patch,omitempty"`
+}
+
+// uiPackageCoverage is the coverage of a package, and how it's changed since
+// the mainline version before.
+type uiPackageCoverage struct {
+	Package string   `json:"package"`
+	Covered int      `json:"covered"`
+	Total   int      `json:"total"`
+	Percent float64  `json:"percent"`
+	Change  *float64 `json:"change,omitempty"`
+}
+
+// uiCoveragePoint is the total coverage of one mainline version.
+type uiCoveragePoint struct {
+	VersionId string  `json:"version_id"`
+	Revision  string  `json:"revision"`
+	Order     int     `json:"order"`
+	Percent   float64 `json:"percent"`
+}
+
+// uiPatchCoverage is the coverage of the lines a patch changed.
+type uiPatchCoverage struct {
+	Covered int                     `json:"covered"`
+	Total   int                     `json:"total"`
+	Percent float64                 `json:"percent"`
+	Files   []coverage.FileCoverage `json:"files"`
+}
+
+// getVersionCoverage returns the coverage of the version, compared to the
+// mainline versions before it, or nil if none of its tasks attached any.
+func getVersionCoverage(versionId string) (*uiCoverage, error) {
+	vc, err := coverage.FindOneVersion(coverage.VersionById(versionId))
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding coverage for version")
+	}
+	if vc == nil {
+		return nil, nil
+	}
+	trend, err := coverage.FindTrend(vc, coverage.DefaultTrendLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding coverage trend")
+	}
+
+	result := &uiCoverage{
+		Covered:  vc.Total.Covered,
+		Total:    vc.Total.Total,
+		Percent:  vc.Total.Percent(),
+		Packages: []uiPackageCoverage{},
+		Trend:    []uiCoveragePoint{},
+	}
+
+	// compare to the version before a mainline version, or to the version a
+	// patch was made against
+	var base *coverage.VersionCoverage
+	for i := range trend {
+		if trend[i].Id != vc.Id {
+			base = &trend[i]
+			break
+		}
+	}
+	basePackages := map[string]coverage.Summary{}
+	if base != nil {
+		for _, pkg := range base.Packages {
+			basePackages[pkg.Package] = pkg.Summary
+		}
+	}
+	for _, pkg := range vc.Packages {
+		uiPkg := uiPackageCoverage{
+			Package: pkg.Package,
+			Covered: pkg.Summary.Covered,
+			Total:   pkg.Summary.Total,
+			Percent: pkg.Summary.Percent(),
+		}
+		if baseSummary, ok := basePackages[pkg.Package]; ok {
+			change := uiPkg.Percent - baseSummary.Percent()
+			uiPkg.Change = &change
+		}
+		result.Packages = append(result.Packages, uiPkg)
+	}
+
+	// oldest first, for charting
+	for i := len(trend) - 1; i >= 0; i-- {
+		result.Trend = append(result.Trend, uiCoveragePoint{
+			VersionId: trend[i].Id,
+			Revision:  trend[i].Revision,
+			Order:     trend[i].RevisionOrderNumber,
+			Percent:   trend[i].Total.Percent(),
+		})
+	}
+
+	if vc.Patch != nil {
+		result.Patch = &uiPatchCoverage{
+			Covered: vc.Patch.Total.Covered,
+			Total:   vc.Patch.Total.Total,
+			Percent: vc.Patch.Total.Percent(),
+			Files:   vc.Patch.Files,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package attach
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PerfFormatGoBench is the output of go test -bench.
+	PerfFormatGoBench = "gobench"
+	// PerfFormatJSON is a list of perf.Results, or an object with the list
+	// in its "results" field.
+	PerfFormatJSON = "json"
+)
+
+// goBenchLine matches a benchmark result line, saving the benchmark's name,
+// its GOMAXPROCS suffix, and the measurements after its iteration count.
+var goBenchLine = regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?\s+\d+\s+(.*)$`)
+
+// AttachPerfResultsCommand reads in performance results files and sends them
+// to the API server, which compares them to the results of earlier runs of the
+// task to find regressions.
+type AttachPerfResultsCommand struct {
+	// File describes the relative path of the file to be sent. Supports globbing.
+	// Note that this can also be described via expansions.
+	File  string   `mapstructure:"file" plugin:"expand"`
+	Files []string `mapstructure:"files" plugin:"expand"`
+
+	// Format is the format of the files, either "gobench" or "json".
+	Format string `mapstructure:"format"`
+}
+
+func (c *AttachPerfResultsCommand) Name() string {
+	return AttachPerfResultsCmd
+}
+
+func (c *AttachPerfResultsCommand) Plugin() string {
+	return AttachPluginName
+}
+
+// ParseParams reads and validates the command parameters. This is required
+// to satisfy the 'Command' interface
+func (c *AttachPerfResultsCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrapf(err, "error decoding '%s' params", c.Name())
+	}
+
+	if err := c.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating '%s' params", c.Name())
+	}
+
+	return nil
+}
+
+func (c *AttachPerfResultsCommand) validateParams() error {
+	if c.File == "" && len(c.Files) == 0 {
+		return errors.New("must specify at least one file")
+	}
+	c.Format = strings.ToLower(c.Format)
+	if c.Format != PerfFormatGoBench && c.Format != PerfFormatJSON {
+		return errors.Errorf("format must be one of %v, %v", PerfFormatGoBench, PerfFormatJSON)
+	}
+	return nil
+}
+
+// Execute carries out the AttachPerfResultsCommand command - this is required
+// to satisfy the 'Command' interface
+func (c *AttachPerfResultsCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	taskConfig *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := c.expandParams(taskConfig); err != nil {
+		return err
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- c.parseAndUploadResults(taskConfig, pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of attach perf results command")
+		return nil
+	}
+}
+
+func (c *AttachPerfResultsCommand) expandParams(conf *model.TaskConfig) error {
+	if c.File != "" {
+		c.Files = append(c.Files, c.File)
+	}
+
+	var err error
+	catcher := grip.NewCatcher()
+
+	for idx, f := range c.Files {
+		c.Files[idx], err = conf.Expansions.ExpandString(f)
+		catcher.Add(err)
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "problem expanding paths")
+}
+
+func (c *AttachPerfResultsCommand) parseAndUploadResults(
+	taskConfig *model.TaskConfig, pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator) error {
+
+	reportFilePaths, err := getFilePaths(taskConfig.WorkDir, c.Files)
+	if err != nil {
+		return err
+	}
+
+	results := []perf.Result{}
+	for _, reportFileLoc := range reportFilePaths {
+		file, err := os.Open(reportFileLoc)
+		if err != nil {
+			return errors.Wrap(err, "couldn't open perf results file")
+		}
+
+		var fileResults []perf.Result
+		if c.Format == PerfFormatGoBench {
+			fileResults, err = ParseGoBenchResults(file)
+		} else {
+			fileResults, err = ParsePerfJSONResults(file)
+		}
+		grip.CatchWarning(file.Close())
+		if err != nil {
+			return errors.Wrapf(err, "error parsing %s", reportFileLoc)
+		}
+
+		pluginLogger.LogTask(slogger.INFO, "Found %v perf results in %v", len(fileResults), reportFileLoc)
+		results = append(results, fileResults...)
+	}
+	if len(results) == 0 {
+		pluginLogger.LogTask(slogger.WARN, "No perf results found")
+		return nil
+	}
+
+	pluginLogger.LogTask(slogger.INFO, "Posting %v perf results", len(results))
+	regressions := []perf.Regression{}
+	retriablePost := util.RetriableFunc(
+		func() error {
+			resp, err := pluginCom.TaskPostJSON(AttachPerfAPIEndpoint, results)
+			if resp != nil {
+				defer resp.Body.Close()
+			}
+			if err != nil {
+				return util.RetriableError{Failure: errors.Wrap(err, "error posting perf results")}
+			}
+			if resp.StatusCode != http.StatusOK {
+				body, _ := ioutil.ReadAll(resp.Body)
+				err = errors.Errorf("error posting perf results (%v): %s", resp.StatusCode, string(body))
+				if resp.StatusCode == http.StatusBadRequest {
+					return err
+				}
+				return util.RetriableError{Failure: err}
+			}
+			return errors.Wrap(util.ReadJSONInto(resp.Body, &regressions), "error reading regressions")
+		},
+	)
+	if _, err = util.Retry(retriablePost, AttachResultsPostRetries, AttachResultsRetrySleepSec); err != nil {
+		return err
+	}
+
+	for _, r := range regressions {
+		pluginLogger.LogTask(slogger.WARN, "Performance regression: %v %v is %v %v, %.1f%% worse than %v in the last %v runs",
+			r.Test, r.Metric, r.Value, r.Unit, r.PercentChange(), r.BaselineMean, r.Samples)
+	}
+	return nil
+}
+
+// ParseGoBenchResults parses the output of go test -bench, with a result for
+// each measurement of each benchmark, like ns/op and B/op. The GOMAXPROCS a
+// benchmark ran with is its thread level, and benchmarks are named after
+// their package if the output says which it is.
+func ParseGoBenchResults(r io.Reader) ([]perf.Result, error) {
+	results := []perf.Result{}
+	pkg := ""
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimSpace(strings.TrimPrefix(line, "pkg: "))
+			continue
+		}
+
+		m := goBenchLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		test := m[1]
+		if pkg != "" {
+			test = pkg + "." + test
+		}
+		threads, _ := strconv.Atoi(m[2])
+
+		// the measurements are pairs of values and units
+		fields := strings.Fields(m[3])
+		for i := 0; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			results = append(results, perf.Result{
+				Test:        test,
+				Metric:      fields[i+1],
+				Value:       value,
+				Unit:        fields[i+1],
+				ThreadLevel: threads,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading benchmark output")
+	}
+	return results, nil
+}
+
+// ParsePerfJSONResults parses a JSON list of results, or an object with the
+// list in its "results" field. Each result has a test, metric, value, unit
+// and thread_level, and optionally higher_is_better.
+func ParsePerfJSONResults(r io.Reader) ([]perf.Result, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading perf results")
+	}
+
+	results := []perf.Result{}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		wrapper := struct {
+			Results []perf.Result `json:"results"`
+		}{}
+		err = json.Unmarshal(data, &wrapper)
+		results = wrapper.Results
+	} else {
+		err = json.Unmarshal(data, &results)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing perf results json")
+	}
+
+	for i, result := range results {
+		if result.Test == "" || result.Metric == "" {
+			return nil, errors.Errorf("result %v must have a test and a metric", i)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,84 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoBenchResults(t *testing.T) {
+	assert := assert.New(t)
+	file, err := os.Open(filepath.Join("testdata", "gobench.txt"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	results, err := ParseGoBenchResults(file)
+	require.NoError(t, err)
+	require.Len(t, results, 8)
+
+	assert.Equal(perf.Result{
+		Test:        "github.com/evergreen-ci/evergreen/util.BenchmarkRedact",
+		Metric:      "ns/op",
+		Value:       2533,
+		Unit:        "ns/op",
+		ThreadLevel: 8,
+	}, results[0])
+	assert.Equal("B/op", results[1].Metric)
+	assert.Equal("allocs/op", results[2].Metric)
+	assert.Equal(2467.0, results[3].Value)
+
+	assert.Equal("github.com/evergreen-ci/evergreen/util.BenchmarkCopy/small", results[6].Test)
+	assert.Equal("MB/s", results[7].Unit)
+	assert.Equal(167.32, results[7].Value)
+}
+
+func TestParsePerfJSONResults(t *testing.T) {
+	assert := assert.New(t)
+	file, err := os.Open(filepath.Join("testdata", "perf.json"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	results, err := ParsePerfJSONResults(file)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal("insert", results[0].Test)
+	assert.Equal("throughput", results[0].Metric)
+	assert.Equal(1500.5, results[0].Value)
+	assert.Equal(8, results[0].ThreadLevel)
+	require.NotNil(t, results[2].HigherIsBetter)
+	assert.True(*results[2].HigherIsBetter)
+
+	results, err = ParsePerfJSONResults(strings.NewReader(`[{"test": "a", "metric": "m", "value": 1}]`))
+	require.NoError(t, err)
+	assert.Len(results, 1)
+
+	_, err = ParsePerfJSONResults(strings.NewReader(`[{"test": "a", "value": 1}]`))
+	assert.Error(err)
+}
+
+func TestAttachPerfResultsParseParams(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &AttachPerfResultsCommand{}
+	assert.NoError(cmd.ParseParams(map[string]interface{}{
+		"file":   "bench.txt",
+		"format": "GoBench",
+	}))
+	assert.Equal(PerfFormatGoBench, cmd.Format)
+
+	cmd = &AttachPerfResultsCommand{}
+	assert.Error(cmd.ParseParams(map[string]interface{}{
+		"file":   "bench.txt",
+		"format": "csv",
+	}))
+
+	cmd = &AttachPerfResultsCommand{}
+	assert.Error(cmd.ParseParams(map[string]interface{}{
+		"format": "json",
+	}))
+}
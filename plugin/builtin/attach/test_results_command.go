@@ -0,0 +1,150 @@
+package attach
+
+import (
+	"os"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/attach/testresults"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+// AttachTestResultsCommand reads in test results files of one of the formats
+// the testresults package has parsers for, like TAP, TRX, Cucumber JSON and
+// NUnit XML, and converts them to a format MCI can use.
+type AttachTestResultsCommand struct {
+	// File describes the relative path of the file to be sent. Supports globbing.
+	// Note that this can also be described via expansions.
+	File  string   `mapstructure:"file" plugin:"expand"`
+	Files []string `mapstructure:"files" plugin:"expand"`
+
+	// Format is the format of the files.
+	Format string `mapstructure:"format"`
+}
+
+func (c *AttachTestResultsCommand) Name() string {
+	return AttachTestResultsCmd
+}
+
+func (c *AttachTestResultsCommand) Plugin() string {
+	return AttachPluginName
+}
+
+// ParseParams reads and validates the command parameters. This is required
+// to satisfy the 'Command' interface
+func (c *AttachTestResultsCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, c); err != nil {
+		return errors.Wrapf(err, "error decoding '%s' params", c.Name())
+	}
+
+	if err := c.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating '%s' params", c.Name())
+	}
+
+	return nil
+}
+
+func (c *AttachTestResultsCommand) validateParams() error {
+	if c.File == "" && len(c.Files) == 0 {
+		return errors.New("must specify at least one file")
+	}
+	c.Format = strings.ToLower(c.Format)
+	if _, ok := testresults.GetParser(c.Format); !ok {
+		return errors.Errorf("format must be one of %v", strings.Join(testresults.Formats(), ", "))
+	}
+	return nil
+}
+
+// Execute carries out the AttachTestResultsCommand command - this is required
+// to satisfy the 'Command' interface
+func (c *AttachTestResultsCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	taskConfig *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := c.expandParams(taskConfig); err != nil {
+		return err
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- c.parseAndUploadResults(taskConfig, pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of attach test results command")
+		return nil
+	}
+}
+
+func (c *AttachTestResultsCommand) expandParams(conf *model.TaskConfig) error {
+	if c.File != "" {
+		c.Files = append(c.Files, c.File)
+	}
+
+	var err error
+	catcher := grip.NewCatcher()
+
+	for idx, f := range c.Files {
+		c.Files[idx], err = conf.Expansions.ExpandString(f)
+		catcher.Add(err)
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "problem expanding paths")
+}
+
+func (c *AttachTestResultsCommand) parseAndUploadResults(
+	taskConfig *model.TaskConfig, pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator) error {
+	tests := []task.TestResult{}
+	logs := []*model.TestLog{}
+	logIdxToTestIdx := []int{}
+
+	parser, ok := testresults.GetParser(c.Format)
+	if !ok {
+		return errors.Errorf("no parser for format '%s'", c.Format)
+	}
+
+	reportFilePaths, err := getFilePaths(taskConfig.WorkDir, c.Files)
+	if err != nil {
+		return err
+	}
+
+	for _, reportFileLoc := range reportFilePaths {
+		file, err := os.Open(reportFileLoc)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't open %s file", c.Format)
+		}
+
+		results, err := parser.Parse(file)
+		if err != nil {
+			grip.CatchWarning(file.Close())
+			return errors.Wrapf(err, "error parsing %s", reportFileLoc)
+		}
+
+		if err = file.Close(); err != nil {
+			return errors.Wrapf(err, "error closing %s file", c.Format)
+		}
+
+		pluginLogger.LogTask(slogger.INFO, "Found %v test results in %v", len(results), reportFileLoc)
+		for _, result := range results {
+			test, log := result.ToModelTestResultAndLog(taskConfig.Task)
+			if log != nil {
+				logs = append(logs, log)
+				logIdxToTestIdx = append(logIdxToTestIdx, len(tests))
+			}
+			tests = append(tests, test)
+		}
+	}
+
+	return sendResultsAndLogs(taskConfig, pluginLogger, pluginCom, tests, logs, logIdxToTestIdx)
+}
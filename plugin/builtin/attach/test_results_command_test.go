@@ -0,0 +1,32 @@
+package attach
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachTestResultsParseParams(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &AttachTestResultsCommand{}
+	assert.NoError(cmd.ParseParams(map[string]interface{}{
+		"files":  []string{"results/*.trx"},
+		"format": "TRX",
+	}))
+	assert.Equal("trx", cmd.Format)
+
+	cmd = &AttachTestResultsCommand{}
+	assert.Error(cmd.ParseParams(map[string]interface{}{
+		"format": "tap",
+	}))
+
+	cmd = &AttachTestResultsCommand{}
+	err := cmd.ParseParams(map[string]interface{}{
+		"file":   "results.html",
+		"format": "html",
+	})
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "cucumber, junit, nunit, tap, trx")
+	}
+}
@@ -0,0 +1,123 @@
+package testresults
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("cucumber", ParserFunc(parseCucumber))
+}
+
+type cucumberFeature struct {
+	Name     string            `json:"name"`
+	URI      string            `json:"uri"`
+	Elements []cucumberElement `json:"elements"`
+}
+
+type cucumberElement struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Before []cucumberStep `json:"before"`
+	Steps  []cucumberStep `json:"steps"`
+	After  []cucumberStep `json:"after"`
+}
+
+type cucumberStep struct {
+	Keyword string   `json:"keyword"`
+	Name    string   `json:"name"`
+	Output  []string `json:"output"`
+	Result  struct {
+		Status string `json:"status"`
+		// Duration is in nanoseconds
+		Duration     int64  `json:"duration"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"result"`
+}
+
+// parseCucumber parses Cucumber's JSON report, with a result for each
+// scenario. The steps of a feature's background are part of each of its
+// scenarios that follows them.
+func parseCucumber(r io.Reader) ([]Result, error) {
+	features := []cucumberFeature{}
+	if err := json.NewDecoder(r).Decode(&features); err != nil {
+		return nil, errors.Wrap(err, "error parsing cucumber json")
+	}
+
+	results := []Result{}
+	for _, feature := range features {
+		featureName := feature.Name
+		if featureName == "" {
+			featureName = feature.URI
+		}
+
+		var background []cucumberStep
+		for _, element := range feature.Elements {
+			if element.Type == "background" {
+				background = element.Steps
+				continue
+			}
+
+			res := Result{
+				Name:   fmt.Sprintf("%v: %v", featureName, element.Name),
+				Status: Passed,
+			}
+			steps := append([]cucumberStep{}, element.Before...)
+			steps = append(steps, background...)
+			steps = append(steps, element.Steps...)
+			steps = append(steps, element.After...)
+			background = nil
+
+			for _, step := range steps {
+				res.Duration += time.Duration(step.Result.Duration)
+				res.Output = append(res.Output, step.describe())
+				for _, output := range step.Output {
+					res.Output = append(res.Output, splitOutput(output)...)
+				}
+				res.Output = append(res.Output, splitOutput(step.Result.ErrorMessage)...)
+
+				status, message := step.status()
+				// the first step that didn't pass decides the result,
+				// since cucumber skips the steps after it
+				if res.Status == Passed && status != Passed {
+					res.Status = status
+					res.Message = message
+				}
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// describe returns a line saying how the step went. Hooks have no keyword.
+func (s cucumberStep) describe() string {
+	name := strings.TrimSpace(s.Keyword + s.Name)
+	if name == "" {
+		name = "Hook"
+	}
+	return fmt.Sprintf("%v ... %v", name, s.Result.Status)
+}
+
+func (s cucumberStep) status() (string, string) {
+	name := strings.TrimSpace(s.Keyword + s.Name)
+	switch s.Result.Status {
+	case "passed":
+		return Passed, ""
+	case "failed":
+		if s.Keyword == "" {
+			return Errored, "hook failed"
+		}
+		return Failed, fmt.Sprintf("step '%v' failed", name)
+	case "skipped":
+		return Skipped, fmt.Sprintf("step '%v' was skipped", name)
+	case "pending", "undefined":
+		return Skipped, fmt.Sprintf("step '%v' is %v", name, s.Result.Status)
+	}
+	return Errored, fmt.Sprintf("step '%v' is %v", name, s.Result.Status)
+}
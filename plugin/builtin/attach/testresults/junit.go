@@ -0,0 +1,55 @@
+package testresults
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/plugin/builtin/attach/xunit"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("junit", ParserFunc(parseJUnit))
+}
+
+// parseJUnit parses JUnit-style XML, which attach.xunit_results also reads.
+func parseJUnit(r io.Reader) ([]Result, error) {
+	suites, err := xunit.ParseXMLResults(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing junit xml")
+	}
+
+	results := []Result{}
+	for _, suite := range suites {
+		for _, tc := range suite.TestCases {
+			res := Result{
+				Name:     tc.Name,
+				Status:   Passed,
+				Duration: time.Duration(tc.Time * float64(time.Second)),
+			}
+			if tc.ClassName != "" {
+				res.Name = fmt.Sprintf("%v.%v", tc.ClassName, tc.Name)
+			}
+
+			details := (*xunit.FailureDetails)(nil)
+			switch {
+			case tc.Failure != nil:
+				res.Status, details = Failed, tc.Failure
+			case tc.Error != nil:
+				res.Status, details = Errored, tc.Error
+			case tc.Skipped != nil:
+				res.Status, details = Skipped, tc.Skipped
+			}
+			if details != nil {
+				res.Message = details.Message
+				if details.Type != "" {
+					res.Message = fmt.Sprintf("%v (%v)", details.Message, details.Type)
+				}
+				res.Output = splitOutput(details.Content)
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package testresults
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("nunit", ParserFunc(parseNUnit))
+}
+
+// nunitSuite is an NUnit 3 test-run or test-suite element, which suites and
+// test cases can be nested in to any depth.
+type nunitSuite struct {
+	Suites []nunitSuite    `xml:"test-suite"`
+	Cases  []nunitTestCase `xml:"test-case"`
+}
+
+type nunitTestCase struct {
+	FullName string  `xml:"fullname,attr"`
+	Name     string  `xml:"name,attr"`
+	Result   string  `xml:"result,attr"`
+	Label    string  `xml:"label,attr"`
+	Duration float64 `xml:"duration,attr"`
+	Failure  struct {
+		Message    string `xml:"message"`
+		StackTrace string `xml:"stack-trace"`
+	} `xml:"failure"`
+	Reason struct {
+		Message string `xml:"message"`
+	} `xml:"reason"`
+	Output string `xml:"output"`
+}
+
+// parseNUnit parses NUnit 3 XML results.
+func parseNUnit(r io.Reader) ([]Result, error) {
+	run := nunitSuite{}
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, errors.Wrap(err, "error parsing nunit xml")
+	}
+
+	results := []Result{}
+	var add func(nunitSuite)
+	add = func(suite nunitSuite) {
+		for _, tc := range suite.Cases {
+			results = append(results, tc.toResult())
+		}
+		for _, child := range suite.Suites {
+			add(child)
+		}
+	}
+	add(run)
+	return results, nil
+}
+
+func (tc nunitTestCase) toResult() Result {
+	res := Result{
+		Name:     tc.FullName,
+		Duration: time.Duration(tc.Duration * float64(time.Second)),
+		Message:  strings.TrimSpace(tc.Failure.Message),
+	}
+	if res.Name == "" {
+		res.Name = tc.Name
+	}
+
+	switch tc.Result {
+	case "Passed", "Warning":
+		res.Status = Passed
+	case "Skipped", "Inconclusive":
+		res.Status = Skipped
+		res.Message = strings.TrimSpace(tc.Reason.Message)
+	default:
+		// the label says why a test didn't pass, if it wasn't an assertion
+		switch tc.Label {
+		case "Error", "Cancelled", "Invalid":
+			res.Status = Errored
+		default:
+			res.Status = Failed
+		}
+	}
+	if res.Status != Passed && res.Message == "" {
+		res.Message = strings.TrimSpace(tc.Result + " " + tc.Label)
+	}
+
+	res.Output = append(res.Output, labeledOutput("Stack trace", tc.Failure.StackTrace)...)
+	res.Output = append(res.Output, labeledOutput("Output", tc.Output)...)
+	return res
+}
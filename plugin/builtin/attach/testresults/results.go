@@ -0,0 +1,150 @@
+// Package testresults parses test results files of several formats into
+// task test results and logs.
+package testresults
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// The states a parsed test can end in.
+const (
+	Passed  = "passed"
+	Failed  = "failed"
+	Errored = "errored"
+	Skipped = "skipped"
+)
+
+// Result is a test result parsed from a results file of any format.
+type Result struct {
+	// Name identifies the test, e.g. by its class and method.
+	Name string
+	// Status is one of Passed, Failed, Errored or Skipped. Errored tests
+	// broke, rather than failing an assertion.
+	Status   string
+	Duration time.Duration
+	// Message explains why the test didn't pass.
+	Message string
+	// Output is the test's details, stack trace and stdout and stderr, in
+	// lines.
+	Output []string
+}
+
+// Parser parses test results files of one format.
+type Parser interface {
+	Parse(io.Reader) ([]Result, error)
+}
+
+// ParserFunc is a function that can be used as a Parser.
+type ParserFunc func(io.Reader) ([]Result, error)
+
+// Parse calls f.
+func (f ParserFunc) Parse(r io.Reader) ([]Result, error) {
+	return f(r)
+}
+
+var (
+	parsers      = map[string]Parser{}
+	parsersMutex sync.RWMutex
+)
+
+// Register makes the parser available for results files of format.
+func Register(format string, p Parser) {
+	parsersMutex.Lock()
+	defer parsersMutex.Unlock()
+
+	parsers[format] = p
+}
+
+// GetParser returns the parser for results files of format.
+func GetParser(format string) (Parser, bool) {
+	parsersMutex.RLock()
+	defer parsersMutex.RUnlock()
+
+	p, ok := parsers[format]
+	return p, ok
+}
+
+// Formats returns the formats there are parsers for.
+func Formats() []string {
+	parsersMutex.RLock()
+	defer parsersMutex.RUnlock()
+
+	formats := []string{}
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ToModelTestResultAndLog converts the result into a task.TestResult, and a
+// model.TestLog of its message and output, if it has any.
+func (r Result) ToModelTestResultAndLog(t *task.Task) (task.TestResult, *model.TestLog) {
+	res := task.TestResult{
+		// replace spaces, dashes, etc. with underscores
+		TestFile: util.CleanForPath(r.Name),
+	}
+	res.StartTime = float64(time.Now().Unix())
+	res.EndTime = res.StartTime + r.Duration.Seconds()
+
+	lines := []string{}
+	switch r.Status {
+	case Passed:
+		res.Status = evergreen.TestSucceededStatus
+	case Skipped:
+		res.Status = evergreen.TestSkippedStatus
+		lines = append(lines, fmt.Sprintf("SKIPPED: %v", r.Message))
+	case Errored:
+		res.Status = evergreen.TestFailedStatus
+		lines = append(lines, fmt.Sprintf("ERROR: %v", r.Message))
+	default:
+		res.Status = evergreen.TestFailedStatus
+		lines = append(lines, fmt.Sprintf("FAILURE: %v", r.Message))
+	}
+	if r.Message == "" {
+		lines = nil
+	}
+	lines = append(lines, r.Output...)
+	if len(lines) == 0 {
+		return res, nil
+	}
+
+	log := &model.TestLog{
+		Name:          res.TestFile,
+		Task:          t.Id,
+		TaskExecution: t.Execution,
+		Lines:         lines,
+	}
+	res.URL = log.URL()
+	return res, log
+}
+
+// splitOutput splits output from a results file into lines, dropping the
+// blank lines around it.
+func splitOutput(output string) []string {
+	output = strings.Trim(output, "\r\n")
+	if strings.TrimSpace(output) == "" {
+		return nil
+	}
+	return strings.Split(strings.Replace(output, "\r\n", "\n", -1), "\n")
+}
+
+// labeledOutput returns output split into lines under a label, if there is
+// any.
+func labeledOutput(label, output string) []string {
+	lines := splitOutput(output)
+	if len(lines) == 0 {
+		return nil
+	}
+	return append([]string{label + ":"}, lines...)
+}
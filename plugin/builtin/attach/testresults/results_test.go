@@ -0,0 +1,197 @@
+package testresults
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFile(t *testing.T, format, name string) []Result {
+	parser, ok := GetParser(format)
+	require.True(t, ok)
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer file.Close()
+
+	results, err := parser.Parse(file)
+	require.NoError(t, err)
+	return results
+}
+
+func TestFormats(t *testing.T) {
+	assert.Equal(t, []string{"cucumber", "junit", "nunit", "tap", "trx"}, Formats())
+
+	_, ok := GetParser("junit")
+	assert.True(t, ok)
+	_, ok = GetParser("html")
+	assert.False(t, ok)
+}
+
+func TestParseTAP(t *testing.T) {
+	assert := assert.New(t)
+	results := parseFile(t, "tap", "results.tap")
+	require.Len(t, results, 7)
+
+	assert.Equal("Input file opened", results[0].Name)
+	assert.Equal(Passed, results[0].Status)
+	assert.Empty(results[0].Output)
+
+	assert.Equal("First line of the input valid", results[1].Name)
+	assert.Equal(Failed, results[1].Status)
+	assert.Equal(12500*time.Microsecond, results[1].Duration)
+	assert.Contains(results[1].Output, "  message: 'First line invalid'")
+
+	assert.Equal(Skipped, results[2].Status)
+	assert.Equal("not implemented", results[2].Message)
+	assert.Equal("Read the rest of the file", results[2].Name)
+
+	assert.Equal(Skipped, results[3].Status)
+	assert.Equal("TODO Not written yet", results[3].Message)
+
+	assert.Equal("config", results[4].Name)
+	assert.Equal(Failed, results[4].Status)
+	assert.Equal([]string{
+		"    # Subtest: config",
+		"    ok 1 - loads defaults",
+		"    not ok 2 - reads overrides",
+		"    1..2",
+		"#   Failed test 'config'",
+		"#   at t/config.t line 12.",
+	}, results[4].Output)
+
+	assert.Equal("test 6", results[5].Name)
+	assert.Equal(Passed, results[5].Status)
+
+	assert.Equal("test 7", results[6].Name)
+	assert.Equal(Errored, results[6].Status)
+	assert.Equal("planned 7 tests but only 6 ran", results[6].Message)
+}
+
+func TestParseTAPBailOut(t *testing.T) {
+	parser, _ := GetParser("tap")
+	results, err := parser.Parse(strings.NewReader("1..3\nok 1 - connects\nBail out! database went away\n"))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Bail out!", results[1].Name)
+	assert.Equal(t, Errored, results[1].Status)
+	assert.Equal(t, "database went away", results[1].Message)
+}
+
+func TestParseTRX(t *testing.T) {
+	assert := assert.New(t)
+	results := parseFile(t, "trx", "results.trx")
+	require.Len(t, results, 5)
+
+	assert.Equal("Calculator.Tests.MathTests.AddsNumbers", results[0].Name)
+	assert.Equal(Passed, results[0].Status)
+	assert.Equal(12345600*time.Nanosecond, results[0].Duration)
+	assert.Equal([]string{"Standard output:", "adding 1 and 2"}, results[0].Output)
+
+	assert.Equal("Calculator.Tests.MathTests.DividesByZero", results[1].Name)
+	assert.Equal(Failed, results[1].Status)
+	assert.Equal(1500*time.Millisecond, results[1].Duration)
+	assert.Equal("Assert.AreEqual failed. Expected:<0>. Actual:<1>.", results[1].Message)
+	assert.Equal("Stack trace:", results[1].Output[0])
+
+	assert.Equal("Calculator.Tests.DbTests.UsesDatabase", results[2].Name)
+	assert.Equal(Skipped, results[2].Status)
+	assert.Equal("NotExecuted", results[2].Message)
+
+	// data driven rows are reported on their own, under their parent's class
+	assert.Equal("Calculator.Tests.DataTests.Rows (1)", results[3].Name)
+	assert.Equal(Passed, results[3].Status)
+	assert.Equal("Calculator.Tests.DataTests.Rows (2)", results[4].Name)
+	assert.Equal(Errored, results[4].Status)
+	assert.Equal([]string{"Standard error:", "connection refused"}, results[4].Output)
+}
+
+func TestParseCucumber(t *testing.T) {
+	assert := assert.New(t)
+	results := parseFile(t, "cucumber", "results.cucumber.json")
+	require.Len(t, results, 4)
+
+	assert.Equal("Login: Successful login", results[0].Name)
+	assert.Equal(Passed, results[0].Status)
+	assert.Equal(6*time.Millisecond, results[0].Duration)
+	assert.Equal([]string{
+		"Given a registered user ... passed",
+		"When they log in ... passed",
+		"logged in as alice",
+		"Then they see the dashboard ... passed",
+	}, results[0].Output)
+
+	assert.Equal("Login: Wrong password", results[1].Name)
+	assert.Equal(Failed, results[1].Status)
+	assert.Equal("step 'When they log in with the wrong password' failed", results[1].Message)
+	assert.Contains(results[1].Output, "got 500")
+
+	// the background only applies to the scenario after it
+	assert.Equal(Skipped, results[2].Status)
+	assert.Equal("step 'When they enter a code' is undefined", results[2].Message)
+	assert.Len(results[2].Output, 1)
+
+	assert.Equal(Errored, results[3].Status)
+	assert.Equal("hook failed", results[3].Message)
+	assert.Equal("Hook ... failed", results[3].Output[0])
+}
+
+func TestParseNUnit(t *testing.T) {
+	assert := assert.New(t)
+	results := parseFile(t, "nunit", "results.nunit.xml")
+	require.Len(t, results, 5)
+
+	// nested cases come after the cases of the suite they're in
+	assert.Equal("Shop.Warns", results[0].Name)
+	assert.Equal(Passed, results[0].Status)
+
+	assert.Equal("Shop.CartTests.AddsItem", results[1].Name)
+	assert.Equal(Passed, results[1].Status)
+	assert.Equal(12*time.Millisecond, results[1].Duration)
+	assert.Equal([]string{"Output:", "cart has 1 item"}, results[1].Output)
+
+	assert.Equal(Failed, results[2].Status)
+	assert.Equal("Expected: 0\n  But was:  1", results[2].Message)
+	assert.Equal([]string{"Stack trace:", "at Shop.CartTests.RemovesItem() in CartTests.cs:line 30"}, results[2].Output)
+
+	assert.Equal(Errored, results[3].Status)
+	assert.Equal(Skipped, results[4].Status)
+	assert.Equal("not ready", results[4].Message)
+}
+
+func TestToModelTestResultAndLog(t *testing.T) {
+	assert := assert.New(t)
+	tsk := &task.Task{Id: "task1", Execution: 2}
+
+	res, log := Result{Name: "a.b", Status: Passed, Duration: time.Second}.ToModelTestResultAndLog(tsk)
+	assert.Nil(log)
+	assert.Equal(evergreen.TestSucceededStatus, res.Status)
+	assert.Equal("a.b", res.TestFile)
+	assert.Equal(1.0, res.EndTime-res.StartTime)
+	assert.Empty(res.URL)
+
+	res, log = Result{Name: "suite test", Status: Skipped, Message: "not ready"}.ToModelTestResultAndLog(tsk)
+	assert.Equal(evergreen.TestSkippedStatus, res.Status)
+	require.NotNil(t, log)
+	assert.Equal([]string{"SKIPPED: not ready"}, log.Lines)
+
+	res, log = Result{
+		Name:    "broken",
+		Status:  Errored,
+		Message: "panic",
+		Output:  []string{"stack"},
+	}.ToModelTestResultAndLog(tsk)
+	assert.Equal(evergreen.TestFailedStatus, res.Status)
+	require.NotNil(t, log)
+	assert.Equal("task1", log.Task)
+	assert.Equal(2, log.TaskExecution)
+	assert.Equal([]string{"ERROR: panic", "stack"}, log.Lines)
+	assert.Equal(log.URL(), res.URL)
+}
@@ -0,0 +1,145 @@
+package testresults
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("tap", ParserFunc(parseTAP))
+}
+
+var (
+	// tapTestLine matches a test line, saving whether it's "not ok", its
+	// number, its description and its directive
+	tapTestLine = regexp.MustCompile(`^(not )?ok\b\s*(\d*)\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(.*))?$`)
+	tapPlanLine = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapBailOut  = regexp.MustCompile(`^Bail out!\s*(.*)$`)
+	// tapDuration matches the durations node-tap and others put in the YAML
+	// block after a test
+	tapDuration = regexp.MustCompile(`^\s*duration_ms:\s*([0-9.]+)`)
+)
+
+// parseTAP parses the Test Anything Protocol, as written by Perl's
+// Test::More, node-tap and others. Lines that aren't tests are kept as the
+// output of the test before them, except for indented subtests, which are
+// kept as the output of the test they're part of. Tests in the plan that
+// never ran are errors.
+func parseTAP(r io.Reader) ([]Result, error) {
+	results := []Result{}
+	pending := []string{}
+	planned := 0
+	inYAML := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		var last *Result
+		if len(results) > 0 {
+			last = &results[len(results)-1]
+		}
+
+		// YAML blocks describe the test before them
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+				continue
+			}
+			if last != nil {
+				if m := tapDuration.FindStringSubmatch(line); m != nil {
+					ms, _ := strconv.ParseFloat(m[1], 64)
+					last.Duration = time.Duration(ms * float64(time.Millisecond))
+				}
+				last.Output = append(last.Output, line)
+			}
+			continue
+		}
+		if trimmed == "---" && line != trimmed && len(pending) == 0 {
+			inYAML = true
+			continue
+		}
+
+		// subtests are indented, and are followed by the test they're part of
+		if line != trimmed && trimmed != "" {
+			pending = append(pending, line)
+			continue
+		}
+
+		if m := tapTestLine.FindStringSubmatch(line); m != nil {
+			res := Result{Name: m[3], Status: Passed, Output: pending}
+			pending = []string{}
+			if res.Name == "" {
+				res.Name = fmt.Sprintf("test %v", m[2])
+				if m[2] == "" {
+					res.Name = fmt.Sprintf("test %v", len(results)+1)
+				}
+			}
+			if m[1] != "" {
+				res.Status = Failed
+				res.Message = "not ok"
+			}
+
+			directive := strings.TrimSpace(m[4])
+			switch upper := strings.ToUpper(directive); {
+			case strings.HasPrefix(upper, "SKIP"):
+				res.Status = Skipped
+				if reason := strings.SplitN(directive, " ", 2); len(reason) == 2 {
+					res.Message = strings.TrimSpace(reason[1])
+				}
+			case strings.HasPrefix(upper, "TODO") && res.Status == Failed:
+				// tests still to do aren't expected to pass
+				res.Status = Skipped
+				res.Message = directive
+			}
+			results = append(results, res)
+			continue
+		}
+
+		if m := tapPlanLine.FindStringSubmatch(trimmed); m != nil {
+			planned, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := tapBailOut.FindStringSubmatch(trimmed); m != nil {
+			results = append(results, Result{
+				Name:    "Bail out!",
+				Status:  Errored,
+				Message: m[1],
+				Output:  pending,
+			})
+			pending = []string{}
+			planned = 0
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "TAP version") {
+			continue
+		}
+
+		// diagnostics are about the test before them
+		if last != nil {
+			last.Output = append(last.Output, line)
+		} else {
+			pending = append(pending, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading tap output")
+	}
+
+	ran := len(results)
+	for i := ran; i < planned; i++ {
+		results = append(results, Result{
+			Name:    fmt.Sprintf("test %v", i+1),
+			Status:  Errored,
+			Message: fmt.Sprintf("planned %v tests but only %v ran", planned, ran),
+		})
+	}
+	return results, nil
+}
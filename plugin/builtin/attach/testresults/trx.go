@@ -0,0 +1,130 @@
+package testresults
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("trx", ParserFunc(parseTRX))
+}
+
+// trxTestRun is a Visual Studio test results file, as written by vstest and
+// dotnet test.
+type trxTestRun struct {
+	Results     []trxResult `xml:"Results>UnitTestResult"`
+	Definitions []struct {
+		Id     string `xml:"id,attr"`
+		Method struct {
+			ClassName string `xml:"className,attr"`
+			Name      string `xml:"name,attr"`
+		} `xml:"TestMethod"`
+	} `xml:"TestDefinitions>UnitTest"`
+}
+
+type trxResult struct {
+	TestId   string `xml:"testId,attr"`
+	TestName string `xml:"testName,attr"`
+	Outcome  string `xml:"outcome,attr"`
+	Duration string `xml:"duration,attr"`
+	Output   struct {
+		StdOut    string `xml:"StdOut"`
+		StdErr    string `xml:"StdErr"`
+		ErrorInfo struct {
+			Message    string `xml:"Message"`
+			StackTrace string `xml:"StackTrace"`
+		} `xml:"ErrorInfo"`
+	} `xml:"Output"`
+	// data driven tests have a result for each row of data
+	InnerResults []trxResult `xml:"InnerResults>UnitTestResult"`
+}
+
+// parseTRX parses Visual Studio test results.
+func parseTRX(r io.Reader) ([]Result, error) {
+	run := trxTestRun{}
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, errors.Wrap(err, "error parsing trx")
+	}
+
+	classes := map[string]string{}
+	for _, def := range run.Definitions {
+		classes[def.Id] = def.Method.ClassName
+	}
+
+	results := []Result{}
+	var add func(trxResult)
+	add = func(tr trxResult) {
+		if len(tr.InnerResults) > 0 {
+			for _, inner := range tr.InnerResults {
+				if inner.TestId == "" {
+					inner.TestId = tr.TestId
+				}
+				add(inner)
+			}
+			return
+		}
+
+		res := Result{
+			Name:    tr.TestName,
+			Status:  trxStatus(tr.Outcome),
+			Message: strings.TrimSpace(tr.Output.ErrorInfo.Message),
+		}
+		// test names are usually the method, but may already include the
+		// class
+		if class := classes[tr.TestId]; class != "" && !strings.HasPrefix(res.Name, class+".") {
+			res.Name = fmt.Sprintf("%v.%v", class, res.Name)
+		}
+		if res.Status != Passed && res.Message == "" {
+			res.Message = tr.Outcome
+		}
+		res.Duration = parseTRXDuration(tr.Duration)
+		res.Output = append(res.Output, labeledOutput("Stack trace", tr.Output.ErrorInfo.StackTrace)...)
+		res.Output = append(res.Output, labeledOutput("Standard output", tr.Output.StdOut)...)
+		res.Output = append(res.Output, labeledOutput("Standard error", tr.Output.StdErr)...)
+		results = append(results, res)
+	}
+	for _, tr := range run.Results {
+		add(tr)
+	}
+	return results, nil
+}
+
+func trxStatus(outcome string) string {
+	switch outcome {
+	case "Passed", "PassedButRunAborted", "Warning":
+		return Passed
+	case "NotExecuted", "NotRunnable", "Inconclusive", "Pending", "Disconnected":
+		return Skipped
+	case "Error", "Aborted":
+		return Errored
+	}
+	return Failed
+}
+
+// parseTRXDuration parses durations like "00:01:02.5000000".
+func parseTRXDuration(duration string) time.Duration {
+	parts := strings.Split(duration, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second))
+}
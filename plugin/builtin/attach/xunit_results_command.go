@@ -154,6 +154,15 @@ func (c *AttachXUnitResultsCommand) parseAndUploadResults(
 		}
 	}
 
+	return sendResultsAndLogs(taskConfig, pluginLogger, pluginCom, tests, logs, logIdxToTestIdx)
+}
+
+// sendResultsAndLogs sends the logs, then the test results with the ids of
+// their logs. logIdxToTestIdx maps each log to the test it's for.
+func sendResultsAndLogs(taskConfig *model.TaskConfig, pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator, tests []task.TestResult, logs []*model.TestLog,
+	logIdxToTestIdx []int) error {
+
 	for i, log := range logs {
 		logId, err := SendJSONLogs(pluginLogger, pluginCom, log)
 		if err != nil {
@@ -0,0 +1,35 @@
+package downstream
+
+import (
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	plugin.Publish(&DownstreamPlugin{})
+}
+
+const (
+	DownstreamPluginName = "downstream"
+	FetchArtifactsCmd    = "fetch_artifacts"
+	ArtifactsRoute       = "artifacts"
+)
+
+// DownstreamPlugin has commands that use the results of the tasks a task
+// depends on.
+type DownstreamPlugin struct{}
+
+// Name returns the name of the plugin. Required to fulfill
+// the Plugin interface.
+func (dp *DownstreamPlugin) Name() string {
+	return DownstreamPluginName
+}
+
+// NewCommand returns the requested command, or returns an error
+// if a non-existing command is requested.
+func (dp *DownstreamPlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	if cmdName == FetchArtifactsCmd {
+		return &FetchArtifactsCommand{}, nil
+	}
+	return nil, errors.Errorf("no such command: %v", cmdName)
+}
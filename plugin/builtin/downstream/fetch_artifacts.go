@@ -0,0 +1,312 @@
+package downstream
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/archive"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	fetchAttempts = 5
+	fetchSleep    = 5 * time.Second
+)
+
+// FetchArtifactsCommand downloads files attached to the tasks the task
+// depends on, or to another task in the same version, into the working
+// directory.
+type FetchArtifactsCommand struct {
+	// TaskName, if set, is the display name of the task in the same version
+	// to fetch files from, instead of the tasks the task depends on.
+	TaskName string `mapstructure:"task" plugin:"expand"`
+
+	// Variant is the build variant of TaskName, if it isn't the task's own.
+	Variant string `mapstructure:"variant" plugin:"expand"`
+
+	// Files are the names, or glob patterns of names, of the files to fetch.
+	// All attached files are fetched if it's empty.
+	Files []string `mapstructure:"files" plugin:"expand"`
+
+	// Directory is where the files are downloaded to, relative to the
+	// working directory.
+	Directory string `mapstructure:"directory" plugin:"expand"`
+
+	// Extract, if set to true, extracts downloaded tarballs and zip files
+	// into the directory.
+	Extract bool `mapstructure:"extract"`
+
+	// Optional, if set to true, doesn't fail the command when some of the
+	// files don't match any attached file.
+	Optional bool `mapstructure:"optional"`
+
+	// client is used to download files, and defaults to http.DefaultClient.
+	client *http.Client
+}
+
+// artifactDownload is an attached file to download, and the name it's
+// downloaded under.
+type artifactDownload struct {
+	file      artifact.File
+	taskName  string
+	localName string
+}
+
+func (fac *FetchArtifactsCommand) Name() string {
+	return FetchArtifactsCmd
+}
+
+func (fac *FetchArtifactsCommand) Plugin() string {
+	return DownstreamPluginName
+}
+
+// ParseParams reads in the command's parameters.
+func (fac *FetchArtifactsCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, fac); err != nil {
+		return errors.Wrapf(err, "error decoding %v params", fac.Name())
+	}
+	if fac.Variant != "" && fac.TaskName == "" {
+		return errors.Errorf("error parsing '%v' params: variant needs a task", fac.Name())
+	}
+	for _, pattern := range fac.Files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf("error parsing '%v' params: invalid file pattern '%v'",
+				fac.Name(), pattern)
+		}
+	}
+	return nil
+}
+
+// route returns the route that the attached files are fetched from. Task and
+// variant names may have characters that aren't allowed in a path, so they're
+// escaped.
+func (fac *FetchArtifactsCommand) route() string {
+	switch {
+	case fac.Variant != "":
+		return fmt.Sprintf("%s/%s/%s", ArtifactsRoute, url.PathEscape(fac.TaskName), url.PathEscape(fac.Variant))
+	case fac.TaskName != "":
+		return fmt.Sprintf("%s/%s", ArtifactsRoute, url.PathEscape(fac.TaskName))
+	default:
+		return ArtifactsRoute
+	}
+}
+
+// fetchEntries gets the files attached to the tasks from the API server.
+func (fac *FetchArtifactsCommand) fetchEntries(pluginCom plugin.PluginCommunicator) ([]artifact.Entry, error) {
+	entries := []artifact.Entry{}
+	getFunc := func() error {
+		resp, err := pluginCom.TaskGetJSON(fac.route())
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+		if err != nil {
+			return util.RetriableError{Failure: err}
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return errors.Errorf("task %v not found", fac.TaskName)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return util.RetriableError{
+				Failure: errors.Errorf("unexpected status code: %v", resp.StatusCode),
+			}
+		}
+		return errors.Wrap(util.ReadJSONInto(resp.Body, &entries), "failed to read JSON reply")
+	}
+
+	retryFail, err := util.Retry(getFunc, fetchAttempts, fetchSleep)
+	if retryFail {
+		return nil, errors.Wrapf(err, "fetching attached files failed after %v tries", fetchAttempts)
+	}
+	return entries, err
+}
+
+// localName returns the name a file is downloaded under, which is the last
+// element of its link's path.
+func localName(file artifact.File) (string, error) {
+	link, err := url.Parse(file.Link)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid link for file %v", file.Name)
+	}
+	name := path.Base(link.Path)
+	if name == "." || name == "/" || name == ".." {
+		return "", errors.Errorf("link for file %v doesn't name a file", file.Name)
+	}
+	return name, nil
+}
+
+// selectFiles returns the attached files that match the command's files.
+// It's an error for patterns to match nothing, unless the command is
+// optional, or for two files to be downloaded under the same name.
+func (fac *FetchArtifactsCommand) selectFiles(entries []artifact.Entry) ([]artifactDownload, []string, error) {
+	matched := map[string]bool{}
+	byLocalName := map[string]artifactDownload{}
+	downloads := []artifactDownload{}
+
+	for _, entry := range entries {
+		for _, file := range entry.Files {
+			selected := len(fac.Files) == 0
+			for _, pattern := range fac.Files {
+				if ok, _ := path.Match(pattern, file.Name); ok {
+					matched[pattern] = true
+					selected = true
+				}
+			}
+			if !selected {
+				continue
+			}
+
+			name, err := localName(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			if other, ok := byLocalName[name]; ok {
+				return nil, nil, errors.Errorf("files %v from %v and %v from %v would both be downloaded to %v",
+					other.file.Name, other.taskName, file.Name, entry.TaskDisplayName, name)
+			}
+			download := artifactDownload{file: file, taskName: entry.TaskDisplayName, localName: name}
+			byLocalName[name] = download
+			downloads = append(downloads, download)
+		}
+	}
+
+	unmatched := []string{}
+	for _, pattern := range fac.Files {
+		if !matched[pattern] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	if len(unmatched) != 0 && !fac.Optional {
+		return nil, nil, errors.Errorf("no attached files match %v", strings.Join(unmatched, ", "))
+	}
+	return downloads, unmatched, nil
+}
+
+// download writes the file at link to localPath, retrying on errors.
+func (fac *FetchArtifactsCommand) download(link, localPath string) error {
+	client := fac.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	downloadFunc := func() error {
+		resp, err := client.Get(link)
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+		if err != nil {
+			return util.RetriableError{Failure: err}
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return util.RetriableError{
+				Failure: errors.Errorf("unexpected status code: %v", resp.StatusCode),
+			}
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected status code: %v", resp.StatusCode)
+		}
+
+		// download next to the destination, so a failed download doesn't
+		// leave a partial file
+		tmp, err := ioutil.TempFile(filepath.Dir(localPath), ".download")
+		if err != nil {
+			return errors.Wrap(err, "error creating temporary file")
+		}
+		defer os.Remove(tmp.Name())
+		_, err = io.Copy(tmp, resp.Body)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return util.RetriableError{Failure: errors.Wrap(err, "error reading file")}
+		}
+		return errors.Wrap(os.Rename(tmp.Name(), localPath), "error moving file into place")
+	}
+
+	retryFail, err := util.Retry(downloadFunc, fetchAttempts, fetchSleep)
+	if retryFail {
+		return errors.Wrapf(err, "downloading %v failed after %v tries", link, fetchAttempts)
+	}
+	return err
+}
+
+// Fetch downloads the selected files into dir, extracting them if the
+// command is set to.
+func (fac *FetchArtifactsCommand) Fetch(pluginLogger plugin.Logger, entries []artifact.Entry, dir string) error {
+	downloads, unmatched, err := fac.selectFiles(entries)
+	if err != nil {
+		return err
+	}
+	if len(unmatched) != 0 {
+		pluginLogger.LogTask(slogger.INFO, "No attached files match %v", strings.Join(unmatched, ", "))
+	}
+	if len(downloads) == 0 {
+		pluginLogger.LogTask(slogger.INFO, "No files to fetch")
+		return nil
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory %v", dir)
+	}
+	for _, download := range downloads {
+		localPath := filepath.Join(dir, download.localName)
+		pluginLogger.LogTask(slogger.INFO, "Fetching %v from %v to %v",
+			download.file.Name, download.taskName, localPath)
+		if err = fac.download(download.file.Link, localPath); err != nil {
+			return errors.Wrapf(err, "error fetching %v from %v", download.file.Name, download.taskName)
+		}
+
+		format := archive.FormatOf(download.localName)
+		if !fac.Extract || format == "" {
+			continue
+		}
+		pluginLogger.LogTask(slogger.INFO, "Extracting %v into %v", localPath, dir)
+		if err = archive.Unpack(format, localPath, dir); err != nil {
+			return errors.Wrapf(err, "error extracting %v", localPath)
+		}
+	}
+	return nil
+}
+
+// Execute fetches the files.
+func (fac *FetchArtifactsCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(fac, conf.Expansions); err != nil {
+		return errors.Wrap(err, "error expanding params")
+	}
+	dir := filepath.Join(conf.WorkDir, fac.Directory)
+
+	errChan := make(chan error)
+	go func() {
+		entries, err := fac.fetchEntries(pluginCom)
+		if err == nil {
+			err = fac.Fetch(pluginLogger, entries, dir)
+		}
+		errChan <- err
+	}()
+
+	select {
+	case err := <-errChan:
+		return errors.WithStack(err)
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of fetch artifacts command")
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package downstream
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// artifactsCommunicator answers requests for attached files with the
+// entries for each route.
+type artifactsCommunicator struct {
+	entries  map[string][]artifact.Entry
+	requests []string
+}
+
+func (ac *artifactsCommunicator) TaskGetJSON(endpoint string) (*http.Response, error) {
+	ac.requests = append(ac.requests, endpoint)
+	entries, ok := ac.entries[endpoint]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (ac *artifactsCommunicator) TaskPostJSON(endpoint string, data interface{}) (*http.Response, error) {
+	return nil, nil
+}
+func (ac *artifactsCommunicator) TaskPostResults(results *task.TestResults) error    { return nil }
+func (ac *artifactsCommunicator) TaskPostTestLog(log *model.TestLog) (string, error) { return "", nil }
+func (ac *artifactsCommunicator) PostTaskFiles(files []*artifact.File) error         { return nil }
+
+// tarGz returns a gzipped tarball of the files.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}))
+		_, err := tw.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestFetchArtifactsParseParams(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &FetchArtifactsCommand{}
+	require.NoError(t, cmd.ParseParams(map[string]interface{}{
+		"task":      "compile",
+		"variant":   "linux",
+		"files":     []interface{}{"binaries", "*.tgz"},
+		"directory": "build",
+		"extract":   true,
+	}))
+	assert.Equal("compile", cmd.TaskName)
+	assert.Equal([]string{"binaries", "*.tgz"}, cmd.Files)
+	assert.True(cmd.Extract)
+	assert.Equal("artifacts/compile/linux", cmd.route())
+	assert.Equal("artifacts", (&FetchArtifactsCommand{}).route())
+	assert.Equal("artifacts/unit%20tests/linux%3F64",
+		(&FetchArtifactsCommand{TaskName: "unit tests", Variant: "linux?64"}).route())
+
+	assert.Error((&FetchArtifactsCommand{}).ParseParams(map[string]interface{}{"variant": "linux"}))
+	assert.Error((&FetchArtifactsCommand{}).ParseParams(map[string]interface{}{"files": []interface{}{"[bad"}}))
+}
+
+func TestFetchArtifactsSelectFiles(t *testing.T) {
+	assert := assert.New(t)
+	entries := []artifact.Entry{
+		{TaskDisplayName: "compile", Files: []artifact.File{
+			{Name: "binaries", Link: "http://files/compile/dist.tgz"},
+			{Name: "compile log", Link: "http://files/compile/log.txt"},
+		}},
+		{TaskDisplayName: "lint", Files: []artifact.File{
+			{Name: "lint log", Link: "http://files/lint/log.txt"},
+		}},
+	}
+
+	downloads, _, err := (&FetchArtifactsCommand{Files: []string{"binaries"}}).selectFiles(entries)
+	require.NoError(t, err)
+	require.Len(t, downloads, 1)
+	assert.Equal("dist.tgz", downloads[0].localName)
+
+	// files from different tasks can't be downloaded to the same name
+	_, _, err = (&FetchArtifactsCommand{Files: []string{"* log"}}).selectFiles(entries)
+	assert.Error(err)
+	_, _, err = (&FetchArtifactsCommand{}).selectFiles(entries)
+	assert.Error(err)
+
+	// patterns that match nothing fail the command unless it's optional
+	_, _, err = (&FetchArtifactsCommand{Files: []string{"binaries", "coverage"}}).selectFiles(entries)
+	assert.Error(err)
+	downloads, unmatched, err := (&FetchArtifactsCommand{Files: []string{"binaries", "coverage"}, Optional: true}).selectFiles(entries)
+	require.NoError(t, err)
+	assert.Len(downloads, 1)
+	assert.Equal([]string{"coverage"}, unmatched)
+}
+
+func TestFetchArtifactsExecute(t *testing.T) {
+	assert := assert.New(t)
+
+	files := map[string][]byte{
+		"/compile/dist.tgz": tarGz(t, map[string]string{"bin/tool": "tool"}),
+		"/compile/log.txt":  []byte("compile log"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(contents)
+	}))
+	defer srv.Close()
+
+	com := &artifactsCommunicator{entries: map[string][]artifact.Entry{
+		"artifacts": {{TaskDisplayName: "compile", Files: []artifact.File{
+			{Name: "binaries", Link: srv.URL + "/compile/dist.tgz"},
+			{Name: "compile log", Link: srv.URL + "/compile/log.txt"},
+		}}},
+		"artifacts/lint": {{TaskDisplayName: "lint", Files: []artifact.File{
+			{Name: "lint log", Link: srv.URL + "/lint/missing.txt"},
+		}}},
+	}}
+
+	dir, err := ioutil.TempDir("", "fetch-artifacts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	conf := &model.TaskConfig{
+		Expansions: command.NewExpansions(map[string]string{"out": "deps"}),
+		Task:       &task.Task{},
+		WorkDir:    dir,
+	}
+
+	cmd := &FetchArtifactsCommand{Files: []string{"binaries", "compile log"}, Directory: "${out}", Extract: true}
+	require.NoError(t, cmd.Execute(&plugintest.MockLogger{}, com, conf, make(chan bool)))
+
+	for name, contents := range map[string]string{"log.txt": "compile log", "bin/tool": "tool"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "deps", filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(contents, string(data))
+	}
+	_, err = os.Stat(filepath.Join(dir, "deps", "dist.tgz"))
+	assert.NoError(err)
+
+	// missing files and tasks fail the command without retrying
+	cmd = &FetchArtifactsCommand{TaskName: "lint"}
+	assert.Error(cmd.Execute(&plugintest.MockLogger{}, com, conf, make(chan bool)))
+	_, err = os.Stat(filepath.Join(dir, "missing.txt"))
+	assert.True(os.IsNotExist(err))
+
+	cmd = &FetchArtifactsCommand{TaskName: "test"}
+	assert.Error(cmd.Execute(&plugintest.MockLogger{}, com, conf, make(chan bool)))
+	assert.Equal([]string{"artifacts", "artifacts/lint", "artifacts/test"}, com.requests)
+}
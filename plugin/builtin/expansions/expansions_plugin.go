@@ -52,6 +52,10 @@ type UpdateCommand struct {
 	// in the form of
 	//   "expansion_key: expansions_value"
 	YamlFile string `mapstructure:"file"`
+
+	// Redact keeps the values of the updated expansions out of the task's
+	// logs. Values made from private variables are always kept out.
+	Redact bool `mapstructure:"redact"`
 }
 
 // PutCommandParams are pairings of expansion names
@@ -120,6 +124,12 @@ func (self *UpdateCommand) ExecuteUpdates(conf *model.TaskConfig) error {
 func (self *UpdateCommand) Execute(pluginLogger plugin.Logger,
 	pluginCom plugin.PluginCommunicator, conf *model.TaskConfig, stop chan bool) error {
 
+	before := map[string]string{}
+	for key, value := range *conf.Expansions {
+		before[key] = value
+	}
+	defer self.redactUpdates(conf, before)
+
 	err := self.ExecuteUpdates(conf)
 	if err != nil {
 		return err
@@ -141,3 +151,20 @@ func (self *UpdateCommand) Execute(pluginLogger plugin.Logger,
 	return nil
 
 }
+
+// redactUpdates keeps the values of the expansions that changed since before
+// out of the task's logs, if the command redacts its updates or they contain
+// values that are already being redacted.
+func (self *UpdateCommand) redactUpdates(conf *model.TaskConfig, before map[string]string) {
+	if conf.Redactor == nil {
+		return
+	}
+	for key, value := range *conf.Expansions {
+		if old, ok := before[key]; ok && old == value {
+			continue
+		}
+		if self.Redact || conf.Redactor.Redact(value) != value {
+			conf.Redactor.Add(key, value)
+		}
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/evergreen-ci/evergreen/plugin/plugintest"
 	"github.com/evergreen-ci/evergreen/service"
 	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestExpansionsPlugin(t *testing.T) {
@@ -76,3 +78,26 @@ func TestExpansionsPluginWExecution(t *testing.T) {
 		})
 	})
 }
+
+func TestUpdateCommandRedactsExpansions(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := &model.TaskConfig{
+		Expansions: command.NewExpansions(map[string]string{"secret": "hunter22", "plain": "value"}),
+		Redactor:   util.NewRedactor(),
+	}
+	conf.Redactor.Add("secret", "hunter22")
+
+	cmd := &UpdateCommand{Updates: []PutCommandParams{
+		{Key: "auth", Value: "user:${secret}"},
+		{Key: "greeting", Value: "hello ${plain}"},
+	}}
+	assert.NoError(cmd.Execute(&plugintest.MockLogger{}, nil, conf, nil))
+	// values made from secrets are hidden whole, and other values aren't
+	assert.Equal("<REDACTED:auth>", conf.Redactor.Redact("user:hunter22"))
+	assert.Equal("hello value", conf.Redactor.Redact("hello value"))
+
+	cmd = &UpdateCommand{Redact: true, Updates: []PutCommandParams{{Key: "token", Value: "abcdef"}}}
+	assert.NoError(cmd.Execute(&plugintest.MockLogger{}, nil, conf, nil))
+	assert.Equal("token=<REDACTED:token>", conf.Redactor.Redact("token=abcdef"))
+}
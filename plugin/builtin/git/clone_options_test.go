@@ -0,0 +1,155 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitGetProjectParseCloneOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &GitGetProjectCommand{}
+	require.NoError(t, cmd.ParseParams(map[string]interface{}{
+		"directory":            "src",
+		"clone_depth":          50,
+		"sparse_paths":         []interface{}{"/docs/", "/src/server/"},
+		"recursive_submodules": true,
+		"submodule_overrides": []interface{}{
+			map[string]interface{}{"name": "vendor/big", "skip": true},
+			map[string]interface{}{"name": "third_party", "url": "git@mirror:tp.git", "clone_depth": 1},
+		},
+		"reference_dir": "/data/mirrors/project.git",
+	}))
+	assert.Equal(50, cmd.CloneDepth)
+	assert.Equal([]string{"/docs/", "/src/server/"}, cmd.SparsePaths)
+	assert.True(cmd.RecursiveSubmodules)
+	assert.Equal([]SubmoduleOverride{
+		{Name: "vendor/big", Skip: true},
+		{Name: "third_party", URL: "git@mirror:tp.git", CloneDepth: 1},
+	}, cmd.SubmoduleOverrides)
+	assert.Equal("/data/mirrors/project.git", cmd.ReferenceDir)
+
+	assert.Error((&GitGetProjectCommand{}).ParseParams(map[string]interface{}{
+		"directory": "src", "clone_depth": -1,
+	}))
+	assert.Error((&GitGetProjectCommand{}).ParseParams(map[string]interface{}{
+		"directory":           "src",
+		"submodule_overrides": []interface{}{map[string]interface{}{"name": "a", "skip": true}},
+	}))
+	assert.Error((&GitGetProjectCommand{}).ParseParams(map[string]interface{}{
+		"directory":            "src",
+		"recursive_submodules": true,
+		"submodule_overrides":  []interface{}{map[string]interface{}{"skip": true}},
+	}))
+}
+
+func TestGitGetProjectCommandsFullClone(t *testing.T) {
+	cmd := &GitGetProjectCommand{Directory: "src"}
+	assert.Equal(t, []string{
+		"set -o errexit",
+		"set -o verbose",
+		"rm -rf src",
+		"git clone 'git@github.com:evergreen-ci/sample.git' 'src' --branch 'master'",
+		"cd src",
+		"git reset --hard abc123",
+	}, cmd.getProjectCommands("git@github.com:evergreen-ci/sample.git", "master", "abc123", ""))
+}
+
+func TestGitGetProjectCommandsWithOptions(t *testing.T) {
+	cmd := &GitGetProjectCommand{
+		Directory:           "src",
+		CloneDepth:          10,
+		SparsePaths:         []string{"/docs/", "*.md"},
+		RecursiveSubmodules: true,
+		SubmoduleOverrides: []SubmoduleOverride{
+			{Name: "big", Skip: true},
+			{Name: "tp", URL: "git@mirror:tp.git", CloneDepth: 1},
+		},
+	}
+	assert.Equal(t, []string{
+		"set -o errexit",
+		"set -o verbose",
+		"rm -rf src",
+		"git clone 'repo' 'src' --branch 'master' --depth 10 --no-checkout --reference '/mirror' --dissociate",
+		"cd src",
+		"git config core.sparseCheckout true",
+		"printf '%s\\n' '/docs/' '*.md' > .git/info/sparse-checkout",
+		"git cat-file -e 'abc123^{commit}' 2>/dev/null || git fetch --depth 10 origin 'abc123'",
+		"git reset --hard abc123",
+		"git submodule init",
+		"git config 'submodule.big.update' none",
+		"git config 'submodule.tp.url' 'git@mirror:tp.git'",
+		"git submodule update --recursive --depth 1 -- \"$(git config -f .gitmodules 'submodule.tp.path')\"",
+		"git submodule update --recursive --depth 10",
+	}, cmd.getProjectCommands("repo", "master", "abc123", "/mirror"))
+}
+
+func TestGitGetModuleCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &GitGetProjectCommand{Directory: "src"}
+	assert.Equal([]string{
+		"set -o errexit",
+		"set -o verbose",
+		"git clone repo 'src/modules/mod'",
+		"cd 'src/modules/mod'",
+		"git checkout 'master'",
+	}, cmd.getModuleCommands("repo", "src/modules/mod", "master"))
+
+	cmd.CloneDepth = 5
+	assert.Equal([]string{
+		"set -o errexit",
+		"set -o verbose",
+		"git clone repo 'src/modules/mod' --depth 5 --no-single-branch",
+		"cd 'src/modules/mod'",
+		"git cat-file -e 'master^{commit}' 2>/dev/null || git fetch --depth 5 origin 'master'",
+		"git checkout 'master'",
+	}, cmd.getModuleCommands("repo", "src/modules/mod", "master"))
+}
+
+func TestGetPatchCommandsShallow(t *testing.T) {
+	assert := assert.New(t)
+	modulePatch := patch.ModulePatch{Githash: "abc123"}
+
+	full := getPatchCommands(modulePatch, "src", "/tmp/patch", 0)
+	assert.Equal(GetPatchCommands(modulePatch, "src", "/tmp/patch"), full)
+	for _, line := range full {
+		assert.NotContains(line, "git fetch")
+	}
+
+	shallow := getPatchCommands(modulePatch, "src", "/tmp/patch", 20)
+	require.Len(t, shallow, len(full)+1)
+	fetchIdx, resetIdx := -1, -1
+	for i, line := range shallow {
+		if strings.Contains(line, "git fetch --depth 20 origin 'abc123'") {
+			fetchIdx = i
+		}
+		if line == "git reset --hard 'abc123'" {
+			resetIdx = i
+		}
+	}
+	assert.NotEqual(-1, fetchIdx)
+	assert.Equal(fetchIdx+1, resetIdx)
+}
+
+func TestExistingReferenceDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "git-reference")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Equal("", (&GitGetProjectCommand{}).existingReferenceDir())
+	assert.Equal("", (&GitGetProjectCommand{ReferenceDir: filepath.Join(dir, "missing")}).existingReferenceDir())
+	assert.Equal("", (&GitGetProjectCommand{ReferenceDir: dir}).existingReferenceDir())
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "objects"), 0755))
+	assert.Equal(dir, (&GitGetProjectCommand{ReferenceDir: dir}).existingReferenceDir())
+}
@@ -25,6 +25,40 @@ type GitGetProjectCommand struct {
 	// Revisions are the optional revisions associated with the modules of a project.
 	// Note: If a module does not have a revision it will use the module's branch to get the project.
 	Revisions map[string]string `plugin:"expand"`
+
+	// CloneDepth, if set, makes shallow clones of the project and its
+	// modules with that many commits of history. Revisions older than that
+	// are fetched on their own.
+	CloneDepth int `mapstructure:"clone_depth"`
+
+	// SparsePaths, if set, limits the files checked out of the project to
+	// those matching these sparse-checkout patterns. Patches that change
+	// files outside of them can't be applied.
+	SparsePaths []string `mapstructure:"sparse_paths" plugin:"expand"`
+
+	// RecursiveSubmodules checks out the project's submodules, and theirs.
+	RecursiveSubmodules bool `mapstructure:"recursive_submodules"`
+
+	// SubmoduleOverrides change how some of the project's own submodules
+	// are checked out.
+	SubmoduleOverrides []SubmoduleOverride `mapstructure:"submodule_overrides" plugin:"expand"`
+
+	// ReferenceDir is a local clone or mirror of the project on the host,
+	// whose objects are copied rather than downloaded when it exists.
+	ReferenceDir string `mapstructure:"reference_dir" plugin:"expand"`
+}
+
+// SubmoduleOverride changes how a submodule is checked out.
+type SubmoduleOverride struct {
+	// Name is the submodule's name in .gitmodules.
+	Name string `mapstructure:"name" plugin:"expand"`
+	// URL, if set, replaces the submodule's url, such as with a mirror.
+	URL string `mapstructure:"url" plugin:"expand"`
+	// Skip leaves the submodule uninitialized.
+	Skip bool `mapstructure:"skip"`
+	// CloneDepth, if set, replaces the command's clone depth for the
+	// submodule.
+	CloneDepth int `mapstructure:"clone_depth"`
 }
 
 func (ggpc *GitGetProjectCommand) Name() string {
@@ -47,9 +81,172 @@ func (ggpc *GitGetProjectCommand) ParseParams(params map[string]interface{}) err
 		return errors.Errorf("error parsing '%v' params: value for directory "+
 			"must not be blank", ggpc.Name())
 	}
+	if ggpc.CloneDepth < 0 {
+		return errors.Errorf("error parsing '%v' params: clone_depth may not be negative", ggpc.Name())
+	}
+	if len(ggpc.SubmoduleOverrides) != 0 && !ggpc.RecursiveSubmodules {
+		return errors.Errorf("error parsing '%v' params: submodule_overrides "+
+			"need recursive_submodules", ggpc.Name())
+	}
+	for _, override := range ggpc.SubmoduleOverrides {
+		if override.Name == "" {
+			return errors.Errorf("error parsing '%v' params: submodule overrides "+
+				"must have a name", ggpc.Name())
+		}
+		if override.CloneDepth < 0 {
+			return errors.Errorf("error parsing '%v' params: clone_depth of "+
+				"submodule %v may not be negative", ggpc.Name(), override.Name)
+		}
+	}
 	return nil
 }
 
+// logOptions records the options the project is fetched with in the task log.
+func (ggpc *GitGetProjectCommand) logOptions(pluginLogger plugin.Logger, referenceDir string) {
+	options := []string{}
+	if ggpc.CloneDepth > 0 {
+		options = append(options, fmt.Sprintf("clone depth %d", ggpc.CloneDepth))
+	}
+	if len(ggpc.SparsePaths) != 0 {
+		options = append(options, fmt.Sprintf("sparse paths %v", ggpc.SparsePaths))
+	}
+	if ggpc.RecursiveSubmodules {
+		options = append(options, "recursive submodules")
+	}
+	for _, override := range ggpc.SubmoduleOverrides {
+		options = append(options, fmt.Sprintf("submodule %v with %+v", override.Name, override))
+	}
+	if referenceDir != "" {
+		options = append(options, fmt.Sprintf("reference directory %v", referenceDir))
+	} else if ggpc.ReferenceDir != "" {
+		options = append(options, fmt.Sprintf("missing reference directory %v (not used)", ggpc.ReferenceDir))
+	}
+
+	if len(options) == 0 {
+		pluginLogger.LogTask(slogger.INFO, "Fetching source with a full clone")
+		return
+	}
+	pluginLogger.LogTask(slogger.INFO, "Fetching source with %v", strings.Join(options, ", "))
+}
+
+// existingReferenceDir returns the reference directory if it holds a
+// repository on this host, which may be a bare mirror, or "" otherwise.
+func (ggpc *GitGetProjectCommand) existingReferenceDir() string {
+	if ggpc.ReferenceDir == "" {
+		return ""
+	}
+	for _, name := range []string{".git", "objects"} {
+		if _, err := os.Stat(filepath.Join(ggpc.ReferenceDir, name)); err == nil {
+			return ggpc.ReferenceDir
+		}
+	}
+	return ""
+}
+
+// fetchRevisionCommand returns a command that fetches a revision that isn't
+// in a shallow clone, such as one older than the clone's depth.
+func fetchRevisionCommand(revision string, depth int) string {
+	return fmt.Sprintf("git cat-file -e '%s^{commit}' 2>/dev/null || git fetch --depth %d origin '%s'",
+		revision, depth, revision)
+}
+
+// getProjectCommands returns the commands that clone the project from
+// location into the command's directory and check out the revision. If
+// referenceDir is set, objects are copied from the repository in it.
+func (ggpc *GitGetProjectCommand) getProjectCommands(location, branch, revision, referenceDir string) []string {
+	gitCommands := []string{
+		fmt.Sprintf("set -o errexit"),
+		fmt.Sprintf("set -o verbose"),
+		fmt.Sprintf("rm -rf %s", ggpc.Directory),
+	}
+
+	cloneCmd := fmt.Sprintf("git clone '%s' '%s'", location, ggpc.Directory)
+	if branch != "" {
+		cloneCmd = fmt.Sprintf("%s --branch '%s'", cloneCmd, branch)
+	}
+	if ggpc.CloneDepth > 0 {
+		cloneCmd = fmt.Sprintf("%s --depth %d", cloneCmd, ggpc.CloneDepth)
+	}
+	if len(ggpc.SparsePaths) != 0 {
+		cloneCmd = fmt.Sprintf("%s --no-checkout", cloneCmd)
+	}
+	if referenceDir != "" {
+		// dissociate so the clone doesn't break if the reference changes
+		cloneCmd = fmt.Sprintf("%s --reference '%s' --dissociate", cloneCmd, referenceDir)
+	}
+	gitCommands = append(gitCommands, cloneCmd, fmt.Sprintf("cd %v", ggpc.Directory))
+
+	if len(ggpc.SparsePaths) != 0 {
+		gitCommands = append(gitCommands, "git config core.sparseCheckout true")
+		patterns := make([]string, 0, len(ggpc.SparsePaths))
+		for _, path := range ggpc.SparsePaths {
+			patterns = append(patterns, fmt.Sprintf("'%s'", path))
+		}
+		gitCommands = append(gitCommands,
+			fmt.Sprintf("printf '%%s\\n' %s > .git/info/sparse-checkout", strings.Join(patterns, " ")))
+	}
+
+	if ggpc.CloneDepth > 0 {
+		gitCommands = append(gitCommands, fetchRevisionCommand(revision, ggpc.CloneDepth))
+	}
+	gitCommands = append(gitCommands, fmt.Sprintf("git reset --hard %s", revision))
+
+	if ggpc.RecursiveSubmodules {
+		gitCommands = append(gitCommands, ggpc.getSubmoduleCommands()...)
+	}
+	return gitCommands
+}
+
+// getSubmoduleCommands returns the commands that check out the submodules
+// of the project, applying the overrides to them.
+func (ggpc *GitGetProjectCommand) getSubmoduleCommands() []string {
+	gitCommands := []string{"git submodule init"}
+
+	for _, override := range ggpc.SubmoduleOverrides {
+		if override.URL != "" {
+			gitCommands = append(gitCommands,
+				fmt.Sprintf("git config 'submodule.%s.url' '%s'", override.Name, override.URL))
+		}
+		if override.Skip {
+			gitCommands = append(gitCommands,
+				fmt.Sprintf("git config 'submodule.%s.update' none", override.Name))
+			continue
+		}
+		if override.CloneDepth > 0 {
+			gitCommands = append(gitCommands, fmt.Sprintf(
+				"git submodule update --recursive --depth %d -- \"$(git config -f .gitmodules 'submodule.%s.path')\"",
+				override.CloneDepth, override.Name))
+		}
+	}
+
+	updateCmd := "git submodule update --recursive"
+	if ggpc.CloneDepth > 0 {
+		updateCmd = fmt.Sprintf("%s --depth %d", updateCmd, ggpc.CloneDepth)
+	}
+	return append(gitCommands, updateCmd)
+}
+
+// getModuleCommands returns the commands that clone a module from repo into
+// moduleBase and check out the revision, which may be a branch.
+func (ggpc *GitGetProjectCommand) getModuleCommands(repo, moduleBase, revision string) []string {
+	cloneCmd := fmt.Sprintf("git clone %v '%v'", repo, moduleBase)
+	if ggpc.CloneDepth > 0 {
+		// fetch every branch's tip, since the revision may be a branch
+		cloneCmd = fmt.Sprintf("%s --depth %d --no-single-branch", cloneCmd, ggpc.CloneDepth)
+	}
+
+	moduleCmds := []string{
+		fmt.Sprintf("set -o errexit"),
+		fmt.Sprintf("set -o verbose"),
+		cloneCmd,
+		fmt.Sprintf("cd '%v'", moduleBase),
+	}
+	if ggpc.CloneDepth > 0 {
+		moduleCmds = append(moduleCmds, fetchRevisionCommand(revision, ggpc.CloneDepth))
+	}
+	return append(moduleCmds, fmt.Sprintf("git checkout '%v'", revision))
+}
+
 // Execute gets the source code required by the project
 func (ggpc *GitGetProjectCommand) Execute(pluginLogger plugin.Logger,
 	pluginCom plugin.PluginCommunicator,
@@ -67,20 +264,10 @@ func (ggpc *GitGetProjectCommand) Execute(pluginLogger plugin.Logger,
 		return err
 	}
 
-	gitCommands := []string{
-		fmt.Sprintf("set -o errexit"),
-		fmt.Sprintf("set -o verbose"),
-		fmt.Sprintf("rm -rf %s", ggpc.Directory),
-	}
+	referenceDir := ggpc.existingReferenceDir()
+	ggpc.logOptions(pluginLogger, referenceDir)
 
-	cloneCmd := fmt.Sprintf("git clone '%s' '%s'", location, ggpc.Directory)
-	if conf.ProjectRef.Branch != "" {
-		cloneCmd = fmt.Sprintf("%s --branch '%s'", cloneCmd, conf.ProjectRef.Branch)
-	}
-
-	gitCommands = append(gitCommands,
-		cloneCmd,
-		fmt.Sprintf("cd %v; git reset --hard %s", ggpc.Directory, conf.Task.Revision))
+	gitCommands := ggpc.getProjectCommands(location, conf.ProjectRef.Branch, conf.Task.Revision, referenceDir)
 
 	cmdsJoined := strings.Join(gitCommands, "\n")
 
@@ -90,6 +277,7 @@ func (ggpc *GitGetProjectCommand) Execute(pluginLogger plugin.Logger,
 		Stdout:           pluginLogger.GetTaskLogWriter(slogger.INFO),
 		Stderr:           pluginLogger.GetTaskLogWriter(slogger.ERROR),
 		ScriptMode:       true,
+		Cgroup:           conf.Cgroup,
 	}
 
 	errChan := make(chan error)
@@ -153,12 +341,7 @@ func (ggpc *GitGetProjectCommand) Execute(pluginLogger plugin.Logger,
 			}
 		}
 
-		moduleCmds := []string{
-			fmt.Sprintf("set -o errexit"),
-			fmt.Sprintf("set -o verbose"),
-			fmt.Sprintf("git clone %v '%v'", module.Repo, filepath.ToSlash(moduleBase)),
-			fmt.Sprintf("cd %v; git checkout '%v'", filepath.ToSlash(moduleBase), revision),
-		}
+		moduleCmds := ggpc.getModuleCommands(module.Repo, filepath.ToSlash(moduleBase), revision)
 
 		moduleFetchCmd := &command.LocalCommand{
 			CmdString:        strings.Join(moduleCmds, "\n"),
@@ -166,6 +349,7 @@ func (ggpc *GitGetProjectCommand) Execute(pluginLogger plugin.Logger,
 			Stdout:           pluginLogger.GetTaskLogWriter(slogger.INFO),
 			Stderr:           pluginLogger.GetTaskLogWriter(slogger.ERROR),
 			ScriptMode:       true,
+			Cgroup:           conf.Cgroup,
 		}
 
 		go func() {
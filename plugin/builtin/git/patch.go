@@ -151,13 +151,23 @@ func (ggpc GitGetProjectCommand) getPatchContents(com plugin.PluginCommunicator,
 // GetPatchCommands, given a module patch of a patch, will return the appropriate list of commands that
 // need to be executed. If the patch is empty it will not apply the patch.
 func GetPatchCommands(modulePatch patch.ModulePatch, dir, patchPath string) []string {
+	return getPatchCommands(modulePatch, dir, patchPath, 0)
+}
+
+// getPatchCommands returns the commands that apply the patch to a clone
+// with the given depth, or to a full clone if depth is 0. Shallow clones may
+// not have the patch's base revision yet, so it's fetched first.
+func getPatchCommands(modulePatch patch.ModulePatch, dir, patchPath string, depth int) []string {
 	patchCommands := []string{
 		fmt.Sprintf("set -o verbose"),
 		fmt.Sprintf("set -o errexit"),
 		fmt.Sprintf("ls"),
 		fmt.Sprintf("cd '%s'", dir),
-		fmt.Sprintf("git reset --hard '%s'", modulePatch.Githash),
 	}
+	if depth > 0 {
+		patchCommands = append(patchCommands, fetchRevisionCommand(modulePatch.Githash, depth))
+	}
+	patchCommands = append(patchCommands, fmt.Sprintf("git reset --hard '%s'", modulePatch.Githash))
 	if modulePatch.PatchSet.Patch == "" {
 		return patchCommands
 	}
@@ -215,7 +225,7 @@ func (ggpc *GitGetProjectCommand) applyPatch(conf *model.TaskConfig,
 		tempAbsPath := tempFile.Name()
 
 		// this applies the patch using the patch files in the temp directory
-		patchCommandStrings := GetPatchCommands(patchPart, dir, tempAbsPath)
+		patchCommandStrings := getPatchCommands(patchPart, dir, tempAbsPath, ggpc.CloneDepth)
 		cmdsJoined := strings.Join(patchCommandStrings, "\n")
 		patchCmd := &command.LocalCommand{
 			CmdString:        cmdsJoined,
@@ -223,6 +233,7 @@ func (ggpc *GitGetProjectCommand) applyPatch(conf *model.TaskConfig,
 			Stdout:           pluginLogger.GetTaskLogWriter(slogger.INFO),
 			Stderr:           pluginLogger.GetTaskLogWriter(slogger.ERROR),
 			ScriptMode:       true,
+			Cgroup:           conf.Cgroup,
 		}
 
 		if err = patchCmd.Run(); err != nil {
@@ -0,0 +1,324 @@
+package gotest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// the actions of the events test2json writes
+const (
+	jsonOutput      = "output"
+	jsonPass        = "pass"
+	jsonFail        = "fail"
+	jsonSkip        = "skip"
+	jsonBench       = "bench"
+	jsonBuildOutput = "build-output"
+)
+
+// jsonDetectBytes is how much of the start of test output is looked at to
+// tell whether it's in the JSON format.
+const jsonDetectBytes = 64 * 1024
+
+// jsonEvent is an event written by go test -json or test2json.
+type jsonEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+
+	// ImportPath identifies the package being built by build events, and
+	// FailedBuild the package whose build failure failed a package.
+	ImportPath  string
+	FailedBuild string
+}
+
+// jsonPackage is the state of a package whose tests are being parsed.
+type jsonPackage struct {
+	output *bytes.Buffer
+	tests  []*jsonTest
+	status string
+
+	elapsed     time.Duration
+	failedBuild string
+}
+
+// jsonTest is a test being parsed along with its output.
+type jsonTest struct {
+	result *TestResult
+	output *bytes.Buffer
+}
+
+// JSONParser parses the events that go test -json and test2json write. Since
+// they attribute each line of output to the test that wrote it, subtests and
+// parallel tests get results and logs of their own, which are kept in each
+// result's Output. Build failures and failures of packages with no failed
+// tests are reported as failed tests named after the package.
+type JSONParser struct {
+	Suite string
+	logs  []string
+
+	packages     map[string]*jsonPackage
+	packageOrder []string
+	tests        map[string]*jsonTest
+	// buildOutput is the output of building each package, by import path
+	buildOutput map[string]*bytes.Buffer
+	// otherOutput is the output that isn't in events, like build failures
+	// reported by older versions of go test, grouped by the package they
+	// are headed with
+	otherOutput map[string]*bytes.Buffer
+	otherPath   string
+}
+
+// IsJSONOutput returns true if the start of test output contains events
+// written by go test -json or test2json.
+func IsJSONOutput(start []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(start))
+	for scanner.Scan() {
+		if _, ok := parseJSONEvent(scanner.Text()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NewParser returns a parser for test output in the format of the start of
+// output, which must be buffered by at least jsonDetectBytes.
+func NewParser(suite string, output *bufio.Reader) Parser {
+	// the error is only for output shorter than what's peeked
+	start, _ := output.Peek(jsonDetectBytes)
+	if IsJSONOutput(start) {
+		return &JSONParser{Suite: suite}
+	}
+	return &VanillaParser{Suite: suite}
+}
+
+// Logs returns all of the lines of test output, in the order they were
+// written.
+func (jp *JSONParser) Logs() []string {
+	return jp.logs
+}
+
+// Results returns the results of the tests, each with its own output, along
+// with a failed result for each package that failed outside of its tests.
+func (jp *JSONParser) Results() []*TestResult {
+	out := []*TestResult{}
+	for _, name := range jp.packageOrder {
+		pkg := jp.packages[name]
+
+		failed := false
+		for _, t := range pkg.tests {
+			// tests that never finished were cut short
+			if t.result.Status == "" {
+				t.result.Status = FAIL
+			}
+			t.result.Output = splitLines(t.output.String())
+			t.result.EndLine = len(t.result.Output)
+			out = append(out, t.result)
+			failed = failed || t.result.Status == FAIL
+		}
+		if pkg.status != FAIL || failed {
+			continue
+		}
+
+		failure := &TestResult{
+			Name:      name,
+			SuiteName: jp.Suite,
+			Status:    FAIL,
+			RunTime:   pkg.elapsed,
+			StartLine: 1,
+		}
+		output := &bytes.Buffer{}
+		if build := jp.findBuildFailure(name, pkg); build != nil {
+			failure.Name = name + " [build failed]"
+			output.Write(build.Bytes())
+		}
+		output.Write(pkg.output.Bytes())
+		failure.Output = splitLines(output.String())
+		failure.EndLine = len(failure.Output)
+		out = append(out, failure)
+	}
+	return out
+}
+
+// Parse reads in test output and stores its results and logs.
+func (jp *JSONParser) Parse(testOutput io.Reader) error {
+	jp.packages = map[string]*jsonPackage{}
+	jp.tests = map[string]*jsonTest{}
+	jp.buildOutput = map[string]*bytes.Buffer{}
+	jp.otherOutput = map[string]*bytes.Buffer{}
+
+	reader := bufio.NewReader(testOutput)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "error reading test output")
+		}
+		if line != "" {
+			jp.handleLine(strings.TrimSuffix(line, "\n"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func (jp *JSONParser) handleLine(line string) {
+	event, ok := parseJSONEvent(line)
+	if !ok {
+		// a line like "# example.com/pkg" heads the output about a package
+		if strings.HasPrefix(line, "# ") {
+			jp.otherPath = packagePath(strings.TrimPrefix(line, "# "))
+		}
+		jp.appendOutput(jp.otherOutput, jp.otherPath, line+"\n")
+		jp.logs = append(jp.logs, line)
+		return
+	}
+	if event.Output != "" {
+		jp.logs = append(jp.logs, strings.TrimSuffix(event.Output, "\n"))
+	}
+
+	if event.Action == jsonBuildOutput {
+		jp.appendOutput(jp.buildOutput, event.ImportPath, event.Output)
+		return
+	}
+	if event.Package == "" {
+		return
+	}
+	pkg := jp.getPackage(event.Package)
+
+	if event.Test == "" {
+		switch event.Action {
+		case jsonOutput:
+			pkg.output.WriteString(event.Output)
+		case jsonPass, jsonFail, jsonSkip:
+			pkg.status = jsonStatus(event.Action)
+			pkg.elapsed = jsonElapsed(event.Elapsed)
+			pkg.failedBuild = event.FailedBuild
+			// tests that never finished, like benchmarks, end with
+			// their package
+			for _, t := range pkg.tests {
+				if t.result.Status == "" {
+					t.result.Status = pkg.status
+				}
+			}
+		}
+		return
+	}
+
+	t := jp.getTest(pkg, event.Package, event.Test)
+	switch event.Action {
+	case jsonOutput:
+		t.output.WriteString(event.Output)
+	case jsonPass, jsonFail, jsonSkip, jsonBench:
+		t.result.Status = jsonStatus(event.Action)
+		t.result.RunTime = jsonElapsed(event.Elapsed)
+	}
+}
+
+// findBuildFailure returns the output of the failed build that failed the
+// package, if it failed to build.
+func (jp *JSONParser) findBuildFailure(name string, pkg *jsonPackage) *bytes.Buffer {
+	if pkg.failedBuild != "" {
+		if output, ok := jp.buildOutput[pkg.failedBuild]; ok {
+			return output
+		}
+		return &bytes.Buffer{}
+	}
+	// older versions of go test write build failures as plain text
+	if strings.Contains(pkg.output.String(), "[build failed]") {
+		if output, ok := jp.otherOutput[name]; ok {
+			return output
+		}
+		return &bytes.Buffer{}
+	}
+	return nil
+}
+
+func (jp *JSONParser) getPackage(name string) *jsonPackage {
+	pkg, ok := jp.packages[name]
+	if !ok {
+		pkg = &jsonPackage{output: &bytes.Buffer{}}
+		jp.packages[name] = pkg
+		jp.packageOrder = append(jp.packageOrder, name)
+	}
+	return pkg
+}
+
+// getTest returns the named test of the package, which is created by the
+// first event about it.
+func (jp *JSONParser) getTest(pkg *jsonPackage, pkgName, name string) *jsonTest {
+	key := pkgName + "\x00" + name
+	t, ok := jp.tests[key]
+	if !ok {
+		t = &jsonTest{
+			result: &TestResult{
+				Name:      name,
+				SuiteName: jp.Suite,
+				StartLine: 1,
+			},
+			output: &bytes.Buffer{},
+		}
+		jp.tests[key] = t
+		pkg.tests = append(pkg.tests, t)
+	}
+	return t
+}
+
+func (jp *JSONParser) appendOutput(outputs map[string]*bytes.Buffer, key, output string) {
+	buf, ok := outputs[key]
+	if !ok {
+		buf = &bytes.Buffer{}
+		outputs[key] = buf
+	}
+	buf.WriteString(output)
+}
+
+// parseJSONEvent parses a line of test output as a test2json event.
+func parseJSONEvent(line string) (*jsonEvent, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	event := &jsonEvent{}
+	if err := json.Unmarshal([]byte(line), event); err != nil || event.Action == "" {
+		return nil, false
+	}
+	return event, true
+}
+
+// packagePath returns the import path of the package that a build's import
+// path, like "example.com/pkg [example.com/pkg.test]", is for.
+func packagePath(importPath string) string {
+	if fields := strings.Fields(importPath); len(fields) > 0 {
+		return fields[0]
+	}
+	return importPath
+}
+
+func jsonStatus(action string) string {
+	switch action {
+	case jsonFail:
+		return FAIL
+	case jsonSkip:
+		return SKIP
+	}
+	return PASS
+}
+
+func jsonElapsed(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func splitLines(output string) []string {
+	if output == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+}
@@ -0,0 +1,105 @@
+package gotest
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParser(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := os.Open(filepath.Join(testutil.GetDirectoryOfFile(), "testdata", "json.suite"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, jsonDetectBytes)
+	parser := NewParser("json", reader)
+	require.IsType(t, &JSONParser{}, parser)
+	require.NoError(t, parser.Parse(reader))
+
+	results := map[string]*TestResult{}
+	names := []string{}
+	for _, result := range parser.Results() {
+		results[result.Name] = result
+		names = append(names, result.Name)
+		assert.Equal("json", result.SuiteName)
+		assert.Equal(1, result.StartLine)
+		assert.Equal(len(result.Output), result.EndLine)
+	}
+	assert.Equal([]string{
+		"example.com/gj/pkgbuild [build failed]",
+		"example.com/gj/old [build failed]",
+		"example.com/gj/initpanic",
+		"TestPanics",
+		"TestPass",
+		"TestSub",
+		"TestSub/ok",
+		"TestSub/bad",
+		"TestParallelOne",
+		"TestParallelTwo",
+		"TestSkip",
+		"BenchmarkNothing",
+	}, names)
+
+	// build and package failures
+	build := results["example.com/gj/pkgbuild [build failed]"]
+	assert.Equal(FAIL, build.Status)
+	assert.Contains(strings.Join(build.Output, "\n"), "undefined: undefined")
+	old := results["example.com/gj/old [build failed]"]
+	assert.Equal(FAIL, old.Status)
+	assert.Contains(strings.Join(old.Output, "\n"), "syntax error")
+	assert.Equal([]string{"panic: init failed", "FAIL\texample.com/gj/initpanic\t0.002s"},
+		results["example.com/gj/initpanic"].Output)
+
+	assert.Equal(FAIL, results["TestPanics"].Status)
+	assert.Contains(strings.Join(results["TestPanics"].Output, "\n"), "assignment to entry in nil map")
+
+	// subtests are results of their own
+	assert.Equal(PASS, results["TestPass"].Status)
+	assert.Equal(FAIL, results["TestSub"].Status)
+	assert.Equal(PASS, results["TestSub/ok"].Status)
+	assert.Equal(FAIL, results["TestSub/bad"].Status)
+	assert.Equal([]string{
+		"=== RUN   TestSub/bad",
+		"    a_test.go:14: sub failed",
+		"--- FAIL: TestSub/bad (0.00s)",
+	}, results["TestSub/bad"].Output)
+	assert.Equal(SKIP, results["TestSkip"].Status)
+
+	// parallel tests only get their own output
+	one := results["TestParallelOne"]
+	assert.Equal(PASS, one.Status)
+	assert.Equal(20*time.Millisecond, one.RunTime)
+	assert.NotContains(strings.Join(one.Output, "\n"), "two")
+
+	// benchmarks end with their package
+	assert.Equal(PASS, results["BenchmarkNothing"].Status)
+	assert.Contains(strings.Join(results["BenchmarkNothing"].Output, "\n"), "ns/op")
+
+	assert.Contains(parser.Logs(), "ok  \texample.com/gj/bench\t0.004s")
+}
+
+func TestNewParserDetectsFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, output := range []string{
+		"",
+		"=== RUN   TestThing\n--- PASS: TestThing (0.00s)\n",
+		"{not an event}\n",
+	} {
+		assert.IsType(&VanillaParser{}, NewParser("", bufio.NewReader(strings.NewReader(output))), output)
+	}
+
+	// build failures can come before the events
+	output := "# example.com/pkg\nbroken.go:1: syntax error\n" +
+		`{"Action":"start","Package":"example.com/other"}` + "\n"
+	assert.IsType(&JSONParser{}, NewParser("", bufio.NewReaderSize(strings.NewReader(output), jsonDetectBytes)))
+}
@@ -1,6 +1,7 @@
 package gotest
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
 	"strings"
@@ -178,15 +179,38 @@ func ParseTestOutputFiles(outputFiles []string, stop chan bool,
 		}
 		defer fileReader.Close()
 
-		// parse the output logs
-		parser := &VanillaParser{Suite: suiteName}
-		if err := parser.Parse(fileReader); err != nil {
+		// parse the output logs, in whichever format they're in
+		bufferedReader := bufio.NewReaderSize(fileReader, jsonDetectBytes)
+		parser := NewParser(suiteName, bufferedReader)
+		if err := parser.Parse(bufferedReader); err != nil {
 			// continue on error
 			pluginLogger.LogTask(slogger.ERROR, "Error parsing file '%v': %v",
 				outputFile, err)
 			continue
 		}
 
+		// tests with output of their own get logs of their own, and the
+		// rest share the log of the whole file
+		fileResults := []*TestResult{}
+		ownLogs := 0
+		for _, result := range parser.Results() {
+			if result.Output == nil {
+				fileResults = append(fileResults, result)
+				continue
+			}
+			logs = append(logs, model.TestLog{
+				Name:          result.Name,
+				Task:          taskConfig.Task.Id,
+				TaskExecution: taskConfig.Task.Execution,
+				Lines:         result.Output,
+			})
+			results = append(results, []*TestResult{result})
+			ownLogs++
+		}
+		if len(fileResults) == 0 && ownLogs > 0 {
+			continue
+		}
+
 		// build up the test logs
 		logLines := parser.Logs()
 		testLog := model.TestLog{
@@ -196,7 +220,7 @@ func ParseTestOutputFiles(outputFiles []string, stop chan bool,
 			Lines:         logLines,
 		}
 		// save the results
-		results = append(results, parser.Results())
+		results = append(results, fileResults)
 		logs = append(logs, testLog)
 
 	}
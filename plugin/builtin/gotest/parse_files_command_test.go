@@ -13,6 +13,7 @@ import (
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/mongodb/grip/slogger"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAllOutputFiles(t *testing.T) {
@@ -99,6 +100,32 @@ func TestParseOutputFiles(t *testing.T) {
 
 }
 
+func TestParseJSONOutputFiles(t *testing.T) {
+	assert := assert.New(t)
+	cwd := testutil.GetDirectoryOfFile()
+	logger := agentutil.NewTestLogger(slogger.StdOutAppender())
+	taskConfig := &model.TaskConfig{Task: &task.Task{Id: "taskOne", Execution: 1}}
+
+	logs, results, err := ParseTestOutputFiles([]string{
+		filepath.Join(cwd, "testdata", "json.suite"),
+		filepath.Join(cwd, "testdata", "monitor.suite"),
+	}, nil, logger, taskConfig)
+	assert.NoError(err)
+	assert.Len(results, len(logs))
+
+	// each test in the JSON output gets a log of its own, and the text
+	// output shares one
+	assert.Len(logs, 13)
+	for i, log := range logs[:12] {
+		assert.Len(results[i], 1)
+		assert.Equal(results[i][0].Name, log.Name)
+		assert.Equal(results[i][0].Output, log.Lines)
+		assert.Equal("taskOne", log.Task)
+	}
+	assert.Equal("monitor", logs[12].Name)
+	assert.True(len(results[12]) > 1)
+}
+
 func TestResultConversion(t *testing.T) {
 	Convey("With a set of results", t, func() {
 		results := []*TestResult{
@@ -74,6 +74,11 @@ type TestResult struct {
 	// Can be set to mark the id of the server-side log that this
 	// results corresponds to
 	LogId string
+
+	// Output, if set, is the test's own output, which is logged apart
+	// from the rest of the test output. StartLine and EndLine are then
+	// line numbers in it.
+	Output []string
 }
 
 // VanillaParser parses tests following go test output format.
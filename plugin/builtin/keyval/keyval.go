@@ -103,8 +103,15 @@ func (incCmd *IncCommand) Execute(pluginLogger plugin.Logger,
 }
 
 func (self *KeyValPlugin) NewCommand(cmdName string) (plugin.Command, error) {
-	if cmdName == IncCommandName {
+	switch cmdName {
+	case IncCommandName:
 		return &IncCommand{}, nil
+	case GetCommandName:
+		return &GetCommand{}, nil
+	case SetCommandName:
+		return &SetCommand{}, nil
+	case CASCommandName:
+		return &CASCommand{}, nil
 	}
 	return nil, &plugin.ErrUnknownCommand{cmdName}
 }
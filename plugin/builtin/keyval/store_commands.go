@@ -0,0 +1,266 @@
+package keyval
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	GetCommandName = "get"
+	SetCommandName = "set"
+	CASCommandName = "cas"
+	GetRoute       = "get"
+	SetRoute       = "set"
+	CASRoute       = "cas"
+
+	keyValAttempts = 10
+	keyValSleep    = 1 * time.Second
+)
+
+// errKeyNotFound is returned when getting a key that isn't set.
+var errKeyNotFound = errors.New("key not found")
+
+// validateKey checks the key and namespace params every store command has.
+// The namespace is one of global, project or version, and defaults to
+// project.
+func validateKey(cmdName, key string, namespace *string) error {
+	if key == "" {
+		return errors.Errorf("error parsing '%v' params: key may not be blank", cmdName)
+	}
+	if *namespace == "" {
+		*namespace = model.KeyValNamespaceProject
+	}
+	switch *namespace {
+	case model.KeyValNamespaceGlobal, model.KeyValNamespaceProject, model.KeyValNamespaceVersion:
+		return nil
+	}
+	if plugin.IsExpandable(*namespace) {
+		return nil
+	}
+	return errors.Errorf("error parsing '%v' params: namespace must be one of %v, %v or %v",
+		cmdName, model.KeyValNamespaceGlobal, model.KeyValNamespaceProject, model.KeyValNamespaceVersion)
+}
+
+// postKeyVal sends the request to the keyval route and reads the reply into
+// out, retrying on errors. A key that isn't set returns errKeyNotFound.
+func postKeyVal(pluginCom plugin.PluginCommunicator, route string, req *model.KeyValRequest, out interface{}) error {
+	postFunc := func() error {
+		resp, err := pluginCom.TaskPostJSON(route, req)
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+		if err != nil {
+			return util.RetriableError{Failure: err}
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return errKeyNotFound
+		}
+		if resp.StatusCode == http.StatusBadRequest {
+			return errors.Errorf("invalid %v request for key %v", route, req.Key)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return util.RetriableError{
+				Failure: errors.Errorf("unexpected status code: %v", resp.StatusCode),
+			}
+		}
+		return errors.Wrap(util.ReadJSONInto(resp.Body, out), "failed to read JSON reply")
+	}
+
+	retryFail, err := util.Retry(postFunc, keyValAttempts, keyValSleep)
+	if retryFail {
+		return errors.Wrapf(err, "%v of key %v failed after %v tries", route, req.Key, keyValAttempts)
+	}
+	return err
+}
+
+// GetCommand writes the value of a key into an expansion.
+type GetCommand struct {
+	Key         string `mapstructure:"key" plugin:"expand"`
+	Namespace   string `mapstructure:"namespace" plugin:"expand"`
+	Destination string `mapstructure:"destination" plugin:"expand"`
+	// Optional keys that aren't set leave the destination unset, instead of
+	// failing the command.
+	Optional bool `mapstructure:"optional"`
+}
+
+func (getCmd *GetCommand) Name() string {
+	return GetCommandName
+}
+
+func (getCmd *GetCommand) Plugin() string {
+	return KeyValPluginName
+}
+
+// ParseParams validates the input to the GetCommand, returning an error
+// if something is incorrect. Fulfills Command interface.
+func (getCmd *GetCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, getCmd); err != nil {
+		return errors.WithStack(err)
+	}
+	if getCmd.Destination == "" {
+		return errors.Errorf("error parsing '%v' params: destination may not be blank", GetCommandName)
+	}
+	return validateKey(GetCommandName, getCmd.Key, &getCmd.Namespace)
+}
+
+// Execute fetches the value from the API server.
+func (getCmd *GetCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator, conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(getCmd, conf.Expansions); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := validateKey(GetCommandName, getCmd.Key, &getCmd.Namespace); err != nil {
+		return err
+	}
+
+	req := &model.KeyValRequest{Namespace: getCmd.Namespace, Key: getCmd.Key}
+	entry := &model.KeyValEntry{}
+	err := postKeyVal(pluginCom, GetRoute, req, entry)
+	if err == errKeyNotFound && getCmd.Optional {
+		pluginLogger.LogTask(slogger.INFO, "Key %v is not set in the %v namespace",
+			getCmd.Key, getCmd.Namespace)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error getting key %v", getCmd.Key)
+	}
+
+	conf.Expansions.Put(getCmd.Destination, entry.Value)
+	return nil
+}
+
+// SetCommand sets the value of a key, which expires after TTLSecs if it's
+// set.
+type SetCommand struct {
+	Key       string `mapstructure:"key" plugin:"expand"`
+	Namespace string `mapstructure:"namespace" plugin:"expand"`
+	Value     string `mapstructure:"value" plugin:"expand"`
+	TTLSecs   int    `mapstructure:"ttl_secs"`
+}
+
+func (setCmd *SetCommand) Name() string {
+	return SetCommandName
+}
+
+func (setCmd *SetCommand) Plugin() string {
+	return KeyValPluginName
+}
+
+// ParseParams validates the input to the SetCommand, returning an error
+// if something is incorrect. Fulfills Command interface.
+func (setCmd *SetCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, setCmd); err != nil {
+		return errors.WithStack(err)
+	}
+	if setCmd.TTLSecs < 0 {
+		return errors.Errorf("error parsing '%v' params: ttl_secs may not be negative", SetCommandName)
+	}
+	return validateKey(SetCommandName, setCmd.Key, &setCmd.Namespace)
+}
+
+// Execute stores the value through the API server.
+func (setCmd *SetCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator, conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(setCmd, conf.Expansions); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := validateKey(SetCommandName, setCmd.Key, &setCmd.Namespace); err != nil {
+		return err
+	}
+
+	req := &model.KeyValRequest{
+		Namespace: setCmd.Namespace,
+		Key:       setCmd.Key,
+		Value:     setCmd.Value,
+		TTLSecs:   setCmd.TTLSecs,
+	}
+	if err := postKeyVal(pluginCom, SetRoute, req, &model.KeyValEntry{}); err != nil {
+		return errors.Wrapf(err, "error setting key %v", setCmd.Key)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Set key %v in the %v namespace", setCmd.Key, setCmd.Namespace)
+	return nil
+}
+
+// CASCommand sets the value of a key only if its current value is Expected,
+// or if Expected is empty and the key isn't set. If Destination is set,
+// whether the value was swapped is written to it as "true" or "false";
+// otherwise a failed swap fails the command.
+type CASCommand struct {
+	Key         string `mapstructure:"key" plugin:"expand"`
+	Namespace   string `mapstructure:"namespace" plugin:"expand"`
+	Expected    string `mapstructure:"expected" plugin:"expand"`
+	Value       string `mapstructure:"value" plugin:"expand"`
+	TTLSecs     int    `mapstructure:"ttl_secs"`
+	Destination string `mapstructure:"destination" plugin:"expand"`
+}
+
+func (casCmd *CASCommand) Name() string {
+	return CASCommandName
+}
+
+func (casCmd *CASCommand) Plugin() string {
+	return KeyValPluginName
+}
+
+// ParseParams validates the input to the CASCommand, returning an error
+// if something is incorrect. Fulfills Command interface.
+func (casCmd *CASCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, casCmd); err != nil {
+		return errors.WithStack(err)
+	}
+	if casCmd.TTLSecs < 0 {
+		return errors.Errorf("error parsing '%v' params: ttl_secs may not be negative", CASCommandName)
+	}
+	return validateKey(CASCommandName, casCmd.Key, &casCmd.Namespace)
+}
+
+// Execute swaps the value through the API server.
+func (casCmd *CASCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator, conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(casCmd, conf.Expansions); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := validateKey(CASCommandName, casCmd.Key, &casCmd.Namespace); err != nil {
+		return err
+	}
+
+	req := &model.KeyValRequest{
+		Namespace: casCmd.Namespace,
+		Key:       casCmd.Key,
+		Value:     casCmd.Value,
+		Expected:  casCmd.Expected,
+		TTLSecs:   casCmd.TTLSecs,
+	}
+	result := &model.KeyValSwapResult{}
+	if err := postKeyVal(pluginCom, CASRoute, req, result); err != nil {
+		return errors.Wrapf(err, "error swapping key %v", casCmd.Key)
+	}
+
+	if casCmd.Destination != "" {
+		conf.Expansions.Put(casCmd.Destination, fmt.Sprintf("%t", result.Swapped))
+	}
+	if !result.Swapped {
+		pluginLogger.LogTask(slogger.INFO, "Key %v in the %v namespace is '%v', not '%v'",
+			casCmd.Key, casCmd.Namespace, result.Value, casCmd.Expected)
+		if casCmd.Destination == "" {
+			return errors.Errorf("key %v did not have the expected value", casCmd.Key)
+		}
+		return nil
+	}
+	pluginLogger.LogTask(slogger.INFO, "Swapped key %v in the %v namespace", casCmd.Key, casCmd.Namespace)
+	return nil
+}
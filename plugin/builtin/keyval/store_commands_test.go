@@ -0,0 +1,130 @@
+package keyval
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeCommunicator is a plugin communicator backed by an in-memory store,
+// which answers the store commands' requests like the API server.
+type storeCommunicator struct {
+	values map[model.KeyValRequest]string
+}
+
+func (sc *storeCommunicator) TaskPostJSON(endpoint string, data interface{}) (*http.Response, error) {
+	req := *data.(*model.KeyValRequest)
+	key := model.KeyValRequest{Namespace: req.Namespace, Key: req.Key}
+	current, ok := sc.values[key]
+
+	var reply interface{}
+	switch endpoint {
+	case GetRoute:
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+		}
+		reply = model.KeyValEntry{Value: current}
+	case SetRoute:
+		sc.values[key] = req.Value
+		reply = model.KeyValEntry{Value: req.Value}
+	case CASRoute:
+		result := model.KeyValSwapResult{Value: current}
+		if (req.Expected == "" && !ok) || (ok && req.Expected == current) {
+			sc.values[key] = req.Value
+			result = model.KeyValSwapResult{Swapped: true, Value: req.Value}
+		}
+		reply = result
+	}
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (sc *storeCommunicator) TaskGetJSON(endpoint string) (*http.Response, error) { return nil, nil }
+func (sc *storeCommunicator) TaskPostResults(results *task.TestResults) error     { return nil }
+func (sc *storeCommunicator) TaskPostTestLog(log *model.TestLog) (string, error)  { return "", nil }
+func (sc *storeCommunicator) PostTaskFiles(files []*artifact.File) error          { return nil }
+
+func runStoreCommand(t *testing.T, com *storeCommunicator, conf *model.TaskConfig,
+	name string, params map[string]interface{}) error {
+	cmd, err := (&KeyValPlugin{}).NewCommand(name)
+	require.NoError(t, err)
+	require.NoError(t, cmd.ParseParams(params))
+	return cmd.Execute(&plugintest.MockLogger{}, com, conf, make(chan bool))
+}
+
+func TestStoreCommands(t *testing.T) {
+	assert := assert.New(t)
+	com := &storeCommunicator{values: map[model.KeyValRequest]string{}}
+	conf := &model.TaskConfig{Expansions: command.NewExpansions(map[string]string{"build_id": "b1"})}
+
+	// values are expanded, and the namespace defaults to project
+	assert.NoError(runStoreCommand(t, com, conf, SetCommandName, map[string]interface{}{
+		"key": "endpoint", "value": "host-${build_id}", "ttl_secs": 60,
+	}))
+	assert.Equal("host-b1", com.values[model.KeyValRequest{Namespace: model.KeyValNamespaceProject, Key: "endpoint"}])
+
+	assert.NoError(runStoreCommand(t, com, conf, GetCommandName, map[string]interface{}{
+		"key": "endpoint", "destination": "cluster",
+	}))
+	assert.Equal("host-b1", conf.Expansions.Get("cluster"))
+
+	// keys in other namespaces are separate
+	assert.Error(runStoreCommand(t, com, conf, GetCommandName, map[string]interface{}{
+		"key": "endpoint", "namespace": model.KeyValNamespaceGlobal, "destination": "cluster",
+	}))
+	assert.NoError(runStoreCommand(t, com, conf, GetCommandName, map[string]interface{}{
+		"key": "endpoint", "namespace": model.KeyValNamespaceGlobal, "destination": "missing", "optional": true,
+	}))
+	assert.False(conf.Expansions.Exists("missing"))
+
+	// compare and swap creates keys that aren't set, and fails on a stale value
+	assert.NoError(runStoreCommand(t, com, conf, CASCommandName, map[string]interface{}{
+		"key": "version", "namespace": model.KeyValNamespaceVersion, "value": "1",
+	}))
+	assert.Error(runStoreCommand(t, com, conf, CASCommandName, map[string]interface{}{
+		"key": "version", "namespace": model.KeyValNamespaceVersion, "expected": "0", "value": "2",
+	}))
+	assert.NoError(runStoreCommand(t, com, conf, CASCommandName, map[string]interface{}{
+		"key": "version", "namespace": model.KeyValNamespaceVersion, "expected": "0", "value": "2",
+		"destination": "swapped",
+	}))
+	assert.Equal("false", conf.Expansions.Get("swapped"))
+	assert.NoError(runStoreCommand(t, com, conf, CASCommandName, map[string]interface{}{
+		"key": "version", "namespace": model.KeyValNamespaceVersion, "expected": "1", "value": "2",
+		"destination": "swapped",
+	}))
+	assert.Equal("true", conf.Expansions.Get("swapped"))
+	assert.Equal("2", com.values[model.KeyValRequest{Namespace: model.KeyValNamespaceVersion, Key: "version"}])
+}
+
+func TestStoreCommandParams(t *testing.T) {
+	assert := assert.New(t)
+	p := &KeyValPlugin{}
+
+	cmd, err := p.NewCommand(GetCommandName)
+	require.NoError(t, err)
+	assert.Error(cmd.ParseParams(map[string]interface{}{"key": "k"}))
+	assert.Error(cmd.ParseParams(map[string]interface{}{"key": "k", "destination": "d", "namespace": "build"}))
+	assert.NoError(cmd.ParseParams(map[string]interface{}{"key": "k", "destination": "d", "namespace": "${ns}"}))
+
+	cmd, err = p.NewCommand(SetCommandName)
+	require.NoError(t, err)
+	assert.Error(cmd.ParseParams(map[string]interface{}{"value": "v"}))
+	assert.Error(cmd.ParseParams(map[string]interface{}{"key": "k", "ttl_secs": -1}))
+
+	_, err = p.NewCommand("delete")
+	assert.Error(err)
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/goamz/goamz/aws"
 	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/slogger"
 	"github.com/pkg/errors"
 )
@@ -48,6 +50,10 @@ type S3GetCommand struct {
 	// downloaded to the specified directory.
 	LocalFile string `mapstructure:"local_file" plugin:"expand"`
 	ExtractTo string `mapstructure:"extract_to" plugin:"expand"`
+
+	// bucket is where the file is fetched from, made from the command's
+	// params the first time it's needed.
+	bucket thirdparty.S3Bucket
 }
 
 func (self *S3GetCommand) Name() string {
@@ -191,19 +197,10 @@ func (self *S3GetCommand) GetWithRetry(pluginLogger plugin.Logger) error {
 	return nil
 }
 
-// Fetch the specified resource from s3.
+// Fetch the specified resource from s3, verifying its contents against its
+// checksum if it has one.
 func (self *S3GetCommand) Get() error {
-	// get the appropriate session and bucket
-	auth := &aws.Auth{
-		AccessKey: self.AwsKey,
-		SecretKey: self.AwsSecret,
-	}
-
-	session := thirdparty.NewS3Session(auth, aws.USEast)
-	bucket := session.Bucket(self.Bucket)
-
-	// get a reader for the bucket
-	reader, err := bucket.GetReader(self.RemoteFile)
+	reader, _, err := self.getBucket().Get(self.RemoteFile)
 	if err != nil {
 		return errors.Wrapf(err, "error getting bucket reader for file %v", self.RemoteFile)
 	}
@@ -231,8 +228,13 @@ func (self *S3GetCommand) Get() error {
 		}
 		defer file.Close()
 
-		_, err = io.Copy(file, reader)
-		return errors.WithStack(err)
+		if _, err = io.Copy(file, reader); err != nil {
+			// don't leave a corrupt or partial file behind
+			grip.CatchError(file.Close())
+			grip.CatchError(os.Remove(self.LocalFile))
+			return errors.WithStack(err)
+		}
+		return nil
 	}
 
 	// wrap the reader in a gzip reader and a tar reader
@@ -247,5 +249,21 @@ func (self *S3GetCommand) Get() error {
 		return errors.Wrapf(err, "error extracting %v to %v", self.RemoteFile, self.ExtractTo)
 	}
 
+	// the checksum is only checked once the whole file has been read
+	if _, err = io.Copy(ioutil.Discard, reader); err != nil {
+		return errors.Wrapf(err, "error verifying %v", self.RemoteFile)
+	}
+
 	return nil
 }
+
+func (self *S3GetCommand) getBucket() thirdparty.S3Bucket {
+	if self.bucket == nil {
+		auth := &aws.Auth{
+			AccessKey: self.AwsKey,
+			SecretKey: self.AwsSecret,
+		}
+		self.bucket = thirdparty.NewS3Bucket(auth, aws.USEast, self.Bucket)
+	}
+	return self.bucket
+}
@@ -2,7 +2,6 @@ package s3
 
 import (
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -79,6 +78,10 @@ type S3PutCommand struct {
 	// the path specified in local_file does not exist. Defaults to false, which triggers errors
 	// for missing files.
 	Optional bool `mapstructure:"optional"`
+
+	// bucket is where files are put, made from the command's params the
+	// first time it's needed.
+	bucket thirdparty.S3Bucket
 }
 
 func (s3pc *S3PutCommand) Name() string {
@@ -270,19 +273,10 @@ func (s3pc *S3PutCommand) Put() ([]string, error) {
 			remoteName = fmt.Sprintf("%s%s", s3pc.RemoteFile, fname)
 		}
 
-		auth := &aws.Auth{
-			AccessKey: s3pc.AwsKey,
-			SecretKey: s3pc.AwsSecret,
-		}
-		s3URL := url.URL{
-			Scheme: "s3",
-			Host:   s3pc.Bucket,
-			Path:   remoteName,
-		}
-		err := thirdparty.PutS3File(auth, fpath, s3URL.String(), s3pc.ContentType, s3pc.Permissions)
+		err := s3pc.getBucket().Put(fpath, remoteName, s3pc.ContentType, s3pc.Permissions)
 		if err != nil {
 			if !s3pc.isMulti() {
-				if s3pc.Optional && os.IsNotExist(err) {
+				if s3pc.Optional && os.IsNotExist(errors.Cause(err)) {
 					// important to *not* wrap this error.
 					return nil, errSkippedFile
 				}
@@ -293,6 +287,17 @@ func (s3pc *S3PutCommand) Put() ([]string, error) {
 	return filesList, nil
 }
 
+func (s3pc *S3PutCommand) getBucket() thirdparty.S3Bucket {
+	if s3pc.bucket == nil {
+		auth := &aws.Auth{
+			AccessKey: s3pc.AwsKey,
+			SecretKey: s3pc.AwsSecret,
+		}
+		s3pc.bucket = thirdparty.NewS3Bucket(auth, aws.USEast, s3pc.Bucket)
+	}
+	return s3pc.bucket
+}
+
 // AttachTaskFiles is responsible for sending the
 // specified file to the API Server. Does not support multiple file putting.
 func (s3pc *S3PutCommand) AttachTaskFiles(log plugin.Logger,
@@ -21,6 +21,7 @@ type S3Plugin struct {
 const (
 	S3GetCmd     = "get"
 	S3PutCmd     = "put"
+	S3SyncCmd    = "sync"
 	S3PluginName = "s3"
 )
 
@@ -43,6 +44,9 @@ func (self *S3Plugin) NewCommand(cmdName string) (plugin.Command, error) {
 	if cmdName == S3GetCmd {
 		return &S3GetCommand{}, nil
 	}
+	if cmdName == S3SyncCmd {
+		return &S3SyncCommand{}, nil
+	}
 	return nil, errors.Errorf("No such command: %v", cmdName)
 }
 
@@ -0,0 +1,384 @@
+package s3
+
+import (
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SyncPush uploads a local directory to a prefix of a bucket.
+	SyncPush = "push"
+	// SyncPull downloads a prefix of a bucket to a local directory.
+	SyncPull = "pull"
+)
+
+// S3SyncCommand is a plugin command that mirrors a local directory to a
+// prefix of an s3 bucket, or a prefix of a bucket to a local directory.
+// Only files whose contents differ are transferred.
+type S3SyncCommand struct {
+	// AwsKey and AwsSecret are the user's credentials for
+	// authenticating interactions with s3.
+	AwsKey    string `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret string `mapstructure:"aws_secret" plugin:"expand"`
+
+	// Bucket is the s3 bucket to sync with.
+	Bucket string `mapstructure:"bucket" plugin:"expand"`
+
+	// LocalDir is the directory to sync, relative to the working directory
+	// if it isn't absolute.
+	LocalDir string `mapstructure:"local_dir" plugin:"expand"`
+
+	// RemotePrefix is the prefix of the keys to sync, which is treated as a
+	// directory in the bucket.
+	RemotePrefix string `mapstructure:"remote_prefix" plugin:"expand"`
+
+	// Direction is "push" to upload LocalDir to RemotePrefix, or "pull" to
+	// download RemotePrefix to LocalDir.
+	Direction string `mapstructure:"direction" plugin:"expand"`
+
+	// Delete removes the files at the destination that aren't in the source.
+	Delete bool `mapstructure:"delete"`
+
+	// Permissions is the ACL to apply to uploaded files. Defaults to
+	// private.
+	Permissions string `mapstructure:"permissions"`
+
+	// ContentType is the MIME type of uploaded files. If it's not set, it's
+	// guessed from each file's extension.
+	ContentType string `mapstructure:"content_type" plugin:"expand"`
+
+	// BuildVariants stores a list of MCI build variants to run the command for.
+	// If the list is empty, it runs for all build variants.
+	BuildVariants []string `mapstructure:"build_variants"`
+
+	// bucket is the bucket synced with, made from the command's params the
+	// first time it's needed.
+	bucket thirdparty.S3Bucket
+}
+
+func (s3sc *S3SyncCommand) Name() string {
+	return S3SyncCmd
+}
+
+func (s3sc *S3SyncCommand) Plugin() string {
+	return S3PluginName
+}
+
+// S3SyncCommand-specific implementation of ParseParams.
+func (s3sc *S3SyncCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, s3sc); err != nil {
+		return errors.Wrapf(err, "error decoding %s params", s3sc.Name())
+	}
+
+	if err := s3sc.validateParams(); err != nil {
+		return errors.Wrapf(err, "error validating %s params", s3sc.Name())
+	}
+
+	return nil
+}
+
+func (s3sc *S3SyncCommand) validateParams() error {
+	if s3sc.AwsKey == "" {
+		return errors.New("aws_key cannot be blank")
+	}
+	if s3sc.AwsSecret == "" {
+		return errors.New("aws_secret cannot be blank")
+	}
+	if s3sc.LocalDir == "" {
+		return errors.New("local_dir cannot be blank")
+	}
+	if s3sc.RemotePrefix == "" {
+		return errors.New("remote_prefix cannot be blank")
+	}
+	if s3sc.Direction != SyncPush && s3sc.Direction != SyncPull && !plugin.IsExpandable(s3sc.Direction) {
+		return errors.Errorf("direction must be '%v' or '%v'", SyncPush, SyncPull)
+	}
+
+	if err := validateS3BucketName(s3sc.Bucket); err != nil {
+		return errors.Wrapf(err, "%v is an invalid bucket name", s3sc.Bucket)
+	}
+
+	if s3sc.Permissions == "" {
+		s3sc.Permissions = string(s3.Private)
+	}
+	if !validS3Permissions(s3sc.Permissions) {
+		return errors.Errorf("permissions '%v' are not valid", s3sc.Permissions)
+	}
+
+	return nil
+}
+
+func (s3sc *S3SyncCommand) shouldRunForVariant(buildVariantName string) bool {
+	if len(s3sc.BuildVariants) == 0 {
+		return true
+	}
+	return util.SliceContains(s3sc.BuildVariants, buildVariantName)
+}
+
+// Execute expands the parameters, and then syncs the directory with s3.
+func (s3sc *S3SyncCommand) Execute(log plugin.Logger,
+	com plugin.PluginCommunicator, conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(s3sc, conf.Expansions); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := s3sc.validateParams(); err != nil {
+		return errors.Wrap(err, "expanded params are not valid")
+	}
+
+	if !s3sc.shouldRunForVariant(conf.BuildVariant.Name) {
+		log.LogTask(slogger.INFO, "Skipping S3 sync of %v for variant %v",
+			s3sc.LocalDir, conf.BuildVariant.Name)
+		return nil
+	}
+
+	if !filepath.IsAbs(s3sc.LocalDir) {
+		s3sc.LocalDir = filepath.Join(conf.WorkDir, s3sc.LocalDir)
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- errors.WithStack(s3sc.Sync(log))
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		log.LogExecution(slogger.INFO, "Received signal to terminate execution of S3 Sync Command")
+		return nil
+	}
+}
+
+// Sync mirrors the source of the sync to its destination.
+func (s3sc *S3SyncCommand) Sync(log plugin.Logger) error {
+	prefix := s3sc.RemotePrefix
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	localFiles, err := listLocalFiles(s3sc.LocalDir)
+	if err != nil {
+		return errors.Wrapf(err, "error listing files in %v", s3sc.LocalDir)
+	}
+	objects, err := s3sc.getBucket().List(prefix)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	remoteFiles := map[string]thirdparty.S3Object{}
+	for _, obj := range objects {
+		remoteFiles[strings.TrimPrefix(obj.Key, prefix)] = obj
+	}
+
+	if s3sc.Direction == SyncPush {
+		log.LogTask(slogger.INFO, "Syncing %v to path %v in s3 bucket %v",
+			s3sc.LocalDir, prefix, s3sc.Bucket)
+		return errors.WithStack(s3sc.push(log, localFiles, remoteFiles, prefix))
+	}
+	log.LogTask(slogger.INFO, "Syncing path %v in s3 bucket %v to %v",
+		prefix, s3sc.Bucket, s3sc.LocalDir)
+	return errors.WithStack(s3sc.pull(log, localFiles, remoteFiles))
+}
+
+// push uploads the local files that differ from the remote ones, and removes
+// the remote files that aren't local if Delete is set.
+func (s3sc *S3SyncCommand) push(log plugin.Logger, localFiles map[string]os.FileInfo,
+	remoteFiles map[string]thirdparty.S3Object, prefix string) error {
+	uploaded := 0
+	for name, info := range localFiles {
+		localPath := filepath.Join(s3sc.LocalDir, filepath.FromSlash(name))
+		if obj, ok := remoteFiles[name]; ok {
+			same, err := s3sc.sameContents(localPath, info, obj)
+			if err != nil {
+				return err
+			}
+			if same {
+				continue
+			}
+		}
+
+		contentType := s3sc.ContentType
+		if contentType == "" {
+			contentType = guessContentType(name)
+		}
+		log.LogExecution(slogger.INFO, "Uploading %v", name)
+		if err := s3sc.getBucket().Put(localPath, prefix+name, contentType, s3sc.Permissions); err != nil {
+			return errors.WithStack(err)
+		}
+		uploaded++
+	}
+
+	deleted := 0
+	if s3sc.Delete {
+		for name, obj := range remoteFiles {
+			if _, ok := localFiles[name]; ok {
+				continue
+			}
+			log.LogExecution(slogger.INFO, "Deleting %v", obj.Key)
+			if err := s3sc.getBucket().Delete(obj.Key); err != nil {
+				return errors.WithStack(err)
+			}
+			deleted++
+		}
+	}
+
+	log.LogTask(slogger.INFO, "Uploaded %d files and deleted %d files", uploaded, deleted)
+	return nil
+}
+
+// pull downloads the remote files that differ from the local ones, and
+// removes the local files that aren't remote if Delete is set.
+func (s3sc *S3SyncCommand) pull(log plugin.Logger, localFiles map[string]os.FileInfo,
+	remoteFiles map[string]thirdparty.S3Object) error {
+	downloaded := 0
+	for name, obj := range remoteFiles {
+		// keys that look like directories can't be downloaded as files
+		if name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+		localPath := filepath.Join(s3sc.LocalDir, filepath.FromSlash(name))
+		if !strings.HasPrefix(localPath, filepath.Clean(s3sc.LocalDir)+string(filepath.Separator)) {
+			return errors.Errorf("%v would be downloaded outside of %v", obj.Key, s3sc.LocalDir)
+		}
+		if info, ok := localFiles[name]; ok {
+			same, err := s3sc.sameContents(localPath, info, obj)
+			if err != nil {
+				return err
+			}
+			if same {
+				continue
+			}
+		}
+
+		log.LogExecution(slogger.INFO, "Downloading %v", obj.Key)
+		if err := s3sc.download(obj.Key, localPath); err != nil {
+			return err
+		}
+		downloaded++
+	}
+
+	deleted := 0
+	if s3sc.Delete {
+		for name := range localFiles {
+			if _, ok := remoteFiles[name]; ok {
+				continue
+			}
+			log.LogExecution(slogger.INFO, "Deleting %v", name)
+			if err := os.Remove(filepath.Join(s3sc.LocalDir, filepath.FromSlash(name))); err != nil {
+				return errors.WithStack(err)
+			}
+			deleted++
+		}
+	}
+
+	log.LogTask(slogger.INFO, "Downloaded %d files and deleted %d files", downloaded, deleted)
+	return nil
+}
+
+// download writes the object at key to localPath, verifying its checksum.
+// The file is written next to localPath first so that a failed download
+// doesn't leave a partial file in its place.
+func (s3sc *S3SyncCommand) download(key, localPath string) error {
+	reader, _, err := s3sc.getBucket().Get(key)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer reader.Close()
+
+	if err = os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpPath := localPath + ".evg-download"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.Wrapf(err, "error opening local file %v", tmpPath)
+	}
+	if _, err = io.Copy(file, reader); err != nil {
+		grip.CatchError(file.Close())
+		grip.CatchError(os.Remove(tmpPath))
+		return errors.Wrapf(err, "error downloading %v", key)
+	}
+	if err = file.Close(); err != nil {
+		grip.CatchError(os.Remove(tmpPath))
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpPath, localPath))
+}
+
+// sameContents returns whether the local file and the object have the same
+// contents, by their sizes and then their checksums. Objects without a
+// checksum are treated as different, so syncing them stores one.
+func (s3sc *S3SyncCommand) sameContents(localPath string, info os.FileInfo, obj thirdparty.S3Object) (bool, error) {
+	if info.Size() != obj.Size {
+		return false, nil
+	}
+	head, err := s3sc.getBucket().Head(obj.Key)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if head == nil || head.Checksum == "" {
+		return false, nil
+	}
+	checksum, err := thirdparty.FileChecksum(localPath)
+	if err != nil {
+		return false, errors.Wrapf(err, "error computing checksum of %v", localPath)
+	}
+	return checksum == head.Checksum, nil
+}
+
+func (s3sc *S3SyncCommand) getBucket() thirdparty.S3Bucket {
+	if s3sc.bucket == nil {
+		auth := &aws.Auth{
+			AccessKey: s3sc.AwsKey,
+			SecretKey: s3sc.AwsSecret,
+		}
+		s3sc.bucket = thirdparty.NewS3Bucket(auth, aws.USEast, s3sc.Bucket)
+	}
+	return s3sc.bucket
+}
+
+// listLocalFiles returns the regular files under dir, keyed by their slash
+// separated paths relative to it. A missing directory has no files.
+func listLocalFiles(dir string) (map[string]os.FileInfo, error) {
+	files := map[string]os.FileInfo{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	return files, errors.WithStack(err)
+}
+
+func guessContentType(name string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
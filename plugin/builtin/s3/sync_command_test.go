@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/plugin/plugintest"
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	"github.com/goamz/goamz/s3/s3test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalBucket starts an in-memory S3 server with a bucket in it.
+func newLocalBucket(t *testing.T) (*s3test.Server, thirdparty.S3Bucket) {
+	srv, err := s3test.NewServer(&s3test.Config{})
+	require.NoError(t, err)
+	region := aws.Region{Name: "local", S3Endpoint: srv.URL(), S3LocationConstraint: true}
+	auth := &aws.Auth{AccessKey: "key", SecretKey: "secret"}
+	require.NoError(t, thirdparty.NewS3Session(auth, region).Bucket("local-bucket").PutBucket(s3.Private))
+	return srv, thirdparty.NewS3Bucket(auth, region, "local-bucket")
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+}
+
+func TestS3SyncPushAndPull(t *testing.T) {
+	assert := assert.New(t)
+	srv, bucket := newLocalBucket(t)
+	defer srv.Quit()
+
+	dir, err := ioutil.TempDir("", "s3-sync")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	writeFiles(t, source, map[string]string{
+		"a.txt":       "a",
+		"sub/b.txt":   "b",
+		"sub/c/d.bin": "d",
+	})
+
+	push := &S3SyncCommand{
+		LocalDir:     source,
+		RemotePrefix: "sync",
+		Direction:    SyncPush,
+		Delete:       true,
+		Permissions:  string(s3.Private),
+		bucket:       bucket,
+	}
+	require.NoError(t, push.Sync(&plugintest.MockLogger{}))
+
+	objects, err := bucket.List("sync/")
+	require.NoError(t, err)
+	assert.Len(objects, 3)
+	head, err := bucket.Head("sync/sub/b.txt")
+	require.NoError(t, err)
+	require.NotNil(t, head)
+	assert.NotEmpty(head.Checksum)
+
+	// changing, adding and removing files is mirrored on the next push
+	writeFiles(t, source, map[string]string{"a.txt": "changed", "e.txt": "e"})
+	require.NoError(t, os.Remove(filepath.Join(source, "sub", "c", "d.bin")))
+	require.NoError(t, push.Sync(&plugintest.MockLogger{}))
+
+	objects, err = bucket.List("sync/")
+	require.NoError(t, err)
+	keys := []string{}
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	assert.Equal([]string{"sync/a.txt", "sync/e.txt", "sync/sub/b.txt"}, keys)
+
+	// pulling mirrors the bucket into a directory
+	dest := filepath.Join(dir, "dest")
+	writeFiles(t, dest, map[string]string{"stale.txt": "stale", "a.txt": "old"})
+	pull := &S3SyncCommand{
+		LocalDir:     dest,
+		RemotePrefix: "sync/",
+		Direction:    SyncPull,
+		Delete:       true,
+		bucket:       bucket,
+	}
+	require.NoError(t, pull.Sync(&plugintest.MockLogger{}))
+
+	files, err := listLocalFiles(dest)
+	require.NoError(t, err)
+	assert.Len(files, 3)
+	for name, contents := range map[string]string{"a.txt": "changed", "e.txt": "e", "sub/b.txt": "b"} {
+		data, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(contents, string(data))
+	}
+}
+
+func TestS3GetVerifiesChecksum(t *testing.T) {
+	assert := assert.New(t)
+	srv, bucket := newLocalBucket(t)
+	defer srv.Quit()
+
+	dir, err := ioutil.TempDir("", "s3-get")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{"file.txt": "contents"})
+
+	put := &S3PutCommand{
+		LocalFile:   filepath.Join(dir, "file.txt"),
+		RemoteFile:  "file.txt",
+		ContentType: "text/plain",
+		Permissions: string(s3.Private),
+		bucket:      bucket,
+	}
+	_, err = put.Put()
+	require.NoError(t, err)
+
+	get := &S3GetCommand{
+		RemoteFile: "file.txt",
+		LocalFile:  filepath.Join(dir, "out.txt"),
+		bucket:     bucket,
+	}
+	require.NoError(t, get.Get())
+	data, err := ioutil.ReadFile(get.LocalFile)
+	require.NoError(t, err)
+	assert.Equal("contents", string(data))
+
+	// overwrite the object with contents that don't match its checksum
+	head, err := bucket.Head("file.txt")
+	require.NoError(t, err)
+	raw := thirdparty.NewS3Session(&aws.Auth{AccessKey: "key", SecretKey: "secret"},
+		aws.Region{Name: "local", S3Endpoint: srv.URL(), S3LocationConstraint: true}).Bucket("local-bucket")
+	require.NoError(t, raw.Put("file.txt", []byte("tampered"), "text/plain", s3.Private,
+		s3.Options{Meta: map[string][]string{thirdparty.S3ChecksumMetadata: {head.Checksum}}}))
+
+	assert.Error(get.Get())
+	_, err = os.Stat(get.LocalFile)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestS3SyncValidateParams(t *testing.T) {
+	params := map[string]interface{}{
+		"aws_key":       "key",
+		"aws_secret":    "secret",
+		"bucket":        "bucket",
+		"local_dir":     "out",
+		"remote_prefix": "artifacts/",
+		"direction":     SyncPush,
+	}
+	cmd := &S3SyncCommand{}
+	require.NoError(t, cmd.ParseParams(params))
+	assert.Equal(t, string(s3.Private), cmd.Permissions)
+
+	params["direction"] = "sideways"
+	assert.Error(t, (&S3SyncCommand{}).ParseParams(params))
+
+	params["direction"] = SyncPull
+	delete(params, "remote_prefix")
+	assert.Error(t, (&S3SyncCommand{}).ParseParams(params))
+}
@@ -171,6 +171,8 @@ func (sec *ShellExecCommand) Execute(pluginLogger plugin.Logger,
 	if sec.Shell != "" {
 		localCmd.Shell = sec.Shell
 	}
+	localCmd.Container = conf.Container
+	localCmd.Cgroup = conf.Cgroup
 
 	err := localCmd.PrepToRun(conf.Expansions)
 	if err != nil {
@@ -187,24 +189,10 @@ func (sec *ShellExecCommand) Execute(pluginLogger plugin.Logger,
 	doneStatus := make(chan error)
 	go func() {
 		var err error
-		env := os.Environ()
-		env = append(env, fmt.Sprintf("EVR_TASK_ID=%v", conf.Task.Id))
-		env = append(env, fmt.Sprintf("EVR_AGENT_PID=%v", os.Getpid()))
-		localCmd.Environment = env
-		err = localCmd.Start()
-		if err == nil {
-			pluginLogger.LogSystem(slogger.DEBUG, "spawned shell process with pid %v", localCmd.Cmd.Process.Pid)
-
-			// Call the platform's process-tracking function. On some OSes this will be a noop,
-			// on others this may need to do some additional work to track the process so that
-			// it can be cleaned up later.
-			trackProcess(conf.Task.Id, localCmd.Cmd.Process.Pid, pluginLogger)
-
-			if !sec.Background {
-				err = localCmd.Cmd.Wait()
-			}
-		} else {
-			pluginLogger.LogSystem(slogger.DEBUG, "error spawning shell process: %v", err)
+		localCmd.Environment = append(baseEnvironment(conf), processMarkers(conf.Task.Id)...)
+		err = startTracked(localCmd, conf, pluginLogger)
+		if err == nil && !sec.Background {
+			err = localCmd.Cmd.Wait()
 		}
 		doneStatus <- err
 	}()
@@ -242,6 +230,44 @@ func (sec *ShellExecCommand) Execute(pluginLogger plugin.Logger,
 	return nil
 }
 
+// baseEnvironment returns the environment a command's process starts with.
+// The host's environment doesn't apply inside of a container, which is torn
+// down with everything in it rather than being searched for processes with
+// the markers.
+func baseEnvironment(conf *model.TaskConfig) []string {
+	if conf.Container != nil {
+		return nil
+	}
+	return os.Environ()
+}
+
+// processMarkers returns the environment variables that mark the processes
+// spawned by the task, so they can be cleaned up after it.
+func processMarkers(taskId string) []string {
+	return []string{
+		fmt.Sprintf("EVR_TASK_ID=%v", taskId),
+		fmt.Sprintf("EVR_AGENT_PID=%v", os.Getpid()),
+	}
+}
+
+// startTracked starts the command's process and tracks it so that it can be
+// cleaned up after the task.
+func startTracked(localCmd *command.LocalCommand, conf *model.TaskConfig, pluginLogger plugin.Logger) error {
+	if err := localCmd.Start(); err != nil {
+		pluginLogger.LogSystem(slogger.DEBUG, "error spawning process: %v", err)
+		return err
+	}
+	pluginLogger.LogSystem(slogger.DEBUG, "spawned process with pid %v", localCmd.Cmd.Process.Pid)
+
+	// Call the platform's process-tracking function. On some OSes this will be a noop,
+	// on others this may need to do some additional work to track the process so that
+	// it can be cleaned up later.
+	if conf.Container == nil {
+		trackProcess(conf.Task.Id, localCmd.Cmd.Process.Pid, pluginLogger)
+	}
+	return nil
+}
+
 // envHasMarkers returns a bool indicating if both marker vars are found in an environment var list
 func envHasMarkers(env []string, pidMarker, taskMarker string) bool {
 	hasPidMarker := false
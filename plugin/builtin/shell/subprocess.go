@@ -0,0 +1,243 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mongodb/grip/slogger"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	plugin.Publish(&SubprocessPlugin{})
+}
+
+const (
+	SubprocessPluginName = "subprocess"
+	SubprocessExecCmd    = "exec"
+)
+
+// SubprocessPlugin runs binaries on the agent's machine without a shell.
+type SubprocessPlugin struct{}
+
+// Name returns the name of the plugin. Required to fulfill
+// the Plugin interface.
+func (sp *SubprocessPlugin) Name() string {
+	return SubprocessPluginName
+}
+
+// NewCommand returns the requested command, or returns an error
+// if a non-existing command is requested.
+func (sp *SubprocessPlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	if cmdName == SubprocessExecCmd {
+		return &SubprocessExecCommand{}, nil
+	}
+	return nil, errors.Errorf("no such command: %v", cmdName)
+}
+
+// SubprocessExecCommand runs a binary with a list of arguments, which are
+// passed to it as they are rather than being interpreted by a shell.
+type SubprocessExecCommand struct {
+	// Binary is the path to, or name on the PATH of, the binary to run.
+	// Relative paths are relative to the working directory.
+	Binary string `mapstructure:"binary" plugin:"expand"`
+
+	// Args are the arguments passed to the binary.
+	Args []string `mapstructure:"args" plugin:"expand"`
+
+	// Env are environment variables set for the process, which override
+	// those of the agent and the expansions.
+	Env map[string]string `mapstructure:"env" plugin:"expand"`
+
+	// AddExpansionsToEnv, if set to true, sets an environment variable for
+	// every expansion.
+	AddExpansionsToEnv bool `mapstructure:"add_expansions_to_env"`
+
+	// WorkingDir is the working directory to start the process in, relative
+	// to the task's working directory.
+	WorkingDir string `mapstructure:"working_dir" plugin:"expand"`
+
+	// Silent, if set to true, prevents the command line and output from
+	// being logged to the agent's task logs.
+	Silent bool `mapstructure:"silent"`
+
+	// Background, if set to true, doesn't wait for the process to exit.
+	Background bool `mapstructure:"background"`
+
+	// SystemLog, if set to true, writes the process's output to the system
+	// logs instead of the task logs.
+	SystemLog bool `mapstructure:"system_log"`
+
+	// OkExitCodes are exit codes, in addition to 0, that don't fail the
+	// command.
+	OkExitCodes []int `mapstructure:"ok_exit_codes"`
+
+	// ContinueOnError, if set to true, doesn't fail the command when the
+	// process can't be started or exits with a code that isn't ok.
+	ContinueOnError bool `mapstructure:"continue_on_err"`
+}
+
+func (_ *SubprocessExecCommand) Name() string {
+	return SubprocessExecCmd
+}
+
+func (_ *SubprocessExecCommand) Plugin() string {
+	return SubprocessPluginName
+}
+
+// ParseParams reads in the command's parameters.
+func (sec *SubprocessExecCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, sec); err != nil {
+		return errors.Wrapf(err, "error decoding %v params", sec.Name())
+	}
+	if sec.Binary == "" {
+		return errors.Errorf("error parsing '%v' params: binary must not be blank", sec.Name())
+	}
+	for name := range sec.Env {
+		if name == "" || strings.Contains(name, "=") {
+			return errors.Errorf("error parsing '%v' params: invalid environment variable name '%v'",
+				sec.Name(), name)
+		}
+	}
+	return nil
+}
+
+// environment returns the process's environment, made of the base
+// environment, the expansions if they're added, the command's own variables
+// and then the markers used to clean up the process.
+func (sec *SubprocessExecCommand) environment(base []string, expansions *command.Expansions, markers []string) []string {
+	env := append([]string{}, base...)
+	if sec.AddExpansionsToEnv && expansions != nil {
+		env = append(env, sortedEnv(*expansions)...)
+	}
+	env = append(env, sortedEnv(sec.Env)...)
+	// later variables take precedence, so the markers can't be overridden
+	return append(env, markers...)
+}
+
+// sortedEnv returns the variables as environment entries, sorted by name.
+func sortedEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, fmt.Sprintf("%v=%v", name, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// binaryPath returns the path of the binary to run. Relative paths are
+// resolved against the working directory, since they'd otherwise be
+// resolved against the agent's.
+func (sec *SubprocessExecCommand) binaryPath(workingDir string) string {
+	if filepath.IsAbs(sec.Binary) || !strings.ContainsAny(sec.Binary, `/\`) {
+		return sec.Binary
+	}
+	return filepath.Join(workingDir, sec.Binary)
+}
+
+// isOkExitCode returns whether the process exiting with the code doesn't
+// fail the command.
+func (sec *SubprocessExecCommand) isOkExitCode(code int) bool {
+	if code == 0 {
+		return true
+	}
+	for _, ok := range sec.OkExitCodes {
+		if code == ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute runs the binary with its given parameters.
+func (sec *SubprocessExecCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(sec, conf.Expansions); err != nil {
+		return errors.Wrap(err, "Failed to apply expansions")
+	}
+
+	logWriterInfo := pluginLogger.GetTaskLogWriter(slogger.INFO)
+	logWriterErr := pluginLogger.GetTaskLogWriter(slogger.ERROR)
+	if sec.SystemLog {
+		logWriterInfo = pluginLogger.GetSystemLogWriter(slogger.INFO)
+		logWriterErr = pluginLogger.GetSystemLogWriter(slogger.ERROR)
+	}
+
+	workingDir := conf.WorkDir
+	if sec.WorkingDir != "" {
+		workingDir = filepath.Join(conf.WorkDir, sec.WorkingDir)
+	}
+
+	binary := sec.Binary
+	if conf.Container == nil {
+		binary = sec.binaryPath(workingDir)
+	}
+
+	localCmd := &command.LocalCommand{
+		Binary:           binary,
+		Args:             sec.Args,
+		WorkingDirectory: workingDir,
+		Environment:      sec.environment(baseEnvironment(conf), conf.Expansions, processMarkers(conf.Task.Id)),
+		Stdout:           logWriterInfo,
+		Stderr:           logWriterErr,
+		Container:        conf.Container,
+		Cgroup:           conf.Cgroup,
+	}
+
+	if sec.Silent {
+		pluginLogger.LogExecution(slogger.INFO, "Executing %v (arguments hidden)...", binary)
+	} else {
+		pluginLogger.LogExecution(slogger.INFO, "Executing %v with arguments %q", binary, sec.Args)
+	}
+
+	doneStatus := make(chan error)
+	go func() {
+		err := startTracked(localCmd, conf, pluginLogger)
+		if err == nil && !sec.Background {
+			err = localCmd.Cmd.Wait()
+		}
+		doneStatus <- err
+	}()
+
+	defer pluginLogger.Flush()
+	select {
+	case err := <-doneStatus:
+		if err == nil {
+			pluginLogger.LogExecution(slogger.INFO, "Process finished.")
+			return nil
+		}
+		if code, exited := command.ExitCode(err); exited && sec.isOkExitCode(code) {
+			pluginLogger.LogExecution(slogger.INFO, "Process finished with ok exit code %v.", code)
+			return nil
+		}
+		if sec.ContinueOnError {
+			pluginLogger.LogExecution(slogger.INFO, "(ignoring) Process finished with error: %v", err)
+			return nil
+		}
+		pluginLogger.LogExecution(slogger.INFO, "Process finished with error: %v", err)
+		return errors.Wrapf(err, "error running %v", binary)
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Got kill signal")
+
+		// need to check command has started
+		if localCmd.GetPid() != -1 {
+			pluginLogger.LogExecution(slogger.INFO, "Stopping process: %v", localCmd.GetPid())
+
+			// try and stop the process
+			if err := localCmd.Stop(); err != nil {
+				pluginLogger.LogExecution(slogger.ERROR, "Error occurred stopping process: %v", err)
+			}
+		}
+
+		return errors.New("Subprocess command interrupted.")
+	}
+}
@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubprocessExecParseParams(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &SubprocessExecCommand{}
+	require.NoError(t, cmd.ParseParams(map[string]interface{}{
+		"binary":                "make",
+		"args":                  []interface{}{"-j", "8", "all"},
+		"env":                   map[string]interface{}{"CC": "clang"},
+		"add_expansions_to_env": true,
+		"working_dir":           "src",
+		"ok_exit_codes":         []interface{}{1, 2},
+	}))
+	assert.Equal("make", cmd.Binary)
+	assert.Equal([]string{"-j", "8", "all"}, cmd.Args)
+	assert.Equal(map[string]string{"CC": "clang"}, cmd.Env)
+	assert.True(cmd.AddExpansionsToEnv)
+	assert.Equal([]int{1, 2}, cmd.OkExitCodes)
+
+	assert.Error((&SubprocessExecCommand{}).ParseParams(map[string]interface{}{"args": []interface{}{"x"}}))
+	assert.Error((&SubprocessExecCommand{}).ParseParams(map[string]interface{}{
+		"binary": "make",
+		"env":    map[string]interface{}{"A=B": "c"},
+	}))
+}
+
+func TestSubprocessExecEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &SubprocessExecCommand{Env: map[string]string{"B": "cmd", "C": "cmd"}}
+	expansions := command.NewExpansions(map[string]string{"A": "exp", "B": "exp"})
+	markers := []string{"EVR_TASK_ID=t1"}
+
+	assert.Equal([]string{"PATH=/bin", "B=cmd", "C=cmd", "EVR_TASK_ID=t1"},
+		cmd.environment([]string{"PATH=/bin"}, expansions, markers))
+
+	cmd.AddExpansionsToEnv = true
+	assert.Equal([]string{"PATH=/bin", "A=exp", "B=exp", "B=cmd", "C=cmd", "EVR_TASK_ID=t1"},
+		cmd.environment([]string{"PATH=/bin"}, expansions, markers))
+}
+
+func TestSubprocessExecExecute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("subprocess test uses sh")
+	}
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "subprocess")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bin", "write.sh"),
+		[]byte("#!/bin/sh\necho \"$1 $FROM_ENV $FROM_EXPANSION\" > \"$2\"\nexit $3\n"), 0755))
+
+	conf := &model.TaskConfig{
+		Expansions: command.NewExpansions(map[string]string{"FROM_EXPANSION": "expanded", "out": "out.txt"}),
+		Task:       &task.Task{Id: "t1"},
+		Project:    &model.Project{},
+		WorkDir:    dir,
+	}
+	stop := make(chan bool)
+	defer close(stop)
+
+	cmd := &SubprocessExecCommand{
+		Binary:             "bin/write.sh",
+		Args:               []string{"it's a `literal` $arg", "${out}", "0"},
+		Env:                map[string]string{"FROM_ENV": "env"},
+		AddExpansionsToEnv: true,
+	}
+	require.NoError(t, cmd.Execute(&plugintest.MockLogger{}, nil, conf, stop))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal("it's a `literal` $arg env expanded\n", string(data))
+
+	// exit codes fail the command unless they're ok
+	cmd = &SubprocessExecCommand{Binary: "sh", Args: []string{"-c", "exit 3"}}
+	assert.Error(cmd.Execute(&plugintest.MockLogger{}, nil, conf, stop))
+	cmd.OkExitCodes = []int{3}
+	assert.NoError(cmd.Execute(&plugintest.MockLogger{}, nil, conf, stop))
+	cmd = &SubprocessExecCommand{Binary: "sh", Args: []string{"-c", "exit 3"}, ContinueOnError: true}
+	assert.NoError(cmd.Execute(&plugintest.MockLogger{}, nil, conf, stop))
+
+	// binaries that don't exist fail the command
+	cmd = &SubprocessExecCommand{Binary: "evergreen-no-such-binary", OkExitCodes: []int{1}}
+	assert.Error(cmd.Execute(&plugintest.MockLogger{}, nil, conf, stop))
+}
@@ -18,6 +18,7 @@ package config
 // ===== PLUGINS INCLUDED WITH MCI =====
 import _ "github.com/evergreen-ci/evergreen/plugin/builtin/archive"
 import _ "github.com/evergreen-ci/evergreen/plugin/builtin/attach"
+import _ "github.com/evergreen-ci/evergreen/plugin/builtin/downstream"
 import _ "github.com/evergreen-ci/evergreen/plugin/builtin/expansions"
 import _ "github.com/evergreen-ci/evergreen/plugin/builtin/git"
 import _ "github.com/evergreen-ci/evergreen/plugin/builtin/helloworld"
@@ -25,6 +25,9 @@ mciModule.controller('DistrosCtrl', function($scope, $window, $location, mciDist
   }, {
     'id': 'openstack',
     'display': 'OpenStack'
+  }, {
+    'id': 'libvirt',
+    'display': 'Libvirt/QEMU'
   }];
 
   $scope.architectures = [{
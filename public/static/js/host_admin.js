@@ -31,6 +31,34 @@ mciModule.controller('AdminOptionsCtrl', ['$scope', 'mciHostRestService', 'notif
     $scope.newStatus = status;
   };
 
+  // an empty drain action stops draining the host
+  $scope.drainActions = [
+    { label: 'exit the agent', value: 'exit' },
+    { label: 'terminate the host', value: 'terminate' },
+    { label: 'stop draining', value: '' }
+  ];
+  $scope.newDrainAction = $scope.drainActions[0];
+
+  $scope.setDrainAction = function(action) {
+    $scope.newDrainAction = action;
+  };
+
+  $scope.drain = function() {
+    hostRestService.updateStatus(
+      $scope.host.id,
+      'drain',
+      { drain_action: $scope.newDrainAction.value },
+      {
+        success: function(data, status) {
+          window.location.reload();
+        },
+        error: function(jqXHR, status, errorThrown) {
+          notifier.pushNotification('Error draining host: ' + jqXHR.error, 'errorModal');
+        }
+      }
+    );
+  };
+
   $scope.openAdminModal = function(opt) {
     $scope.adminOption = opt;
     $scope.modalOpen = true;
@@ -52,6 +80,10 @@ mciModule.controller('AdminOptionsCtrl', ['$scope', 'mciHostRestService', 'notif
           $scope.updateStatus();
           $('#admin-modal').modal('hide');
         }
+        if ($scope.adminOption === 'drain') {
+          $scope.drain();
+          $('#admin-modal').modal('hide');
+        }
       }
     });
   };
@@ -64,3 +96,10 @@ mciModule.directive('adminUpdateStatus', function() {
     templateUrl: '/static/partials/host_status_update.html'
   };
 });
+
+mciModule.directive('adminDrain', function() {
+  return {
+    restrict: 'E',
+    templateUrl: '/static/partials/host_drain.html'
+  };
+});
@@ -28,6 +28,39 @@ mciModule.controller('AdminOptionsCtrl', ['$scope', '$filter', 'mciHostsRestServ
     $scope.newStatus = status;
   };
 
+  // an empty drain action stops draining the hosts
+  $scope.drainActions = [
+    { label: 'exit the agent', value: 'exit' },
+    { label: 'terminate the host', value: 'terminate' },
+    { label: 'stop draining', value: '' }
+  ];
+  $scope.newDrainAction = $scope.drainActions[0];
+
+  $scope.setDrainAction = function(action) {
+    $scope.newDrainAction = action;
+  };
+
+  $scope.drain = function() {
+    var selectedHosts = $scope.selectedHosts();
+    var hostIds = [];
+    for (var i = 0; i < selectedHosts.length; ++i) {
+      hostIds.push(selectedHosts[i].id);
+    }
+    hostsRestService.updateStatus(
+      hostIds,
+      'drain',
+      { drain_action: $scope.newDrainAction.value },
+      {
+        success: function(data, status) {
+          window.location.reload();
+        },
+        error: function(jqXHR, status, errorThrown) {
+          notifier.pushNotification('Error draining hosts: ' + jqXHR.error, 'errorModal');
+        }
+      }
+    );
+  };
+
   $scope.openAdminModal = function(opt) {
     $scope.adminOption = opt;
     $scope.modalOpen = true;
@@ -50,6 +83,10 @@ mciModule.controller('AdminOptionsCtrl', ['$scope', '$filter', 'mciHostsRestServ
           $scope.updateStatus();
           $('#admin-modal').modal('hide');
         }
+        if ($scope.adminOption === 'drain') {
+          $scope.drain();
+          $('#admin-modal').modal('hide');
+        }
       }
     });
   };
@@ -62,3 +99,10 @@ mciModule.directive('adminUpdateStatus', function() {
     templateUrl: '/static/partials/hosts_status_update.html'
   };
 });
+
+mciModule.directive('adminDrain', function() {
+  return {
+    restrict: 'E',
+    templateUrl: '/static/partials/hosts_drain.html'
+  };
+});
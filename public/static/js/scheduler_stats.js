@@ -60,6 +60,24 @@ mciModule.controller('SchedulerStatsCtrl', function($scope, $http, $window, $fil
 		return (data.task/(data.static_host + data.dynamic_host)* 100).toFixed(2);
 	};
 
+	$scope.quotaData = [];
+
+	$scope.getProviderQuotaData = function(){
+		$http.get('/scheduler/stats/quotas')
+		.success(function(data){
+			$scope.quotaData = data;
+		})
+		.error(function(data, status){
+			console.log(status)
+		});
+	};
+
+	// isBackingOff returns true if spawns for the provider are held back
+	// because it recently throttled requests
+	$scope.isBackingOff = function(quota){
+		return moment(quota.backoff_until).isAfter(moment());
+	};
 
 	$scope.getHostUtilizationData();
+	$scope.getProviderQuotaData();
 });
\ No newline at end of file
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/pkg/errors"
+)
+
+// DBCoverageConnector is a struct that implements the code coverage related
+// methods from the Connector through interactions with the backing database.
+type DBCoverageConnector struct{}
+
+// FindVersionCoverage returns the merged coverage of a version's tasks,
+// including each of its files.
+func (cc *DBCoverageConnector) FindVersionCoverage(versionId string) (*coverage.VersionCoverage, error) {
+	vc, err := coverage.FindOneVersion(coverage.VersionById(versionId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem fetching coverage for version %s", versionId)
+	}
+	if vc == nil {
+		return nil, &rest.APIError{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("coverage for version with id '%s' not found", versionId),
+		}
+	}
+	vc.Files, err = coverage.FindVersionFiles(versionId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem fetching file coverage for version %s", versionId)
+	}
+	return vc, nil
+}
+
+// FindCoverageTrend returns the coverage of the mainline versions leading up
+// to the version, most recent first.
+func (cc *DBCoverageConnector) FindCoverageTrend(vc *coverage.VersionCoverage, length int) ([]coverage.VersionCoverage, error) {
+	trend, err := coverage.FindTrend(vc, length)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem fetching coverage trend for version %s", vc.Id)
+	}
+	return trend, nil
+}
+
+// MockCoverageConnector stores a cached set of version coverage that is
+// queried against by the implementations of the Connector interface's
+// coverage related functions.
+type MockCoverageConnector struct {
+	CachedCoverage []coverage.VersionCoverage
+}
+
+// FindVersionCoverage returns the cached coverage of the version.
+func (mcc *MockCoverageConnector) FindVersionCoverage(versionId string) (*coverage.VersionCoverage, error) {
+	for _, vc := range mcc.CachedCoverage {
+		if vc.Id == versionId {
+			return &vc, nil
+		}
+	}
+	return nil, &rest.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("coverage for version with id '%s' not found", versionId),
+	}
+}
+
+// FindCoverageTrend returns the cached coverage of the project's mainline
+// versions up to the version's base, which should be cached most recent
+// first.
+func (mcc *MockCoverageConnector) FindCoverageTrend(vc *coverage.VersionCoverage, length int) ([]coverage.VersionCoverage, error) {
+	trend := []coverage.VersionCoverage{}
+	for _, cached := range mcc.CachedCoverage {
+		if cached.ProjectId == vc.ProjectId && cached.Mainline && cached.RevisionOrderNumber <= vc.BaseOrder {
+			trend = append(trend, cached)
+		}
+	}
+	if len(trend) > length {
+		trend = trend[:length]
+	}
+	return trend, nil
+}
@@ -44,6 +44,37 @@ func (hc *DBHostConnector) FindHostById(id string) (*host.Host, error) {
 	return h, nil
 }
 
+// DrainHost sets the drain action of the host with the given id.
+func (hc *DBHostConnector) DrainHost(id, action string) (*host.Host, error) {
+	h, err := hc.FindHostById(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = h.SetDrainAction(action); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// DrainDistroHosts sets the drain action of the working hosts of the distro
+// with the given id.
+func (hc *DBHostConnector) DrainDistroHosts(distroId, action string) ([]host.Host, error) {
+	if err := host.DrainHostsWithDistroId(distroId, action); err != nil {
+		return nil, err
+	}
+	hosts, err := host.Find(host.ByDistroId(distroId))
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, &rest.APIError{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("no hosts found for distro %s", distroId),
+		}
+	}
+	return hosts, nil
+}
+
 // MockHostConnector is a struct that implements the Host related methods
 // from the Connector through interactions with he backing database.
 type MockHostConnector struct {
@@ -89,3 +120,33 @@ func (hc *MockHostConnector) FindHostById(id string) (*host.Host, error) {
 		Message:    fmt.Sprintf("host with id %s not found", id),
 	}
 }
+
+func (hc *MockHostConnector) DrainHost(id, action string) (*host.Host, error) {
+	for ix, h := range hc.CachedHosts {
+		if h.Id == id {
+			hc.CachedHosts[ix].DrainAction = action
+			return &hc.CachedHosts[ix], nil
+		}
+	}
+	return nil, &rest.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("host with id %s not found", id),
+	}
+}
+
+func (hc *MockHostConnector) DrainDistroHosts(distroId, action string) ([]host.Host, error) {
+	hosts := []host.Host{}
+	for ix, h := range hc.CachedHosts {
+		if h.Distro.Id == distroId {
+			hc.CachedHosts[ix].DrainAction = action
+			hosts = append(hosts, hc.CachedHosts[ix])
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, &rest.APIError{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("no hosts found for distro %s", distroId),
+		}
+	}
+	return hosts, nil
+}
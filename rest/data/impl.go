@@ -16,6 +16,9 @@ type DBConnector struct {
 	DBHostConnector
 	DBTestConnector
 	DBMetricsConnector
+	DBPerfConnector
+	DBCoverageConnector
+	DBKeyValConnector
 	DBBuildConnector
 	DBVersionConnector
 }
@@ -39,6 +42,9 @@ type MockConnector struct {
 	MockHostConnector
 	MockTestConnector
 	MockMetricsConnector
+	MockPerfConnector
+	MockCoverageConnector
+	MockKeyValConnector
 	MockBuildConnector
 	MockVersionConnector
 	MockDistroCostConnector
@@ -6,8 +6,10 @@ import (
 	"github.com/evergreen-ci/evergreen/auth"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/build"
+	"github.com/evergreen-ci/evergreen/model/coverage"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/mongodb/grip/message"
 )
@@ -67,6 +69,15 @@ type Connector interface {
 	FindHostsById(string, string, int, int) ([]host.Host, error)
 	FindHostById(string) (*host.Host, error)
 
+	// DrainHost starts draining the host with the given ID, so that the drain
+	// action is taken once its tasks have finished, or stops draining it if
+	// the drain action is empty. It returns the updated host.
+	DrainHost(string, string) (*host.Host, error)
+
+	// DrainDistroHosts starts or stops draining all the working hosts of the
+	// distro with the given ID, like DrainHost, and returns them.
+	DrainDistroHosts(string, string) ([]host.Host, error)
+
 	// FetchContext is a method to fetch a context given a series of identifiers.
 	FetchContext(string, string, string, string, string) (model.Context, error)
 
@@ -78,6 +89,20 @@ type Connector interface {
 	FindTaskSystemMetrics(string, time.Time, int, int) ([]*message.SystemInfo, error)
 	FindTaskProcessMetrics(string, time.Time, int, int) ([][]*message.ProcessInfo, error)
 
+	// FindTaskPerf returns the performance results an execution of a task
+	// attached, and the regressions found in them.
+	FindTaskPerf(string, int) (*perf.TaskPerf, error)
+
+	// FindVersionCoverage returns the merged code coverage of a version's
+	// tasks, and FindCoverageTrend the coverage of up to the given number of
+	// mainline versions leading up to it.
+	FindVersionCoverage(string) (*coverage.VersionCoverage, error)
+	FindCoverageTrend(*coverage.VersionCoverage, int) ([]coverage.VersionCoverage, error)
+
+	// FindKeyValEntry returns the value of a key in the store written to by
+	// the keyval commands.
+	FindKeyValEntry(model.KeyValEntryId) (*model.KeyValEntry, error)
+
 	// FindCostByVersionId returns cost data of a version given its ID.
 	FindCostByVersionId(string) (*task.VersionCost, error)
 
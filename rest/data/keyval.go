@@ -0,0 +1,50 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/pkg/errors"
+)
+
+// DBKeyValConnector is a struct that implements the key-value store related
+// methods from the Connector through interactions with the backing database.
+type DBKeyValConnector struct{}
+
+// FindKeyValEntry returns the value of a key that's set and hasn't expired.
+func (kc *DBKeyValConnector) FindKeyValEntry(id model.KeyValEntryId) (*model.KeyValEntry, error) {
+	entry, err := model.FindKeyValEntry(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem fetching key %s", id.Key)
+	}
+	if entry == nil {
+		return nil, keyNotFound(id)
+	}
+	return entry, nil
+}
+
+// MockKeyValConnector stores a cached set of entries that is queried against
+// by the implementations of the Connector interface's key-value store related
+// functions.
+type MockKeyValConnector struct {
+	CachedEntries []model.KeyValEntry
+}
+
+// FindKeyValEntry returns the cached entry for the key.
+func (mkc *MockKeyValConnector) FindKeyValEntry(id model.KeyValEntryId) (*model.KeyValEntry, error) {
+	for _, entry := range mkc.CachedEntries {
+		if entry.Id == id {
+			return &entry, nil
+		}
+	}
+	return nil, keyNotFound(id)
+}
+
+func keyNotFound(id model.KeyValEntryId) error {
+	return &rest.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("key '%s' not found in the %s namespace", id.Key, id.Namespace),
+	}
+}
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/pkg/errors"
+)
+
+// DBPerfConnector is a struct that implements the performance results related
+// methods from the Connector through interactions with the backing database.
+type DBPerfConnector struct{}
+
+// FindTaskPerf returns the performance results an execution of a task
+// attached, and the regressions found in them.
+func (pc *DBPerfConnector) FindTaskPerf(taskId string, execution int) (*perf.TaskPerf, error) {
+	tp, err := perf.FindOne(perf.ByTaskIdAndExecution(taskId, execution))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem fetching perf results for task %s", taskId)
+	}
+	if tp == nil {
+		return nil, &rest.APIError{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("perf results for task with id '%s' not found", taskId),
+		}
+	}
+	return tp, nil
+}
+
+// MockPerfConnector stores a cached set of performance results that are
+// queried against by the implementations of the Connector interface's perf
+// related functions.
+type MockPerfConnector struct {
+	CachedPerf []perf.TaskPerf
+}
+
+// FindTaskPerf returns the cached results for the execution of the task.
+func (mpc *MockPerfConnector) FindTaskPerf(taskId string, execution int) (*perf.TaskPerf, error) {
+	for _, tp := range mpc.CachedPerf {
+		if tp.TaskId == taskId && tp.Execution == execution {
+			return &tp, nil
+		}
+	}
+	return nil, &rest.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("perf results for task with id '%s' not found", taskId),
+	}
+}
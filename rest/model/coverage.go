@@ -0,0 +1,125 @@
+package model
+
+import (
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/pkg/errors"
+)
+
+// APIVersionCoverage is the model to be returned by the API whenever the code
+// coverage of a version is fetched.
+type APIVersionCoverage struct {
+	VersionId APIString             `json:"version_id"`
+	Revision  APIString             `json:"revision"`
+	Total     APICoverageSummary    `json:"total"`
+	Packages  []APIPackageCoverage  `json:"packages"`
+	Files     []APIFileCoverage     `json:"files"`
+	Patch     *APIPatchCoverage     `json:"patch"`
+	Trend     []APICoverageTrendRun `json:"trend"`
+}
+
+// APICoverageSummary counts the covered and instrumented lines of some files.
+type APICoverageSummary struct {
+	Covered int     `json:"covered"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// APIPackageCoverage is the coverage of the files in a directory.
+type APIPackageCoverage struct {
+	Package APIString          `json:"package"`
+	Summary APICoverageSummary `json:"summary"`
+}
+
+// APIFileCoverage is the line coverage of a file.
+type APIFileCoverage struct {
+	File      APIString `json:"file"`
+	Covered   []int     `json:"covered"`
+	Uncovered []int     `json:"uncovered"`
+}
+
+// APIPatchCoverage is the coverage of the lines a patch changed.
+type APIPatchCoverage struct {
+	Total APICoverageSummary `json:"total"`
+	Files []APIFileCoverage  `json:"files"`
+}
+
+// APICoverageTrendRun is the coverage of one mainline version in a trend.
+type APICoverageTrendRun struct {
+	VersionId APIString            `json:"version_id"`
+	Revision  APIString            `json:"revision"`
+	Order     int                  `json:"order"`
+	Total     APICoverageSummary   `json:"total"`
+	Packages  []APIPackageCoverage `json:"packages"`
+}
+
+// BuildFromService converts from service level coverage by loading the data
+// into the appropriate fields of the APIVersionCoverage. It accepts the
+// version's coverage, or a slice of the coverage of mainline versions
+// leading up to it to load as the trend.
+func (apiCoverage *APIVersionCoverage) BuildFromService(h interface{}) error {
+	switch v := h.(type) {
+	case *coverage.VersionCoverage:
+		apiCoverage.VersionId = APIString(v.Id)
+		apiCoverage.Revision = APIString(v.Revision)
+		apiCoverage.Total = buildCoverageSummary(v.Total)
+		apiCoverage.Packages = buildPackageCoverage(v.Packages)
+		apiCoverage.Files = buildFileCoverage(v.Files)
+		if v.Patch != nil {
+			apiCoverage.Patch = &APIPatchCoverage{
+				Total: buildCoverageSummary(v.Patch.Total),
+				Files: buildFileCoverage(v.Patch.Files),
+			}
+		}
+	case []coverage.VersionCoverage:
+		apiCoverage.Trend = []APICoverageTrendRun{}
+		for _, run := range v {
+			apiCoverage.Trend = append(apiCoverage.Trend, APICoverageTrendRun{
+				VersionId: APIString(run.Id),
+				Revision:  APIString(run.Revision),
+				Order:     run.RevisionOrderNumber,
+				Total:     buildCoverageSummary(run.Total),
+				Packages:  buildPackageCoverage(run.Packages),
+			})
+		}
+	default:
+		return errors.Errorf("incorrect type when converting version coverage type")
+	}
+	return nil
+}
+
+// ToService returns service level coverage using the data from
+// APIVersionCoverage.
+func (apiCoverage *APIVersionCoverage) ToService() (interface{}, error) {
+	return nil, errors.Errorf("ToService() is not implemented for APIVersionCoverage")
+}
+
+func buildCoverageSummary(s coverage.Summary) APICoverageSummary {
+	return APICoverageSummary{
+		Covered: s.Covered,
+		Total:   s.Total,
+		Percent: s.Percent(),
+	}
+}
+
+func buildPackageCoverage(packages []coverage.PackageSummary) []APIPackageCoverage {
+	apiPackages := []APIPackageCoverage{}
+	for _, pkg := range packages {
+		apiPackages = append(apiPackages, APIPackageCoverage{
+			Package: APIString(pkg.Package),
+			Summary: buildCoverageSummary(pkg.Summary),
+		})
+	}
+	return apiPackages
+}
+
+func buildFileCoverage(files []coverage.FileCoverage) []APIFileCoverage {
+	apiFiles := []APIFileCoverage{}
+	for _, file := range files {
+		apiFiles = append(apiFiles, APIFileCoverage{
+			File:      APIString(file.File),
+			Covered:   file.Covered,
+			Uncovered: file.Uncovered,
+		})
+	}
+	return apiFiles
+}
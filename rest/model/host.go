@@ -16,6 +16,7 @@ type APIHost struct {
 	Type        APIString  `json:"host_type"`
 	User        APIString  `json:"user"`
 	Status      APIString  `json:"status"`
+	DrainAction APIString  `json:"drain_action"`
 	RunningTask taskInfo   `json:"running_task"`
 }
 
@@ -44,6 +45,7 @@ func (apiHost *APIHost) BuildFromService(h interface{}) error {
 		apiHost.Type = APIString(v.InstanceType)
 		apiHost.User = APIString(v.UserData)
 		apiHost.Status = APIString(v.Status)
+		apiHost.DrainAction = APIString(v.DrainAction)
 
 		di := distroInfo{
 			Id:       APIString(v.Distro.Id),
@@ -74,6 +76,7 @@ func (apiHost *APIHost) ToService() (interface{}, error) {
 		InstanceType: string(apiHost.Type),
 		UserData:     string(apiHost.User),
 		Status:       string(apiHost.Status),
+		DrainAction:  string(apiHost.DrainAction),
 	}
 	return interface{}(h), nil
 }
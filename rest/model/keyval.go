@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/pkg/errors"
+)
+
+// APIKeyValEntry is the model to be returned by the API whenever a key
+// written by the keyval commands is fetched.
+type APIKeyValEntry struct {
+	Namespace APIString `json:"namespace"`
+	Scope     APIString `json:"scope"`
+	Key       APIString `json:"key"`
+	Value     APIString `json:"value"`
+	UpdatedAt APITime   `json:"updated_at"`
+	ExpireAt  *APITime  `json:"expire_at"`
+}
+
+// BuildFromService converts from a service level KeyValEntry by loading the
+// data into the appropriate fields of the APIKeyValEntry.
+func (apiEntry *APIKeyValEntry) BuildFromService(h interface{}) error {
+	var entry *model.KeyValEntry
+	switch v := h.(type) {
+	case *model.KeyValEntry:
+		entry = v
+	case model.KeyValEntry:
+		entry = &v
+	default:
+		return errors.Errorf("incorrect type when converting keyval entry type")
+	}
+	apiEntry.Namespace = APIString(entry.Id.Namespace)
+	apiEntry.Scope = APIString(entry.Id.Scope)
+	apiEntry.Key = APIString(entry.Id.Key)
+	apiEntry.Value = APIString(entry.Value)
+	apiEntry.UpdatedAt = NewTime(entry.UpdatedAt)
+	apiEntry.ExpireAt = nil
+	if !entry.ExpireAt.IsZero() {
+		expireAt := NewTime(entry.ExpireAt)
+		apiEntry.ExpireAt = &expireAt
+	}
+	return nil
+}
+
+// ToService returns a service level KeyValEntry using the data from the
+// APIKeyValEntry.
+func (apiEntry *APIKeyValEntry) ToService() (interface{}, error) {
+	return nil, errors.Errorf("ToService() is not implemented for APIKeyValEntry")
+}
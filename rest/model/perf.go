@@ -0,0 +1,82 @@
+package model
+
+import (
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/pkg/errors"
+)
+
+// APITaskPerf is the model to be returned by the API whenever the performance
+// results of a task are fetched.
+type APITaskPerf struct {
+	TaskId      APIString           `json:"task_id"`
+	Execution   int                 `json:"execution"`
+	Results     []APIPerfResult     `json:"results"`
+	Regressions []APIPerfRegression `json:"regressions"`
+}
+
+// APIPerfResult is one measurement in a task's performance results.
+type APIPerfResult struct {
+	Test        APIString `json:"test"`
+	Metric      APIString `json:"metric"`
+	Value       float64   `json:"value"`
+	Unit        APIString `json:"unit"`
+	ThreadLevel int       `json:"thread_level"`
+}
+
+// APIPerfRegression is a result significantly worse than the task's history.
+type APIPerfRegression struct {
+	Test           APIString `json:"test"`
+	Metric         APIString `json:"metric"`
+	Unit           APIString `json:"unit"`
+	ThreadLevel    int       `json:"thread_level"`
+	Value          float64   `json:"value"`
+	BaselineMean   float64   `json:"baseline_mean"`
+	BaselineStdDev float64   `json:"baseline_stddev"`
+	Samples        int       `json:"samples"`
+	Change         float64   `json:"change"`
+	ZScore         float64   `json:"z_score"`
+}
+
+// BuildFromService converts from service level perf results by loading the
+// data into the appropriate fields of the APITaskPerf.
+func (apiPerf *APITaskPerf) BuildFromService(h interface{}) error {
+	v, ok := h.(*perf.TaskPerf)
+	if !ok {
+		return errors.Errorf("incorrect type when converting task perf type")
+	}
+
+	apiPerf.TaskId = APIString(v.TaskId)
+	apiPerf.Execution = v.Execution
+	apiPerf.Results = []APIPerfResult{}
+	for _, r := range v.Results {
+		apiPerf.Results = append(apiPerf.Results, APIPerfResult{
+			Test:        APIString(r.Test),
+			Metric:      APIString(r.Metric),
+			Value:       r.Value,
+			Unit:        APIString(r.Unit),
+			ThreadLevel: r.ThreadLevel,
+		})
+	}
+	apiPerf.Regressions = []APIPerfRegression{}
+	for _, r := range v.Regressions {
+		apiPerf.Regressions = append(apiPerf.Regressions, APIPerfRegression{
+			Test:           APIString(r.Test),
+			Metric:         APIString(r.Metric),
+			Unit:           APIString(r.Unit),
+			ThreadLevel:    r.ThreadLevel,
+			Value:          r.Value,
+			BaselineMean:   r.BaselineMean,
+			BaselineStdDev: r.BaselineStdDev,
+			Samples:        r.Samples,
+			Change:         r.Change,
+			ZScore:         r.ZScore,
+		})
+	}
+	return nil
+}
+
+// ToService returns service level perf results using the data from
+// APITaskPerf.
+func (apiPerf *APITaskPerf) ToService() (interface{}, error) {
+	return nil, errors.Errorf("ToService() is not implemented for APITaskPerf")
+}
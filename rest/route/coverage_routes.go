@@ -0,0 +1,77 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/evergreen-ci/evergreen/rest/data"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// getVersionCoverageRouteManager gets the route manager for
+// GET /versions/{version_id}/coverage, which returns the merged code coverage
+// of the version's tasks and the coverage trend of the mainline leading up
+// to it.
+func getVersionCoverageRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser, PrefetchProjectContext},
+				Authenticator:     &NoAuthAuthenticator{},
+				RequestHandler:    &versionCoverageHandler{},
+				MethodType:        evergreen.MethodGet,
+			},
+		},
+		Version: version,
+	}
+}
+
+type versionCoverageHandler struct {
+	versionId string
+}
+
+func (vch *versionCoverageHandler) Handler() RequestHandler {
+	return &versionCoverageHandler{}
+}
+
+func (vch *versionCoverageHandler) ParseAndValidate(ctx context.Context, r *http.Request) error {
+	projCtx := MustHaveProjectContext(ctx)
+	if projCtx.Version == nil {
+		return rest.APIError{
+			Message:    "Version not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	vch.versionId = projCtx.Version.Id
+	return nil
+}
+
+func (vch *versionCoverageHandler) Execute(ctx context.Context, sc data.Connector) (ResponseData, error) {
+	foundCoverage, err := sc.FindVersionCoverage(vch.versionId)
+	if err != nil {
+		if _, ok := err.(*rest.APIError); !ok {
+			err = errors.Wrap(err, "Database error")
+		}
+		return ResponseData{}, err
+	}
+	trend, err := sc.FindCoverageTrend(foundCoverage, coverage.DefaultTrendLength)
+	if err != nil {
+		return ResponseData{}, errors.Wrap(err, "Database error")
+	}
+
+	coverageModel := &model.APIVersionCoverage{}
+	if err = coverageModel.BuildFromService(foundCoverage); err != nil {
+		return ResponseData{}, errors.Wrap(err, "API model error")
+	}
+	if err = coverageModel.BuildFromService(trend); err != nil {
+		return ResponseData{}, errors.Wrap(err, "API model error")
+	}
+	return ResponseData{
+		Result: []model.Model{coverageModel},
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/evergreen-ci/evergreen/rest"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
@@ -56,3 +57,72 @@ func (dgh *distroGetHandler) Execute(ctx context.Context, sc data.Connector) (Re
 		Result: models,
 	}, nil
 }
+
+func getDistroDrainRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser},
+				Authenticator:     &SuperUserAuthenticator{},
+				RequestHandler:    &distroDrainHandler{},
+				MethodType:        evergreen.MethodPost,
+			},
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser},
+				Authenticator:     &SuperUserAuthenticator{},
+				RequestHandler:    &distroDrainHandler{stop: true},
+				MethodType:        evergreen.MethodDelete,
+			},
+		},
+		Version: version,
+	}
+}
+
+// distroDrainHandler implements the routes POST /distros/{distro_id}/drain,
+// which starts draining the working hosts of the distro, and
+// DELETE /distros/{distro_id}/drain, which stops draining them.
+type distroDrainHandler struct {
+	stop bool
+
+	distroId string
+	action   string
+}
+
+func (ddh *distroDrainHandler) Handler() RequestHandler {
+	return &distroDrainHandler{stop: ddh.stop}
+}
+
+func (ddh *distroDrainHandler) ParseAndValidate(ctx context.Context, r *http.Request) error {
+	ddh.distroId = mux.Vars(r)["distro_id"]
+	if ddh.stop {
+		return nil
+	}
+
+	var err error
+	ddh.action, err = parseDrainAction(r)
+	return err
+}
+
+func (ddh *distroDrainHandler) Execute(ctx context.Context, sc data.Connector) (ResponseData, error) {
+	hosts, err := sc.DrainDistroHosts(ddh.distroId, ddh.action)
+	if err != nil {
+		if _, ok := err.(*rest.APIError); !ok {
+			err = errors.Wrap(err, "Database error")
+		}
+		return ResponseData{}, err
+	}
+
+	models := make([]model.Model, len(hosts))
+	for i, h := range hosts {
+		hostModel := &model.APIHost{}
+		if err = hostModel.BuildFromService(h); err != nil {
+			return ResponseData{}, errors.Wrap(err, "API model error")
+		}
+		models[i] = hostModel
+	}
+
+	return ResponseData{
+		Result: models,
+	}, nil
+}
@@ -1,6 +1,9 @@
 package route
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/evergreen-ci/evergreen"
@@ -9,6 +12,7 @@ import (
 	"github.com/evergreen-ci/evergreen/rest"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -51,6 +55,103 @@ func getHostIDRouteManager(route string, version int) *RouteManager {
 	return &hostRoute
 }
 
+func getHostDrainRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser},
+				Authenticator:     &SuperUserAuthenticator{},
+				RequestHandler:    &hostDrainHandler{},
+				MethodType:        evergreen.MethodPost,
+			},
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser},
+				Authenticator:     &SuperUserAuthenticator{},
+				RequestHandler:    &hostDrainHandler{stop: true},
+				MethodType:        evergreen.MethodDelete,
+			},
+		},
+		Version: version,
+	}
+}
+
+// hostDrainHandler implements the routes POST /hosts/{host_id}/drain, which
+// starts draining the host, and DELETE /hosts/{host_id}/drain, which stops
+// draining it.
+type hostDrainHandler struct {
+	stop bool
+
+	hostId string
+	action string
+}
+
+func (hdh *hostDrainHandler) Handler() RequestHandler {
+	return &hostDrainHandler{stop: hdh.stop}
+}
+
+// ParseAndValidate fetches the hostId from the http request, and the drain
+// action from its body when the host is to start draining.
+func (hdh *hostDrainHandler) ParseAndValidate(ctx context.Context, r *http.Request) error {
+	hdh.hostId = mux.Vars(r)["host_id"]
+	if hdh.stop {
+		return nil
+	}
+
+	var err error
+	hdh.action, err = parseDrainAction(r)
+	return err
+}
+
+// Execute calls the data DrainHost function and returns the updated host.
+func (hdh *hostDrainHandler) Execute(ctx context.Context, sc data.Connector) (ResponseData, error) {
+	drainedHost, err := sc.DrainHost(hdh.hostId, hdh.action)
+	if err != nil {
+		if _, ok := err.(*rest.APIError); !ok {
+			err = errors.Wrap(err, "Database error")
+		}
+		return ResponseData{}, err
+	}
+
+	hostModel := &model.APIHost{}
+	if err = hostModel.BuildFromService(*drainedHost); err != nil {
+		return ResponseData{}, errors.Wrap(err, "API model error")
+	}
+
+	return ResponseData{
+		Result: []model.Model{hostModel},
+	}, nil
+}
+
+// parseDrainAction reads the drain action from the body of a request to
+// start draining hosts. Hosts are drained to exit if there is no body.
+func parseDrainAction(r *http.Request) (string, error) {
+	body := util.NewRequestReader(r)
+	defer body.Close()
+
+	opts := struct {
+		Action string `json:"action"`
+	}{}
+	if err := json.NewDecoder(body).Decode(&opts); err != nil && err != io.EOF {
+		return "", rest.APIError{
+			Message:    fmt.Sprintf("Invalid request body: %v", err),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if opts.Action == "" {
+		return host.DrainExit, nil
+	}
+	if !util.SliceContains(host.DrainActions, opts.Action) {
+		return "", rest.APIError{
+			Message: fmt.Sprintf("'%s' is not a valid drain action, expecting one of %v",
+				opts.Action, host.DrainActions),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return opts.Action, nil
+}
+
 type hostIDGetHandler struct {
 	hostId string
 }
@@ -1,9 +1,13 @@
 package route
 
 import (
+	"bytes"
+	"net/http"
 	"testing"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/rest"
 	"github.com/evergreen-ci/evergreen/rest/data"
 	"github.com/evergreen-ci/evergreen/rest/model"
 	"github.com/stretchr/testify/suite"
@@ -59,3 +63,47 @@ func (s *HostSuite) TestFindByIdFail() {
 	_, ok := handler.Execute(nil, s.sc)
 	s.Error(ok)
 }
+
+func (s *HostSuite) TestDrainHost() {
+	handler := &hostDrainHandler{hostId: "host1", action: host.DrainTerminate}
+	res, err := handler.Execute(nil, s.sc)
+	s.NoError(err)
+	s.Equal(1, len(res.Result))
+	h, ok := (res.Result[0]).(*model.APIHost)
+	s.True(ok)
+	s.Equal(model.APIString(host.DrainTerminate), h.DrainAction)
+
+	handler = &hostDrainHandler{stop: true, hostId: "host1"}
+	res, err = handler.Execute(nil, s.sc)
+	s.NoError(err)
+	h, ok = (res.Result[0]).(*model.APIHost)
+	s.True(ok)
+	s.Equal(model.APIString(""), h.DrainAction)
+
+	handler = &hostDrainHandler{hostId: "host3", action: host.DrainExit}
+	_, err = handler.Execute(nil, s.sc)
+	s.Error(err)
+}
+
+func (s *HostSuite) TestParseDrainAction() {
+	for body, expected := range map[string]string{
+		``:                        host.DrainExit,
+		`{}`:                      host.DrainExit,
+		`{"action": "terminate"}`: host.DrainTerminate,
+	} {
+		r, err := http.NewRequest(evergreen.MethodPost, "/hosts/host1/drain", bytes.NewBufferString(body))
+		s.Require().NoError(err)
+		action, err := parseDrainAction(r)
+		s.NoError(err)
+		s.Equal(expected, action)
+	}
+
+	for _, body := range []string{`{"action": "reboot"}`, `{"action": 1}`} {
+		r, err := http.NewRequest(evergreen.MethodPost, "/hosts/host1/drain", bytes.NewBufferString(body))
+		s.Require().NoError(err)
+		_, err = parseDrainAction(r)
+		apiErr, ok := err.(rest.APIError)
+		s.True(ok)
+		s.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	}
+}
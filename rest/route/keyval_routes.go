@@ -0,0 +1,126 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen"
+	serviceModel "github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/evergreen-ci/evergreen/rest/data"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// getGlobalKeyValRouteManager gets the route manager for GET /keyval/{key},
+// which returns the value of a key in the global namespace of the store the
+// keyval commands write to.
+func getGlobalKeyValRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser},
+				Authenticator:     &RequireUserAuthenticator{},
+				RequestHandler:    &keyValHandler{namespace: serviceModel.KeyValNamespaceGlobal},
+				MethodType:        evergreen.MethodGet,
+			},
+		},
+		Version: version,
+	}
+}
+
+// getProjectKeyValRouteManager gets the route manager for
+// GET /projects/{project_id}/keyval/{key}, which returns the value of a key in
+// the project's namespace.
+func getProjectKeyValRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser, PrefetchProjectContext},
+				Authenticator:     &RequireUserAuthenticator{},
+				RequestHandler:    &keyValHandler{namespace: serviceModel.KeyValNamespaceProject},
+				MethodType:        evergreen.MethodGet,
+			},
+		},
+		Version: version,
+	}
+}
+
+// getVersionKeyValRouteManager gets the route manager for
+// GET /versions/{version_id}/keyval/{key}, which returns the value of a key in
+// the version's namespace.
+func getVersionKeyValRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser, PrefetchProjectContext},
+				Authenticator:     &RequireUserAuthenticator{},
+				RequestHandler:    &keyValHandler{namespace: serviceModel.KeyValNamespaceVersion},
+				MethodType:        evergreen.MethodGet,
+			},
+		},
+		Version: version,
+	}
+}
+
+// keyValHandler fetches a key from one of the store's namespaces. The scope
+// of project and version keys comes from the request's project context.
+type keyValHandler struct {
+	namespace string
+	id        serviceModel.KeyValEntryId
+}
+
+func (kvh *keyValHandler) Handler() RequestHandler {
+	return &keyValHandler{namespace: kvh.namespace}
+}
+
+func (kvh *keyValHandler) ParseAndValidate(ctx context.Context, r *http.Request) error {
+	kvh.id = serviceModel.KeyValEntryId{
+		Namespace: kvh.namespace,
+		Key:       mux.Vars(r)["key"],
+	}
+
+	switch kvh.namespace {
+	case serviceModel.KeyValNamespaceProject:
+		projCtx := MustHaveProjectContext(ctx)
+		if projCtx.ProjectRef == nil {
+			return rest.APIError{
+				Message:    "Project not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		kvh.id.Scope = projCtx.ProjectRef.Identifier
+	case serviceModel.KeyValNamespaceVersion:
+		projCtx := MustHaveProjectContext(ctx)
+		if projCtx.Version == nil {
+			return rest.APIError{
+				Message:    "Version not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		kvh.id.Scope = projCtx.Version.Id
+	}
+	return nil
+}
+
+func (kvh *keyValHandler) Execute(ctx context.Context, sc data.Connector) (ResponseData, error) {
+	entry, err := sc.FindKeyValEntry(kvh.id)
+	if err != nil {
+		if _, ok := err.(*rest.APIError); !ok {
+			err = errors.Wrap(err, "Database error")
+		}
+		return ResponseData{}, err
+	}
+
+	entryModel := &model.APIKeyValEntry{}
+	if err = entryModel.BuildFromService(entry); err != nil {
+		return ResponseData{}, errors.Wrap(err, "API model error")
+	}
+	return ResponseData{
+		Result: []model.Model{entryModel},
+	}, nil
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/rest"
+	"github.com/evergreen-ci/evergreen/rest/data"
+	"github.com/evergreen-ci/evergreen/rest/model"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// getTaskPerfRouteManager gets the route manager for GET /tasks/{task_id}/perf,
+// which returns the performance results the task attached and the regressions
+// found in them.
+func getTaskPerfRouteManager(route string, version int) *RouteManager {
+	return &RouteManager{
+		Route: route,
+		Methods: []MethodHandler{
+			{
+				PrefetchFunctions: []PrefetchFunc{PrefetchUser, PrefetchProjectContext},
+				Authenticator:     &NoAuthAuthenticator{},
+				RequestHandler:    &taskPerfHandler{},
+				MethodType:        evergreen.MethodGet,
+			},
+		},
+		Version: version,
+	}
+}
+
+type taskPerfHandler struct {
+	taskId    string
+	execution int
+}
+
+func (tph *taskPerfHandler) Handler() RequestHandler {
+	return &taskPerfHandler{}
+}
+
+func (tph *taskPerfHandler) ParseAndValidate(ctx context.Context, r *http.Request) error {
+	projCtx := MustHaveProjectContext(ctx)
+	if projCtx.Task == nil {
+		return rest.APIError{
+			Message:    "Task not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	tph.taskId = projCtx.Task.Id
+	tph.execution = projCtx.Task.Execution
+	return nil
+}
+
+func (tph *taskPerfHandler) Execute(ctx context.Context, sc data.Connector) (ResponseData, error) {
+	foundPerf, err := sc.FindTaskPerf(tph.taskId, tph.execution)
+	if err != nil {
+		if _, ok := err.(*rest.APIError); !ok {
+			err = errors.Wrap(err, "Database error")
+		}
+		return ResponseData{}, err
+	}
+
+	perfModel := &model.APITaskPerf{}
+	if err = perfModel.BuildFromService(foundPerf); err != nil {
+		return ResponseData{}, errors.Wrap(err, "API model error")
+	}
+	return ResponseData{
+		Result: []model.Model{perfModel},
+	}, nil
+}
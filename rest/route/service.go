@@ -28,14 +28,21 @@ func GetHandler(r *mux.Router, sc data.Connector) http.Handler {
 		"/builds/{build_id}":       getBuildIdRouteManager,
 		"/builds/{build_id}/tasks": getTasksByBuildRouteManager,
 		"/distros":                 getDistroRouteManager,
+		"/distros/{distro_id}/drain": getDistroDrainRouteManager,
 		"/hosts":                   getHostRouteManager,
 		"/hosts/{host_id}":                                     getHostIDRouteManager,
+		"/hosts/{host_id}/drain":                               getHostDrainRouteManager,
+		"/keyval/{key}":                                        getGlobalKeyValRouteManager,
+		"/projects/{project_id}/keyval/{key}":                  getProjectKeyValRouteManager,
 		"/projects/{project_id}/revisions/{commit_hash}/tasks": getTasksByProjectAndCommitRouteManager,
 		"/tasks/{task_id}":                                     getTaskRouteManager,
 		"/tasks/{task_id}/metrics/process":                     getTaskProcessMetricsManager,
 		"/tasks/{task_id}/metrics/system":                      getTaskSystemMetricsManager,
+		"/tasks/{task_id}/perf":                                getTaskPerfRouteManager,
 		"/tasks/{task_id}/restart":                             getTaskRestartRouteManager,
 		"/tasks/{task_id}/tests":                               getTestRouteManager,
+		"/versions/{version_id}/coverage":                      getVersionCoverageRouteManager,
+		"/versions/{version_id}/keyval/{key}":                  getVersionKeyValRouteManager,
 		"/cost/version/{version_id}":                           getCostByVersionIdRouteManager,
 		"/cost/distro/{distro_id}":                             getCostByDistroIdRouteManager,
 	}
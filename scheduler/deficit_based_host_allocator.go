@@ -4,7 +4,6 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/model/distro"
-	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -70,16 +69,14 @@ func (self *DeficitBasedHostAllocator) numNewHostsForDistro(
 	existingDistroHosts := hostAllocatorData.existingDistroHosts[distro.Id]
 	runnableDistroTasks := hostAllocatorData.taskQueueItems[distro.Id]
 
-	freeHosts := make([]host.Host, 0, len(existingDistroHosts))
+	numFreeSlots := 0
 	for _, existingDistroHost := range existingDistroHosts {
-		if existingDistroHost.RunningTask == "" {
-			freeHosts = append(freeHosts, existingDistroHost)
-		}
+		numFreeSlots += existingDistroHost.FreeSlots()
 	}
 
 	numNewHosts := util.Min(
-		// the deficit of available hosts vs. tasks to be run
-		len(runnableDistroTasks)-len(freeHosts),
+		// the deficit of available task slots vs. tasks to be run
+		hostsForTasks(len(runnableDistroTasks)-numFreeSlots, distro.Slots()),
 		// the maximum number of new hosts we're allowed to spin up
 		distro.PoolSize-len(existingDistroHosts),
 	)
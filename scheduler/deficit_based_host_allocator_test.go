@@ -10,6 +10,7 @@ import (
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/mongodb/grip"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 func init() {
@@ -295,3 +296,14 @@ func TestDeficitBasedHostAllocator(t *testing.T) {
 	})
 
 }
+
+func TestHostsForTasks(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, hostsForTasks(0, 4))
+	assert.Equal(0, hostsForTasks(-3, 4))
+	assert.Equal(5, hostsForTasks(5, 1))
+	assert.Equal(5, hostsForTasks(5, 0))
+	assert.Equal(1, hostsForTasks(4, 4))
+	assert.Equal(2, hostsForTasks(5, 4))
+}
@@ -197,10 +197,8 @@ func computeRunningTasksDuration(existingDistroHosts []host.Host,
 	runningTaskIds := []string{}
 
 	for _, existingDistroHost := range existingDistroHosts {
-		if existingDistroHost.RunningTask != "" {
-			runningTaskIds = append(runningTaskIds,
-				existingDistroHost.RunningTask)
-		}
+		runningTaskIds = append(runningTaskIds,
+			existingDistroHost.RunningTasks()...)
 	}
 
 	// if this distro's hosts are all free, return immediately
@@ -424,13 +422,13 @@ func (self *DurationBasedHostAllocator) numNewHostsForDistro(
 	taskQueueItems := hostAllocatorData.taskQueueItems[distro.Id]
 	taskRunDistros := hostAllocatorData.taskRunDistros
 
-	// determine how many free hosts we have
-	numFreeHosts := 0
+	// determine how many free hosts we have, counting the free task slots on
+	// hosts with more than one slot as fractions of a host
+	numFreeSlots := 0
 	for _, existingDistroHost := range existingDistroHosts {
-		if existingDistroHost.RunningTask == "" {
-			numFreeHosts += 1
-		}
+		numFreeSlots += existingDistroHost.FreeSlots()
 	}
+	numFreeHosts := numFreeSlots / distro.Slots()
 
 	// determine the total remaining running time of all
 	// tasks currently running on the hosts for this distro
@@ -456,14 +454,18 @@ func (self *DurationBasedHostAllocator) numNewHostsForDistro(
 
 	// find the number of new hosts needed based on the total estimated
 	// duration for all outstanding and in-flight tasks for this distro
+	// hosts with more than one task slot get through that many times as much
+	// work in the same time
 	durationBasedNumNewHosts := computeDurationBasedNumNewHosts(
 		scheduledTasksDuration, runningTasksDuration,
-		float64(len(existingDistroHosts)), MaxDurationPerDistroHost)
+		float64(len(existingDistroHosts)),
+		MaxDurationPerDistroHost*time.Duration(distro.Slots()))
 
 	// revise the new host estimate based on the cap of the number of new hosts
 	// and the number of free hosts
 	numNewHosts = numNewDistroHosts(distro.PoolSize, len(existingDistroHosts),
-		numFreeHosts, durationBasedNumNewHosts, len(taskQueueItems))
+		numFreeHosts, durationBasedNumNewHosts,
+		hostsForTasks(len(taskQueueItems), distro.Slots()))
 
 	// create an entry for this distro in the scheduling map
 	distroScheduleData[distro.Id] = DistroScheduleData{
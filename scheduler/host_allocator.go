@@ -28,3 +28,15 @@ type HostAllocatorData struct {
 	distros              map[string]distro.Distro
 	projectTaskDurations model.ProjectTaskDurations
 }
+
+// hostsForTasks returns the number of hosts of a distro with the given number
+// of task slots needed to run the given number of tasks at once.
+func hostsForTasks(numTasks, slots int) int {
+	if numTasks <= 0 {
+		return 0
+	}
+	if slots < 1 {
+		slots = 1
+	}
+	return (numTasks + slots - 1) / slots
+}
@@ -19,6 +19,20 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// maxSpawnWait is the longest the scheduler waits for a provider's rate
+	// limit before leaving the remaining spawns for its next run.
+	maxSpawnWait = 30 * time.Second
+
+	// spawnAttempts is how many times a spawn that the provider throttles
+	// is tried before it is left for the scheduler's next run.
+	spawnAttempts = 3
+)
+
+// errSpawnDeferred is returned by spawnWithLimits when a provider's limits
+// mean no more hosts should be spawned for a distro during this run.
+var errSpawnDeferred = errors.New("spawn deferred by provider limits")
+
 // Responsible for prioritizing and scheduling tasks to be run, on a per-distro
 // basis.
 type Scheduler struct {
@@ -399,6 +413,11 @@ func (s *Scheduler) splitTasksByDistro(tasksToSplit []task.Task) (
 func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 	map[string][]host.Host, error) {
 
+	limiters, err := newProviderLimiters(s.Settings)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	// loop over the distros, spawning up the appropriate number of hosts
 	// for each distro
 	hostsSpawnedPerDistro := make(map[string][]host.Host)
@@ -441,10 +460,17 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 			hostOptions := cloud.HostOptions{
 				UserName: evergreen.User,
 				UserHost: false,
+				Tags:     cloud.SettingsTags(s.Settings),
+			}
+			newHost, err := spawnWithLimits(cloudManager, limiters, d, hostOptions)
+			if errors.Cause(err) == errSpawnDeferred || errors.Cause(err) == cloud.ErrQuotaExceeded {
+				// the rest of this distro's hosts wait for the next run
+				grip.Noticef("Deferring %d spawns for distro '%s': %v",
+					numHostsToSpawn-i, distroId, err)
+				break
 			}
-			newHost, err := cloudManager.SpawnInstance(d, hostOptions)
 			if err != nil {
-				err = errors.Wrapf(err, "error spawning instance $s", distroId)
+				err = errors.Wrapf(err, "error spawning instance %s", distroId)
 				grip.Error(err)
 				continue
 			}
@@ -457,5 +483,132 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 			delete(hostsSpawnedPerDistro, distroId)
 		}
 	}
+
+	saveProviderQuotas()
+
 	return hostsSpawnedPerDistro, nil
 }
+
+// spawnWithLimits spawns a host, keeping within the provider's API rate limit
+// and instance quota if it has them. Spawns the provider throttles are retried
+// with a backoff. It returns errSpawnDeferred or cloud.ErrQuotaExceeded if no
+// more hosts should be spawned for the distro during this run.
+func spawnWithLimits(cloudManager cloud.CloudManager, limiters *providerLimiters,
+	d *distro.Distro, hostOptions cloud.HostOptions) (*host.Host, error) {
+
+	limitedManager, ok := cloudManager.(cloud.RateLimitedManager)
+	if !ok {
+		return cloudManager.SpawnInstance(d, hostOptions)
+	}
+
+	limiter := limiters.get(limitedManager, d)
+
+	for attempt := 1; ; attempt++ {
+		wait, err := limiter.Reserve()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if wait > maxSpawnWait {
+			limiter.Release()
+			return nil, errors.Wrapf(errSpawnDeferred, "next request allowed in %s", wait)
+		}
+		time.Sleep(wait)
+
+		newHost, err := cloudManager.SpawnInstance(d, hostOptions)
+		if err == nil {
+			limiter.Succeeded()
+			return newHost, nil
+		}
+
+		if !limitedManager.IsThrottlingError(err) {
+			limiter.Release()
+			return nil, err
+		}
+
+		limiter.Throttled()
+		grip.Warningf("Provider '%s' throttled spawn %d of %d for distro '%s': %v",
+			d.Provider, attempt, spawnAttempts, d.Id, err)
+		if attempt >= spawnAttempts {
+			return nil, errors.Wrapf(errSpawnDeferred, "throttled %d times", attempt)
+		}
+	}
+}
+
+// providerLimiters hands out the limiters for the provider accounts and
+// regions that hosts are spawned in during a scheduler run. Each limiter's
+// count of instances against the quota is brought up to date once per run,
+// from the hosts that existed when it started.
+type providerLimiters struct {
+	instances map[string]int
+	limiters  map[string]*cloud.Limiter
+}
+
+// newProviderLimiters counts the unterminated hosts in each provider account
+// and region.
+func newProviderLimiters(settings *evergreen.Settings) (*providerLimiters, error) {
+	hosts, err := host.Find(host.IsRunning)
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding unterminated hosts")
+	}
+
+	managers := map[string]cloud.RateLimitedManager{}
+	instances := map[string]int{}
+	for i := range hosts {
+		limitedManager, ok := managers[hosts[i].Provider]
+		if !ok {
+			// hosts of providers without limits, or that aren't
+			// configured, don't count against any quota
+			cloudManager, err := providers.GetCloudManager(hosts[i].Provider, settings)
+			if err == nil {
+				limitedManager, _ = cloudManager.(cloud.RateLimitedManager)
+			}
+			managers[hosts[i].Provider] = limitedManager
+		}
+		if limitedManager == nil {
+			continue
+		}
+		region := limitedManager.GetRegion(&hosts[i].Distro)
+		instances[cloud.LimiterKey(limitedManager.GetAccount(), region)]++
+	}
+
+	return &providerLimiters{
+		instances: instances,
+		limiters:  map[string]*cloud.Limiter{},
+	}, nil
+}
+
+// get returns the limiter for the account and region the distro's hosts are
+// spawned in.
+func (pl *providerLimiters) get(limitedManager cloud.RateLimitedManager, d *distro.Distro) *cloud.Limiter {
+	account := limitedManager.GetAccount()
+	region := limitedManager.GetRegion(d)
+	key := cloud.LimiterKey(account, region)
+
+	limiter, ok := pl.limiters[key]
+	if !ok {
+		limiter = cloud.GetLimiter(account, region, limitedManager.GetLimits(region))
+		limiter.SetInstances(pl.instances[key])
+		pl.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// saveProviderQuotas records the state of every provider limiter so that it
+// can be shown on the scheduler stats pages.
+func saveProviderQuotas() {
+	now := time.Now()
+	for _, status := range cloud.GetLimiterStatuses() {
+		quota := &model.ProviderQuota{
+			Provider:          status.Provider,
+			Region:            status.Region,
+			Instances:         status.Instances,
+			InstanceQuota:     status.InstanceQuota,
+			RequestsPerSecond: status.RequestsPerSecond,
+			ThrottledRequests: status.ThrottledRequests,
+			BackoffUntil:      status.BackoffUntil,
+			LastUpdated:       now,
+		}
+		grip.Error(errors.Wrapf(quota.Upsert(), "error saving quota usage for %s",
+			model.ProviderQuotaId(status.Provider, status.Region)))
+	}
+}
@@ -238,9 +238,9 @@ func (as *APIServer) checkHost(next http.HandlerFunc) http.HandlerFunc {
 		// if the task is attached to the context, check host-task relationship
 		if ctxTask := context.Get(r, apiTaskKey); ctxTask != nil {
 			if t, ok := ctxTask.(*task.Task); ok {
-				if h.RunningTask != t.Id {
+				if !h.IsRunningTask(t.Id) {
 					as.LoggedError(w, r, http.StatusConflict,
-						errors.Errorf("Host %v should be running %v, not %v", h.Id, h.RunningTask, t.Id))
+						errors.Errorf("Host %v should be running %v, not %v", h.Id, h.RunningTasks(), t.Id))
 					return
 				}
 			}
@@ -334,7 +334,8 @@ func (as *APIServer) AttachResults(w http.ResponseWriter, r *http.Request) {
 }
 
 // FetchProjectVars is an API hook for returning the project variables
-// associated with a task's project.
+// associated with a task's project. Agents that redact private variables
+// from their logs are also sent which variables are private.
 func (as *APIServer) FetchProjectVars(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 	projectVars, err := model.FindOneProjectVars(t.Project)
@@ -343,11 +344,17 @@ func (as *APIServer) FetchProjectVars(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if projectVars == nil {
-		as.WriteJSON(w, http.StatusOK, apimodels.ExpansionVars{})
-		return
+		projectVars = &model.ProjectVars{Vars: map[string]string{}}
 	}
 
-	as.WriteJSON(w, http.StatusOK, projectVars.Vars)
+	if r.FormValue(apimodels.PrivateVarsParam) == "true" {
+		as.WriteJSON(w, http.StatusOK, apimodels.ProjectVars{
+			Vars:        projectVars.Vars,
+			PrivateVars: projectVars.PrivateVars,
+		})
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, apimodels.ExpansionVars(projectVars.Vars))
 }
 
 // AttachFiles updates file mappings for a task or build
@@ -803,6 +810,7 @@ func (as *APIServer) Handler() (http.Handler, error) {
 	// Agent routes
 	agentRouter := r.PathPrefix("/agent").Subrouter()
 	agentRouter.HandleFunc("/next_task", as.checkHost(as.NextTask)).Methods("GET")
+	agentRouter.HandleFunc("/binary", as.checkHost(as.AgentBinary)).Methods("GET")
 
 	taskRouter := r.PathPrefix("/task/{taskId}").Subrouter()
 
@@ -813,6 +821,7 @@ func (as *APIServer) Handler() (http.Handler, error) {
 
 	taskRouter.HandleFunc("/log", as.checkTask(true, as.checkHost(as.AppendTaskLog))).Methods("POST")
 	taskRouter.HandleFunc("/heartbeat", as.checkTask(true, as.checkHost(as.Heartbeat))).Methods("POST")
+	taskRouter.HandleFunc("/stream", as.checkTask(true, as.checkHost(as.TaskStream))).Methods("GET")
 	taskRouter.HandleFunc("/results", as.checkTask(true, as.checkHost(as.AttachResults))).Methods("POST")
 	taskRouter.HandleFunc("/test_logs", as.checkTask(true, as.checkHost(as.AttachTestLog))).Methods("POST")
 	taskRouter.HandleFunc("/files", as.checkTask(false, as.checkHost(as.AttachFiles))).Methods("POST")
@@ -825,9 +834,17 @@ func (as *APIServer) Handler() (http.Handler, error) {
 	taskRouter.HandleFunc("/fetch_vars", as.checkTask(true, as.FetchProjectVars)).Methods("GET")
 
 	// plugins
+	taskRouter.HandleFunc("/attach/perf", as.checkTask(true, as.checkHost(as.attachPerfResults))).Methods("POST")
+	taskRouter.HandleFunc("/attach/coverage", as.checkTask(true, as.checkHost(as.attachCoverage))).Methods("POST")
+	taskRouter.HandleFunc("/downstream/artifacts", as.checkTask(false, as.downstreamArtifacts)).Methods("GET")
+	taskRouter.HandleFunc("/downstream/artifacts/{task_name}", as.checkTask(false, as.downstreamArtifacts)).Methods("GET")
+	taskRouter.HandleFunc("/downstream/artifacts/{task_name}/{variant}", as.checkTask(false, as.downstreamArtifacts)).Methods("GET")
 	taskRouter.HandleFunc("/git/patchfile/{patchfile_id}", as.checkTask(false, as.gitServePatchFile)).Methods("GET")
 	taskRouter.HandleFunc("/git/patch", as.checkTask(false, as.gitServePatch)).Methods("GET")
 	taskRouter.HandleFunc("/keyval/inc", as.checkTask(false, as.keyValPluginInc)).Methods("POST")
+	taskRouter.HandleFunc("/keyval/get", as.checkTask(false, as.keyValPluginGet)).Methods("POST")
+	taskRouter.HandleFunc("/keyval/set", as.checkTask(false, as.keyValPluginSet)).Methods("POST")
+	taskRouter.HandleFunc("/keyval/cas", as.checkTask(false, as.keyValPluginCAS)).Methods("POST")
 	taskRouter.HandleFunc("/manifest/load", as.checkTask(false, as.manifestLoadHandler)).Methods("GET")
 	taskRouter.HandleFunc("/s3Copy/s3Copy", as.checkTask(false, as.s3copyPlugin)).Methods("POST")
 
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/taskrunner"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// agentBinaryRoute is the route, relative to the API root, that agents
+// download their updates from.
+const agentBinaryRoute = "agent/binary"
+
+// agentChecksums caches the checksums of agent binaries by path, so that they
+// are only computed again when a binary is replaced.
+var agentChecksums = struct {
+	sync.Mutex
+	byPath map[string]agentChecksum
+}{byPath: map[string]agentChecksum{}}
+
+// agentChecksum is the checksum of an agent binary as of its modification
+// time and size.
+type agentChecksum struct {
+	modTime time.Time
+	size    int64
+	sha256  string
+}
+
+// agentCanSelfUpdate returns true if the agent that sent the request reports
+// its revision, which only agents that can update themselves do. Windows
+// agents can't replace their own binary, so they are always restarted by the
+// taskrunner instead.
+func agentCanSelfUpdate(h *host.Host, r *http.Request) bool {
+	return r.Header.Get(evergreen.AgentRevisionHeader) != "" &&
+		!strings.HasPrefix(h.Distro.Arch, "windows")
+}
+
+// recordAgentRevision updates the host's agent revision to the one reported
+// by its agent, which changes when the agent updates itself.
+func recordAgentRevision(h *host.Host, r *http.Request) error {
+	revision := r.Header.Get(evergreen.AgentRevisionHeader)
+	if revision == "" || revision == h.AgentRevision {
+		return nil
+	}
+
+	grip.Infof("host %s reports agent revision %s, was %s", h.Id, revision, h.AgentRevision)
+	return errors.Wrapf(h.SetAgentRevision(revision),
+		"error setting agent revision for host %s", h.Id)
+}
+
+// getAgentUpdate returns the update the host's agent should install, or nil
+// if the agent is up to date or has to be restarted by the taskrunner.
+func (as *APIServer) getAgentUpdate(h *host.Host, r *http.Request, latestRevision string) (*apimodels.AgentUpdate, error) {
+	if h.AgentRevision == latestRevision || !agentCanSelfUpdate(h, r) {
+		return nil, nil
+	}
+
+	path := as.agentBinaryPath(h)
+	checksum, err := agentBinaryChecksum(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		grip.Warningf("no agent binary at %s for host %s to update to", path, h.Id)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &apimodels.AgentUpdate{
+		Revision: latestRevision,
+		URL:      agentBinaryRoute,
+		SHA256:   checksum,
+	}, nil
+}
+
+// agentBinaryChecksum returns the SHA256 checksum of the agent binary at
+// path, computing it only if the binary has changed since it was last
+// computed.
+func agentBinaryChecksum(path string) (string, error) {
+	agentChecksums.Lock()
+	defer agentChecksums.Unlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "error opening agent binary %s", path)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting info for agent binary %s", path)
+	}
+	cached, ok := agentChecksums.byPath[path]
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.sha256, nil
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", errors.Wrapf(err, "error computing checksum of agent binary %s", path)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	agentChecksums.byPath[path] = agentChecksum{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		sha256:  checksum,
+	}
+	return checksum, nil
+}
+
+// agentBinaryPath returns the path of the current agent binary for the
+// host's architecture.
+func (as *APIServer) agentBinaryPath(h *host.Host) string {
+	return filepath.Join(evergreen.FindEvergreenHome(), as.Settings.AgentExecutablesDir,
+		taskrunner.AgentSubPath(&h.Distro))
+}
+
+// AgentBinary serves the current agent binary for the requesting host's
+// architecture, so that the agent can update itself.
+func (as *APIServer) AgentBinary(w http.ResponseWriter, r *http.Request) {
+	h := MustHaveHost(r)
+
+	path := as.agentBinaryPath(h)
+	if _, err := os.Stat(path); err != nil {
+		as.LoggedError(w, r, http.StatusNotFound,
+			errors.Wrapf(err, "no agent binary for host %s", h.Id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentBinaryChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "agent-checksum")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "main")
+	require.NoError(t, ioutil.WriteFile(path, []byte("agent"), 0755))
+
+	checksum, err := agentBinaryChecksum(path)
+	require.NoError(t, err)
+	assert.Equal("d4f0bc5a29de06b510f9aa428f1eedba926012b591fef7a518e776a7c9bd1824", checksum)
+
+	// the cached checksum is used until the binary changes
+	agentChecksums.Lock()
+	cached := agentChecksums.byPath[path]
+	cached.sha256 = "cached"
+	agentChecksums.byPath[path] = cached
+	agentChecksums.Unlock()
+	checksum, err = agentBinaryChecksum(path)
+	require.NoError(t, err)
+	assert.Equal("cached", checksum)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("new agent"), 0755))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	checksum, err = agentBinaryChecksum(path)
+	require.NoError(t, err)
+	assert.Len(checksum, 64)
+	assert.NotEqual("cached", checksum)
+
+	_, err = agentBinaryChecksum(filepath.Join(dir, "missing"))
+	assert.True(os.IsNotExist(errors.Cause(err)))
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/pkg/errors"
+)
+
+// attachCoverage merges the line coverage a task attaches into the coverage
+// of its version, and responds with the version's updated coverage.
+func (as *APIServer) attachCoverage(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+
+	files := []coverage.FileCoverage{}
+	if err := util.ReadJSONInto(util.NewRequestReader(r), &files); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	vc, err := coverage.AttachCoverage(t, files)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			errors.Wrapf(err, "problem saving coverage for task %s", t.Id))
+		return
+	}
+
+	// the agent only needs the totals
+	vc.Files = nil
+	as.WriteJSON(w, http.StatusOK, vc)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// downstreamArtifacts responds with the artifact entries of the tasks the
+// requesting task depends on, or of the task named in the url. Named tasks
+// are in the same version, and in the requesting task's build variant unless
+// another is in the url.
+func (as *APIServer) downstreamArtifacts(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	vars := mux.Vars(r)
+	taskName := vars["task_name"]
+
+	taskIds := []string{}
+	if taskName == "" {
+		for _, dep := range t.DependsOn {
+			taskIds = append(taskIds, dep.TaskId)
+		}
+	} else {
+		variant := vars["variant"]
+		if variant == "" {
+			variant = t.BuildVariant
+		}
+		named, err := task.FindOne(task.ByVersionBuildVariantAndDisplayName(t.Version, variant, taskName))
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError,
+				errors.Wrapf(err, "error finding task %v on %v", taskName, variant))
+			return
+		}
+		if named == nil {
+			http.Error(w, fmt.Sprintf("task %v not found on %v", taskName, variant), http.StatusNotFound)
+			return
+		}
+		taskIds = append(taskIds, named.Id)
+	}
+
+	entries := []artifact.Entry{}
+	if len(taskIds) != 0 {
+		var err error
+		entries, err = artifact.FindAll(artifact.ByTaskIds(taskIds))
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError,
+				errors.Wrap(err, "error finding artifact files"))
+			return
+		}
+	}
+	as.WriteJSON(w, http.StatusOK, entries)
+}
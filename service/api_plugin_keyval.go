@@ -2,6 +2,7 @@ package service
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/util"
@@ -26,3 +27,89 @@ func (as *APIServer) keyValPluginInc(w http.ResponseWriter, r *http.Request) {
 
 	as.WriteJSON(w, http.StatusOK, keyVal)
 }
+
+// readKeyValRequest reads a keyval command's request, and returns the id of
+// the key it's for as seen by the requesting task.
+func readKeyValRequest(r *http.Request) (*model.KeyValRequest, model.KeyValEntryId, error) {
+	req := &model.KeyValRequest{}
+	if err := util.ReadJSONInto(util.NewRequestReader(r), req); err != nil {
+		return nil, model.KeyValEntryId{}, errors.Wrap(err, "could not read keyval request")
+	}
+	id, err := model.NewKeyValEntryId(req.Namespace, req.Key, MustHaveTask(r))
+	return req, id, err
+}
+
+// keyValPluginGet responds with the value of a key, or a 404 if it isn't set.
+func (as *APIServer) keyValPluginGet(w http.ResponseWriter, r *http.Request) {
+	_, id, err := readKeyValRequest(r)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := model.FindKeyValEntry(id)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if entry == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, entry)
+}
+
+// keyValPluginSet sets the value of a key.
+func (as *APIServer) keyValPluginSet(w http.ResponseWriter, r *http.Request) {
+	req, id, err := readKeyValRequest(r)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	entry, err := model.NewKeyValEntry(id, req.Value, time.Duration(req.TTLSecs)*time.Second)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = entry.Set(); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, entry)
+}
+
+// keyValPluginCAS sets the value of a key if its value is the expected one,
+// and responds with whether it was set and the key's value.
+func (as *APIServer) keyValPluginCAS(w http.ResponseWriter, r *http.Request) {
+	req, id, err := readKeyValRequest(r)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	entry, err := model.NewKeyValEntry(id, req.Value, time.Duration(req.TTLSecs)*time.Second)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	result := model.KeyValSwapResult{}
+	result.Swapped, err = entry.CompareAndSwap(req.Expected)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if result.Swapped {
+		result.Value = entry.Value
+	} else {
+		current, err := model.FindKeyValEntry(id)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if current != nil {
+			result.Value = current.Value
+		}
+	}
+	as.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/alerts"
+	"github.com/evergreen-ci/evergreen/model/perf"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// attachPerfResults stores the performance results a task attaches, compares
+// them to the task's mainline history, and responds with the regressions found.
+func (as *APIServer) attachPerfResults(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+
+	results := []perf.Result{}
+	if err := util.ReadJSONInto(util.NewRequestReader(r), &results); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	tp, err := perf.FindByTask(t)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if tp == nil {
+		tp = perf.NewTaskPerf(t)
+	}
+
+	if err = tp.AddResults(results, perf.DefaultDetectionOptions); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			errors.Wrapf(err, "problem saving perf results for task %s", t.Id))
+		return
+	}
+
+	if len(tp.Regressions) > 0 {
+		grip.Infof("Found %d perf regressions in task %s", len(tp.Regressions), t.Id)
+		grip.Error(errors.Wrapf(alerts.RunPerfRegressionTriggers(t, tp),
+			"processing perf regression triggers for task %s", t.Id))
+	}
+
+	as.WriteJSON(w, http.StatusOK, tp.Regressions)
+}
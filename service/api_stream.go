@@ -0,0 +1,234 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+)
+
+// streamAbortInterval is how often the API server checks whether the tasks
+// agents are streaming for have been aborted.
+const streamAbortInterval = 500 * time.Millisecond
+
+// streamAborts watches the tasks that have open streams on this API server.
+var streamAborts = &abortWatcher{streams: map[string]map[*websocket.Conn]bool{}}
+
+// TaskStream upgrades the request to a websocket that carries the task's
+// heartbeats and logs from the agent, and tells the agent as soon as the
+// task is aborted.
+func (as *APIServer) TaskStream(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	secret := r.Header.Get(evergreen.TaskSecretHeader)
+
+	server := websocket.Server{
+		// agents aren't browsers, and have already been authenticated
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			as.serveTaskStream(ws, t.Id, secret)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveTaskStream handles the messages the agent sends over the stream until
+// it's closed, while watching for the task to be aborted.
+func (as *APIServer) serveTaskStream(ws *websocket.Conn, taskId, secret string) {
+	streamAborts.add(taskId, ws)
+	defer streamAborts.remove(taskId, ws)
+
+	for {
+		msg := &apimodels.StreamMessage{}
+		if err := websocket.JSON.Receive(ws, msg); err != nil {
+			grip.DebugWhenf(err.Error() != "EOF", "task stream for %s closed: %v", taskId, err)
+			return
+		}
+
+		reply := &apimodels.StreamMessage{Id: msg.Id, Type: apimodels.StreamReply}
+		var err error
+		switch msg.Type {
+		case apimodels.StreamHeartbeat:
+			reply.Abort, err = streamHeartbeat(taskId, secret)
+		case apimodels.StreamLog:
+			err = streamLog(taskId, msg.Log)
+		default:
+			err = errors.Errorf("unknown message type '%s'", msg.Type)
+		}
+		if err != nil {
+			grip.Warningf("error handling %s message for task %s: %+v", msg.Type, taskId, err)
+			reply.Error = err.Error()
+		}
+
+		if err = websocket.JSON.Send(ws, reply); err != nil {
+			grip.Debugf("error replying on task stream for %s: %v", taskId, err)
+			return
+		}
+	}
+}
+
+// streamHeartbeat records a heartbeat for the task, and returns whether it
+// has been aborted.
+func streamHeartbeat(taskId, secret string) (bool, error) {
+	t, err := task.FindOne(task.ById(taskId))
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if t == nil {
+		return false, errors.Errorf("task %s not found", taskId)
+	}
+	// the task may have been restarted since the stream was opened
+	if t.Secret != secret {
+		return false, errors.New("wrong secret!")
+	}
+
+	if t.Aborted {
+		grip.Noticef("Sending abort signal for task %s", t.Id)
+	}
+	if err = t.UpdateHeartbeat(); err != nil {
+		grip.Warningf("Error updating heartbeat for task %s: %+v", t.Id, err)
+	}
+	return t.Aborted, nil
+}
+
+// streamLog stores a batch of the task's logs.
+func streamLog(taskId string, log *apimodels.TaskLog) error {
+	if log == nil {
+		return errors.New("log message has no logs")
+	}
+
+	t, err := task.FindOne(task.ById(taskId).WithFields(task.ExecutionKey))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if t == nil {
+		return errors.Errorf("task %s not found", taskId)
+	}
+
+	taskLog := &model.TaskLog{
+		TaskId:       taskId,
+		Execution:    t.Execution,
+		Timestamp:    log.Timestamp,
+		MessageCount: log.MessageCount,
+		Messages:     log.Messages,
+	}
+	return errors.WithStack(taskLog.Insert())
+}
+
+// abortWatcher sends an abort down the streams of tasks as soon as they're
+// aborted. It checks all of the tasks with open streams in one query, and
+// only runs while there are any.
+type abortWatcher struct {
+	// streams holds the open streams for each task that's still running
+	streams map[string]map[*websocket.Conn]bool
+	running bool
+	mutex   sync.Mutex
+}
+
+// add starts watching the task for an abort to send down ws.
+func (aw *abortWatcher) add(taskId string, ws *websocket.Conn) {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	if aw.streams[taskId] == nil {
+		aw.streams[taskId] = map[*websocket.Conn]bool{}
+	}
+	aw.streams[taskId][ws] = true
+	if !aw.running {
+		aw.running = true
+		go aw.run()
+	}
+}
+
+// remove stops watching the task for ws, once the stream has closed.
+func (aw *abortWatcher) remove(taskId string, ws *websocket.Conn) {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	delete(aw.streams[taskId], ws)
+	if len(aw.streams[taskId]) == 0 {
+		delete(aw.streams, taskId)
+	}
+}
+
+// run checks the watched tasks every streamAbortInterval until none are left.
+func (aw *abortWatcher) run() {
+	ticker := time.NewTicker(streamAbortInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		taskIds := aw.taskIds()
+		if len(taskIds) == 0 {
+			return
+		}
+		aw.check(taskIds)
+	}
+}
+
+// taskIds returns the ids of the watched tasks, and marks the watcher as
+// stopped if there are none.
+func (aw *abortWatcher) taskIds() []string {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	taskIds := make([]string, 0, len(aw.streams))
+	for taskId := range aw.streams {
+		taskIds = append(taskIds, taskId)
+	}
+	if len(taskIds) == 0 {
+		aw.running = false
+	}
+	return taskIds
+}
+
+// check sends an abort down the streams of the tasks that have been aborted,
+// and stops watching them along with the tasks that are no longer running.
+func (aw *abortWatcher) check(taskIds []string) {
+	tasks, err := task.Find(task.ByIds(taskIds).WithFields(task.IdKey, task.StatusKey, task.AbortedKey))
+	if err != nil {
+		grip.Warningf("error checking whether streamed tasks were aborted: %+v", err)
+		return
+	}
+	running := map[string]bool{}
+	aborted := []string{}
+	for _, t := range tasks {
+		if !util.SliceContains(evergreen.AbortableStatuses, t.Status) {
+			continue
+		}
+		running[t.Id] = true
+		if t.Aborted {
+			aborted = append(aborted, t.Id)
+		}
+	}
+
+	aw.mutex.Lock()
+	toAbort := map[string][]*websocket.Conn{}
+	for _, taskId := range aborted {
+		for ws := range aw.streams[taskId] {
+			toAbort[taskId] = append(toAbort[taskId], ws)
+		}
+	}
+	for _, taskId := range taskIds {
+		if !running[taskId] || len(toAbort[taskId]) != 0 {
+			delete(aw.streams, taskId)
+		}
+	}
+	aw.mutex.Unlock()
+
+	for taskId, conns := range toAbort {
+		grip.Noticef("Sending abort signal for task %s", taskId)
+		for _, ws := range conns {
+			if err = websocket.JSON.Send(ws, &apimodels.StreamMessage{Type: apimodels.StreamAbort}); err != nil {
+				grip.Debugf("error sending abort on task stream for %s: %v", taskId, err)
+			}
+		}
+	}
+}
@@ -182,6 +182,12 @@ func (as *APIServer) EndTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// agents that can update themselves do so when they ask for their next
+	// task, rather than exiting to be restarted
+	if agentCanSelfUpdate(currentHost, r) {
+		agentRevision = currentHost.AgentRevision
+	}
+
 	shouldExit, message := checkHostHealth(currentHost, agentRevision)
 	if shouldExit {
 		// set the host's last communication time to be zero
@@ -223,12 +229,38 @@ func (as *APIServer) updateTaskCost(t *task.Task, h *host.Host, finishTime time.
 	}
 }
 
+// updateHostProjectTag tags a host with the project of the task it was just
+// assigned, so that its costs can be attributed to that project. Hosts whose
+// providers can't update tags are left as they are. Errors are logged but not
+// returned.
+func (as *APIServer) updateHostProjectTag(h *host.Host, project string) {
+	tags, changed := cloud.ProjectTags(h, project)
+	if !changed {
+		return
+	}
+	manager, err := providers.GetCloudManager(h.Provider, &as.Settings)
+	if err != nil {
+		grip.Errorf("Error loading provider for host %s tags: %+v", h.Id, err)
+		return
+	}
+	if tagger, ok := manager.(cloud.CloudTagger); ok {
+		if err := tagger.UpdateTags(h, tags); err != nil {
+			grip.Errorf("Error updating tags for host %s: %+v", h.Id, err)
+			return
+		}
+		if err := h.SetInstanceTags(tags); err != nil {
+			grip.Errorf("Error saving tags for host %s: %+v", h.Id, err)
+			return
+		}
+	}
+}
+
 // assignNextAvailableTask gets the next task from the queue and sets the running task field
 // of currentHost.
 func assignNextAvailableTask(taskQueue *model.TaskQueue, currentHost *host.Host) (*task.Task, error) {
-	if currentHost.RunningTask != "" {
-		return nil, errors.Errorf("Error host %v must have an unset running task field but has running task %v",
-			currentHost.Id, currentHost.RunningTask)
+	if currentHost.FreeSlots() == 0 {
+		return nil, errors.Errorf("Error host %v must have a free task slot but is running tasks %v",
+			currentHost.Id, currentHost.RunningTasks())
 	}
 	// only proceed if there are pending tasks left
 	for !taskQueue.IsEmpty() {
@@ -260,7 +292,12 @@ func assignNextAvailableTask(taskQueue *model.TaskQueue, currentHost *host.Host)
 		}
 		// attempt to update the host. TODO: double check Last task completed thing...
 		// TODO: get rid of last task completed field in update running task.
-		ok, err := currentHost.UpdateRunningTask(currentHost.LastTaskCompleted, nextTaskId, time.Now())
+		var ok bool
+		if currentHost.Distro.Slots() > 1 {
+			ok, err = currentHost.AssignTaskSlot(currentHost.LastTaskCompleted, nextTaskId, time.Now())
+		} else {
+			ok, err = currentHost.UpdateRunningTask(currentHost.LastTaskCompleted, nextTaskId, time.Now())
+		}
 
 		if err != nil {
 			return nil, errors.WithStack(err)
@@ -290,6 +327,38 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = recordAgentRevision(h, r); err != nil {
+		grip.Error(err)
+		as.WriteJSON(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// a draining host is done once the tasks it was running have finished
+	if h.DrainAction != "" && len(h.RunningTasks()) == 0 {
+		if err = h.FinishDrain(); err != nil {
+			grip.Errorf("error finishing drain of host %s: %+v", h.Id, err)
+			as.WriteJSON(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.ShouldExit = true
+		response.Drained = true
+		response.Message = fmt.Sprintf("host %s has been drained (%s)", h.Id, h.DrainAction)
+		as.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// agents that can update themselves are sent the new revision instead of
+	// being told to exit, and still get a task in case the update fails
+	response.AgentUpdate, err = as.getAgentUpdate(h, r, agentRevision)
+	if err != nil {
+		grip.Error(err)
+		as.WriteJSON(w, http.StatusInternalServerError, err)
+		return
+	}
+	if response.AgentUpdate != nil {
+		agentRevision = h.AgentRevision
+	}
+
 	shouldExit, message := checkHostHealth(h, agentRevision)
 	if shouldExit {
 		// set the host's last communication time to be zero
@@ -304,15 +373,16 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// if there is already a task assigned to the host send back that task
-	if h.RunningTask != "" {
+	// if there is already a task assigned to the host that the agent isn't
+	// running, send back that task
+	if orphan := orphanedTask(h, r.URL.Query()[apimodels.RunningTaskParam]); orphan != "" {
 		var t *task.Task
-		t, err = task.FindOne(task.ById(h.RunningTask))
+		t, err = task.FindOne(task.ById(orphan))
 		if err != nil {
 			err = errors.WithStack(err)
 			grip.Error(err)
 			as.WriteJSON(w, http.StatusInternalServerError,
-				errors.Wrapf(err, "error getting running task %s", h.RunningTask))
+				errors.Wrapf(err, "error getting running task %s", orphan))
 			return
 		}
 
@@ -335,7 +405,11 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		}
 		// the task is not activated so the host's running task should be unset
 		// so it can retrieve a new task.
-		if err = h.ClearRunningTask(h.LastTaskCompleted, time.Now()); err != nil {
+		prevTaskId := h.LastTaskCompleted
+		if util.SliceContains(h.SlotTasks, t.Id) {
+			prevTaskId = t.Id
+		}
+		if err = h.ClearRunningTask(prevTaskId, time.Now()); err != nil {
 			err = errors.WithStack(err)
 			grip.Error(err)
 			as.WriteJSON(w, http.StatusInternalServerError, err)
@@ -348,6 +422,22 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.DrainAction != "" {
+		message = fmt.Sprintf("host %s is draining", h.Id)
+		grip.Info(message)
+		response.Message = message
+		as.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if h.FreeSlots() == 0 {
+		message = fmt.Sprintf("all task slots on host %s are in use", h.Id)
+		grip.Info(message)
+		response.Message = message
+		as.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
 	// retrieve the next task off the task queue and attempt to assign it to the host.
 	// If there is already a host that has the task, it will error
 	taskQueue, err := model.FindTaskQueueForDistro(h.Distro.Id)
@@ -387,8 +477,24 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		as.WriteJSON(w, http.StatusInternalServerError, err)
 		return
 	}
+	// tagging the host has no impact on the task, so do it in its own goroutine
+	go as.updateHostProjectTag(h, nextTask.Project)
+
 	response.TaskId = nextTask.Id
 	response.TaskSecret = nextTask.Secret
 	grip.Infof("assigned task %s to host %s", nextTask.Id, h.Id)
 	as.WriteJSON(w, http.StatusOK, response)
 }
+
+// orphanedTask returns a task that is assigned to one of the host's slots but
+// is not among the tasks the agent reports running, or an empty string if
+// there isn't one. Agents with a single slot don't report their task, so the
+// task in the first slot is always returned for them.
+func orphanedTask(h *host.Host, agentTasks []string) string {
+	for _, taskId := range h.RunningTasks() {
+		if !util.SliceContains(agentTasks, taskId) {
+			return taskId
+		}
+	}
+	return ""
+}
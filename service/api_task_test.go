@@ -24,6 +24,7 @@ import (
 	"github.com/evergreen-ci/evergreen/taskrunner"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -624,3 +625,19 @@ func TestEndTaskEndpoint(t *testing.T) {
 
 	})
 }
+
+func TestOrphanedTask(t *testing.T) {
+	assert := assert.New(t)
+
+	// single slot agents don't report their task, so it is always sent back
+	h := &host.Host{Id: "h", RunningTask: "t1"}
+	assert.Equal("t1", orphanedTask(h, nil))
+	h.RunningTask = ""
+	assert.Equal("", orphanedTask(h, nil))
+
+	// agents with several slots only get back tasks none of their slots have
+	h = &host.Host{Id: "h", RunningTask: "t1", SlotTasks: []string{"t2", "t3"},
+		Distro: distro.Distro{TaskSlots: 4}}
+	assert.Equal("t2", orphanedTask(h, []string{"t1", "t3"}))
+	assert.Equal("", orphanedTask(h, []string{"t1", "t2", "t3"}))
+}
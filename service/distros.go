@@ -99,7 +99,8 @@ func (uis *UIServer) modifyDistro(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if shouldDeco {
-		err = host.DecommissionHostsWithDistroId(newDistro.Id)
+		// let busy hosts finish their tasks before they're terminated
+		err = host.DecommissionOrDrainHostsWithDistroId(newDistro.Id)
 		if err != nil {
 			message := fmt.Sprintf("error decommissioning hosts: %v", err)
 			PushFlash(uis.CookieStore, r, w, NewErrorFlash(message))
@@ -112,7 +113,7 @@ func (uis *UIServer) modifyDistro(w http.ResponseWriter, r *http.Request) {
 
 	message := fmt.Sprintf("Distro %v successfully updated.", id)
 	if shouldDeco {
-		message = fmt.Sprintf("Distro %v successfully updated and running hosts decommissioned", id)
+		message = fmt.Sprintf("Distro %v successfully updated and running hosts decommissioned, once they finish their tasks if they are busy", id)
 	}
 	PushFlash(uis.CookieStore, r, w, NewSuccessFlash(message))
 	uis.WriteJSON(w, http.StatusOK, "distro successfully updated")
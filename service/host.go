@@ -36,6 +36,9 @@ type uiParams struct {
 
 	// for the update status option
 	Status string `json:"status"`
+
+	// for the drain option; empty to stop draining
+	DrainAction string `json:"drain_action"`
 }
 
 func (uis *UIServer) hostPage(w http.ResponseWriter, r *http.Request) {
@@ -143,6 +146,14 @@ func (uis *UIServer) modifyHost(w http.ResponseWriter, r *http.Request) {
 		msg := NewSuccessFlash(fmt.Sprintf("Host status successfully updated from '%v' to '%v'", currentStatus, host.Status))
 		PushFlash(uis.CookieStore, r, w, msg)
 		uis.WriteJSON(w, http.StatusOK, "Successfully updated host status")
+	case "drain":
+		if err = host.SetDrainAction(opts.DrainAction); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := NewSuccessFlash(drainMessage(opts.DrainAction, 1))
+		PushFlash(uis.CookieStore, r, w, msg)
+		uis.WriteJSON(w, http.StatusOK, "Successfully updated host drain")
 	default:
 		uis.WriteJSON(w, http.StatusBadRequest, fmt.Sprintf("Unrecognized action: %v", opts.Action))
 	}
@@ -198,8 +209,24 @@ func (uis *UIServer) modifyHosts(w http.ResponseWriter, r *http.Request) {
 			numHostsUpdated, newStatus))
 		PushFlash(uis.CookieStore, r, w, msg)
 		return
+	case "drain":
+		for _, host := range hosts {
+			if err = host.SetDrainAction(opts.DrainAction); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		PushFlash(uis.CookieStore, r, w, NewSuccessFlash(drainMessage(opts.DrainAction, len(hosts))))
+		return
 	default:
 		http.Error(w, fmt.Sprintf("Unrecognized action: %v", opts.Action), http.StatusBadRequest)
 		return
 	}
 }
+
+func drainMessage(action string, numHosts int) string {
+	if action == "" {
+		return fmt.Sprintf("%v host(s) no longer draining", numHosts)
+	}
+	return fmt.Sprintf("%v host(s) draining, to %v once their tasks finish", numHosts, action)
+}
@@ -52,7 +52,9 @@ func (uis *UIServer) projectsPage(w http.ResponseWriter, r *http.Request) {
 
 	// construct a json-marshaling friendly representation of our supported triggers
 	allTaskTriggers := []interface{}{}
-	for _, taskTrigger := range alerts.AvailableTaskFailTriggers {
+	taskTriggers := append([]alerts.Trigger{}, alerts.AvailableTaskFailTriggers...)
+	taskTriggers = append(taskTriggers, alerts.AvailablePerfTriggers...)
+	for _, taskTrigger := range taskTriggers {
 		allTaskTriggers = append(allTaskTriggers, struct {
 			Id      string `json:"id"`
 			Display string `json:"display"`
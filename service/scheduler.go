@@ -111,3 +111,12 @@ func (uis *UIServer) averageSchedulerStats(w http.ResponseWriter, r *http.Reques
 	}
 	uis.WriteJSON(w, http.StatusOK, avgBuckets)
 }
+
+func (uis *UIServer) schedulerProviderQuotas(w http.ResponseWriter, r *http.Request) {
+	quotas, err := model.FindAllProviderQuotas()
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	uis.WriteJSON(w, http.StatusOK, quotas)
+}
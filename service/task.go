@@ -13,6 +13,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/perf"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/model/version"
@@ -55,6 +56,7 @@ type uiTaskData struct {
 	TimeTaken        time.Duration           `json:"time_taken"`
 	TaskEndDetails   apimodels.TaskEndDetail `json:"task_end_details"`
 	TestResults      []task.TestResult       `json:"test_results"`
+	PerfRegressions  []perf.Regression       `json:"perf_regressions"`
 	Aborted          bool                    `json:"abort"`
 	MinQueuePos      int                     `json:"min_queue_pos"`
 	DependsOn        []uiDep                 `json:"depends_on"`
@@ -210,6 +212,15 @@ func (uis *UIServer) taskPage(w http.ResponseWriter, r *http.Request) {
 		TotalExecutions:     totalExecutions,
 	}
 
+	taskPerf, err := perf.FindByTask(projCtx.Task)
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if taskPerf != nil {
+		task.PerfRegressions = taskPerf.Regressions
+	}
+
 	deps, taskWaiting, err := getTaskDependencies(projCtx.Task)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -188,6 +188,7 @@ func (uis *UIServer) NewRouter() (*mux.Router, error) {
 	r.HandleFunc("/scheduler/stats", uis.loadCtx(uis.schedulerStatsPage))
 	r.HandleFunc("/scheduler/distro/{distro_id}/stats", uis.loadCtx(uis.averageSchedulerStats))
 	r.HandleFunc("/scheduler/stats/utilization", uis.loadCtx(uis.schedulerHostUtilization))
+	r.HandleFunc("/scheduler/stats/quotas", uis.loadCtx(uis.schedulerProviderQuotas))
 
 	// Patch pages
 	r.HandleFunc("/patch/{patch_id}", requireLogin(uis.loadCtx(uis.patchPage))).Methods("GET")
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/model/distro"
@@ -53,6 +55,12 @@ func (agbh *AgentHostGateway) StartAgentOnHost(settings *evergreen.Settings, hos
 	if err != nil {
 		return errors.Wrapf(err, "Failed to get cloud host for %s", hostObj.Id)
 	}
+
+	// some providers can start the agent without going through ssh
+	if runner, ok := cloudHost.CloudMgr.(cloud.CommandRunner); ok && runner.UseCommandRunner(&hostObj) {
+		return agbh.startAgentWithRunner(settings, hostObj, runner)
+	}
+
 	sshOptions, err := cloudHost.GetSSHOptions()
 	if err != nil {
 		return errors.Wrapf(err, "Error getting ssh options for host %s", hostObj.Id)
@@ -110,12 +118,18 @@ func executableSubPath(id string) (string, error) {
 		return "", errors.Wrapf(err, "error finding distro %v", id)
 	}
 
+	return AgentSubPath(d), nil
+}
+
+// AgentSubPath returns the path of the agent binary for the distro's
+// architecture, relative to the agent executables directory.
+func AgentSubPath(d *distro.Distro) string {
 	mainName := "main"
 	if strings.HasPrefix(d.Arch, "windows") {
 		mainName = "main.exe"
 	}
 
-	return filepath.Join(d.Arch, mainName), nil
+	return filepath.Join(d.Arch, mainName)
 }
 
 func newCappedOutputLog() *util.CappedWriter {
@@ -208,22 +222,88 @@ func (agbh *AgentHostGateway) prepRemoteHost(hostObj host.Host, sshOptions []str
 	return preSCPAgentRevision, nil
 }
 
-// Start the agent process on the specified remote host, and have it run the specified task.
-func startAgentOnRemote(settings *evergreen.Settings, hostObj *host.Host, sshOptions []string) error {
-	apiURL := settings.ApiUrl
+// startAgentWithRunner copies the agent to the host and starts it using the
+// provider's API rather than ssh.
+func (agbh *AgentHostGateway) startAgentWithRunner(settings *evergreen.Settings, hostObj host.Host,
+	runner cloud.CommandRunner) error {
+
+	execSubPath, err := executableSubPath(hostObj.Distro.Id)
+	if err != nil {
+		return errors.Wrap(err, "error computing subpath to executable")
+	}
+	agentRevision, err := agbh.GetAgentRevision()
+	if err != nil {
+		return errors.Wrap(err, "error getting agent revision")
+	}
+
+	agentBinary, err := os.Open(filepath.Join(agbh.ExecutablesDir, execSubPath))
+	if err != nil {
+		return errors.Wrap(err, "error opening agent binary")
+	}
+	defer agentBinary.Close()
+
+	grip.Infof("Copying agent to host %v with %T", hostObj.Id, runner)
+	pathToExecutable := filepath.Join(hostObj.Distro.WorkDir, "main")
+	copyCmd := fmt.Sprintf("mkdir -m 777 -p %s && cat > %s && chmod +x %s",
+		hostObj.Distro.WorkDir, pathToExecutable, pathToExecutable)
+	copyOutput := newCappedOutputLog()
+	err = util.RunFunctionWithTimeout(func() error {
+		return runner.RunCommand(&hostObj, []string{"sh", "-c", copyCmd}, agentBinary, copyOutput, false)
+	}, SCPTimeout)
+	if err != nil {
+		if err == util.ErrTimedOut {
+			return errors.Errorf("copying agent binary timed out: %v", copyOutput.String())
+		}
+		return errors.Wrapf(err, "error copying agent binary to host (%v)", copyOutput.String())
+	}
+
+	// generate the host secret if none exists
+	if hostObj.Secret == "" {
+		if err = hostObj.CreateSecret(); err != nil {
+			return errors.Wrapf(err, "creating secret for %s", hostObj.Id)
+		}
+	}
+
+	grip.Infof("Starting agent on host %v", hostObj.Id)
+	err = util.RunFunctionWithTimeout(func() error {
+		return runner.RunCommand(&hostObj, []string{"sh", "-c", agentStartCommand(settings, &hostObj)},
+			nil, nil, true)
+	}, StartAgentTimeout)
+	if err != nil {
+		if err == util.ErrTimedOut {
+			return errors.Errorf("starting agent timed out on %s", hostObj.Id)
+		}
+		return errors.Wrapf(err, "error starting agent on %s", hostObj.Id)
+	}
+	grip.Infof("Agent successfully started for host %v", hostObj.Id)
+
+	return errors.WithStack(hostObj.SetAgentRevision(agentRevision))
+}
+
+// agentStartCommand returns the shell command that runs the agent on the host.
+func agentStartCommand(settings *evergreen.Settings, hostObj *host.Host) string {
 	// the path to the agent binary on the remote machine
 	pathToExecutable := filepath.Join(hostObj.Distro.WorkDir, "main")
 
 	// build the command to run on the remote machine
 	remoteCmd := fmt.Sprintf(
 		`%v -api_server "%v" -host_id "%v" -host_secret "%v" -log_prefix "%v" -https_cert "%v"`,
-		pathToExecutable, apiURL, hostObj.Id, hostObj.Secret,
+		pathToExecutable, settings.ApiUrl, hostObj.Id, hostObj.Secret,
 		filepath.Join(hostObj.Distro.WorkDir, agentFile), "")
+	if slots := hostObj.Distro.Slots(); slots > 1 {
+		remoteCmd = fmt.Sprintf("%s -slots %d", remoteCmd, slots)
+	}
 	grip.Info(remoteCmd)
 
 	if sumoEndpoint, ok := settings.Credentials["sumologic"]; ok {
 		remoteCmd = fmt.Sprintf("GRIP_SUMO_ENDPOINT='%s' %s", sumoEndpoint, remoteCmd)
 	}
+	return remoteCmd
+}
+
+// Start the agent process on the specified remote host, and have it run the specified task.
+func startAgentOnRemote(settings *evergreen.Settings, hostObj *host.Host, sshOptions []string) error {
+	remoteCmd := agentStartCommand(settings, hostObj)
 
 	// compute any info necessary to ssh into the host
 	hostInfo, err := util.ParseSSHInfo(hostObj.Host)
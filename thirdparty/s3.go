@@ -3,11 +3,14 @@ package thirdparty
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -16,6 +19,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/goamz/goamz/aws"
@@ -233,33 +237,22 @@ func PutS3File(pushAuth *aws.Auth, localFilePath, s3URL, contentType, permission
 		return errors.Errorf("Don't know how to use URL with scheme %v", urlParsed.Scheme)
 	}
 
-	localFileReader, err := os.Open(localFilePath)
-	if err != nil {
-		return err
-	}
-
-	fi, err := os.Stat(localFilePath)
-	if err != nil {
-		return err
-	}
-
-	session := NewS3Session(pushAuth, aws.USEast)
-	bucket := session.Bucket(urlParsed.Host)
-	// options for the header
-	options := s3.Options{}
-	return errors.Wrapf(bucket.PutReader(urlParsed.Path, localFileReader, fi.Size(), contentType, s3.ACL(permissionACL), options),
+	bucket := NewS3Bucket(pushAuth, aws.USEast, urlParsed.Host)
+	return errors.Wrapf(bucket.Put(localFilePath, urlParsed.Path, contentType, permissionACL),
 		"problem putting %s to bucket", localFilePath)
 }
 
+// GetS3File returns a reader for the file at s3URL. If the file has a
+// checksum, reading to its end fails if the contents don't match it.
 func GetS3File(auth *aws.Auth, s3URL string) (io.ReadCloser, error) {
 	urlParsed, err := url.Parse(s3URL)
 	if err != nil {
 		return nil, err
 	}
-	session := NewS3Session(auth, aws.USEast)
 
-	bucket := session.Bucket(urlParsed.Host)
-	return bucket.GetReader(urlParsed.Path)
+	bucket := NewS3Bucket(auth, aws.USEast, urlParsed.Host)
+	reader, _, err := bucket.Get(urlParsed.Path)
+	return reader, err
 }
 
 //Taken from https://github.com/mitchellh/goamz/blob/master/s3/sign.go
@@ -351,17 +344,27 @@ func SignAWSRequest(auth aws.Auth, canonicalPath string, req *http.Request) {
 
 func NewS3Session(auth *aws.Auth, region aws.Region) *s3.S3 {
 	var s3Session *s3.S3
-	cert := x509.Certificate{}
+	if client := s3HTTPClient(); client != http.DefaultClient {
+		s3Session = s3.New(*auth, region, client)
+	} else {
+		s3Session = s3.New(*auth, region)
+	}
+	s3Session.ReadTimeout = S3ReadTimeout
+	s3Session.WriteTimeout = S3WriteTimeout
+	s3Session.ConnectTimeout = S3ConnectTimeout
+	return s3Session
+}
+
+// s3HTTPClient returns the client to make S3 requests with, which skips
+// verifying certificates if the system's root certificates can't be loaded.
+func s3HTTPClient() *http.Client {
 	// go's systemVerify panics with no verify options set
 	// TODO: EVG-483
 	if runtime.GOOS == "windows" {
-		s3Session = s3.New(*auth, region)
-		s3Session.ReadTimeout = S3ReadTimeout
-		s3Session.WriteTimeout = S3WriteTimeout
-		s3Session.ConnectTimeout = S3ConnectTimeout
-		return s3Session
+		return http.DefaultClient
 	}
 	// no verify options so system root ca will be used
+	cert := x509.Certificate{}
 	_, err := cert.Verify(x509.VerifyOptions{})
 	rootsError := x509.SystemRootsError{}
 	if err != nil && err.Error() == rootsError.Error() {
@@ -371,13 +374,289 @@ func NewS3Session(auth *aws.Auth, region aws.Region) *s3.S3 {
 		tr := http.Transport{
 			TLSClientConfig: &tlsConfig}
 		// add the Transport to our http client
-		client := &http.Client{Transport: &tr}
-		s3Session = s3.New(*auth, region, client)
-	} else {
-		s3Session = s3.New(*auth, region)
+		return &http.Client{Transport: &tr}
 	}
-	s3Session.ReadTimeout = S3ReadTimeout
-	s3Session.WriteTimeout = S3WriteTimeout
-	s3Session.ConnectTimeout = S3ConnectTimeout
-	return s3Session
+	return http.DefaultClient
+}
+
+const (
+	// S3ChecksumMetadata is the metadata key the hex encoded sha256 of an
+	// object's contents is stored under.
+	S3ChecksumMetadata = "sha256"
+
+	// S3MultipartThreshold is the size of the files that are uploaded in
+	// parts rather than in a single request.
+	S3MultipartThreshold = 64 * 1024 * 1024
+	// S3PartSize is the size of the parts of a multipart upload. S3 needs
+	// every part but the last to be at least 5MB.
+	S3PartSize = 16 * 1024 * 1024
+	// S3UploadWorkers is how many parts of a file are uploaded at once.
+	S3UploadWorkers = 4
+)
+
+// S3Object describes an object in a bucket.
+type S3Object struct {
+	Key  string
+	Size int64
+	// ETag is the md5 of the contents of objects that weren't uploaded in
+	// parts.
+	ETag string
+	// Checksum is the hex encoded sha256 of the contents, if it was stored
+	// when the object was uploaded. It isn't set by List.
+	Checksum string
+}
+
+// S3Bucket stores and fetches the objects in a bucket.
+type S3Bucket interface {
+	// Put uploads the file at localPath to key along with its checksum.
+	// Large files are uploaded in parts, several at a time.
+	Put(localPath, key, contentType, permissionACL string) error
+	// Get returns a reader for the object at key. If the object has a
+	// checksum, reading to its end fails if the contents don't match it.
+	Get(key string) (io.ReadCloser, S3Object, error)
+	// Head returns the object at key, or nil if there is none.
+	Head(key string) (*S3Object, error)
+	// List returns the objects whose keys start with prefix.
+	List(prefix string) ([]S3Object, error)
+	// Delete removes the object at key.
+	Delete(key string) error
+}
+
+type s3Bucket struct {
+	bucket *s3.Bucket
+	client *http.Client
+
+	threshold int64
+	partSize  int64
+	workers   int
+}
+
+// NewS3Bucket returns the named bucket in region. Pointing the region's
+// S3Endpoint elsewhere uses an S3 compatible server instead.
+func NewS3Bucket(auth *aws.Auth, region aws.Region, name string) S3Bucket {
+	return &s3Bucket{
+		bucket:    NewS3Session(auth, region).Bucket(name),
+		client:    s3HTTPClient(),
+		threshold: S3MultipartThreshold,
+		partSize:  S3PartSize,
+		workers:   S3UploadWorkers,
+	}
+}
+
+func (b *s3Bucket) Put(localPath, key, contentType, permissionACL string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	checksum, err := readerChecksum(f)
+	if err != nil {
+		return errors.Wrapf(err, "error computing checksum of %v", localPath)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+
+	meta := map[string][]string{S3ChecksumMetadata: {checksum}}
+	if fi.Size() <= b.threshold {
+		return errors.Wrapf(b.bucket.PutReader(key, f, fi.Size(), contentType, s3.ACL(permissionACL), s3.Options{Meta: meta}),
+			"error putting %v to %v", localPath, key)
+	}
+
+	multi, err := b.initMulti(key, contentType, permissionACL, meta)
+	if err != nil {
+		return errors.Wrapf(err, "error starting upload of %v to %v", localPath, key)
+	}
+	parts, err := b.putParts(multi, f, fi.Size())
+	if err != nil {
+		grip.Warning(errors.Wrapf(multi.Abort(), "error aborting upload of %v", key))
+		return errors.Wrapf(err, "error uploading %v to %v", localPath, key)
+	}
+	return errors.Wrapf(multi.Complete(parts), "error completing upload of %v to %v", localPath, key)
+}
+
+// initMulti starts a multipart upload. goamz's InitMulti can't set metadata,
+// which has to be given when the upload starts, so the request is made here.
+func (b *s3Bucket) initMulti(key, contentType, permissionACL string, meta map[string][]string) (*s3.Multi, error) {
+	req, err := http.NewRequest("POST", b.bucket.URL(key)+"?uploads", nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("x-amz-acl", permissionACL)
+	req.Header.Add("x-amz-date", time.Now().UTC().Format(http.TimeFormat))
+	for name, values := range meta {
+		req.Header["x-amz-meta-"+name] = values
+	}
+	SignAWSRequest(b.bucket.Auth, "/"+b.bucket.Name+"/"+strings.TrimPrefix(key, "/"), req)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.Errorf("S3 returned status code %d: %s", resp.StatusCode, body)
+	}
+
+	result := struct {
+		UploadId string `xml:"UploadId"`
+	}{}
+	if err = xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "error reading upload id")
+	}
+	return &s3.Multi{Bucket: b.bucket, Key: key, UploadId: result.UploadId}, nil
+}
+
+// putParts uploads f in parts of the bucket's part size, using a goroutine
+// per worker. It returns the uploaded parts in order.
+func (b *s3Bucket) putParts(multi *s3.Multi, f *os.File, size int64) ([]s3.Part, error) {
+	numParts := int((size + b.partSize - 1) / b.partSize)
+	parts := make([]s3.Part, numParts)
+	partNums := make(chan int, numParts)
+	for i := 0; i < numParts; i++ {
+		partNums <- i
+	}
+	close(partNums)
+
+	errs := make(chan error, numParts)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < b.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range partNums {
+				offset := int64(i) * b.partSize
+				length := b.partSize
+				if offset+length > size {
+					length = size - offset
+				}
+				part, err := multi.PutPart(i+1, io.NewSectionReader(f, offset, length))
+				if err != nil {
+					errs <- errors.Wrapf(err, "error uploading part %d", i+1)
+					return
+				}
+				parts[i] = part
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	catcher := grip.NewCatcher()
+	for err := range errs {
+		catcher.Add(err)
+	}
+	return parts, catcher.Resolve()
+}
+
+func (b *s3Bucket) Get(key string) (io.ReadCloser, S3Object, error) {
+	resp, err := b.bucket.GetResponse(key)
+	if err != nil {
+		return nil, S3Object{}, errors.Wrapf(err, "error getting %v", key)
+	}
+	obj := objectFromHeader(key, resp)
+	return &checksumReader{
+		ReadCloser: resp.Body,
+		hash:       sha256.New(),
+		key:        key,
+		expected:   obj.Checksum,
+	}, obj, nil
+}
+
+func (b *s3Bucket) Head(key string) (*S3Object, error) {
+	resp, err := b.bucket.Head(key, nil)
+	if err != nil {
+		if s3Err, ok := err.(*s3.Error); ok && s3Err.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error getting %v", key)
+	}
+	defer resp.Body.Close()
+	obj := objectFromHeader(key, resp)
+	return &obj, nil
+}
+
+func (b *s3Bucket) List(prefix string) ([]S3Object, error) {
+	objects := []S3Object{}
+	marker := ""
+	for {
+		resp, err := b.bucket.List(prefix, "", marker, 1000)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing %v", prefix)
+		}
+		for _, key := range resp.Contents {
+			objects = append(objects, S3Object{
+				Key:  key.Key,
+				Size: key.Size,
+				ETag: strings.Trim(key.ETag, `"`),
+			})
+		}
+		if !resp.IsTruncated || len(resp.Contents) == 0 {
+			return objects, nil
+		}
+		marker = resp.NextMarker
+		if marker == "" {
+			marker = resp.Contents[len(resp.Contents)-1].Key
+		}
+	}
+}
+
+func (b *s3Bucket) Delete(key string) error {
+	return errors.Wrapf(b.bucket.Del(key), "error deleting %v", key)
+}
+
+func objectFromHeader(key string, resp *http.Response) S3Object {
+	return S3Object{
+		Key:      key,
+		Size:     resp.ContentLength,
+		ETag:     strings.Trim(resp.Header.Get("ETag"), `"`),
+		Checksum: resp.Header.Get("x-amz-meta-" + S3ChecksumMetadata),
+	}
+}
+
+// FileChecksum returns the hex encoded sha256 of the file at path, the same
+// way it's stored with the objects S3Bucket uploads.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	return readerChecksum(f)
+}
+
+func readerChecksum(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checksumReader hashes an object's contents as they're read, and fails at
+// the end of them if they don't match the object's checksum.
+type checksumReader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	key      string
+	expected string
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.hash.Write(p[:n])
+	if err == io.EOF && r.expected != "" {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			return n, errors.Errorf("checksum of %v is %v, but its contents have checksum %v",
+				r.key, r.expected, actual)
+		}
+	}
+	return n, err
 }
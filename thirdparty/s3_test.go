@@ -1,14 +1,27 @@
 package thirdparty
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	"github.com/goamz/goamz/s3/s3test"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -158,3 +171,145 @@ func TestS3Copy(t *testing.T) {
 		})
 	})
 }
+
+// newLocalS3Bucket starts an in-memory S3 server with a bucket in it.
+func newLocalS3Bucket(t *testing.T) (*s3test.Server, S3Bucket) {
+	srv, err := s3test.NewServer(&s3test.Config{})
+	require.NoError(t, err)
+	region := aws.Region{Name: "local", S3Endpoint: srv.URL(), S3LocationConstraint: true}
+	auth := &aws.Auth{AccessKey: "key", SecretKey: "secret"}
+	require.NoError(t, NewS3Session(auth, region).Bucket("local-bucket").PutBucket(s3.Private))
+	return srv, NewS3Bucket(auth, region, "local-bucket")
+}
+
+func writeTempFile(t *testing.T, contents []byte) string {
+	f, err := ioutil.TempFile("", "s3-test")
+	require.NoError(t, err)
+	_, err = f.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func TestS3BucketPutAndGet(t *testing.T) {
+	assert := assert.New(t)
+	srv, bucket := newLocalS3Bucket(t)
+	defer srv.Quit()
+
+	contents := []byte("some build output")
+	localPath := writeTempFile(t, contents)
+	defer os.Remove(localPath)
+	checksum, err := FileChecksum(localPath)
+	require.NoError(t, err)
+
+	require.NoError(t, bucket.Put(localPath, "dir/file.txt", "text/plain", "private"))
+
+	reader, obj, err := bucket.Get("dir/file.txt")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(err)
+	assert.NoError(reader.Close())
+	assert.Equal(contents, data)
+	assert.Equal(checksum, obj.Checksum)
+
+	head, err := bucket.Head("dir/file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, head)
+	assert.Equal(int64(len(contents)), head.Size)
+	assert.Equal(checksum, head.Checksum)
+
+	head, err = bucket.Head("dir/missing.txt")
+	assert.NoError(err)
+	assert.Nil(head)
+
+	objects, err := bucket.List("dir/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal("dir/file.txt", objects[0].Key)
+	assert.Equal(int64(len(contents)), objects[0].Size)
+
+	require.NoError(t, bucket.Delete("dir/file.txt"))
+	objects, err = bucket.List("dir/")
+	assert.NoError(err)
+	assert.Len(objects, 0)
+}
+
+func TestS3BucketGetVerifiesChecksum(t *testing.T) {
+	srv, bucket := newLocalS3Bucket(t)
+	defer srv.Quit()
+
+	// store an object whose checksum doesn't match its contents
+	b := bucket.(*s3Bucket).bucket
+	require.NoError(t, b.Put("corrupt", []byte("contents"), "text/plain", s3.Private,
+		s3.Options{Meta: map[string][]string{S3ChecksumMetadata: {"0123"}}}))
+
+	reader, _, err := bucket.Get("corrupt")
+	require.NoError(t, err)
+	defer reader.Close()
+	_, err = ioutil.ReadAll(reader)
+	assert.Error(t, err)
+}
+
+// multipartServer is a fake S3 server that only handles multipart uploads.
+type multipartServer struct {
+	mu       sync.Mutex
+	meta     string
+	parts    map[int][]byte
+	complete []byte
+}
+
+func (s *multipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case r.Method == "POST" && query.Get("uploadId") == "":
+		s.meta = r.Header.Get("x-amz-meta-" + S3ChecksumMetadata)
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == "PUT":
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		data, _ := ioutil.ReadAll(r.Body)
+		s.parts[n] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, n))
+	case r.Method == "POST":
+		completed := struct {
+			Parts []int `xml:"Part>PartNumber"`
+		}{}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &completed); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sort.Ints(completed.Parts)
+		for _, n := range completed.Parts {
+			s.complete = append(s.complete, s.parts[n]...)
+		}
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3BucketMultipartPut(t *testing.T) {
+	assert := assert.New(t)
+	fake := &multipartServer{parts: map[int][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	region := aws.Region{Name: "local", S3Endpoint: srv.URL}
+	bucket := NewS3Bucket(&aws.Auth{AccessKey: "key", SecretKey: "secret"}, region, "local-bucket").(*s3Bucket)
+	bucket.threshold = 10
+	bucket.partSize = 10
+
+	contents := bytes.Repeat([]byte("0123456789"), 4)
+	contents = append(contents, []byte("tail")...)
+	localPath := writeTempFile(t, contents)
+	defer os.Remove(localPath)
+	checksum, err := FileChecksum(localPath)
+	require.NoError(t, err)
+
+	require.NoError(t, bucket.Put(localPath, "big", "application/octet-stream", "private"))
+	assert.Equal(checksum, fake.meta)
+	assert.Len(fake.parts, 5)
+	assert.Equal(contents, fake.complete)
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// minRedactedLength is the length below which values aren't redacted,
+	// since they would turn up all over the logs without giving much away.
+	minRedactedLength = 4
+
+	// minRedactedBase64Length is the length below which parts of base64
+	// encoded values aren't redacted, so that they aren't found by chance in
+	// other base64 encoded text.
+	minRedactedBase64Length = 8
+)
+
+// Redactor hides secret values in text, replacing them with the name of the
+// variable they came from. It is safe for concurrent use.
+type Redactor struct {
+	// replacements maps each string to hide to what it's replaced with
+	replacements map[string]string
+	replacer     *strings.Replacer
+	mutex        sync.Mutex
+}
+
+// NewRedactor returns a Redactor that doesn't hide anything yet.
+func NewRedactor() *Redactor {
+	return &Redactor{replacements: map[string]string{}}
+}
+
+// Add makes the Redactor hide the value of the variable name, along with each
+// line of it and the value in the forms it is commonly encoded in.
+func (r *Redactor) Add(name, value string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.add(value, name)
+	// multi-line values are logged a line at a time
+	for _, line := range strings.Split(value, "\n") {
+		r.add(strings.TrimSpace(line), name)
+	}
+
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		for _, encoded := range base64Forms(encoding, value) {
+			r.add(encoded, name+" (base64)")
+		}
+	}
+	r.add(url.QueryEscape(value), name+" (url-encoded)")
+	r.add(url.PathEscape(value), name+" (url-encoded)")
+}
+
+func (r *Redactor) add(secret, name string) {
+	if len(secret) < minRedactedLength {
+		return
+	}
+	// the first variable found with a value is the one it's reported as
+	if _, ok := r.replacements[secret]; ok {
+		return
+	}
+	r.replacements[secret] = fmt.Sprintf("<REDACTED:%s>", name)
+	r.replacer = nil
+}
+
+// Redact returns s with every value the Redactor hides replaced.
+func (r *Redactor) Redact(s string) string {
+	r.mutex.Lock()
+	if len(r.replacements) == 0 {
+		r.mutex.Unlock()
+		return s
+	}
+	if r.replacer == nil {
+		// the replacer prefers the strings it's given first, so longer
+		// secrets are hidden whole before any shorter ones inside them
+		secrets := make([]string, 0, len(r.replacements))
+		for secret := range r.replacements {
+			secrets = append(secrets, secret)
+		}
+		sort.Slice(secrets, func(i, j int) bool {
+			if len(secrets[i]) != len(secrets[j]) {
+				return len(secrets[i]) > len(secrets[j])
+			}
+			return secrets[i] < secrets[j]
+		})
+
+		oldnew := make([]string, 0, 2*len(secrets))
+		for _, secret := range secrets {
+			oldnew = append(oldnew, secret, r.replacements[secret])
+		}
+		r.replacer = strings.NewReplacer(oldnew...)
+	}
+	replacer := r.replacer
+	r.mutex.Unlock()
+
+	return replacer.Replace(s)
+}
+
+// base64Forms returns the strings that are found in any base64 encoding of
+// text containing value. Since each group of four characters encodes three
+// bytes, that depends on where value starts in a group, so there is one form
+// for each offset, without the groups the surrounding text also goes into.
+// The exact encoding of value is returned too.
+func base64Forms(encoding *base64.Encoding, value string) []string {
+	forms := []string{encoding.EncodeToString([]byte(value))}
+	for offset := 0; offset < 3; offset++ {
+		data := append(make([]byte, offset), value...)
+		encoded := encoding.EncodeToString(data)
+
+		start := 0
+		if offset > 0 {
+			start = 4
+		}
+		end := 4 * (len(data) / 3)
+		if end-start >= minRedactedBase64Length {
+			forms = append(forms, encoded[start:end])
+		}
+	}
+	return forms
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRedactor()
+	assert.Equal("nothing to hide", r.Redact("nothing to hide"))
+
+	r.Add("aws_secret", "s3cr3t/value+1")
+	r.Add("short", "abc")
+	r.Add("ssh_key", "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----")
+
+	assert.Equal("key is <REDACTED:aws_secret>!", r.Redact("key is s3cr3t/value+1!"))
+	assert.Equal("abc is too short to hide", r.Redact("abc is too short to hide"))
+
+	// multi-line values are logged a line at a time
+	assert.Equal("<REDACTED:ssh_key>", r.Redact("-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----"))
+	assert.Equal("  <REDACTED:ssh_key>", r.Redact("  MIIEowIBAAKCAQEA"))
+
+	assert.Equal("<REDACTED:aws_secret (url-encoded)>", r.Redact(url.QueryEscape("s3cr3t/value+1")))
+
+	// base64 encoded values are hidden wherever they start in the text
+	for _, text := range []string{"s3cr3t/value+1", "s3cr3t/value+1\n", "x=s3cr3t/value+1", "xy=s3cr3t/value+1;"} {
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+			redacted := r.Redact(encoding.EncodeToString([]byte(text)))
+			assert.Contains(redacted, "<REDACTED:aws_secret (base64)>", text)
+		}
+	}
+	assert.Equal("<REDACTED:aws_secret (base64)>",
+		r.Redact(base64.StdEncoding.EncodeToString([]byte("s3cr3t/value+1"))))
+}
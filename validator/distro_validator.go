@@ -19,6 +19,10 @@ var distroSyntaxValidators = []distroValidator{
 	ensureValidSSHOptions,
 	ensureValidExpansions,
 	ensureStaticHostsAreNotSpawnable,
+	ensureValidTaskSlots,
+	ensureValidTaskContainer,
+	ensureValidResourceLimits,
+	ensureValidTaskCache,
 }
 
 // CheckDistro checks if the distro configuration syntax is valid. Returns
@@ -156,3 +160,41 @@ func ensureValidSSHOptions(d *distro.Distro, s *evergreen.Settings) []Validation
 	}
 	return nil
 }
+
+// ensureValidTaskSlots checks that the distro does not have a negative number
+// of task slots.
+func ensureValidTaskSlots(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.TaskSlots < 0 {
+		return []ValidationError{{Error, fmt.Sprintf("distro '%v' cannot be negative", distro.TaskSlotsKey)}}
+	}
+	return nil
+}
+
+// ensureValidTaskContainer checks that a distro that runs its tasks in
+// containers has an image to create them from.
+func ensureValidTaskContainer(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.TaskContainer != nil && d.TaskContainer.Image == "" {
+		return []ValidationError{{Error, fmt.Sprintf("distro '%v' must have an image", distro.TaskContainerKey)}}
+	}
+	return nil
+}
+
+// ensureValidResourceLimits checks that the distro's task resource limits
+// are not negative.
+func ensureValidResourceLimits(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.ResourceLimits != nil {
+		if err := d.ResourceLimits.Validate(); err != nil {
+			return []ValidationError{{Error, fmt.Sprintf("distro '%v' are invalid: %v", distro.ResourceLimitsKey, err)}}
+		}
+	}
+	return nil
+}
+
+// ensureValidTaskCache checks that the disk space for the distro's task
+// caches is not negative.
+func ensureValidTaskCache(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.TaskCacheMB < 0 {
+		return []ValidationError{{Error, fmt.Sprintf("distro '%v' cannot be negative", distro.TaskCacheMBKey)}}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 var conf = testutil.TestConfig()
@@ -238,3 +239,35 @@ func TestEnsureValidSSHOptions(t *testing.T) {
 		})
 	})
 }
+
+func TestEnsureValidTaskContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(ensureValidTaskContainer(&distro.Distro{}, conf))
+	assert.Len(ensureValidTaskContainer(&distro.Distro{TaskContainer: &distro.TaskContainer{}}, conf), 1)
+	assert.Nil(ensureValidTaskContainer(&distro.Distro{
+		TaskContainer: &distro.TaskContainer{Image: "ubuntu:16.04"}}, conf))
+}
+
+func TestEnsureValidResourceLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(ensureValidResourceLimits(&distro.Distro{}, conf))
+	assert.Nil(ensureValidResourceLimits(&distro.Distro{
+		ResourceLimits: &distro.ResourceLimits{CPUs: 1.5, MemoryMB: 4096}}, conf))
+	assert.Len(ensureValidResourceLimits(&distro.Distro{
+		ResourceLimits: &distro.ResourceLimits{Pids: -1}}, conf), 1)
+}
+
+func TestMergeResourceLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	var limits *distro.ResourceLimits
+	assert.Equal(&distro.ResourceLimits{}, limits.Merge(nil))
+	assert.Equal(&distro.ResourceLimits{Pids: 10}, limits.Merge(&distro.ResourceLimits{Pids: 10}))
+
+	limits = &distro.ResourceLimits{CPUs: 2, MemoryMB: 1024}
+	assert.Equal(&distro.ResourceLimits{CPUs: 2, MemoryMB: 512, Pids: 10},
+		limits.Merge(&distro.ResourceLimits{MemoryMB: 512, Pids: 10}))
+	assert.Equal(&distro.ResourceLimits{CPUs: 2, MemoryMB: 1024}, limits)
+}
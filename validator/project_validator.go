@@ -52,6 +52,8 @@ var projectSyntaxValidators = []projectValidator{
 	checkAllDependenciesSpec,
 	validateProjectTaskNames,
 	validateProjectTaskIdsAndTags,
+	validateBVResourceLimits,
+	validateCaches,
 }
 
 // Functions used to validate the semantics of a project configuration file.
@@ -703,3 +705,55 @@ func verifyTaskDependencies(project *model.Project) []ValidationError {
 	}
 	return errs
 }
+
+// validateBVResourceLimits checks that no build variant's task resource limits
+// are negative.
+func validateBVResourceLimits(project *model.Project) []ValidationError {
+	errs := []ValidationError{}
+	for _, buildVariant := range project.BuildVariants {
+		if buildVariant.ResourceLimits == nil {
+			continue
+		}
+		if err := buildVariant.ResourceLimits.Validate(); err != nil {
+			errs = append(errs,
+				ValidationError{
+					Message: fmt.Sprintf("buildvariant '%v' in project '%v' has invalid "+
+						"resource_limits: %v", buildVariant.Name, project.Identifier, err),
+				},
+			)
+		}
+	}
+	return errs
+}
+
+// validateCaches checks that the caches of every task and build variant can
+// be kept on hosts, and that none of them declares two caches with one name.
+func validateCaches(project *model.Project) []ValidationError {
+	errs := []ValidationError{}
+	check := func(kind, name string, caches []model.CacheDir) {
+		names := map[string]bool{}
+		for _, cache := range caches {
+			if err := cache.Validate(); err != nil {
+				errs = append(errs, ValidationError{
+					Message: fmt.Sprintf("%v '%v' in project '%v' has an invalid cache: %v",
+						kind, name, project.Identifier, err),
+				})
+			}
+			if names[cache.Name] {
+				errs = append(errs, ValidationError{
+					Message: fmt.Sprintf("%v '%v' in project '%v' has more than one cache named '%v'",
+						kind, name, project.Identifier, cache.Name),
+				})
+			}
+			names[cache.Name] = true
+		}
+	}
+
+	for _, task := range project.Tasks {
+		check("task", task.Name, task.Caches)
+	}
+	for _, buildVariant := range project.BuildVariants {
+		check("buildvariant", buildVariant.Name, buildVariant.Caches)
+	}
+	return errs
+}
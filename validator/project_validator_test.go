@@ -11,6 +11,7 @@ import (
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
 	tu "github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
 )
 
 var projectValidatorConf = tu.TestConfig()
@@ -1440,3 +1441,33 @@ func TestEnsureHasNecessaryBVFields(t *testing.T) {
 		})
 	})
 }
+
+func TestValidateCaches(t *testing.T) {
+	assert := assert.New(t)
+
+	project := &model.Project{
+		Tasks: []model.ProjectTask{
+			{Name: "compile", Caches: []model.CacheDir{{Name: "gomod", Path: "go/pkg/mod"}}},
+		},
+		BuildVariants: []model.BuildVariant{
+			{Name: "linux", Caches: []model.CacheDir{{Name: "npm", Path: "src/node_modules"}}},
+		},
+	}
+	assert.Len(validateCaches(project), 0)
+
+	for _, cache := range []model.CacheDir{
+		{Name: "", Path: "cache"},
+		{Name: "../up", Path: "cache"},
+		{Name: "nopath"},
+		{Name: "abs", Path: "/var/cache"},
+		{Name: "outside", Path: "src/../../cache"},
+		{Name: "pattern", Path: "cache", KeyFiles: []string{"[go.sum"}},
+		{Name: "bucket", Path: "cache", S3: &model.CacheS3{Bucket: "caches"}},
+	} {
+		assert.Error(cache.Validate(), cache.Name)
+	}
+
+	project.BuildVariants[0].Caches = append(project.BuildVariants[0].Caches,
+		model.CacheDir{Name: "npm", Path: "node_modules"})
+	assert.Len(validateCaches(project), 1)
+}